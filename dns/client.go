@@ -0,0 +1,259 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"slices"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var errMismatch = errors.New("dns: response does not match the query")
+
+// A headerError is an error parsing the header or questions of a
+// response, which therefore cannot be matched to its query.
+type headerError struct {
+	err error
+}
+
+func (e *headerError) Error() string { return e.err.Error() }
+func (e *headerError) Unwrap() error { return e.err }
+
+const (
+	defaultTimeout  = 5 * time.Second
+	defaultAttempts = 2
+)
+
+// A Client sends DNS queries to a server.
+//
+// The zero value sends queries over UDP, and retries them over TCP when
+// the response is truncated.
+type Client struct {
+	// Net is "udp" to query over UDP with a TCP fallback, "tcp" to
+	// always query over TCP, or "tcp-tls" to query over TLS (RFC 7858).
+	// If empty, "udp" is used.
+	Net string
+
+	// TLSConfig configures the TLS connections of the "tcp-tls"
+	// network. If nil, the default configuration is used. If its
+	// ServerName is empty, the host of the server address is used.
+	TLSConfig *tls.Config
+
+	// Dialer connects to servers. If nil, a zero net.Dialer is used.
+	Dialer *net.Dialer
+
+	// Timeout bounds each attempt to query the server. If zero, 5
+	// seconds is used.
+	Timeout time.Duration
+
+	// Attempts is the number of times a UDP query is sent before giving
+	// up. If zero, 2 is used.
+	Attempts int
+
+	// UDPSize is the UDP payload size that queries without an OPT record
+	// advertise in an added one. If zero, queries are sent as is.
+	UDPSize int
+}
+
+func (c *Client) dialer() *net.Dialer {
+	if c.Dialer != nil {
+		return c.Dialer
+	}
+	return &net.Dialer{}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return defaultTimeout
+}
+
+func (c *Client) attempts() int {
+	if c.Attempts > 0 {
+		return c.Attempts
+	}
+	return defaultAttempts
+}
+
+// Exchange sends the query q to the server at addr and returns its
+// response.
+//
+// The query is sent with a random ID, which the response must have along
+// with the questions of the query; other responses are ignored over UDP,
+// and are an error over TCP. q itself is not modified.
+func (c *Client) Exchange(ctx context.Context, addr string, q *dnsmessage.Message) (*dnsmessage.Message, error) {
+	query := *q
+	query.Header.ID = randomID()
+	query.Header.Response = false
+	if c.UDPSize > 0 && !hasOPT(query.Additionals) {
+		var h dnsmessage.ResourceHeader
+		if err := h.SetEDNS0(c.UDPSize, dnsmessage.RCodeSuccess, false); err != nil {
+			return nil, err
+		}
+		opt := dnsmessage.Resource{Header: h, Body: &dnsmessage.OPTResource{}}
+		query.Additionals = append(slices.Clip(query.Additionals), opt)
+	}
+	b, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	switch c.Net {
+	case "", "udp":
+		resp, truncated, err := c.exchangeUDP(ctx, addr, &query, b)
+		if err != nil || !truncated {
+			return resp, err
+		}
+	case "tcp":
+	case "tcp-tls":
+		return c.exchangeTLS(ctx, addr, &query, b)
+	default:
+		return nil, net.UnknownNetworkError(c.Net)
+	}
+	return c.exchangeTCP(ctx, addr, &query, b)
+}
+
+func randomID() uint16 {
+	var b [2]byte
+	rand.Read(b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// exchangeUDP sends the query b, retransmitting it after each timeout.
+// It reports whether the response is truncated.
+func (c *Client) exchangeUDP(ctx context.Context, addr string, q *dnsmessage.Message, b []byte) (*dnsmessage.Message, bool, error) {
+	conn, err := c.dialer().DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	buf := make([]byte, maxMsgSize)
+	for attempt := 0; ; attempt++ {
+		conn.SetDeadline(time.Now().Add(c.timeout()))
+		if _, err := conn.Write(b); err != nil {
+			return nil, false, ctxErr(ctx, err)
+		}
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var ne net.Error
+				if errors.As(err, &ne) && ne.Timeout() && ctx.Err() == nil && attempt+1 < c.attempts() {
+					break
+				}
+				return nil, false, ctxErr(ctx, err)
+			}
+			resp, truncated, err := checkResponse(q, buf[:n])
+			var he *headerError
+			if err == errMismatch || errors.As(err, &he) {
+				// Ignore responses to other queries, which may be
+				// forged (RFC 5452 section 9.1), and datagrams that
+				// are not DNS responses at all.
+				continue
+			}
+			return resp, truncated, err
+		}
+	}
+}
+
+func (c *Client) exchangeTCP(ctx context.Context, addr string, q *dnsmessage.Message, b []byte) (*dnsmessage.Message, error) {
+	conn, err := c.dialer().DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return exchangeStream(ctx, conn, q, b, c.timeout())
+}
+
+func (c *Client) exchangeTLS(ctx context.Context, addr string, q *dnsmessage.Message, b []byte) (*dnsmessage.Message, error) {
+	config := c.TLSConfig
+	if config == nil || config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		config.ServerName = host
+	}
+	d := &tls.Dialer{NetDialer: c.dialer(), Config: config}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return exchangeStream(ctx, conn, q, b, c.timeout())
+}
+
+// exchangeStream sends the query b over the connection conn, on which
+// messages are prefixed by their length, and reads the response.
+func exchangeStream(ctx context.Context, conn net.Conn, q *dnsmessage.Message, b []byte, timeout time.Duration) (*dnsmessage.Message, error) {
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := writeStreamMsg(conn, b); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	msg, err := readStreamMsg(conn)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	resp, _, err := checkResponse(q, msg)
+	return resp, err
+}
+
+// ctxErr returns the error of ctx if it is done, since it is the cause of
+// I/O errors on the connections it closes.
+func ctxErr(ctx context.Context, err error) error {
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// checkResponse parses msg and checks that it answers the query q. It
+// reports whether the response is truncated, in which case only its
+// header and questions are returned since the rest may be incomplete.
+func checkResponse(q *dnsmessage.Message, msg []byte) (*dnsmessage.Message, bool, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil, false, &headerError{err}
+	}
+	if !h.Response || h.ID != q.Header.ID {
+		return nil, false, errMismatch
+	}
+	qs, err := p.AllQuestions()
+	if err != nil {
+		return nil, false, &headerError{err}
+	}
+	if !sameQuestions(q.Questions, qs) {
+		return nil, false, errMismatch
+	}
+	if h.Truncated {
+		return &dnsmessage.Message{Header: h, Questions: qs}, true, nil
+	}
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil {
+		return nil, false, err
+	}
+	return &m, false, nil
+}
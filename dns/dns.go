@@ -0,0 +1,170 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dns implements a minimal DNS server and client on top of
+// package dnsmessage.
+//
+// The server answers queries received over UDP and TCP with a [Handler],
+// truncating UDP responses that exceed the size negotiated with EDNS(0)
+// (RFC 6891). The client retries lost UDP queries, falls back to TCP for
+// truncated responses, and discards responses that don't match the query.
+//
+// Queries can also be encrypted with DNS over TLS (RFC 7858), see
+// [Server.ServeTLS] and the "tcp-tls" network of [Client]; DNS over HTTPS
+// (RFC 8484), see [DoHHandler] and [DoHClient]; and DNS over QUIC
+// (RFC 9250), see [Server.ServeQUIC] and [DoQClient]. All transports
+// share the messages of package dnsmessage and the [Handler] interface.
+package dns // import "golang.org/x/net/dns"
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// defaultUDPSize is the default UDP payload size advertised with
+	// EDNS(0), which avoids IP fragmentation on most paths.
+	defaultUDPSize = 1232
+
+	// minUDPSize is the size of UDP messages that all hosts must accept
+	// (RFC 1035 section 2.3.4).
+	minUDPSize = 512
+
+	// maxMsgSize is the size of the largest DNS message.
+	maxMsgSize = 65535
+)
+
+var errMsgTooLong = errors.New("dns: message too long")
+
+// A Request is a DNS query received by a server.
+type Request struct {
+	Header    dnsmessage.Header
+	Questions []dnsmessage.Question
+
+	// OPT is the EDNS(0) record of the query, or nil if the query has
+	// none. Its body is an *dnsmessage.OPTResource.
+	OPT *dnsmessage.Resource
+
+	// Network is the transport of the query: "udp", "tcp", "tcp-tls"
+	// for DNS over TLS, "https" for DNS over HTTPS or "quic" for DNS
+	// over QUIC.
+	Network    string
+	RemoteAddr net.Addr
+
+	// MaxSize is the size of the largest response that the client
+	// accepts. Larger responses are truncated.
+	MaxSize int
+}
+
+// NewResponse returns an empty response to r, with the same ID,
+// operation code and questions, and the RecursionDesired bit copied
+// from the query.
+func (r *Request) NewResponse(rcode dnsmessage.RCode) *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               r.Header.ID,
+			Response:         true,
+			OpCode:           r.Header.OpCode,
+			RecursionDesired: r.Header.RecursionDesired,
+			RCode:            rcode,
+		},
+		Questions: r.Questions,
+	}
+}
+
+// A ResponseWriter sends the response to a query.
+type ResponseWriter interface {
+	// WriteMsg sends m as the response. If the query has an OPT record
+	// and m doesn't, an OPT record is added to m. If m is too large for
+	// the transport, a truncated response is sent instead.
+	//
+	// WriteMsg may only be called once.
+	WriteMsg(m *dnsmessage.Message) error
+}
+
+// A Handler responds to DNS queries.
+//
+// If ServeDNS returns without calling WriteMsg, no response is sent and
+// the client eventually times out. This lets handlers drop queries.
+type Handler interface {
+	ServeDNS(ctx context.Context, w ResponseWriter, r *Request)
+}
+
+// The HandlerFunc type is an adapter to allow the use of ordinary
+// functions as DNS handlers.
+type HandlerFunc func(ctx context.Context, w ResponseWriter, r *Request)
+
+// ServeDNS calls f(ctx, w, r).
+func (f HandlerFunc) ServeDNS(ctx context.Context, w ResponseWriter, r *Request) {
+	f(ctx, w, r)
+}
+
+// sameQuestions reports whether the questions of a response match those
+// of the query. Names are compared case-insensitively, since servers
+// may not preserve the case of the query.
+func sameQuestions(a, b []dnsmessage.Question) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Class != b[i].Class ||
+			!equalASCIIFold(a[i].Name.String(), b[i].Name.String()) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalASCIIFold reports whether two names are equal when compared
+// case-insensitively as in DNS, which only folds ASCII letters.
+func equalASCIIFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if lower(a[i]) != lower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// readStreamMsg reads a message prefixed by its two-byte length, as sent
+// over TCP (RFC 1035 section 4.2.2).
+func readStreamMsg(r io.Reader) ([]byte, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// writeStreamMsg writes a message prefixed by its two-byte length. The
+// length and the message are written at once, as recommended by RFC 7766
+// section 8.
+func writeStreamMsg(w io.Writer, b []byte) error {
+	if len(b) > maxMsgSize {
+		return errMsgTooLong
+	}
+	buf := make([]byte, 2, 2+len(b))
+	binary.BigEndian.PutUint16(buf, uint16(len(b)))
+	_, err := w.Write(append(buf, b...))
+	return err
+}
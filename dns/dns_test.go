@@ -0,0 +1,323 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/dns/dnstest"
+)
+
+func query(name string, t dnsmessage.Type) *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  t,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+}
+
+// txtHandler answers with n TXT records of 100 bytes, and records the
+// requests it receives.
+type txtHandler struct {
+	n    int
+	mu   sync.Mutex
+	reqs []*dns.Request
+}
+
+func (h *txtHandler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Request) {
+	h.mu.Lock()
+	h.reqs = append(h.reqs, r)
+	n := h.n
+	h.mu.Unlock()
+	m := r.NewResponse(dnsmessage.RCodeSuccess)
+	for range n {
+		m.Answers = append(m.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: r.Questions[0].Name, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.TXTResource{TXT: []string{strings.Repeat("x", 100)}},
+		})
+	}
+	w.WriteMsg(m)
+}
+
+// reset sets the number of records of the responses and forgets the
+// requests.
+func (h *txtHandler) reset(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.n, h.reqs = n, nil
+}
+
+func (h *txtHandler) networks() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var ns []string
+	for _, r := range h.reqs {
+		ns = append(ns, r.Network)
+	}
+	return ns
+}
+
+func TestExchange(t *testing.T) {
+	h := &txtHandler{n: 1}
+	s := dnstest.NewServer(h)
+	defer s.Close()
+
+	for _, network := range []string{"udp", "tcp"} {
+		c := &dns.Client{Net: network}
+		q := query("Example.COM.", dnsmessage.TypeTXT)
+		resp, err := c.Exchange(context.Background(), s.Addr, q)
+		if err != nil {
+			t.Fatalf("%s: Exchange() = %v", network, err)
+		}
+		if q.Header.ID != 0 {
+			t.Errorf("%s: Exchange() modified the query", network)
+		}
+		if resp.Header.RCode != dnsmessage.RCodeSuccess || !resp.Header.RecursionDesired || len(resp.Answers) != 1 {
+			t.Errorf("%s: Exchange() = %#v", network, resp)
+		}
+	}
+	if got := h.networks(); len(got) != 2 || got[0] != "udp" || got[1] != "tcp" {
+		t.Errorf("queries received over %q, want [udp tcp]", got)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	h := &txtHandler{n: 20}
+	s := dnstest.NewServer(h)
+	defer s.Close()
+
+	// The 2 KB response doesn't fit in 512 bytes, or in the EDNS payload
+	// size of the server.
+	for _, udpSize := range []int{0, 4096} {
+		h.reset(20)
+		c := &dns.Client{UDPSize: udpSize}
+		resp, err := c.Exchange(context.Background(), s.Addr, query("example.com.", dnsmessage.TypeTXT))
+		if err != nil {
+			t.Fatal("Exchange() =", err)
+		}
+		if resp.Header.Truncated || len(resp.Answers) != 20 {
+			t.Errorf("UDPSize %d: got TC = %t and %d answers, want a complete response", udpSize, resp.Header.Truncated, len(resp.Answers))
+		}
+		if got := h.networks(); len(got) != 2 || got[0] != "udp" || got[1] != "tcp" {
+			t.Errorf("UDPSize %d: queries received over %q, want [udp tcp]", udpSize, got)
+		}
+		wantMax := 512
+		if udpSize > 0 {
+			wantMax = 1232
+		}
+		h.mu.Lock()
+		gotMax := h.reqs[0].MaxSize
+		h.mu.Unlock()
+		if got := gotMax; got != wantMax {
+			t.Errorf("UDPSize %d: Request.MaxSize = %d, want %d", udpSize, got, wantMax)
+		}
+	}
+
+	// With EDNS, a 1 KB response fits in UDP.
+	h.reset(10)
+	c := &dns.Client{UDPSize: 4096}
+	resp, err := c.Exchange(context.Background(), s.Addr, query("example.com.", dnsmessage.TypeTXT))
+	if err != nil {
+		t.Fatal("Exchange() =", err)
+	}
+	if got := h.networks(); len(got) != 1 || len(resp.Answers) != 10 {
+		t.Errorf("got %d answers over %q, want 10 answers over UDP", len(resp.Answers), got)
+	}
+	if n := len(resp.Additionals); n != 1 || resp.Additionals[0].Header.Class != 1232 {
+		t.Errorf("response Additionals = %#v, want an OPT record with a payload size of 1232", resp.Additionals)
+	}
+}
+
+func TestEDNS(t *testing.T) {
+	s := dnstest.NewServer(dns.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Request) {
+		w.WriteMsg(r.NewResponse(dnsmessage.RCodeNameError))
+	}))
+	defer s.Close()
+
+	var h dnsmessage.ResourceHeader
+	h.SetEDNS0(4096, dnsmessage.RCodeSuccess, true)
+	for _, tt := range []struct {
+		version uint32
+		want    dnsmessage.RCode
+	}{
+		{0, dnsmessage.RCodeNameError},
+		{1, 16}, // BADVERS
+	} {
+		q := query("example.com.", dnsmessage.TypeA)
+		h.TTL = h.TTL&^0xff0000 | tt.version<<16
+		q.Additionals = []dnsmessage.Resource{{Header: h, Body: &dnsmessage.OPTResource{}}}
+		resp, err := new(dns.Client).Exchange(context.Background(), s.Addr, q)
+		if err != nil {
+			t.Fatal("Exchange() =", err)
+		}
+		if len(resp.Additionals) != 1 {
+			t.Fatalf("EDNS version %d: response has no OPT record", tt.version)
+		}
+		opt := resp.Additionals[0].Header
+		if got := opt.ExtendedRCode(resp.Header.RCode); got != tt.want {
+			t.Errorf("EDNS version %d: RCode = %v, want %v", tt.version, got, tt.want)
+		}
+		if tt.version == 0 && !opt.DNSSECAllowed() {
+			t.Errorf("EDNS version %d: DO bit not copied to the response", tt.version)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	s := dnstest.NewServer(dns.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Request) {
+		mu.Lock()
+		count++
+		drop := count == 1
+		mu.Unlock()
+		if !drop {
+			w.WriteMsg(r.NewResponse(dnsmessage.RCodeSuccess))
+		}
+	}))
+	defer s.Close()
+
+	c := &dns.Client{Timeout: 100 * time.Millisecond, Attempts: 2}
+	if _, err := c.Exchange(context.Background(), s.Addr, query("example.com.", dnsmessage.TypeA)); err != nil {
+		t.Fatal("Exchange() =", err)
+	}
+
+	reset := func() {
+		mu.Lock()
+		count = 0
+		mu.Unlock()
+	}
+
+	// Without retries, the dropped query times out.
+	reset()
+	c.Attempts = 1
+	_, err := c.Exchange(context.Background(), s.Addr, query("example.com.", dnsmessage.TypeA))
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Exchange() of a dropped query = %v, want a timeout", err)
+	}
+
+	// The context bounds the exchange too.
+	reset()
+	c = &dns.Client{Timeout: time.Minute}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Exchange(ctx, s.Addr, query("example.com.", dnsmessage.TypeA)); err != context.DeadlineExceeded {
+		t.Errorf("Exchange() with a short context = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// TestMismatchedResponses checks that the client ignores responses that
+// don't match its query, such as forged ones, and datagrams that can't be
+// parsed.
+func TestMismatchedResponses(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var q dnsmessage.Message
+		if err := q.Unpack(buf[:n]); err != nil {
+			return
+		}
+		send := func(m dnsmessage.Message) {
+			b, _ := m.Pack()
+			pc.WriteTo(b, addr)
+		}
+		resp := q
+		resp.Header.Response = true
+		pc.WriteTo([]byte{0x00}, addr)
+		if b, err := resp.Pack(); err == nil {
+			// The header is intact, but the question is cut short.
+			pc.WriteTo(b[:len(b)-2], addr)
+		}
+		wrongID := resp
+		wrongID.Header.ID++
+		send(wrongID)
+		wrongQ := resp
+		wrongQ.Questions = []dnsmessage.Question{{Name: dnsmessage.MustNewName("evil.example."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}}
+		send(wrongQ)
+		resp.Header.RCode = dnsmessage.RCodeRefused
+		send(resp)
+	}()
+
+	resp, err := new(dns.Client).Exchange(context.Background(), pc.LocalAddr().String(), query("example.com.", dnsmessage.TypeA))
+	if err != nil {
+		t.Fatal("Exchange() =", err)
+	}
+	if resp.Header.RCode != dnsmessage.RCodeRefused {
+		t.Errorf("Exchange() returned %#v, want the matching response", resp)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := dnstest.NewServer(nil)
+	defer s.Close()
+
+	resp, err := new(dns.Client).Exchange(context.Background(), s.Addr, query("example.com.", dnsmessage.TypeA))
+	if err != nil {
+		t.Fatal("Exchange() =", err)
+	}
+	if resp.Header.RCode != dnsmessage.RCodeRefused {
+		t.Errorf("RCode with a nil Handler = %v, want %v", resp.Header.RCode, dnsmessage.RCodeRefused)
+	}
+
+	// A query with a valid header but truncated questions gets a format
+	// error.
+	conn, err := net.Dial("udp", s.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0, 3, 'c', 'o'}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m dnsmessage.Message
+	if err := m.Unpack(buf[:n]); err != nil {
+		t.Fatal(err)
+	}
+	if m.Header.ID != 0x1234 || !m.Header.Response || m.Header.RCode != dnsmessage.RCodeFormatError {
+		t.Errorf("response to a malformed query = %#v, want a format error", &m.Header)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	s := &dns.Server{Addr: "127.0.0.1:0"}
+	errc := make(chan error, 1)
+	go func() { errc <- s.ListenAndServe() }()
+	time.Sleep(10 * time.Millisecond)
+	s.Close()
+	select {
+	case err := <-errc:
+		if err != dns.ErrServerClosed {
+			t.Errorf("ListenAndServe() = %v, want %v", err, dns.ErrServerClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe() did not return after Close")
+	}
+}
@@ -0,0 +1,504 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import (
+	"errors"
+	"slices"
+)
+
+// A DNSSECAlgorithm is a DNSSEC signing algorithm.
+type DNSSECAlgorithm uint8
+
+const (
+	AlgorithmRSASHA256       DNSSECAlgorithm = 8
+	AlgorithmRSASHA512       DNSSECAlgorithm = 10
+	AlgorithmECDSAP256SHA256 DNSSECAlgorithm = 13
+	AlgorithmECDSAP384SHA384 DNSSECAlgorithm = 14
+	AlgorithmED25519         DNSSECAlgorithm = 15
+)
+
+var dnssecAlgorithmNames = map[DNSSECAlgorithm]string{
+	AlgorithmRSASHA256:       "AlgorithmRSASHA256",
+	AlgorithmRSASHA512:       "AlgorithmRSASHA512",
+	AlgorithmECDSAP256SHA256: "AlgorithmECDSAP256SHA256",
+	AlgorithmECDSAP384SHA384: "AlgorithmECDSAP384SHA384",
+	AlgorithmED25519:         "AlgorithmED25519",
+}
+
+// String implements fmt.Stringer.String.
+func (a DNSSECAlgorithm) String() string {
+	if n, ok := dnssecAlgorithmNames[a]; ok {
+		return n
+	}
+	return printUint16(uint16(a))
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (a DNSSECAlgorithm) GoString() string {
+	if n, ok := dnssecAlgorithmNames[a]; ok {
+		return "dnsmessage." + n
+	}
+	return printUint16(uint16(a))
+}
+
+// A DigestType is the digest algorithm of a DS Resource record.
+type DigestType uint8
+
+const (
+	DigestSHA1   DigestType = 1
+	DigestSHA256 DigestType = 2
+	DigestSHA384 DigestType = 4
+)
+
+var digestTypeNames = map[DigestType]string{
+	DigestSHA1:   "DigestSHA1",
+	DigestSHA256: "DigestSHA256",
+	DigestSHA384: "DigestSHA384",
+}
+
+// String implements fmt.Stringer.String.
+func (d DigestType) String() string {
+	if n, ok := digestTypeNames[d]; ok {
+		return n
+	}
+	return printUint16(uint16(d))
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (d DigestType) GoString() string {
+	if n, ok := digestTypeNames[d]; ok {
+		return "dnsmessage." + n
+	}
+	return printUint16(uint16(d))
+}
+
+// DNSKEYResource.Flags bits.
+const (
+	DNSKEYFlagZone uint16 = 0x0100 // the key is a zone key
+	DNSKEYFlagSEP  uint16 = 0x0001 // the key is a secure entry point
+)
+
+// NSEC3Resource.Flags bits.
+const (
+	NSEC3FlagOptOut uint8 = 0x01
+)
+
+// NSEC3HashSHA1 is the NSEC3 hash algorithm defined in RFC 5155.
+const NSEC3HashSHA1 uint8 = 1
+
+var errInvalidTypeBitmap = errors.New("invalid type bitmap")
+
+// A DNSKEYResource is a DNSKEY Resource record, as defined in RFC 4034.
+type DNSKEYResource struct {
+	Flags     uint16
+	Protocol  uint8 // always 3
+	Algorithm DNSSECAlgorithm
+	PublicKey []byte
+}
+
+func (r *DNSKEYResource) realType() Type {
+	return TypeDNSKEY
+}
+
+// pack appends the wire format of the DNSKEYResource to msg.
+func (r *DNSKEYResource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	msg = packUint16(msg, r.Flags)
+	msg = append(msg, r.Protocol, byte(r.Algorithm))
+	return packBytes(msg, r.PublicKey), nil
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *DNSKEYResource) GoString() string {
+	return "dnsmessage.DNSKEYResource{" +
+		"Flags: " + printUint16(r.Flags) + ", " +
+		"Protocol: " + printUint16(uint16(r.Protocol)) + ", " +
+		"Algorithm: " + r.Algorithm.GoString() + ", " +
+		"PublicKey: []byte{" + printByteSlice(r.PublicKey) + "}}"
+}
+
+// KeyTag returns the key tag of the key, which identifies it in RRSIG and
+// DS Resource records (RFC 4034 Appendix B).
+func (r *DNSKEYResource) KeyTag() uint16 {
+	rdata, _ := r.pack(nil, nil, 0)
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xffff
+	return uint16(ac)
+}
+
+func unpackDNSKEYResource(msg []byte, off int, length uint16) (DNSKEYResource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return DNSKEYResource{}, errResourceLen
+	}
+	flags, off, err := unpackUint16(msg[:end], off)
+	if err != nil {
+		return DNSKEYResource{}, &nestedError{"Flags", err}
+	}
+	if off+2 > end {
+		return DNSKEYResource{}, &nestedError{"Algorithm", errBaseLen}
+	}
+	r := DNSKEYResource{
+		Flags:     flags,
+		Protocol:  msg[off],
+		Algorithm: DNSSECAlgorithm(msg[off+1]),
+		PublicKey: slices.Clone(msg[off+2 : end]),
+	}
+	return r, nil
+}
+
+// A DSResource is a DS Resource record, as defined in RFC 4034.
+type DSResource struct {
+	KeyTag     uint16
+	Algorithm  DNSSECAlgorithm
+	DigestType DigestType
+	Digest     []byte
+}
+
+func (r *DSResource) realType() Type {
+	return TypeDS
+}
+
+// pack appends the wire format of the DSResource to msg.
+func (r *DSResource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	msg = packUint16(msg, r.KeyTag)
+	msg = append(msg, byte(r.Algorithm), byte(r.DigestType))
+	return packBytes(msg, r.Digest), nil
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *DSResource) GoString() string {
+	return "dnsmessage.DSResource{" +
+		"KeyTag: " + printUint16(r.KeyTag) + ", " +
+		"Algorithm: " + r.Algorithm.GoString() + ", " +
+		"DigestType: " + r.DigestType.GoString() + ", " +
+		"Digest: []byte{" + printByteSlice(r.Digest) + "}}"
+}
+
+func unpackDSResource(msg []byte, off int, length uint16) (DSResource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return DSResource{}, errResourceLen
+	}
+	keyTag, off, err := unpackUint16(msg[:end], off)
+	if err != nil {
+		return DSResource{}, &nestedError{"KeyTag", err}
+	}
+	if off+2 > end {
+		return DSResource{}, &nestedError{"DigestType", errBaseLen}
+	}
+	r := DSResource{
+		KeyTag:     keyTag,
+		Algorithm:  DNSSECAlgorithm(msg[off]),
+		DigestType: DigestType(msg[off+1]),
+		Digest:     slices.Clone(msg[off+2 : end]),
+	}
+	return r, nil
+}
+
+// An RRSIGResource is an RRSIG Resource record, as defined in RFC 4034.
+type RRSIGResource struct {
+	TypeCovered Type
+	Algorithm   DNSSECAlgorithm
+
+	// Labels is the number of labels in the owner name of the signed
+	// RRset, not counting the root and a leading wildcard label.
+	Labels      uint8
+	OriginalTTL uint32
+
+	// Expiration and Inception bound the validity period of the
+	// signature, in seconds since the Unix epoch modulo 2**32.
+	Expiration uint32
+	Inception  uint32
+	KeyTag     uint16
+	SignerName Name // Not compressed as per RFC 4034.
+	Signature  []byte
+}
+
+func (r *RRSIGResource) realType() Type {
+	return TypeRRSIG
+}
+
+// pack appends the wire format of the RRSIGResource to msg.
+func (r *RRSIGResource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	oldMsg := msg
+	msg, err := r.packSigned(msg)
+	if err != nil {
+		return oldMsg, err
+	}
+	return packBytes(msg, r.Signature), nil
+}
+
+// packSigned appends the wire format of the RRSIGResource without its
+// signature to msg.
+func (r *RRSIGResource) packSigned(msg []byte) ([]byte, error) {
+	oldMsg := msg
+	msg = packType(msg, r.TypeCovered)
+	msg = append(msg, byte(r.Algorithm), r.Labels)
+	msg = packUint32(msg, r.OriginalTTL)
+	msg = packUint32(msg, r.Expiration)
+	msg = packUint32(msg, r.Inception)
+	msg = packUint16(msg, r.KeyTag)
+	msg, err := r.SignerName.pack(msg, nil, 0)
+	if err != nil {
+		return oldMsg, &nestedError{"RRSIGResource.SignerName", err}
+	}
+	return msg, nil
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *RRSIGResource) GoString() string {
+	return "dnsmessage.RRSIGResource{" +
+		"TypeCovered: " + r.TypeCovered.GoString() + ", " +
+		"Algorithm: " + r.Algorithm.GoString() + ", " +
+		"Labels: " + printUint16(uint16(r.Labels)) + ", " +
+		"OriginalTTL: " + printUint32(r.OriginalTTL) + ", " +
+		"Expiration: " + printUint32(r.Expiration) + ", " +
+		"Inception: " + printUint32(r.Inception) + ", " +
+		"KeyTag: " + printUint16(r.KeyTag) + ", " +
+		"SignerName: " + r.SignerName.GoString() + ", " +
+		"Signature: []byte{" + printByteSlice(r.Signature) + "}}"
+}
+
+func unpackRRSIGResource(msg []byte, off int, length uint16) (RRSIGResource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return RRSIGResource{}, errResourceLen
+	}
+	var r RRSIGResource
+	var err error
+	if r.TypeCovered, off, err = unpackType(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"TypeCovered", err}
+	}
+	if off+2 > end {
+		return RRSIGResource{}, &nestedError{"Labels", errBaseLen}
+	}
+	r.Algorithm, r.Labels = DNSSECAlgorithm(msg[off]), msg[off+1]
+	off += 2
+	if r.OriginalTTL, off, err = unpackUint32(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"OriginalTTL", err}
+	}
+	if r.Expiration, off, err = unpackUint32(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"Expiration", err}
+	}
+	if r.Inception, off, err = unpackUint32(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"Inception", err}
+	}
+	if r.KeyTag, off, err = unpackUint16(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"KeyTag", err}
+	}
+	if off, err = r.SignerName.unpack(msg[:end], off); err != nil {
+		return RRSIGResource{}, &nestedError{"SignerName", err}
+	}
+	r.Signature = slices.Clone(msg[off:end])
+	return r, nil
+}
+
+// An NSECResource is an NSEC Resource record, as defined in RFC 4034.
+type NSECResource struct {
+	NextDomain Name // Not compressed as per RFC 4034.
+
+	// Types lists the types of the records that exist at the owner name.
+	// They are packed in increasing order.
+	Types []Type
+}
+
+func (r *NSECResource) realType() Type {
+	return TypeNSEC
+}
+
+// pack appends the wire format of the NSECResource to msg.
+func (r *NSECResource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	oldMsg := msg
+	msg, err := r.NextDomain.pack(msg, nil, compressionOff)
+	if err != nil {
+		return oldMsg, &nestedError{"NSECResource.NextDomain", err}
+	}
+	return packTypeBitmap(msg, r.Types), nil
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *NSECResource) GoString() string {
+	return "dnsmessage.NSECResource{" +
+		"NextDomain: " + r.NextDomain.GoString() + ", " +
+		"Types: " + printTypes(r.Types) + "}"
+}
+
+// HasType reports whether the type bitmap of r includes t.
+func (r *NSECResource) HasType(t Type) bool {
+	return slices.Contains(r.Types, t)
+}
+
+func unpackNSECResource(msg []byte, off int, length uint16) (NSECResource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return NSECResource{}, errResourceLen
+	}
+	var r NSECResource
+	var err error
+	if off, err = r.NextDomain.unpack(msg[:end], off); err != nil {
+		return NSECResource{}, &nestedError{"NextDomain", err}
+	}
+	if r.Types, err = unpackTypeBitmap(msg, off, end); err != nil {
+		return NSECResource{}, &nestedError{"Types", err}
+	}
+	return r, nil
+}
+
+// An NSEC3Resource is an NSEC3 Resource record, as defined in RFC 5155.
+type NSEC3Resource struct {
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+
+	// NextHashedOwner is the binary form of the hashed owner name of the
+	// next record in the hash order of the zone.
+	NextHashedOwner []byte
+
+	// Types lists the types of the records that exist at the original
+	// owner name. They are packed in increasing order.
+	Types []Type
+}
+
+func (r *NSEC3Resource) realType() Type {
+	return TypeNSEC3
+}
+
+// pack appends the wire format of the NSEC3Resource to msg.
+func (r *NSEC3Resource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	if len(r.Salt) > 255 {
+		return msg, &nestedError{"NSEC3Resource.Salt", errStringTooLong}
+	}
+	if len(r.NextHashedOwner) == 0 || len(r.NextHashedOwner) > 255 {
+		return msg, &nestedError{"NSEC3Resource.NextHashedOwner", errStringTooLong}
+	}
+	msg = append(msg, r.HashAlgorithm, r.Flags)
+	msg = packUint16(msg, r.Iterations)
+	msg = append(msg, byte(len(r.Salt)))
+	msg = packBytes(msg, r.Salt)
+	msg = append(msg, byte(len(r.NextHashedOwner)))
+	msg = packBytes(msg, r.NextHashedOwner)
+	return packTypeBitmap(msg, r.Types), nil
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *NSEC3Resource) GoString() string {
+	return "dnsmessage.NSEC3Resource{" +
+		"HashAlgorithm: " + printUint16(uint16(r.HashAlgorithm)) + ", " +
+		"Flags: " + printUint16(uint16(r.Flags)) + ", " +
+		"Iterations: " + printUint16(r.Iterations) + ", " +
+		"Salt: []byte{" + printByteSlice(r.Salt) + "}, " +
+		"NextHashedOwner: []byte{" + printByteSlice(r.NextHashedOwner) + "}, " +
+		"Types: " + printTypes(r.Types) + "}"
+}
+
+// HasType reports whether the type bitmap of r includes t.
+func (r *NSEC3Resource) HasType(t Type) bool {
+	return slices.Contains(r.Types, t)
+}
+
+func unpackNSEC3Resource(msg []byte, off int, length uint16) (NSEC3Resource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return NSEC3Resource{}, errResourceLen
+	}
+	if off+5 > end {
+		return NSEC3Resource{}, errBaseLen
+	}
+	var r NSEC3Resource
+	r.HashAlgorithm, r.Flags = msg[off], msg[off+1]
+	r.Iterations, off, _ = unpackUint16(msg, off+2)
+	n := int(msg[off])
+	off++
+	if off+n > end {
+		return NSEC3Resource{}, &nestedError{"Salt", errCalcLen}
+	}
+	r.Salt = slices.Clone(msg[off : off+n])
+	off += n
+	if off >= end {
+		return NSEC3Resource{}, &nestedError{"NextHashedOwner", errBaseLen}
+	}
+	n = int(msg[off])
+	off++
+	if n == 0 || off+n > end {
+		return NSEC3Resource{}, &nestedError{"NextHashedOwner", errCalcLen}
+	}
+	r.NextHashedOwner = slices.Clone(msg[off : off+n])
+	off += n
+	var err error
+	if r.Types, err = unpackTypeBitmap(msg, off, end); err != nil {
+		return NSEC3Resource{}, &nestedError{"Types", err}
+	}
+	return r, nil
+}
+
+// packTypeBitmap appends the type bitmap of types to msg
+// (RFC 4034 Section 4.1.2).
+func packTypeBitmap(msg []byte, types []Type) []byte {
+	types = slices.Clone(types)
+	slices.Sort(types)
+	types = slices.Compact(types)
+	for i := 0; i < len(types); {
+		window := types[i] >> 8
+		var bitmap [32]byte
+		n := 0
+		for ; i < len(types) && types[i]>>8 == window; i++ {
+			b := types[i] & 0xff
+			bitmap[b/8] |= 0x80 >> (b % 8)
+			n = int(b/8) + 1
+		}
+		msg = append(msg, byte(window), byte(n))
+		msg = append(msg, bitmap[:n]...)
+	}
+	return msg
+}
+
+// unpackTypeBitmap unpacks the type bitmap in msg[off:end].
+func unpackTypeBitmap(msg []byte, off, end int) ([]Type, error) {
+	var types []Type
+	prev := -1
+	for off < end {
+		if off+2 > end {
+			return nil, errBaseLen
+		}
+		window, n := int(msg[off]), int(msg[off+1])
+		off += 2
+		if window <= prev || n == 0 || n > 32 {
+			return nil, errInvalidTypeBitmap
+		}
+		if off+n > end {
+			return nil, errCalcLen
+		}
+		for i, b := range msg[off : off+n] {
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) != 0 {
+					types = append(types, Type(window<<8|i*8+bit))
+				}
+			}
+		}
+		off += n
+		prev = window
+	}
+	return types, nil
+}
+
+func printTypes(types []Type) string {
+	s := "[]dnsmessage.Type{"
+	for i, t := range types {
+		if i > 0 {
+			s += ", "
+		}
+		s += t.GoString()
+	}
+	return s + "}"
+}
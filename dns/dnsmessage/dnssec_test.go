@@ -0,0 +1,458 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustDecodeBase64(s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestDNSSECPackUnpack(t *testing.T) {
+	name := MustNewName("example.com.")
+	hdr := ResourceHeader{Name: name, Class: ClassINET, TTL: 3600}
+	dnskey := DNSKEYResource{Flags: DNSKEYFlagZone | DNSKEYFlagSEP, Protocol: 3, Algorithm: AlgorithmED25519, PublicKey: []byte{1, 2, 3}}
+	ds := DSResource{KeyTag: 3613, Algorithm: AlgorithmED25519, DigestType: DigestSHA256, Digest: []byte{4, 5, 6}}
+	rrsig := RRSIGResource{
+		TypeCovered: TypeMX,
+		Algorithm:   AlgorithmED25519,
+		Labels:      2,
+		OriginalTTL: 3600,
+		Expiration:  1440021600,
+		Inception:   1438207200,
+		KeyTag:      3613,
+		SignerName:  name,
+		Signature:   []byte{7, 8, 9},
+	}
+	nsec := NSECResource{NextDomain: MustNewName("a.example.com."), Types: []Type{TypeNSEC, TypeA, 1234, TypeRRSIG, TypeA}}
+	nsec3 := NSEC3Resource{
+		HashAlgorithm:   NSEC3HashSHA1,
+		Flags:           NSEC3FlagOptOut,
+		Iterations:      12,
+		Salt:            []byte{0xaa, 0xbb, 0xcc, 0xdd},
+		NextHashedOwner: bytes.Repeat([]byte{0x11}, 20),
+		Types:           []Type{TypeMX},
+	}
+
+	b := NewBuilder(nil, Header{Response: true})
+	b.EnableCompression()
+	if err := b.StartAnswers(); err != nil {
+		t.Fatal("Builder.StartAnswers() =", err)
+	}
+	for _, f := range []func() error{
+		func() error { return b.DNSKEYResource(hdr, dnskey) },
+		func() error { return b.DSResource(hdr, ds) },
+		func() error { return b.RRSIGResource(hdr, rrsig) },
+		func() error { return b.NSECResource(hdr, nsec) },
+		func() error { return b.NSEC3Resource(hdr, nsec3) },
+	} {
+		if err := f(); err != nil {
+			t.Fatal("Builder =", err)
+		}
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal("Builder.Finish() =", err)
+	}
+
+	// Packing sorts the type bitmap and removes duplicates.
+	nsec.Types = []Type{TypeA, TypeRRSIG, TypeNSEC, 1234}
+
+	var p Parser
+	if _, err := p.Start(msg); err != nil {
+		t.Fatal("Parser.Start() =", err)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		t.Fatal("Parser.SkipAllQuestions() =", err)
+	}
+	parse := func(typ Type, f func() (any, error), want any) {
+		t.Helper()
+		if h, err := p.AnswerHeader(); err != nil || h.Type != typ {
+			t.Fatalf("Parser.AnswerHeader() = %v, %v, want Type = %v", h, err, typ)
+		}
+		got, err := f()
+		if err != nil {
+			t.Fatalf("parsing %v: %v", typ, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parsing %v: got %#v, want %#v", typ, got, want)
+		}
+	}
+	parse(TypeDNSKEY, func() (any, error) { return p.DNSKEYResource() }, dnskey)
+	parse(TypeDS, func() (any, error) { return p.DSResource() }, ds)
+	parse(TypeRRSIG, func() (any, error) { return p.RRSIGResource() }, rrsig)
+	parse(TypeNSEC, func() (any, error) { return p.NSECResource() }, nsec)
+	parse(TypeNSEC3, func() (any, error) { return p.NSEC3Resource() }, nsec3)
+
+	var m Message
+	if err := m.Unpack(msg); err != nil {
+		t.Fatal("Message.Unpack() =", err)
+	}
+	want := []ResourceBody{&dnskey, &ds, &rrsig, &nsec, &nsec3}
+	for i, a := range m.Answers {
+		if !reflect.DeepEqual(a.Body, want[i]) {
+			t.Errorf("Message.Unpack() Answers[%d].Body = %#v, want = %#v", i, a.Body, want[i])
+		}
+	}
+
+	const wantGoString = `dnsmessage.NSECResource{NextDomain: dnsmessage.MustNewName("a.example.com."), ` +
+		`Types: []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeRRSIG, dnsmessage.TypeNSEC, 1234}}`
+	if got := nsec.GoString(); got != wantGoString {
+		t.Errorf("NSECResource.GoString() = %s\nwant = %s", got, wantGoString)
+	}
+}
+
+func TestTypeBitmapInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		{0x00, 0x00},                         // empty bitmap
+		{0x00, 0x21},                         // bitmap too long
+		{0x00, 0x01, 0x40, 0x00},             // truncated window
+		{0x01, 0x01, 0x40, 0x00, 0x01, 0x40}, // windows out of order
+		{0x00, 0x02, 0x40},                   // truncated bitmap
+	} {
+		if _, err := unpackTypeBitmap(data, 0, len(data)); err == nil {
+			t.Errorf("unpackTypeBitmap(%v) = nil error", data)
+		}
+	}
+}
+
+// TestRFC8080 checks the Ed25519 example of RFC 8080 Section 6.1.
+func TestRFC8080(t *testing.T) {
+	name := MustNewName("example.com.")
+	key := DNSKEYResource{
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: AlgorithmED25519,
+		PublicKey: mustDecodeBase64("l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4="),
+	}
+	if got := key.KeyTag(); got != 3613 {
+		t.Errorf("KeyTag() = %d, want 3613", got)
+	}
+	ds, err := key.DS(MustNewName("EXAMPLE.com."), DigestSHA256)
+	if err != nil {
+		t.Fatal("DS() =", err)
+	}
+	if got, want := hex.EncodeToString(ds.Digest), "3aa5ab37efce57f737fc1627013fee07bdf241bd10f3b1964ab55c78e79a304b"; got != want {
+		t.Errorf("DS() digest = %s, want %s", got, want)
+	}
+
+	rrs := []Resource{{
+		Header: ResourceHeader{Name: MustNewName("Example.COM."), Class: ClassINET, TTL: 60},
+		Body:   &MXResource{Pref: 10, MX: MustNewName("Mail.example.com.")},
+	}}
+	sig := RRSIGResource{
+		TypeCovered: TypeMX,
+		Algorithm:   AlgorithmED25519,
+		Labels:      2,
+		OriginalTTL: 3600,
+		Expiration:  1440021600,
+		Inception:   1438207200,
+		KeyTag:      3613,
+		SignerName:  name,
+		Signature:   mustDecodeBase64("oL9krJun7xfBOIWcGHi7mag5/hdZrKWw15jPGrHpjQeRAvTdszaPD+QLs3fx8A4M3e23mRZ9VrbpMngwcrqNAg=="),
+	}
+	now := time.Unix(1439000000, 0)
+	if err := sig.Verify(rrs, &key, now); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := sig.Verify(rrs, &key, time.Unix(1440021601, 0)); err != errSignatureNotCurrent {
+		t.Errorf("Verify() after expiration = %v, want %v", err, errSignatureNotCurrent)
+	}
+	rrs[0].Body = &MXResource{Pref: 20, MX: MustNewName("mail.example.com.")}
+	if err := sig.Verify(rrs, &key, now); err != errBadSignature {
+		t.Errorf("Verify() of changed records = %v, want %v", err, errBadSignature)
+	}
+}
+
+// testSigner signs with a generated key of a DNSSEC algorithm.
+type testSigner struct {
+	alg  DNSSECAlgorithm
+	key  DNSKEYResource
+	sign func(data []byte) []byte
+}
+
+func newTestSigner(t *testing.T, alg DNSSECAlgorithm) testSigner {
+	s := testSigner{alg: alg}
+	switch alg {
+	case AlgorithmRSASHA256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := big.NewInt(int64(priv.E)).Bytes()
+		s.key.PublicKey = append(append([]byte{byte(len(e))}, e...), priv.N.Bytes()...)
+		s.sign = func(data []byte) []byte {
+			h := sha256.Sum256(data)
+			sig, err := rsa.SignPKCS1v15(nil, priv, crypto.SHA256, h[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sig
+		}
+	case AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384:
+		curve, size := elliptic.P256(), 32
+		hash := func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }
+		if alg == AlgorithmECDSAP384SHA384 {
+			curve, size = elliptic.P384(), 48
+			hash = func(b []byte) []byte { h := sha512.Sum384(b); return h[:] }
+		}
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.key.PublicKey = append(priv.X.FillBytes(make([]byte, size)), priv.Y.FillBytes(make([]byte, size))...)
+		s.sign = func(data []byte) []byte {
+			r, ss, err := ecdsa.Sign(rand.Reader, priv, hash(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return append(r.FillBytes(make([]byte, size)), ss.FillBytes(make([]byte, size))...)
+		}
+	case AlgorithmED25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.key.PublicKey = pub
+		s.sign = func(data []byte) []byte { return ed25519.Sign(priv, data) }
+	}
+	s.key.Flags, s.key.Protocol, s.key.Algorithm = DNSKEYFlagZone, 3, alg
+	return s
+}
+
+func TestRRSIGVerify(t *testing.T) {
+	zone := MustNewName("example.com.")
+	now := time.Unix(1700000000, 0)
+	a := func(owner string, last byte) Resource {
+		return Resource{
+			Header: ResourceHeader{Name: MustNewName(owner), Type: TypeA, Class: ClassINET, TTL: 300},
+			Body:   &AResource{A: [4]byte{192, 0, 2, last}},
+		}
+	}
+	for _, alg := range []DNSSECAlgorithm{AlgorithmRSASHA256, AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384, AlgorithmED25519} {
+		t.Run(alg.String(), func(t *testing.T) {
+			s := newTestSigner(t, alg)
+			sig := RRSIGResource{
+				TypeCovered: TypeA,
+				Algorithm:   alg,
+				Labels:      3,
+				OriginalTTL: 3600,
+				Expiration:  uint32(now.Add(time.Hour).Unix()),
+				Inception:   uint32(now.Add(-time.Hour).Unix()),
+				KeyTag:      s.key.KeyTag(),
+				SignerName:  zone,
+			}
+			data, err := sig.SignedData([]Resource{a("www.example.com.", 1), a("www.example.com.", 2)})
+			if err != nil {
+				t.Fatal("SignedData() =", err)
+			}
+			sig.Signature = s.sign(data)
+
+			// The order, case, TTL and duplicates of the records do not
+			// matter.
+			rrs := []Resource{a("WWW.example.com.", 2), a("www.example.com.", 1), a("www.example.com.", 2)}
+			if err := sig.Verify(rrs, &s.key, now); err != nil {
+				t.Errorf("Verify() = %v", err)
+			}
+			if err := sig.Verify(rrs[1:2], &s.key, now); err != errBadSignature {
+				t.Errorf("Verify() of a partial RRset = %v, want %v", err, errBadSignature)
+			}
+			if err := sig.Verify(rrs, &s.key, now.Add(-2*time.Hour)); err != errSignatureNotCurrent {
+				t.Errorf("Verify() before inception = %v, want %v", err, errSignatureNotCurrent)
+			}
+			other := newTestSigner(t, alg)
+			if err := sig.Verify(rrs, &other.key, now); err != errKeyMismatch && err != errBadSignature {
+				t.Errorf("Verify() with another key = %v", err)
+			}
+			mixed := append(rrs, a("ftp.example.com.", 1))
+			if err := sig.Verify(mixed, &s.key, now); err != errRRSetMismatch {
+				t.Errorf("Verify() of records with different owners = %v, want %v", err, errRRSetMismatch)
+			}
+
+			// A signature of a wildcard RRset verifies expanded records.
+			wsig := sig
+			wsig.Labels = 2
+			data, err = wsig.SignedData([]Resource{a("*.example.com.", 1)})
+			if err != nil {
+				t.Fatal("SignedData() =", err)
+			}
+			wsig.Signature = s.sign(data)
+			if err := wsig.Verify([]Resource{a("foo.bar.example.com.", 1)}, &s.key, now); err != nil {
+				t.Errorf("Verify() of an expanded wildcard = %v", err)
+			}
+		})
+	}
+
+	key := DNSKEYResource{Flags: DNSKEYFlagZone, Protocol: 3, Algorithm: 253, PublicKey: []byte{1}}
+	sig := RRSIGResource{TypeCovered: TypeA, Algorithm: 253, Labels: 3, KeyTag: key.KeyTag(), SignerName: zone,
+		Inception: uint32(now.Unix()), Expiration: uint32(now.Unix())}
+	if err := sig.Verify([]Resource{a("www.example.com.", 1)}, &key, now); err != ErrUnsupportedAlgorithm {
+		t.Errorf("Verify() with a private algorithm = %v, want %v", err, ErrUnsupportedAlgorithm)
+	}
+}
+
+func TestNSECDenial(t *testing.T) {
+	nsec := func(owner, next string, types ...Type) Resource {
+		return Resource{
+			Header: ResourceHeader{Name: MustNewName(owner), Class: ClassINET},
+			Body:   &NSECResource{NextDomain: MustNewName(next), Types: types},
+		}
+	}
+	apex := nsec("example.", "a.example.", TypeNS, TypeSOA, TypeRRSIG, TypeNSEC, TypeDNSKEY)
+	a := nsec("a.example.", "d.example.", TypeA, TypeRRSIG, TypeNSEC)
+	d := nsec("d.example.", "example.", TypeNS, TypeRRSIG, TypeNSEC)
+	all := []Resource{apex, a, d}
+
+	for _, tt := range []struct {
+		rrs  []Resource
+		name string
+		ok   bool
+	}{
+		{all, "b.example.", true},
+		{all, "B.Example.", true},
+		{all, "z.example.", true},
+		{[]Resource{a}, "b.example.", false}, // the wildcard is not covered
+		{[]Resource{apex}, "b.example.", false},
+		{all, "a.example.", false},
+		{all, "x.d.example.", false}, // below a delegation
+	} {
+		err := CheckNSECNameError(tt.rrs, MustNewName(tt.name))
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckNSECNameError(%d records, %s) = %v, want ok = %t", len(tt.rrs), tt.name, err, tt.ok)
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		typ  Type
+		ok   bool
+	}{
+		{"a.example.", TypeAAAA, true},
+		{"A.example.", TypeMX, true},
+		{"a.example.", TypeA, false},
+		{"b.example.", TypeA, false},
+		{"d.example.", TypeDS, true},
+		{"d.example.", TypeA, false}, // the parent side of a delegation
+	} {
+		err := CheckNSECNoData(all, MustNewName(tt.name), tt.typ)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckNSECNoData(%s, %v) = %v, want ok = %t", tt.name, tt.typ, err, tt.ok)
+		}
+	}
+}
+
+// nsec3Params are the NSEC3 parameters of the example zone of RFC 5155
+// Appendix A.
+var nsec3Params = NSEC3Resource{
+	HashAlgorithm: NSEC3HashSHA1,
+	Flags:         NSEC3FlagOptOut,
+	Iterations:    12,
+	Salt:          []byte{0xaa, 0xbb, 0xcc, 0xdd},
+}
+
+func TestNSEC3Hash(t *testing.T) {
+	for name, want := range map[string]string{
+		"example.":       "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom",
+		"a.example.":     "35mthgpgcu1qg68fab165klnsnk3dpvl",
+		"A.EXAMPLE.":     "35mthgpgcu1qg68fab165klnsnk3dpvl",
+		"ns1.example.":   "2t7b4g4vsa5smi47k61mv5bv1a22bojr",
+		"w.example.":     "k8udemvp1j2f7eg6jebps17vp3n8i58h",
+		"*.w.example.":   "r53bq7cc2uvmubfu5ocmm6pers9tk9en",
+		"x.w.example.":   "b4um86eghhds6nea196smvmlo4ors995",
+		"xx.example.":    "t644ebqk9bibcna874givr6joj62mlhv",
+		"x.y.w.example.": "2vptu5timamqttgl4luu9kg21e0aor3s",
+	} {
+		h, err := nsec3Params.NSEC3Hash(MustNewName(name))
+		if err != nil {
+			t.Fatalf("NSEC3Hash(%s) = %v", name, err)
+		}
+		if got := strings.ToLower(nsec3Encoding.EncodeToString(h)); got != want {
+			t.Errorf("NSEC3Hash(%s) = %s, want %s", name, got, want)
+		}
+	}
+
+	p := nsec3Params
+	p.Iterations = maxNSEC3Iterations + 1
+	if _, err := p.NSEC3Hash(MustNewName("example.")); err != errTooManyIterations {
+		t.Errorf("NSEC3Hash() with too many iterations = %v, want %v", err, errTooManyIterations)
+	}
+}
+
+// TestNSEC3Denial checks the examples of RFC 5155 Appendix B.
+func TestNSEC3Denial(t *testing.T) {
+	zone := MustNewName("example.")
+	nsec3 := func(owner, next string, types ...Type) Resource {
+		r := nsec3Params
+		var err error
+		if r.NextHashedOwner, err = nsec3Encoding.DecodeString(strings.ToUpper(next)); err != nil {
+			t.Fatal(err)
+		}
+		r.Types = types
+		return Resource{
+			Header: ResourceHeader{Name: MustNewName(owner + ".example."), Class: ClassINET},
+			Body:   &r,
+		}
+	}
+	apex := nsec3("0p9mhaveqvm6t7vbl5lop2u3t2rp3tom", "2t7b4g4vsa5smi47k61mv5bv1a22bojr",
+		TypeMX, TypeDNSKEY, TypeNS, TypeSOA, TypeRRSIG)
+	xw := nsec3("b4um86eghhds6nea196smvmlo4ors995", "gjeqe526plbf1g8mklp59enfd789njgi", TypeMX, TypeRRSIG)
+	a := nsec3("35mthgpgcu1qg68fab165klnsnk3dpvl", "b4um86eghhds6nea196smvmlo4ors995", TypeNS, TypeDS, TypeRRSIG)
+	ns1 := nsec3("2t7b4g4vsa5smi47k61mv5bv1a22bojr", "2vptu5timamqttgl4luu9kg21e0aor3s", TypeA, TypeRRSIG)
+
+	// B.1: a.c.x.w.example. does not exist.
+	name := MustNewName("a.c.x.w.example.")
+	if err := CheckNSEC3NameError([]Resource{apex, xw, a}, zone, name); err != nil {
+		t.Errorf("CheckNSEC3NameError() = %v", err)
+	}
+	if err := CheckNSEC3NameError([]Resource{apex, xw}, zone, name); err == nil {
+		t.Error("CheckNSEC3NameError() without the wildcard proof = nil error")
+	}
+	if err := CheckNSEC3NameError([]Resource{xw, a}, zone, name); err == nil {
+		t.Error("CheckNSEC3NameError() without the next closer proof = nil error")
+	}
+	if err := CheckNSEC3NameError([]Resource{apex, xw, a}, MustNewName("other."), name); err == nil {
+		t.Error("CheckNSEC3NameError() for another zone = nil error")
+	}
+	// x.w.example. is the closest encloser, but a delegation point or a
+	// DNAME owner cannot prove that the names below it do not exist.
+	for _, types := range [][]Type{{TypeNS, TypeDS, TypeRRSIG}, {typeDNAME, TypeRRSIG}} {
+		xw := nsec3("b4um86eghhds6nea196smvmlo4ors995", "gjeqe526plbf1g8mklp59enfd789njgi", types...)
+		if err := CheckNSEC3NameError([]Resource{apex, xw, a}, zone, name); err == nil {
+			t.Errorf("CheckNSEC3NameError() with a closest encloser of types %v = nil error", types)
+		}
+	}
+
+	// B.2: ns1.example. has no MX records.
+	if err := CheckNSEC3NoData([]Resource{ns1}, zone, MustNewName("ns1.example."), TypeMX); err != nil {
+		t.Errorf("CheckNSEC3NoData() = %v", err)
+	}
+	if err := CheckNSEC3NoData([]Resource{ns1}, zone, MustNewName("ns1.example."), TypeA); err == nil {
+		t.Error("CheckNSEC3NoData() of an existing type = nil error")
+	}
+	if err := CheckNSEC3NoData([]Resource{apex}, zone, MustNewName("ns1.example."), TypeMX); err == nil {
+		t.Error("CheckNSEC3NoData() without a matching record = nil error")
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import "errors"
+
+// EDNS(0) option codes.
+const (
+	OptionCodeNSID          uint16 = 3  // RFC 5001
+	OptionCodeClientSubnet  uint16 = 8  // RFC 7871
+	OptionCodeCookie        uint16 = 10 // RFC 7873
+	OptionCodeTCPKeepalive  uint16 = 11 // RFC 7828
+	OptionCodePadding       uint16 = 12 // RFC 7830
+	OptionCodeExtendedError uint16 = 15 // RFC 8914
+)
+
+var (
+	errOptionCode = errors.New("option has a different code")
+	errOptionData = errors.New("invalid option data")
+)
+
+// Option returns the first option of r with the given code.
+func (r *OPTResource) Option(code uint16) (Option, bool) {
+	for _, o := range r.Options {
+		if o.Code == code {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// UDPPayloadSize returns the largest UDP payload that the sender of the
+// OPT record can reassemble. Values below 512 are treated as 512
+// (RFC 6891 section 6.2.3).
+func (h *ResourceHeader) UDPPayloadSize() int {
+	return max(int(h.Class), 512)
+}
+
+// SetUDPPayloadSize sets the UDP payload size of an OPT record.
+func (h *ResourceHeader) SetUDPPayloadSize(n int) {
+	h.Class = Class(n)
+}
+
+// EDNSVersion returns the EDNS version of an OPT record.
+func (h *ResourceHeader) EDNSVersion() uint8 {
+	return uint8(h.TTL & ednsVersionMask >> 16)
+}
+
+// SetDNSSECAllowed sets or clears the DNSSEC OK bit of an OPT record.
+func (h *ResourceHeader) SetDNSSECAllowed(dnssecOK bool) {
+	h.TTL &^= edns0DNSSECOK
+	if dnssecOK {
+		h.TTL |= edns0DNSSECOK
+	}
+}
+
+// SetExtendedRCode sets the upper 8 bits of the extended RCode rcode in an
+// OPT record. The lower 4 bits must be set in the DNS message header, as
+// returned by [RCode.HeaderRCode].
+func (h *ResourceHeader) SetExtendedRCode(rcode RCode) {
+	h.TTL = h.TTL&0x00ffffff | uint32(rcode)>>4<<24
+}
+
+// HeaderRCode returns the lower 4 bits of the extended RCode r, which are
+// carried by the DNS message header.
+func (r RCode) HeaderRCode() RCode {
+	return r & 0xF
+}
+
+// A ClientSubnetOption is an EDNS Client Subnet option (RFC 7871).
+type ClientSubnetOption struct {
+	// Family is the address family of Address: 1 for IPv4 or 2 for IPv6.
+	Family uint16
+
+	SourcePrefixLength uint8
+	ScopePrefixLength  uint8
+
+	// Address holds the 4 or 16 bytes of an address. Only the first
+	// SourcePrefixLength bits are packed; the others are unpacked as
+	// zero.
+	Address []byte
+}
+
+// addressLen returns the length of an address of the family f, or 0 if f
+// isn't supported.
+func addressLen(f uint16) int {
+	switch f {
+	case 1:
+		return 4
+	case 2:
+		return 16
+	}
+	return 0
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *ClientSubnetOption) Option() (Option, error) {
+	n := addressLen(o.Family)
+	if n == 0 || len(o.Address) != n ||
+		int(o.SourcePrefixLength) > 8*n || int(o.ScopePrefixLength) > 8*n {
+		return Option{}, errOptionData
+	}
+	data := packUint16(make([]byte, 0, 4+n), o.Family)
+	data = append(data, o.SourcePrefixLength, o.ScopePrefixLength)
+	prefix := (int(o.SourcePrefixLength) + 7) / 8
+	data = append(data, o.Address[:prefix]...)
+	if bits := o.SourcePrefixLength % 8; bits != 0 {
+		data[len(data)-1] &= 0xff << (8 - bits)
+	}
+	return Option{Code: OptionCodeClientSubnet, Data: data}, nil
+}
+
+// ClientSubnet parses an EDNS Client Subnet option.
+func (o *Option) ClientSubnet() (ClientSubnetOption, error) {
+	if o.Code != OptionCodeClientSubnet {
+		return ClientSubnetOption{}, errOptionCode
+	}
+	if len(o.Data) < 4 {
+		return ClientSubnetOption{}, errOptionData
+	}
+	r := ClientSubnetOption{
+		Family:             uint16(o.Data[0])<<8 | uint16(o.Data[1]),
+		SourcePrefixLength: o.Data[2],
+		ScopePrefixLength:  o.Data[3],
+	}
+	n := addressLen(r.Family)
+	prefix := (int(r.SourcePrefixLength) + 7) / 8
+	addr := o.Data[4:]
+	// The address must be truncated to the source prefix, and the bits
+	// beyond it must be zero (RFC 7871 section 6).
+	if n == 0 || int(r.SourcePrefixLength) > 8*n || int(r.ScopePrefixLength) > 8*n || len(addr) != prefix {
+		return ClientSubnetOption{}, errOptionData
+	}
+	if bits := r.SourcePrefixLength % 8; bits != 0 && addr[prefix-1]&^(0xff<<(8-bits)) != 0 {
+		return ClientSubnetOption{}, errOptionData
+	}
+	r.Address = make([]byte, n)
+	copy(r.Address, addr)
+	return r, nil
+}
+
+// A CookieOption is a DNS Cookie option (RFC 7873).
+type CookieOption struct {
+	Client [8]byte
+
+	// Server is the server cookie, which has between 8 and 32 bytes. It
+	// is empty in queries to servers whose cookie is unknown.
+	Server []byte
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *CookieOption) Option() (Option, error) {
+	if len(o.Server) != 0 && (len(o.Server) < 8 || len(o.Server) > 32) {
+		return Option{}, errOptionData
+	}
+	data := append(o.Client[:len(o.Client):len(o.Client)], o.Server...)
+	return Option{Code: OptionCodeCookie, Data: data}, nil
+}
+
+// Cookie parses a DNS Cookie option.
+func (o *Option) Cookie() (CookieOption, error) {
+	if o.Code != OptionCodeCookie {
+		return CookieOption{}, errOptionCode
+	}
+	if n := len(o.Data); n != 8 && (n < 16 || n > 40) {
+		return CookieOption{}, errOptionData
+	}
+	var r CookieOption
+	copy(r.Client[:], o.Data)
+	if len(o.Data) > 8 {
+		r.Server = append([]byte(nil), o.Data[8:]...)
+	}
+	return r, nil
+}
+
+// A PaddingOption is a Padding option (RFC 7830).
+type PaddingOption struct {
+	Length uint16
+}
+
+// NewPaddingOption returns the Padding option that pads a message of
+// length unpaddedLen, which does not include the option, to a multiple of
+// blockSize bytes. RFC 8467 recommends blocks of 128 bytes for queries and
+// 468 bytes for responses.
+func NewPaddingOption(unpaddedLen, blockSize int) PaddingOption {
+	// The option header takes 4 bytes.
+	n := unpaddedLen + 4
+	return PaddingOption{Length: uint16((blockSize - n%blockSize) % blockSize)}
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *PaddingOption) Option() (Option, error) {
+	return Option{Code: OptionCodePadding, Data: make([]byte, o.Length)}, nil
+}
+
+// Padding parses a Padding option.
+func (o *Option) Padding() (PaddingOption, error) {
+	if o.Code != OptionCodePadding {
+		return PaddingOption{}, errOptionCode
+	}
+	return PaddingOption{Length: uint16(len(o.Data))}, nil
+}
+
+// An ExtendedErrorCode is the INFO-CODE of an Extended DNS Error.
+type ExtendedErrorCode uint16
+
+// Extended DNS Error codes (RFC 8914 section 4).
+const (
+	ExtendedErrorOther                      ExtendedErrorCode = 0
+	ExtendedErrorUnsupportedDNSKEYAlgorithm ExtendedErrorCode = 1
+	ExtendedErrorUnsupportedDSDigestType    ExtendedErrorCode = 2
+	ExtendedErrorStaleAnswer                ExtendedErrorCode = 3
+	ExtendedErrorForgedAnswer               ExtendedErrorCode = 4
+	ExtendedErrorDNSSECIndeterminate        ExtendedErrorCode = 5
+	ExtendedErrorDNSSECBogus                ExtendedErrorCode = 6
+	ExtendedErrorSignatureExpired           ExtendedErrorCode = 7
+	ExtendedErrorSignatureNotYetValid       ExtendedErrorCode = 8
+	ExtendedErrorDNSKEYMissing              ExtendedErrorCode = 9
+	ExtendedErrorRRSIGsMissing              ExtendedErrorCode = 10
+	ExtendedErrorNoZoneKeyBitSet            ExtendedErrorCode = 11
+	ExtendedErrorNSECMissing                ExtendedErrorCode = 12
+	ExtendedErrorCachedError                ExtendedErrorCode = 13
+	ExtendedErrorNotReady                   ExtendedErrorCode = 14
+	ExtendedErrorBlocked                    ExtendedErrorCode = 15
+	ExtendedErrorCensored                   ExtendedErrorCode = 16
+	ExtendedErrorFiltered                   ExtendedErrorCode = 17
+	ExtendedErrorProhibited                 ExtendedErrorCode = 18
+	ExtendedErrorStaleNXDOMAINAnswer        ExtendedErrorCode = 19
+	ExtendedErrorNotAuthoritative           ExtendedErrorCode = 20
+	ExtendedErrorNotSupported               ExtendedErrorCode = 21
+	ExtendedErrorNoReachableAuthority       ExtendedErrorCode = 22
+	ExtendedErrorNetworkError               ExtendedErrorCode = 23
+	ExtendedErrorInvalidData                ExtendedErrorCode = 24
+)
+
+var extendedErrorCodeNames = map[ExtendedErrorCode]string{
+	ExtendedErrorOther:                      "ExtendedErrorOther",
+	ExtendedErrorUnsupportedDNSKEYAlgorithm: "ExtendedErrorUnsupportedDNSKEYAlgorithm",
+	ExtendedErrorUnsupportedDSDigestType:    "ExtendedErrorUnsupportedDSDigestType",
+	ExtendedErrorStaleAnswer:                "ExtendedErrorStaleAnswer",
+	ExtendedErrorForgedAnswer:               "ExtendedErrorForgedAnswer",
+	ExtendedErrorDNSSECIndeterminate:        "ExtendedErrorDNSSECIndeterminate",
+	ExtendedErrorDNSSECBogus:                "ExtendedErrorDNSSECBogus",
+	ExtendedErrorSignatureExpired:           "ExtendedErrorSignatureExpired",
+	ExtendedErrorSignatureNotYetValid:       "ExtendedErrorSignatureNotYetValid",
+	ExtendedErrorDNSKEYMissing:              "ExtendedErrorDNSKEYMissing",
+	ExtendedErrorRRSIGsMissing:              "ExtendedErrorRRSIGsMissing",
+	ExtendedErrorNoZoneKeyBitSet:            "ExtendedErrorNoZoneKeyBitSet",
+	ExtendedErrorNSECMissing:                "ExtendedErrorNSECMissing",
+	ExtendedErrorCachedError:                "ExtendedErrorCachedError",
+	ExtendedErrorNotReady:                   "ExtendedErrorNotReady",
+	ExtendedErrorBlocked:                    "ExtendedErrorBlocked",
+	ExtendedErrorCensored:                   "ExtendedErrorCensored",
+	ExtendedErrorFiltered:                   "ExtendedErrorFiltered",
+	ExtendedErrorProhibited:                 "ExtendedErrorProhibited",
+	ExtendedErrorStaleNXDOMAINAnswer:        "ExtendedErrorStaleNXDOMAINAnswer",
+	ExtendedErrorNotAuthoritative:           "ExtendedErrorNotAuthoritative",
+	ExtendedErrorNotSupported:               "ExtendedErrorNotSupported",
+	ExtendedErrorNoReachableAuthority:       "ExtendedErrorNoReachableAuthority",
+	ExtendedErrorNetworkError:               "ExtendedErrorNetworkError",
+	ExtendedErrorInvalidData:                "ExtendedErrorInvalidData",
+}
+
+// String implements fmt.Stringer.String.
+func (c ExtendedErrorCode) String() string {
+	if n, ok := extendedErrorCodeNames[c]; ok {
+		return n
+	}
+	return printUint16(uint16(c))
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (c ExtendedErrorCode) GoString() string {
+	if n, ok := extendedErrorCodeNames[c]; ok {
+		return "dnsmessage." + n
+	}
+	return printUint16(uint16(c))
+}
+
+// An ExtendedErrorOption is an Extended DNS Error option (RFC 8914).
+type ExtendedErrorOption struct {
+	InfoCode ExtendedErrorCode
+
+	// ExtraText is an optional UTF-8 explanation of the error, meant
+	// for humans.
+	ExtraText string
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *ExtendedErrorOption) Option() (Option, error) {
+	data := packUint16(make([]byte, 0, 2+len(o.ExtraText)), uint16(o.InfoCode))
+	return Option{Code: OptionCodeExtendedError, Data: append(data, o.ExtraText...)}, nil
+}
+
+// ExtendedError parses an Extended DNS Error option.
+func (o *Option) ExtendedError() (ExtendedErrorOption, error) {
+	if o.Code != OptionCodeExtendedError {
+		return ExtendedErrorOption{}, errOptionCode
+	}
+	if len(o.Data) < 2 {
+		return ExtendedErrorOption{}, errOptionData
+	}
+	text := o.Data[2:]
+	// Senders should not, but may, terminate the text with a NUL byte
+	// (RFC 8914 section 2).
+	if len(text) > 0 && text[len(text)-1] == 0 {
+		text = text[:len(text)-1]
+	}
+	return ExtendedErrorOption{
+		InfoCode:  ExtendedErrorCode(uint16(o.Data[0])<<8 | uint16(o.Data[1])),
+		ExtraText: string(text),
+	}, nil
+}
+
+// A TCPKeepaliveOption is an edns-tcp-keepalive option (RFC 7828).
+type TCPKeepaliveOption struct {
+	// Timeout is the idle timeout of the connection in units of 100
+	// milliseconds. It is only present in responses.
+	Timeout    uint16
+	HasTimeout bool
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *TCPKeepaliveOption) Option() (Option, error) {
+	opt := Option{Code: OptionCodeTCPKeepalive}
+	if o.HasTimeout {
+		opt.Data = packUint16(nil, o.Timeout)
+	}
+	return opt, nil
+}
+
+// TCPKeepalive parses an edns-tcp-keepalive option.
+func (o *Option) TCPKeepalive() (TCPKeepaliveOption, error) {
+	if o.Code != OptionCodeTCPKeepalive {
+		return TCPKeepaliveOption{}, errOptionCode
+	}
+	switch len(o.Data) {
+	case 0:
+		return TCPKeepaliveOption{}, nil
+	case 2:
+		return TCPKeepaliveOption{Timeout: uint16(o.Data[0])<<8 | uint16(o.Data[1]), HasTimeout: true}, nil
+	}
+	return TCPKeepaliveOption{}, errOptionData
+}
+
+// An NSIDOption is a Name Server Identifier option (RFC 5001).
+type NSIDOption struct {
+	// ID identifies the server. It is empty in queries.
+	ID []byte
+}
+
+// Option returns the EDNS(0) option that carries o.
+func (o *NSIDOption) Option() (Option, error) {
+	return Option{Code: OptionCodeNSID, Data: o.ID}, nil
+}
+
+// NSID parses a Name Server Identifier option.
+func (o *Option) NSID() (NSIDOption, error) {
+	if o.Code != OptionCodeNSID {
+		return NSIDOption{}, errOptionCode
+	}
+	return NSIDOption{ID: append([]byte(nil), o.Data...)}, nil
+}
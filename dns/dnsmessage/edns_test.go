@@ -0,0 +1,215 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEDNSHeaderAccessors(t *testing.T) {
+	var h ResourceHeader
+	if err := h.SetEDNS0(1232, RCodeSuccess, false); err != nil {
+		t.Fatal("SetEDNS0() =", err)
+	}
+	if got := h.UDPPayloadSize(); got != 1232 {
+		t.Errorf("UDPPayloadSize() = %d, want = 1232", got)
+	}
+	h.SetUDPPayloadSize(100)
+	if got := h.UDPPayloadSize(); got != 512 {
+		t.Errorf("UDPPayloadSize() of a small size = %d, want = 512", got)
+	}
+	h.SetDNSSECAllowed(true)
+	if !h.DNSSECAllowed() {
+		t.Error("DNSSECAllowed() = false after SetDNSSECAllowed(true)")
+	}
+	const rcodeBadVers RCode = 16
+	h.SetExtendedRCode(rcodeBadVers)
+	if got := h.ExtendedRCode(rcodeBadVers.HeaderRCode()); got != rcodeBadVers {
+		t.Errorf("ExtendedRCode() = %v, want = %v", got, rcodeBadVers)
+	}
+	if !h.DNSSECAllowed() || h.EDNSVersion() != 0 {
+		t.Errorf("SetExtendedRCode() changed the other fields: TTL = %#x", h.TTL)
+	}
+	h.SetDNSSECAllowed(false)
+	if h.DNSSECAllowed() {
+		t.Error("DNSSECAllowed() = true after SetDNSSECAllowed(false)")
+	}
+	h.TTL |= 1 << 16
+	if got := h.EDNSVersion(); got != 1 {
+		t.Errorf("EDNSVersion() = %d, want = 1", got)
+	}
+}
+
+func TestEDNSOptions(t *testing.T) {
+	type packer interface{ Option() (Option, error) }
+	for _, tt := range []struct {
+		name  string
+		opt   packer
+		data  []byte
+		parse func(o *Option) (any, error)
+	}{
+		{
+			"client subnet IPv4",
+			&ClientSubnetOption{Family: 1, SourcePrefixLength: 22, Address: []byte{198, 51, 100, 0}},
+			[]byte{0, 1, 22, 0, 198, 51, 100},
+			func(o *Option) (any, error) { r, err := o.ClientSubnet(); return &r, err },
+		},
+		{
+			"client subnet IPv6",
+			&ClientSubnetOption{Family: 2, SourcePrefixLength: 56, ScopePrefixLength: 48, Address: []byte{0x20, 0x01, 0x0d, 0xb8, 1, 2, 3, 15: 0}},
+			[]byte{0, 2, 56, 48, 0x20, 0x01, 0x0d, 0xb8, 1, 2, 3},
+			func(o *Option) (any, error) { r, err := o.ClientSubnet(); return &r, err },
+		},
+		{
+			"client cookie",
+			&CookieOption{Client: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+			[]byte{1, 2, 3, 4, 5, 6, 7, 8},
+			func(o *Option) (any, error) { r, err := o.Cookie(); return &r, err },
+		},
+		{
+			"server cookie",
+			&CookieOption{Client: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, Server: bytes.Repeat([]byte{9}, 16)},
+			append([]byte{1, 2, 3, 4, 5, 6, 7, 8}, bytes.Repeat([]byte{9}, 16)...),
+			func(o *Option) (any, error) { r, err := o.Cookie(); return &r, err },
+		},
+		{
+			"padding",
+			&PaddingOption{Length: 3},
+			[]byte{0, 0, 0},
+			func(o *Option) (any, error) { r, err := o.Padding(); return &r, err },
+		},
+		{
+			"extended error",
+			&ExtendedErrorOption{InfoCode: ExtendedErrorBlocked, ExtraText: "policy"},
+			[]byte{0, 15, 'p', 'o', 'l', 'i', 'c', 'y'},
+			func(o *Option) (any, error) { r, err := o.ExtendedError(); return &r, err },
+		},
+		{
+			"keepalive query",
+			&TCPKeepaliveOption{},
+			nil,
+			func(o *Option) (any, error) { r, err := o.TCPKeepalive(); return &r, err },
+		},
+		{
+			"keepalive response",
+			&TCPKeepaliveOption{Timeout: 1200, HasTimeout: true},
+			[]byte{0x04, 0xb0},
+			func(o *Option) (any, error) { r, err := o.TCPKeepalive(); return &r, err },
+		},
+		{
+			"nsid",
+			&NSIDOption{ID: []byte("ns1")},
+			[]byte("ns1"),
+			func(o *Option) (any, error) { r, err := o.NSID(); return &r, err },
+		},
+	} {
+		o, err := tt.opt.Option()
+		if err != nil {
+			t.Errorf("%s: Option() = %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(o.Data, tt.data) {
+			t.Errorf("%s: Option().Data = %v, want = %v", tt.name, o.Data, tt.data)
+		}
+
+		// Round-trip the option through an OPT record.
+		var h ResourceHeader
+		if err := h.SetEDNS0(4096, RCodeSuccess, false); err != nil {
+			t.Fatal("SetEDNS0() =", err)
+		}
+		b := NewBuilder(nil, Header{})
+		b.StartAdditionals()
+		if err := b.OPTResource(h, OPTResource{Options: []Option{{Code: 65001}, o}}); err != nil {
+			t.Fatalf("%s: Builder.OPTResource() = %v", tt.name, err)
+		}
+		msg, err := b.Finish()
+		if err != nil {
+			t.Fatalf("%s: Builder.Finish() = %v", tt.name, err)
+		}
+		var m Message
+		if err := m.Unpack(msg); err != nil {
+			t.Fatalf("%s: Message.Unpack() = %v", tt.name, err)
+		}
+		opt, ok := m.Additionals[0].Body.(*OPTResource).Option(o.Code)
+		if !ok {
+			t.Errorf("%s: OPTResource.Option(%d) not found", tt.name, o.Code)
+			continue
+		}
+		got, err := tt.parse(&opt)
+		if err != nil {
+			t.Errorf("%s: parse = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.opt) {
+			t.Errorf("%s: parse = %#v, want = %#v", tt.name, got, tt.opt)
+		}
+	}
+}
+
+func TestEDNSOptionsInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opt  Option
+		want error
+	}{
+		{"wrong code", Option{Code: OptionCodeNSID}, errOptionCode},
+		{"short", Option{Code: OptionCodeClientSubnet, Data: []byte{0, 1, 24}}, errOptionData},
+		{"unknown family", Option{Code: OptionCodeClientSubnet, Data: []byte{0, 3, 0, 0}}, errOptionData},
+		{"long prefix", Option{Code: OptionCodeClientSubnet, Data: []byte{0, 1, 33, 0, 1, 2, 3, 4, 5}}, errOptionData},
+		{"untruncated address", Option{Code: OptionCodeClientSubnet, Data: []byte{0, 1, 8, 0, 10, 0}}, errOptionData},
+		{"nonzero host bits", Option{Code: OptionCodeClientSubnet, Data: []byte{0, 1, 7, 0, 11}}, errOptionData},
+	} {
+		if _, err := tt.opt.ClientSubnet(); err != tt.want {
+			t.Errorf("%s: ClientSubnet() = %v, want = %v", tt.name, err, tt.want)
+		}
+	}
+
+	for _, n := range []int{0, 7, 9, 15, 41} {
+		o := Option{Code: OptionCodeCookie, Data: make([]byte, n)}
+		if _, err := o.Cookie(); err != errOptionData {
+			t.Errorf("Cookie() of %d bytes = %v, want = %v", n, err, errOptionData)
+		}
+	}
+	if _, err := (&CookieOption{Server: make([]byte, 4)}).Option(); err != errOptionData {
+		t.Errorf("CookieOption.Option() with a 4-byte server cookie = %v, want = %v", err, errOptionData)
+	}
+	o := Option{Code: OptionCodeTCPKeepalive, Data: []byte{1}}
+	if _, err := o.TCPKeepalive(); err != errOptionData {
+		t.Errorf("TCPKeepalive() of 1 byte = %v, want = %v", err, errOptionData)
+	}
+	o = Option{Code: OptionCodeExtendedError, Data: []byte{0, 3, 'x', 0}}
+	if e, err := o.ExtendedError(); err != nil || e.ExtraText != "x" {
+		t.Errorf("ExtendedError() of NUL-terminated text = %#v, %v", e, err)
+	}
+}
+
+func TestNewPaddingOption(t *testing.T) {
+	for _, tt := range []struct {
+		unpadded, block int
+		want            uint16
+	}{
+		{50, 128, 74},
+		{124, 128, 0},
+		{125, 128, 127},
+		{400, 468, 64},
+	} {
+		if got := NewPaddingOption(tt.unpadded, tt.block); got.Length != tt.want {
+			t.Errorf("NewPaddingOption(%d, %d).Length = %d, want = %d", tt.unpadded, tt.block, got.Length, tt.want)
+		}
+	}
+}
+
+func TestClientSubnetOptionMasksAddress(t *testing.T) {
+	o := ClientSubnetOption{Family: 1, SourcePrefixLength: 20, Address: []byte{192, 0, 2, 1}}
+	opt, err := o.Option()
+	if err != nil {
+		t.Fatal("ClientSubnetOption.Option() =", err)
+	}
+	if want := []byte{0, 1, 20, 0, 192, 0, 0}; !bytes.Equal(opt.Data, want) {
+		t.Errorf("ClientSubnetOption.Option().Data = %v, want = %v", opt.Data, want)
+	}
+}
@@ -6,7 +6,8 @@
 // DNS message packing and unpacking.
 //
 // The package also supports messages with Extension Mechanisms for DNS
-// (EDNS(0)) as defined in RFC 6891.
+// (EDNS(0)) as defined in RFC 6891, and provides the DNSSEC record types
+// of RFC 4034 and RFC 5155 along with helpers to validate them.
 //
 // This implementation is designed to minimize heap allocations and avoid
 // unnecessary packing and unpacking as much as possible.
@@ -23,16 +24,23 @@ type Type uint16
 
 const (
 	// ResourceHeader.Type and Question.Type
-	TypeA     Type = 1
-	TypeNS    Type = 2
-	TypeCNAME Type = 5
-	TypeSOA   Type = 6
-	TypePTR   Type = 12
-	TypeMX    Type = 15
-	TypeTXT   Type = 16
-	TypeAAAA  Type = 28
-	TypeSRV   Type = 33
-	TypeOPT   Type = 41
+	TypeA      Type = 1
+	TypeNS     Type = 2
+	TypeCNAME  Type = 5
+	TypeSOA    Type = 6
+	TypePTR    Type = 12
+	TypeMX     Type = 15
+	TypeTXT    Type = 16
+	TypeAAAA   Type = 28
+	TypeSRV    Type = 33
+	TypeOPT    Type = 41
+	TypeDS     Type = 43
+	TypeRRSIG  Type = 46
+	TypeNSEC   Type = 47
+	TypeDNSKEY Type = 48
+	TypeNSEC3  Type = 50
+	TypeSVCB   Type = 64
+	TypeHTTPS  Type = 65
 
 	// Question.Type
 	TypeWKS   Type = 11
@@ -43,21 +51,28 @@ const (
 )
 
 var typeNames = map[Type]string{
-	TypeA:     "TypeA",
-	TypeNS:    "TypeNS",
-	TypeCNAME: "TypeCNAME",
-	TypeSOA:   "TypeSOA",
-	TypePTR:   "TypePTR",
-	TypeMX:    "TypeMX",
-	TypeTXT:   "TypeTXT",
-	TypeAAAA:  "TypeAAAA",
-	TypeSRV:   "TypeSRV",
-	TypeOPT:   "TypeOPT",
-	TypeWKS:   "TypeWKS",
-	TypeHINFO: "TypeHINFO",
-	TypeMINFO: "TypeMINFO",
-	TypeAXFR:  "TypeAXFR",
-	TypeALL:   "TypeALL",
+	TypeA:      "TypeA",
+	TypeNS:     "TypeNS",
+	TypeCNAME:  "TypeCNAME",
+	TypeSOA:    "TypeSOA",
+	TypePTR:    "TypePTR",
+	TypeMX:     "TypeMX",
+	TypeTXT:    "TypeTXT",
+	TypeAAAA:   "TypeAAAA",
+	TypeSRV:    "TypeSRV",
+	TypeOPT:    "TypeOPT",
+	TypeDS:     "TypeDS",
+	TypeRRSIG:  "TypeRRSIG",
+	TypeNSEC:   "TypeNSEC",
+	TypeDNSKEY: "TypeDNSKEY",
+	TypeNSEC3:  "TypeNSEC3",
+	TypeSVCB:   "TypeSVCB",
+	TypeHTTPS:  "TypeHTTPS",
+	TypeWKS:    "TypeWKS",
+	TypeHINFO:  "TypeHINFO",
+	TypeMINFO:  "TypeMINFO",
+	TypeAXFR:   "TypeAXFR",
+	TypeALL:    "TypeALL",
 }
 
 // String implements fmt.Stringer.String.
@@ -255,6 +270,10 @@ var (
 	// parsed or finished.
 	ErrSectionDone = errors.New("parsing/packing of this section has completed")
 
+	// ErrMessageTooLarge indicates that a question or resource doesn't fit
+	// in the maximum size of a Builder.
+	ErrMessageTooLarge = errors.New("message exceeds the maximum size")
+
 	errBaseLen            = errors.New("insufficient data for base length type")
 	errCalcLen            = errors.New("insufficient data for calculated length type")
 	errReserved           = errors.New("segment prefix is reserved")
@@ -1036,6 +1055,132 @@ func (p *Parser) AAAAResource() (AAAAResource, error) {
 	return r, nil
 }
 
+// DNSKEYResource parses a single DNSKEYResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) DNSKEYResource() (DNSKEYResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeDNSKEY {
+		return DNSKEYResource{}, ErrNotStarted
+	}
+	r, err := unpackDNSKEYResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return DNSKEYResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// DSResource parses a single DSResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) DSResource() (DSResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeDS {
+		return DSResource{}, ErrNotStarted
+	}
+	r, err := unpackDSResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return DSResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// RRSIGResource parses a single RRSIGResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) RRSIGResource() (RRSIGResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeRRSIG {
+		return RRSIGResource{}, ErrNotStarted
+	}
+	r, err := unpackRRSIGResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return RRSIGResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// NSECResource parses a single NSECResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) NSECResource() (NSECResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeNSEC {
+		return NSECResource{}, ErrNotStarted
+	}
+	r, err := unpackNSECResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return NSECResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// NSEC3Resource parses a single NSEC3Resource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) NSEC3Resource() (NSEC3Resource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeNSEC3 {
+		return NSEC3Resource{}, ErrNotStarted
+	}
+	r, err := unpackNSEC3Resource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return NSEC3Resource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// SVCBResource parses a single SVCBResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) SVCBResource() (SVCBResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeSVCB {
+		return SVCBResource{}, ErrNotStarted
+	}
+	r, err := unpackSVCBResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return SVCBResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
+// HTTPSResource parses a single HTTPSResource.
+//
+// One of the XXXHeader methods must have been called before calling this
+// method.
+func (p *Parser) HTTPSResource() (HTTPSResource, error) {
+	if !p.resHeaderValid || p.resHeaderType != TypeHTTPS {
+		return HTTPSResource{}, ErrNotStarted
+	}
+	r, err := unpackHTTPSResource(p.msg, p.off, p.resHeaderLength)
+	if err != nil {
+		return HTTPSResource{}, err
+	}
+	p.off += int(p.resHeaderLength)
+	p.resHeaderValid = false
+	p.index++
+	return r, nil
+}
+
 // OPTResource parses a single OPTResource.
 //
 // One of the XXXHeader methods must have been called before calling this
@@ -1229,6 +1374,34 @@ type Builder struct {
 	// compression is a mapping from name suffixes to their starting index
 	// in msg.
 	compression map[string]uint16
+
+	// maxSize is the maximum size of the message, or 0 if it is only
+	// limited by the format.
+	maxSize int
+
+	// truncated is set once an RRset of the answer or authority section
+	// didn't fit in maxSize.
+	truncated bool
+
+	// rrset is the RRset of the last added resource, which is removed as a
+	// whole if one of its resources doesn't fit in maxSize.
+	rrset builderRRSet
+}
+
+// A builderRRSet locates the resources of an RRset in a Builder.
+type builderRRSet struct {
+	section section
+	name    Name
+	typ     Type
+	class   Class
+
+	// start is the index of the first resource in msg, and count the
+	// number of resources.
+	start int
+	count uint16
+
+	// dropped is set if the RRset didn't fit and was removed.
+	dropped bool
 }
 
 // NewBuilder creates a new builder with compression disabled.
@@ -1268,6 +1441,129 @@ func (b *Builder) EnableCompression() {
 	b.compression = map[string]uint16{}
 }
 
+// SetMaxSize limits the size of the message to n bytes, such as 512 or the
+// UDP payload size advertised with EDNS(0), not counting the initial
+// buffer passed to NewBuilder. It should be called before any questions or
+// resources are added.
+//
+// A question or resource that would make the message larger is rejected
+// with ErrMessageTooLarge, and the message is truncated at an RRset
+// boundary: the resources already added for the same RRset are removed
+// too, as are later resources of that RRset.
+//
+// If the RRset is in the answer or authority section, the TC bit of the
+// header is set and the resources of these sections are rejected from
+// then on. Additional resources can still be added in the remaining
+// space, so that truncated responses keep their OPT record. RRsets that
+// don't fit in the additional section are omitted without setting the TC
+// bit (RFC 2181, section 9).
+func (b *Builder) SetMaxSize(n int) {
+	b.maxSize = n
+}
+
+// Remaining returns the number of bytes that can still be added to the
+// message: up to the size set with SetMaxSize, or else up to 65535 bytes,
+// the size of the largest message.
+func (b *Builder) Remaining() int {
+	maxSize := b.maxSize
+	if maxSize <= 0 {
+		maxSize = int(^uint16(0))
+	}
+	return max(maxSize-(len(b.msg)-b.start), 0)
+}
+
+// Truncated reports whether the builder dropped an RRset of the answer or
+// authority section and set the TC bit of the header.
+func (b *Builder) Truncated() bool {
+	return b.truncated
+}
+
+// fits reports whether msg, an extension of b.msg, fits in maxSize.
+// Otherwise it removes the names of the rest of msg from the compression
+// table.
+func (b *Builder) fits(msg []byte) bool {
+	if b.maxSize <= 0 || len(msg)-b.start <= b.maxSize {
+		return true
+	}
+	b.uncompress(len(b.msg))
+	return false
+}
+
+// uncompress removes the names located at msg[off:] from the compression
+// table.
+func (b *Builder) uncompress(off int) {
+	for name, ptr := range b.compression {
+		if int(ptr) >= off-b.start {
+			delete(b.compression, name)
+		}
+	}
+}
+
+// addResource adds the resource with header h that was packed at the end
+// of msg, an extension of b.msg, if it fits in maxSize.
+func (b *Builder) addResource(h *ResourceHeader, msg []byte) error {
+	if b.maxSize > 0 {
+		rrset := &b.rrset
+		if rrset.section != b.section || rrset.typ != h.Type || rrset.class != h.Class || !equalNameFold(&rrset.name, &h.Name) {
+			*rrset = builderRRSet{section: b.section, name: h.Name, typ: h.Type, class: h.Class, start: len(b.msg)}
+		}
+		if rrset.dropped || !b.fits(msg) {
+			b.dropRRSet()
+			return ErrMessageTooLarge
+		}
+	}
+	if err := b.incrementSectionCount(); err != nil {
+		return err
+	}
+	b.rrset.count++
+	b.msg = msg
+	return nil
+}
+
+// dropRRSet removes the resources of the current RRset from the message.
+func (b *Builder) dropRRSet() {
+	rrset := &b.rrset
+	if !rrset.dropped {
+		rrset.dropped = true
+		b.uncompress(rrset.start)
+		b.msg = b.msg[:rrset.start]
+		switch b.section {
+		case sectionAnswers:
+			b.header.answers -= rrset.count
+		case sectionAuthorities:
+			b.header.authorities -= rrset.count
+		case sectionAdditionals:
+			b.header.additionals -= rrset.count
+		}
+		rrset.count = 0
+	}
+	if b.section != sectionAdditionals {
+		b.truncated = true
+		b.header.bits |= headerBitTC
+	}
+}
+
+// equalNameFold reports whether a and b are the same name, ignoring the
+// case of ASCII letters.
+func equalNameFold(a, b *Name) bool {
+	if a.Length != b.Length {
+		return false
+	}
+	for i := 0; i < int(a.Length); i++ {
+		ca, cb := a.Data[i], b.Data[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *Builder) startCheck(s section) error {
 	if b.section <= sectionNotStarted {
 		return ErrNotStarted
@@ -1350,6 +1646,9 @@ func (b *Builder) Question(q Question) error {
 	if err != nil {
 		return err
 	}
+	if !b.fits(msg) {
+		return ErrMessageTooLarge
+	}
 	if err := b.incrementSectionCount(); err != nil {
 		return err
 	}
@@ -1364,6 +1663,9 @@ func (b *Builder) checkResourceSection() error {
 	if b.section > sectionAdditionals {
 		return ErrSectionDone
 	}
+	if b.truncated && b.section < sectionAdditionals {
+		return ErrMessageTooLarge
+	}
 	return nil
 }
 
@@ -1384,11 +1686,7 @@ func (b *Builder) CNAMEResource(h ResourceHeader, r CNAMEResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // MXResource adds a single MXResource.
@@ -1408,11 +1706,7 @@ func (b *Builder) MXResource(h ResourceHeader, r MXResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // NSResource adds a single NSResource.
@@ -1432,11 +1726,7 @@ func (b *Builder) NSResource(h ResourceHeader, r NSResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // PTRResource adds a single PTRResource.
@@ -1456,11 +1746,7 @@ func (b *Builder) PTRResource(h ResourceHeader, r PTRResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // SOAResource adds a single SOAResource.
@@ -1480,11 +1766,7 @@ func (b *Builder) SOAResource(h ResourceHeader, r SOAResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // TXTResource adds a single TXTResource.
@@ -1504,11 +1786,7 @@ func (b *Builder) TXTResource(h ResourceHeader, r TXTResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // SRVResource adds a single SRVResource.
@@ -1528,11 +1806,7 @@ func (b *Builder) SRVResource(h ResourceHeader, r SRVResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // AResource adds a single AResource.
@@ -1552,11 +1826,7 @@ func (b *Builder) AResource(h ResourceHeader, r AResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // AAAAResource adds a single AAAAResource.
@@ -1576,11 +1846,7 @@ func (b *Builder) AAAAResource(h ResourceHeader, r AAAAResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // OPTResource adds a single OPTResource.
@@ -1600,11 +1866,147 @@ func (b *Builder) OPTResource(h ResourceHeader, r OPTResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
+	return b.addResource(&h, msg)
+}
+
+// DNSKEYResource adds a single DNSKEYResource.
+func (b *Builder) DNSKEYResource(h ResourceHeader, r DNSKEYResource) error {
+	if err := b.checkResourceSection(); err != nil {
 		return err
 	}
-	b.msg = msg
-	return nil
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"DNSKEYResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// DSResource adds a single DSResource.
+func (b *Builder) DSResource(h ResourceHeader, r DSResource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"DSResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// RRSIGResource adds a single RRSIGResource.
+func (b *Builder) RRSIGResource(h ResourceHeader, r RRSIGResource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"RRSIGResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// NSECResource adds a single NSECResource.
+func (b *Builder) NSECResource(h ResourceHeader, r NSECResource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"NSECResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// NSEC3Resource adds a single NSEC3Resource.
+func (b *Builder) NSEC3Resource(h ResourceHeader, r NSEC3Resource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"NSEC3Resource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// SVCBResource adds a single SVCBResource.
+func (b *Builder) SVCBResource(h ResourceHeader, r SVCBResource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"SVCBResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
+}
+
+// HTTPSResource adds a single HTTPSResource.
+func (b *Builder) HTTPSResource(h ResourceHeader, r HTTPSResource) error {
+	if err := b.checkResourceSection(); err != nil {
+		return err
+	}
+	h.Type = r.realType()
+	msg, lenOff, err := h.pack(b.msg, b.compression, b.start)
+	if err != nil {
+		return &nestedError{"ResourceHeader", err}
+	}
+	preLen := len(msg)
+	if msg, err = r.pack(msg, b.compression, b.start); err != nil {
+		return &nestedError{"HTTPSResource body", err}
+	}
+	if err := h.fixLen(msg, lenOff, preLen); err != nil {
+		return err
+	}
+	return b.addResource(&h, msg)
 }
 
 // UnknownResource adds a single UnknownResource.
@@ -1624,11 +2026,7 @@ func (b *Builder) UnknownResource(h ResourceHeader, r UnknownResource) error {
 	if err := h.fixLen(msg, lenOff, preLen); err != nil {
 		return err
 	}
-	if err := b.incrementSectionCount(); err != nil {
-		return err
-	}
-	b.msg = msg
-	return nil
+	return b.addResource(&h, msg)
 }
 
 // Finish ends message building and generates a binary message.
@@ -2225,6 +2623,41 @@ func unpackResourceBody(msg []byte, off int, hdr ResourceHeader) (ResourceBody,
 		rb, err = unpackOPTResource(msg, off, hdr.Length)
 		r = &rb
 		name = "OPT"
+	case TypeDNSKEY:
+		var rb DNSKEYResource
+		rb, err = unpackDNSKEYResource(msg, off, hdr.Length)
+		r = &rb
+		name = "DNSKEY"
+	case TypeDS:
+		var rb DSResource
+		rb, err = unpackDSResource(msg, off, hdr.Length)
+		r = &rb
+		name = "DS"
+	case TypeRRSIG:
+		var rb RRSIGResource
+		rb, err = unpackRRSIGResource(msg, off, hdr.Length)
+		r = &rb
+		name = "RRSIG"
+	case TypeNSEC:
+		var rb NSECResource
+		rb, err = unpackNSECResource(msg, off, hdr.Length)
+		r = &rb
+		name = "NSEC"
+	case TypeNSEC3:
+		var rb NSEC3Resource
+		rb, err = unpackNSEC3Resource(msg, off, hdr.Length)
+		r = &rb
+		name = "NSEC3"
+	case TypeSVCB:
+		var rb SVCBResource
+		rb, err = unpackSVCBResource(msg, off, hdr.Length)
+		r = &rb
+		name = "SVCB"
+	case TypeHTTPS:
+		var rb HTTPSResource
+		rb, err = unpackHTTPSResource(msg, off, hdr.Length)
+		r = &rb
+		name = "HTTPS"
 	default:
 		var rb UnknownResource
 		rb, err = unpackUnknownResource(hdr.Type, msg, off, hdr.Length)
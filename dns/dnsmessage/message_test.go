@@ -353,6 +353,13 @@ func TestResourceNotStarted(t *testing.T) {
 		{"SRVResource", func(p *Parser) error { _, err := p.SRVResource(); return err }},
 		{"AResource", func(p *Parser) error { _, err := p.AResource(); return err }},
 		{"AAAAResource", func(p *Parser) error { _, err := p.AAAAResource(); return err }},
+		{"DNSKEYResource", func(p *Parser) error { _, err := p.DNSKEYResource(); return err }},
+		{"DSResource", func(p *Parser) error { _, err := p.DSResource(); return err }},
+		{"RRSIGResource", func(p *Parser) error { _, err := p.RRSIGResource(); return err }},
+		{"NSECResource", func(p *Parser) error { _, err := p.NSECResource(); return err }},
+		{"NSEC3Resource", func(p *Parser) error { _, err := p.NSEC3Resource(); return err }},
+		{"SVCBResource", func(p *Parser) error { _, err := p.SVCBResource(); return err }},
+		{"HTTPSResource", func(p *Parser) error { _, err := p.HTTPSResource(); return err }},
 		{"UnknownResource", func(p *Parser) error { _, err := p.UnknownResource(); return err }},
 	}
 
@@ -694,6 +701,13 @@ func TestBuilderResourceError(t *testing.T) {
 		{"AResource", func(b *Builder) error { return b.AResource(ResourceHeader{}, AResource{}) }},
 		{"AAAAResource", func(b *Builder) error { return b.AAAAResource(ResourceHeader{}, AAAAResource{}) }},
 		{"OPTResource", func(b *Builder) error { return b.OPTResource(ResourceHeader{}, OPTResource{}) }},
+		{"DNSKEYResource", func(b *Builder) error { return b.DNSKEYResource(ResourceHeader{}, DNSKEYResource{}) }},
+		{"DSResource", func(b *Builder) error { return b.DSResource(ResourceHeader{}, DSResource{}) }},
+		{"RRSIGResource", func(b *Builder) error { return b.RRSIGResource(ResourceHeader{}, RRSIGResource{}) }},
+		{"NSECResource", func(b *Builder) error { return b.NSECResource(ResourceHeader{}, NSECResource{}) }},
+		{"NSEC3Resource", func(b *Builder) error { return b.NSEC3Resource(ResourceHeader{}, NSEC3Resource{}) }},
+		{"SVCBResource", func(b *Builder) error { return b.SVCBResource(ResourceHeader{}, SVCBResource{}) }},
+		{"HTTPSResource", func(b *Builder) error { return b.HTTPSResource(ResourceHeader{}, HTTPSResource{}) }},
 		{"UnknownResource", func(b *Builder) error { return b.UnknownResource(ResourceHeader{}, UnknownResource{}) }},
 	}
 
@@ -1852,3 +1866,148 @@ func TestBuilderCompressionInAppendMode(t *testing.T) {
 		t.Fatalf("msg[maxPtr:] = %v, want: %v", msg[maxPtr:], expect)
 	}
 }
+
+func TestBuilderMaxSize(t *testing.T) {
+	name := MustNewName("example.com.")
+	www := MustNewName("www.example.com.")
+	txt := func(b *Builder, n Name) error {
+		return b.TXTResource(ResourceHeader{Name: n, Class: ClassINET}, TXTResource{[]string{"x"}})
+	}
+
+	// The header and the question take 29 bytes, each A record of
+	// example.com. 16 bytes, and each TXT record of www.example.com. 18
+	// bytes for the first one and 14 for the next ones.
+	const maxSize = 82
+	b := NewBuilder(nil, Header{Response: true})
+	b.EnableCompression()
+	b.SetMaxSize(maxSize)
+	b.StartQuestions()
+	if err := b.Question(Question{Name: name, Type: TypeA, Class: ClassINET}); err != nil {
+		t.Fatal("Question() =", err)
+	}
+	b.StartAnswers()
+	if err := b.AResource(ResourceHeader{Name: name, Class: ClassINET}, AResource{[4]byte{192, 0, 2, 1}}); err != nil {
+		t.Fatal("AResource() =", err)
+	}
+	if got, want := b.Remaining(), maxSize-45; got != want {
+		t.Errorf("Remaining() = %d, want %d", got, want)
+	}
+	for i, want := range []error{nil, nil, ErrMessageTooLarge} {
+		if err := txt(&b, www); err != want {
+			t.Errorf("TXTResource() #%d = %v, want %v", i, err, want)
+		}
+	}
+	if !b.Truncated() {
+		t.Error("Truncated() = false after an answer RRset didn't fit")
+	}
+	if got, want := b.Remaining(), maxSize-45; got != want {
+		t.Errorf("Remaining() after truncation = %d, want %d", got, want)
+	}
+	// The answer section is complete once truncated.
+	if err := b.AResource(ResourceHeader{Name: name, Class: ClassINET}, AResource{}); err != ErrMessageTooLarge {
+		t.Errorf("AResource() after truncation = %v, want %v", err, ErrMessageTooLarge)
+	}
+
+	// The additional section is still filled.
+	b.StartAdditionals()
+	var opt ResourceHeader
+	opt.SetEDNS0(1232, RCodeSuccess, false)
+	if err := b.OPTResource(opt, OPTResource{}); err != nil {
+		t.Fatal("OPTResource() =", err)
+	}
+	// The name of the removed RRset can't be compressed anymore.
+	if err := b.AResource(ResourceHeader{Name: www, Class: ClassINET}, AResource{[4]byte{192, 0, 2, 2}}); err != nil {
+		t.Fatal("AResource() =", err)
+	}
+	if err := b.AAAAResource(ResourceHeader{Name: www, Class: ClassINET}, AAAAResource{}); err != ErrMessageTooLarge {
+		t.Errorf("AAAAResource() = %v, want %v", err, ErrMessageTooLarge)
+	}
+	if got := b.Remaining(); got != 6 {
+		t.Errorf("Remaining() = %d, want 6", got)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal("Finish() =", err)
+	}
+	if len(msg) != maxSize-6 {
+		t.Errorf("len(Finish()) = %d, want %d", len(msg), maxSize-6)
+	}
+
+	var m Message
+	if err := m.Unpack(msg); err != nil {
+		t.Fatal("Unpack() =", err)
+	}
+	if !m.Header.Truncated || len(m.Questions) != 1 || len(m.Answers) != 1 || len(m.Additionals) != 2 {
+		t.Fatalf("got message %#v, want a truncated message with 1 answer and 2 additional resources", &m)
+	}
+	if m.Answers[0].Header.Type != TypeA || m.Additionals[0].Header.Type != TypeOPT || m.Additionals[1].Header.Name != www {
+		t.Errorf("got message %#v, want the A, OPT and www.example.com. A records", &m)
+	}
+}
+
+func TestBuilderMaxSizeRRSet(t *testing.T) {
+	names := []Name{MustNewName("example.com."), MustNewName("EXAMPLE.com."), MustNewName("example.COM.")}
+	b := NewBuilder(nil, Header{})
+	b.SetMaxSize(headerLen + 2*27)
+	b.StartAnswers()
+	for i, want := range []error{nil, nil, ErrMessageTooLarge} {
+		if err := b.AResource(ResourceHeader{Name: names[i], Class: ClassINET}, AResource{[4]byte{192, 0, 2, byte(i)}}); err != want {
+			t.Errorf("AResource() #%d = %v, want %v", i, err, want)
+		}
+	}
+	// The whole RRset is removed, whatever the case of its name.
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal("Finish() =", err)
+	}
+	var m Message
+	if err := m.Unpack(msg); err != nil {
+		t.Fatal("Unpack() =", err)
+	}
+	if !m.Header.Truncated || len(m.Answers) != 0 {
+		t.Errorf("got message %#v, want a truncated message without answers", &m)
+	}
+}
+
+func TestBuilderMaxSizeAdditionals(t *testing.T) {
+	name := MustNewName("example.com.")
+	b := NewBuilder(nil, Header{Response: true})
+	b.SetMaxSize(headerLen + 2*27)
+	b.StartAnswers()
+	if err := b.AResource(ResourceHeader{Name: name, Class: ClassINET}, AResource{}); err != nil {
+		t.Fatal("AResource() =", err)
+	}
+	b.StartAdditionals()
+	if err := b.AAAAResource(ResourceHeader{Name: name, Class: ClassINET}, AAAAResource{}); err != ErrMessageTooLarge {
+		t.Errorf("AAAAResource() = %v, want %v", err, ErrMessageTooLarge)
+	}
+	if b.Truncated() {
+		t.Error("Truncated() = true after an additional RRset didn't fit")
+	}
+	// Smaller RRsets still fit.
+	if err := b.AResource(ResourceHeader{Name: name, Class: ClassINET}, AResource{}); err != nil {
+		t.Fatal("AResource() =", err)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal("Finish() =", err)
+	}
+	var m Message
+	if err := m.Unpack(msg); err != nil {
+		t.Fatal("Unpack() =", err)
+	}
+	if m.Header.Truncated || len(m.Answers) != 1 || len(m.Additionals) != 1 {
+		t.Errorf("got message %#v, want an untruncated message with 1 answer and 1 additional resource", &m)
+	}
+
+	// Questions that don't fit are rejected too.
+	b = NewBuilder(nil, Header{})
+	b.SetMaxSize(headerLen + 16)
+	b.StartQuestions()
+	if err := b.Question(Question{Name: name, Type: TypeA, Class: ClassINET}); err != ErrMessageTooLarge {
+		t.Errorf("Question() = %v, want %v", err, ErrMessageTooLarge)
+	}
+	if got := b.Remaining(); got != 16 {
+		t.Errorf("Remaining() = %d, want 16", got)
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import "errors"
+
+// An SVCBResource is an SVCB Resource record, as defined in RFC 9460.
+type SVCBResource struct {
+	// Priority is 0 for AliasMode records, and the priority of the
+	// alternative endpoint for ServiceMode records.
+	Priority uint16
+	Target   Name // Not compressed as per RFC 9460.
+
+	// Params holds the service parameters in strictly increasing order of
+	// their keys. The typed accessors, such as ALPNParam and
+	// SetALPNParam, keep that order.
+	Params []SVCParam
+}
+
+// An HTTPSResource is an HTTPS Resource record, as defined in RFC 9460. It
+// has the same format as the SVCB Resource record.
+type HTTPSResource struct {
+	SVCBResource
+}
+
+// An SVCParamKey is the key of a service parameter.
+type SVCParamKey uint16
+
+const (
+	SVCParamMandatory     SVCParamKey = 0
+	SVCParamALPN          SVCParamKey = 1
+	SVCParamNoDefaultALPN SVCParamKey = 2
+	SVCParamPort          SVCParamKey = 3
+	SVCParamIPv4Hint      SVCParamKey = 4
+	SVCParamECH           SVCParamKey = 5
+	SVCParamIPv6Hint      SVCParamKey = 6
+)
+
+var svcParamKeyNames = map[SVCParamKey]string{
+	SVCParamMandatory:     "SVCParamMandatory",
+	SVCParamALPN:          "SVCParamALPN",
+	SVCParamNoDefaultALPN: "SVCParamNoDefaultALPN",
+	SVCParamPort:          "SVCParamPort",
+	SVCParamIPv4Hint:      "SVCParamIPv4Hint",
+	SVCParamECH:           "SVCParamECH",
+	SVCParamIPv6Hint:      "SVCParamIPv6Hint",
+}
+
+// String implements fmt.Stringer.String.
+func (k SVCParamKey) String() string {
+	if n, ok := svcParamKeyNames[k]; ok {
+		return n
+	}
+	return printUint16(uint16(k))
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (k SVCParamKey) GoString() string {
+	if n, ok := svcParamKeyNames[k]; ok {
+		return "dnsmessage." + n
+	}
+	return printUint16(uint16(k))
+}
+
+// An SVCParam is a service parameter of an SVCB or HTTPS Resource record.
+type SVCParam struct {
+	Key   SVCParamKey
+	Value []byte // wire format of the value
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (p *SVCParam) GoString() string {
+	return "dnsmessage.SVCParam{" +
+		"Key: " + p.Key.GoString() + ", " +
+		"Value: []byte{" + printByteSlice(p.Value) + "}}"
+}
+
+var (
+	errParamOutOfOrder = errors.New("parameter keys are not in strictly increasing order")
+	errParamValue      = errors.New("invalid parameter value")
+	errParamMandatory  = errors.New("mandatory parameter is missing")
+)
+
+func (r *SVCBResource) realType() Type {
+	return TypeSVCB
+}
+
+// pack appends the wire format of the SVCBResource to msg.
+func (r *SVCBResource) pack(msg []byte, compression map[string]uint16, compressionOff int) ([]byte, error) {
+	oldMsg := msg
+	if err := r.validate(); err != nil {
+		return oldMsg, err
+	}
+	msg = packUint16(msg, r.Priority)
+	msg, err := r.Target.pack(msg, nil, compressionOff)
+	if err != nil {
+		return oldMsg, &nestedError{"SVCBResource.Target", err}
+	}
+	for _, p := range r.Params {
+		if len(p.Value) > 0xffff {
+			return oldMsg, &nestedError{"SVCBResource.Params", errResTooLong}
+		}
+		msg = packUint16(msg, uint16(p.Key))
+		msg = packUint16(msg, uint16(len(p.Value)))
+		msg = packBytes(msg, p.Value)
+	}
+	return msg, nil
+}
+
+// validate checks that the parameters of r are in strictly increasing order
+// of their keys, that the values of the keys defined by RFC 9460 are well
+// formed, and that the keys listed by the mandatory parameter are present.
+func (r *SVCBResource) validate() error {
+	for i, p := range r.Params {
+		if i > 0 && p.Key <= r.Params[i-1].Key {
+			return &nestedError{"SVCBResource.Params", errParamOutOfOrder}
+		}
+		if !validSVCParamValue(p.Key, p.Value) {
+			return &nestedError{"SVCBResource.Params[" + p.Key.String() + "]", errParamValue}
+		}
+	}
+	keys, _ := r.MandatoryParam()
+	for _, k := range keys {
+		if _, ok := r.param(k); !ok {
+			return &nestedError{"SVCBResource.Params[" + k.String() + "]", errParamMandatory}
+		}
+	}
+	return nil
+}
+
+// validSVCParamValue reports whether value is a well-formed value of the
+// parameter key. Values of keys that are not defined by RFC 9460 are always
+// well formed.
+func validSVCParamValue(key SVCParamKey, value []byte) bool {
+	switch key {
+	case SVCParamMandatory:
+		if len(value) == 0 || len(value)%2 != 0 {
+			return false
+		}
+		var prev SVCParamKey
+		for i := 0; i < len(value); i += 2 {
+			k := SVCParamKey(value[i])<<8 | SVCParamKey(value[i+1])
+			if k == SVCParamMandatory || i > 0 && k <= prev {
+				return false
+			}
+			prev = k
+		}
+	case SVCParamALPN:
+		if len(value) == 0 {
+			return false
+		}
+		for len(value) > 0 {
+			n := int(value[0])
+			if n == 0 || 1+n > len(value) {
+				return false
+			}
+			value = value[1+n:]
+		}
+	case SVCParamNoDefaultALPN:
+		return len(value) == 0
+	case SVCParamPort:
+		return len(value) == 2
+	case SVCParamIPv4Hint:
+		return len(value) != 0 && len(value)%4 == 0
+	case SVCParamIPv6Hint:
+		return len(value) != 0 && len(value)%16 == 0
+	}
+	return true
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *SVCBResource) GoString() string {
+	return "dnsmessage.SVCBResource{" + r.goStringFields() + "}"
+}
+
+func (r *SVCBResource) goStringFields() string {
+	s := "Priority: " + printUint16(r.Priority) + ", " +
+		"Target: " + r.Target.GoString() + ", " +
+		"Params: []dnsmessage.SVCParam{"
+	for i, p := range r.Params {
+		if i > 0 {
+			s += ", "
+		}
+		s += p.GoString()
+	}
+	return s + "}"
+}
+
+func unpackSVCBResource(msg []byte, off int, length uint16) (SVCBResource, error) {
+	end := off + int(length)
+	if end > len(msg) {
+		return SVCBResource{}, errResourceLen
+	}
+	priority, off, err := unpackUint16(msg, off)
+	if err != nil {
+		return SVCBResource{}, &nestedError{"Priority", err}
+	}
+	var target Name
+	if off, err = target.unpack(msg, off); err != nil {
+		return SVCBResource{}, &nestedError{"Target", err}
+	}
+	if off > end {
+		return SVCBResource{}, &nestedError{"Target", errResourceLen}
+	}
+	var params []SVCParam
+	for off < end {
+		var p SVCParam
+		var key, l uint16
+		if key, off, err = unpackUint16(msg[:end], off); err != nil {
+			return SVCBResource{}, &nestedError{"Params", err}
+		}
+		p.Key = SVCParamKey(key)
+		if l, off, err = unpackUint16(msg[:end], off); err != nil {
+			return SVCBResource{}, &nestedError{"Params", err}
+		}
+		p.Value = make([]byte, l)
+		if off, err = unpackBytes(msg[:end], off, p.Value); err != nil {
+			return SVCBResource{}, &nestedError{"Params", errCalcLen}
+		}
+		params = append(params, p)
+	}
+	r := SVCBResource{priority, target, params}
+	if err := r.validate(); err != nil {
+		return SVCBResource{}, err
+	}
+	return r, nil
+}
+
+func (r *HTTPSResource) realType() Type {
+	return TypeHTTPS
+}
+
+// GoString implements fmt.GoStringer.GoString.
+func (r *HTTPSResource) GoString() string {
+	return "dnsmessage.HTTPSResource{SVCBResource: dnsmessage.SVCBResource{" + r.SVCBResource.goStringFields() + "}}"
+}
+
+func unpackHTTPSResource(msg []byte, off int, length uint16) (HTTPSResource, error) {
+	r, err := unpackSVCBResource(msg, off, length)
+	if err != nil {
+		return HTTPSResource{}, err
+	}
+	return HTTPSResource{r}, nil
+}
+
+// param returns the value of the parameter key.
+func (r *SVCBResource) param(key SVCParamKey) ([]byte, bool) {
+	for _, p := range r.Params {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Param returns the wire format of the value of the parameter key, and
+// whether r has that parameter.
+func (r *SVCBResource) Param(key SVCParamKey) (value []byte, ok bool) {
+	return r.param(key)
+}
+
+// SetParam sets the wire format of the value of the parameter key, keeping
+// the parameters in strictly increasing order of their keys.
+func (r *SVCBResource) SetParam(key SVCParamKey, value []byte) {
+	i := 0
+	for i < len(r.Params) && r.Params[i].Key < key {
+		i++
+	}
+	if i < len(r.Params) && r.Params[i].Key == key {
+		r.Params[i].Value = value
+		return
+	}
+	r.Params = append(r.Params, SVCParam{})
+	copy(r.Params[i+1:], r.Params[i:])
+	r.Params[i] = SVCParam{key, value}
+}
+
+// DeleteParam removes the parameter key, and reports whether r had it.
+func (r *SVCBResource) DeleteParam(key SVCParamKey) bool {
+	for i, p := range r.Params {
+		if p.Key == key {
+			r.Params = append(r.Params[:i], r.Params[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// typedParam returns the value of the parameter key if it is well formed.
+func (r *SVCBResource) typedParam(key SVCParamKey) ([]byte, bool) {
+	v, ok := r.param(key)
+	if !ok || !validSVCParamValue(key, v) {
+		return nil, false
+	}
+	return v, true
+}
+
+// MandatoryParam returns the keys listed by the mandatory parameter.
+func (r *SVCBResource) MandatoryParam() ([]SVCParamKey, bool) {
+	v, ok := r.typedParam(SVCParamMandatory)
+	if !ok {
+		return nil, false
+	}
+	keys := make([]SVCParamKey, 0, len(v)/2)
+	for i := 0; i < len(v); i += 2 {
+		keys = append(keys, SVCParamKey(v[i])<<8|SVCParamKey(v[i+1]))
+	}
+	return keys, true
+}
+
+// SetMandatoryParam sets the mandatory parameter, which lists the keys of
+// the parameters that clients must support. The keys must be in strictly
+// increasing order.
+func (r *SVCBResource) SetMandatoryParam(keys ...SVCParamKey) {
+	v := make([]byte, 0, 2*len(keys))
+	for _, k := range keys {
+		v = packUint16(v, uint16(k))
+	}
+	r.SetParam(SVCParamMandatory, v)
+}
+
+// ALPNParam returns the ALPN protocol identifiers of the alpn parameter.
+func (r *SVCBResource) ALPNParam() ([]string, bool) {
+	v, ok := r.typedParam(SVCParamALPN)
+	if !ok {
+		return nil, false
+	}
+	var ids []string
+	for len(v) > 0 {
+		n := int(v[0])
+		ids = append(ids, string(v[1:1+n]))
+		v = v[1+n:]
+	}
+	return ids, true
+}
+
+// SetALPNParam sets the alpn parameter, which lists the ALPN protocol
+// identifiers that the endpoint supports. Each identifier must have between
+// 1 and 255 bytes.
+func (r *SVCBResource) SetALPNParam(ids ...string) {
+	var v []byte
+	for _, id := range ids {
+		v = append(v, byte(len(id)))
+		v = append(v, id...)
+	}
+	r.SetParam(SVCParamALPN, v)
+}
+
+// NoDefaultALPNParam reports whether r has the no-default-alpn parameter.
+func (r *SVCBResource) NoDefaultALPNParam() bool {
+	_, ok := r.typedParam(SVCParamNoDefaultALPN)
+	return ok
+}
+
+// SetNoDefaultALPNParam sets the no-default-alpn parameter, which indicates
+// that the endpoint does not support the default protocol of the scheme.
+func (r *SVCBResource) SetNoDefaultALPNParam() {
+	r.SetParam(SVCParamNoDefaultALPN, []byte{})
+}
+
+// PortParam returns the port of the port parameter.
+func (r *SVCBResource) PortParam() (uint16, bool) {
+	v, ok := r.typedParam(SVCParamPort)
+	if !ok {
+		return 0, false
+	}
+	return uint16(v[0])<<8 | uint16(v[1]), true
+}
+
+// SetPortParam sets the port parameter, which is the port of the
+// alternative endpoint.
+func (r *SVCBResource) SetPortParam(port uint16) {
+	r.SetParam(SVCParamPort, packUint16(nil, port))
+}
+
+// IPv4HintParam returns the addresses of the ipv4hint parameter.
+func (r *SVCBResource) IPv4HintParam() ([][4]byte, bool) {
+	v, ok := r.typedParam(SVCParamIPv4Hint)
+	if !ok {
+		return nil, false
+	}
+	addrs := make([][4]byte, len(v)/4)
+	for i := range addrs {
+		copy(addrs[i][:], v[4*i:])
+	}
+	return addrs, true
+}
+
+// SetIPv4HintParam sets the ipv4hint parameter, which lists IPv4 addresses
+// that clients may use to reach the endpoint.
+func (r *SVCBResource) SetIPv4HintParam(addrs ...[4]byte) {
+	v := make([]byte, 0, 4*len(addrs))
+	for _, a := range addrs {
+		v = append(v, a[:]...)
+	}
+	r.SetParam(SVCParamIPv4Hint, v)
+}
+
+// ECHParam returns the ECHConfigList of the ech parameter.
+func (r *SVCBResource) ECHParam() ([]byte, bool) {
+	return r.typedParam(SVCParamECH)
+}
+
+// SetECHParam sets the ech parameter, which holds the ECHConfigList to use
+// for Encrypted ClientHello.
+func (r *SVCBResource) SetECHParam(configList []byte) {
+	r.SetParam(SVCParamECH, configList)
+}
+
+// IPv6HintParam returns the addresses of the ipv6hint parameter.
+func (r *SVCBResource) IPv6HintParam() ([][16]byte, bool) {
+	v, ok := r.typedParam(SVCParamIPv6Hint)
+	if !ok {
+		return nil, false
+	}
+	addrs := make([][16]byte, len(v)/16)
+	for i := range addrs {
+		copy(addrs[i][:], v[16*i:])
+	}
+	return addrs, true
+}
+
+// SetIPv6HintParam sets the ipv6hint parameter, which lists IPv6 addresses
+// that clients may use to reach the endpoint.
+func (r *SVCBResource) SetIPv6HintParam(addrs ...[16]byte) {
+	v := make([]byte, 0, 16*len(addrs))
+	for _, a := range addrs {
+		v = append(v, a[:]...)
+	}
+	r.SetParam(SVCParamIPv6Hint, v)
+}
@@ -0,0 +1,224 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// svcbTestData is the wire format of the ServiceMode record of RFC 9460
+// Appendix D.2 with alpn, mandatory and ipv4hint parameters.
+var svcbTestData = []byte{
+	0x00, 0x10,
+	0x03, 'f', 'o', 'o', 0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x03, 'c', 'o', 'm', 0x00,
+	0x00, 0x00, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04,
+	0x00, 0x01, 0x00, 0x09, 0x02, 'h', '2', 0x05, 'h', '3', '-', '1', '9',
+	0x00, 0x04, 0x00, 0x04, 0xc0, 0x00, 0x02, 0x01,
+}
+
+func TestSVCBPackUnpack(t *testing.T) {
+	var r HTTPSResource
+	r.Priority = 16
+	r.Target = MustNewName("foo.example.com.")
+	r.SetIPv4HintParam([4]byte{192, 0, 2, 1})
+	r.SetALPNParam("h2", "h3-19")
+	r.SetMandatoryParam(SVCParamALPN, SVCParamIPv4Hint)
+
+	got, err := r.pack(nil, nil, 0)
+	if err != nil {
+		t.Fatal("HTTPSResource.pack() =", err)
+	}
+	if !bytes.Equal(got, svcbTestData) {
+		t.Fatalf("HTTPSResource.pack() = %#v, want = %#v", got, svcbTestData)
+	}
+
+	name := MustNewName("example.com.")
+	b := NewBuilder(nil, Header{Response: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal("Builder.StartQuestions() =", err)
+	}
+	if err := b.Question(Question{Name: name, Type: TypeHTTPS, Class: ClassINET}); err != nil {
+		t.Fatal("Builder.Question() =", err)
+	}
+	if err := b.StartAnswers(); err != nil {
+		t.Fatal("Builder.StartAnswers() =", err)
+	}
+	hdr := ResourceHeader{Name: name, Class: ClassINET, TTL: 300}
+	if err := b.HTTPSResource(hdr, r); err != nil {
+		t.Fatal("Builder.HTTPSResource() =", err)
+	}
+	if err := b.SVCBResource(hdr, r.SVCBResource); err != nil {
+		t.Fatal("Builder.SVCBResource() =", err)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal("Builder.Finish() =", err)
+	}
+
+	var p Parser
+	if _, err := p.Start(msg); err != nil {
+		t.Fatal("Parser.Start() =", err)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		t.Fatal("Parser.SkipAllQuestions() =", err)
+	}
+	if h, err := p.AnswerHeader(); err != nil || h.Type != TypeHTTPS {
+		t.Fatalf("Parser.AnswerHeader() = %v, %v, want Type = TypeHTTPS", h, err)
+	}
+	https, err := p.HTTPSResource()
+	if err != nil {
+		t.Fatal("Parser.HTTPSResource() =", err)
+	}
+	if !reflect.DeepEqual(https, r) {
+		t.Errorf("Parser.HTTPSResource() = %#v, want = %#v", &https, &r)
+	}
+	if h, err := p.AnswerHeader(); err != nil || h.Type != TypeSVCB {
+		t.Fatalf("Parser.AnswerHeader() = %v, %v, want Type = TypeSVCB", h, err)
+	}
+	if _, err := p.HTTPSResource(); err != ErrNotStarted {
+		t.Errorf("Parser.HTTPSResource() of an SVCB record = %v, want = %v", err, ErrNotStarted)
+	}
+	svcb, err := p.SVCBResource()
+	if err != nil {
+		t.Fatal("Parser.SVCBResource() =", err)
+	}
+	if !reflect.DeepEqual(svcb, r.SVCBResource) {
+		t.Errorf("Parser.SVCBResource() = %#v, want = %#v", &svcb, &r.SVCBResource)
+	}
+
+	var m Message
+	if err := m.Unpack(msg); err != nil {
+		t.Fatal("Message.Unpack() =", err)
+	}
+	if body, ok := m.Answers[0].Body.(*HTTPSResource); !ok || !reflect.DeepEqual(*body, r) {
+		t.Errorf("Message.Unpack() Answers[0].Body = %#v, want = %#v", m.Answers[0].Body, &r)
+	}
+	if _, ok := m.Answers[1].Body.(*SVCBResource); !ok {
+		t.Errorf("Message.Unpack() Answers[1].Body = %#v, want an *SVCBResource", m.Answers[1].Body)
+	}
+}
+
+func TestSVCBParams(t *testing.T) {
+	var r SVCBResource
+	if _, ok := r.PortParam(); ok {
+		t.Error("PortParam() of an empty record: got ok")
+	}
+	r.SetPortParam(8443)
+	r.SetIPv6HintParam([16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1})
+	r.SetECHParam([]byte{1, 2, 3})
+	r.SetNoDefaultALPNParam()
+	r.SetALPNParam("h3")
+	r.SetMandatoryParam(SVCParamPort)
+	r.SetParam(1234, []byte("opaque"))
+
+	var keys []SVCParamKey
+	for _, p := range r.Params {
+		keys = append(keys, p.Key)
+	}
+	want := []SVCParamKey{
+		SVCParamMandatory, SVCParamALPN, SVCParamNoDefaultALPN, SVCParamPort,
+		SVCParamECH, SVCParamIPv6Hint, 1234,
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Params keys = %v, want = %v", keys, want)
+	}
+
+	if got, ok := r.MandatoryParam(); !ok || !reflect.DeepEqual(got, []SVCParamKey{SVCParamPort}) {
+		t.Errorf("MandatoryParam() = %v, %t", got, ok)
+	}
+	if got, ok := r.ALPNParam(); !ok || !reflect.DeepEqual(got, []string{"h3"}) {
+		t.Errorf("ALPNParam() = %q, %t", got, ok)
+	}
+	if !r.NoDefaultALPNParam() {
+		t.Error("NoDefaultALPNParam() = false")
+	}
+	if got, ok := r.PortParam(); !ok || got != 8443 {
+		t.Errorf("PortParam() = %d, %t", got, ok)
+	}
+	if got, ok := r.ECHParam(); !ok || !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("ECHParam() = %v, %t", got, ok)
+	}
+	if got, ok := r.IPv6HintParam(); !ok || len(got) != 1 || got[0][15] != 1 {
+		t.Errorf("IPv6HintParam() = %v, %t", got, ok)
+	}
+	if got, ok := r.Param(1234); !ok || string(got) != "opaque" {
+		t.Errorf("Param(1234) = %q, %t", got, ok)
+	}
+
+	if !r.DeleteParam(SVCParamNoDefaultALPN) || r.NoDefaultALPNParam() {
+		t.Error("DeleteParam(SVCParamNoDefaultALPN) did not remove the parameter")
+	}
+	if r.DeleteParam(SVCParamIPv4Hint) {
+		t.Error("DeleteParam(SVCParamIPv4Hint) = true for a missing parameter")
+	}
+	r.SetPortParam(443)
+	if got, _ := r.PortParam(); got != 443 || len(r.Params) != 6 {
+		t.Errorf("SetPortParam of an existing parameter: PortParam() = %d, %d params", got, len(r.Params))
+	}
+
+	const wantGoString = `dnsmessage.SVCBResource{Priority: 0, Target: dnsmessage.MustNewName(""), Params: []dnsmessage.SVCParam{` +
+		`dnsmessage.SVCParam{Key: dnsmessage.SVCParamMandatory, Value: []byte{0, 3}}, ` +
+		`dnsmessage.SVCParam{Key: dnsmessage.SVCParamALPN, Value: []byte{2, 104, 51}}, ` +
+		`dnsmessage.SVCParam{Key: dnsmessage.SVCParamPort, Value: []byte{1, 187}}, ` +
+		`dnsmessage.SVCParam{Key: dnsmessage.SVCParamECH, Value: []byte{1, 2, 3}}, ` +
+		`dnsmessage.SVCParam{Key: dnsmessage.SVCParamIPv6Hint, Value: []byte{32, 1, 13, 184, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}}, ` +
+		`dnsmessage.SVCParam{Key: 1234, Value: []byte{111, 112, 97, 113, 117, 101}}}}`
+	if got := r.GoString(); got != wantGoString {
+		t.Errorf("GoString() = %s\nwant = %s", got, wantGoString)
+	}
+}
+
+func TestSVCBInvalid(t *testing.T) {
+	target := MustNewName(".")
+	for _, tt := range []struct {
+		name   string
+		params []SVCParam
+		want   error
+	}{
+		{"out of order", []SVCParam{{SVCParamPort, []byte{0, 1}}, {SVCParamALPN, []byte{1, 'x'}}}, errParamOutOfOrder},
+		{"duplicate", []SVCParam{{SVCParamPort, []byte{0, 1}}, {SVCParamPort, []byte{0, 2}}}, errParamOutOfOrder},
+		{"short port", []SVCParam{{SVCParamPort, []byte{1}}}, errParamValue},
+		{"empty alpn", []SVCParam{{SVCParamALPN, nil}}, errParamValue},
+		{"empty alpn-id", []SVCParam{{SVCParamALPN, []byte{0}}}, errParamValue},
+		{"truncated alpn-id", []SVCParam{{SVCParamALPN, []byte{3, 'h', '2'}}}, errParamValue},
+		{"no-default-alpn with value", []SVCParam{{SVCParamNoDefaultALPN, []byte{0}}}, errParamValue},
+		{"short ipv4hint", []SVCParam{{SVCParamIPv4Hint, []byte{1, 2, 3}}}, errParamValue},
+		{"short ipv6hint", []SVCParam{{SVCParamIPv6Hint, make([]byte, 15)}}, errParamValue},
+		{"mandatory lists mandatory", []SVCParam{{SVCParamMandatory, []byte{0, 0}}}, errParamValue},
+		{"mandatory out of order", []SVCParam{
+			{SVCParamMandatory, []byte{0, 3, 0, 1}},
+			{SVCParamALPN, []byte{1, 'x'}},
+			{SVCParamPort, []byte{0, 1}},
+		}, errParamValue},
+		{"mandatory key missing", []SVCParam{{SVCParamMandatory, []byte{0, 3}}}, errParamMandatory},
+	} {
+		r := SVCBResource{Target: target, Params: tt.params}
+		if _, err := r.pack(nil, nil, 0); err == nil || !strings.Contains(err.Error(), tt.want.Error()) {
+			t.Errorf("%s: SVCBResource.pack() = %v, want %v", tt.name, err, tt.want)
+		}
+
+		// Parsing rejects the same records.
+		var data []byte
+		data = packUint16(data, r.Priority)
+		data, _ = r.Target.pack(data, nil, 0)
+		for _, p := range r.Params {
+			data = packUint16(data, uint16(p.Key))
+			data = packUint16(data, uint16(len(p.Value)))
+			data = append(data, p.Value...)
+		}
+		if _, err := unpackSVCBResource(data, 0, uint16(len(data))); err == nil || !strings.Contains(err.Error(), tt.want.Error()) {
+			t.Errorf("%s: unpackSVCBResource() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A parameter must not extend past the end of the record.
+	if _, err := unpackSVCBResource(svcbTestData, 0, uint16(len(svcbTestData)-1)); err == nil {
+		t.Error("unpackSVCBResource() of a truncated record = nil error")
+	}
+}
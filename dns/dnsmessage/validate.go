@@ -0,0 +1,660 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsmessage
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsupportedAlgorithm indicates that a DNSSEC signing algorithm,
+	// digest type or NSEC3 hash algorithm isn't supported. RFC 4035
+	// Section 5.2 treats data signed only with such algorithms as
+	// unsigned rather than bogus.
+	ErrUnsupportedAlgorithm = errors.New("unsupported DNSSEC algorithm")
+
+	errRRSetMismatch       = errors.New("records are not the RRset covered by the signature")
+	errKeyMismatch         = errors.New("key does not match the signature")
+	errSignatureNotCurrent = errors.New("signature is outside its validity period")
+	errInvalidPublicKey    = errors.New("invalid public key")
+	errBadSignature        = errors.New("signature verification failed")
+	errDenialNotProven     = errors.New("records do not prove the denial of existence")
+	errTooManyIterations   = errors.New("too many NSEC3 iterations")
+)
+
+// maxNSEC3Iterations is the largest number of additional NSEC3 hash
+// iterations that the denial checks perform. RFC 9276 Section 3.2 lets
+// validators treat records with more iterations as insecure.
+const maxNSEC3Iterations = 150
+
+// typeDNAME is the type of DNAME Resource records (RFC 6672), which the
+// denial checks need to recognize.
+const typeDNAME Type = 39
+
+// nameLabels returns the labels of n, without the root.
+func nameLabels(n Name) []string {
+	s := strings.TrimSuffix(n.String(), ".")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ".")
+}
+
+// nameFromLabels returns the name made of labels.
+func nameFromLabels(labels []string) (Name, error) {
+	return NewName(strings.Join(labels, ".") + ".")
+}
+
+// lowerLabel returns the label l with ASCII letters mapped to lower case.
+func lowerLabel(l string) string {
+	b := []byte(l)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// lowerName returns n with ASCII letters mapped to lower case.
+func lowerName(n Name) Name {
+	for i, c := range n.Data[:n.Length] {
+		if 'A' <= c && c <= 'Z' {
+			n.Data[i] = c + 'a' - 'A'
+		}
+	}
+	return n
+}
+
+// equalNames reports whether a and b are the same name, ignoring the case
+// of ASCII letters.
+func equalNames(a, b Name) bool {
+	return CompareNames(a, b) == 0
+}
+
+// isSubdomain reports whether child is parent or a name below it.
+func isSubdomain(child, parent Name) bool {
+	c, p := nameLabels(child), nameLabels(parent)
+	if len(c) < len(p) {
+		return false
+	}
+	for i := range p {
+		if lowerLabel(c[len(c)-1-i]) != lowerLabel(p[len(p)-1-i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareNames compares a and b in the canonical DNS name order
+// (RFC 4034 Section 6.1). The result is 0 if a and b are the same name
+// ignoring the case of ASCII letters, -1 if a sorts before b, and +1
+// otherwise.
+func CompareNames(a, b Name) int {
+	al, bl := nameLabels(a), nameLabels(b)
+	for i := 1; i <= len(al) && i <= len(bl); i++ {
+		if c := strings.Compare(lowerLabel(al[len(al)-i]), lowerLabel(bl[len(bl)-i])); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(al) < len(bl):
+		return -1
+	case len(al) > len(bl):
+		return 1
+	}
+	return 0
+}
+
+// canonicalBody returns b with the domain names in its RDATA mapped to
+// lower case, for the types listed in RFC 4034 Section 6.2 as amended by
+// RFC 6840 Section 5.1.
+func canonicalBody(b ResourceBody) ResourceBody {
+	switch b := b.(type) {
+	case *NSResource:
+		return &NSResource{lowerName(b.NS)}
+	case *CNAMEResource:
+		return &CNAMEResource{lowerName(b.CNAME)}
+	case *PTRResource:
+		return &PTRResource{lowerName(b.PTR)}
+	case *MXResource:
+		return &MXResource{b.Pref, lowerName(b.MX)}
+	case *SRVResource:
+		return &SRVResource{b.Priority, b.Weight, b.Port, lowerName(b.Target)}
+	case *SOAResource:
+		r := *b
+		r.NS, r.MBox = lowerName(b.NS), lowerName(b.MBox)
+		return &r
+	case *RRSIGResource:
+		r := *b
+		r.SignerName = lowerName(b.SignerName)
+		return &r
+	}
+	return b
+}
+
+// AppendCanonicalRRSet appends the canonical form of the RRset rrs to b,
+// as it is signed by an RRSIG Resource record (RFC 4034 Section 6.3).
+//
+// Each record is packed without name compression, with its owner name and
+// the domain names in its data mapped to lower case, and with the TTL ttl.
+// The records are sorted by their data, and duplicates are removed. All
+// records must have the same owner name, class and type.
+func AppendCanonicalRRSet(b []byte, rrs []Resource, ttl uint32) ([]byte, error) {
+	if len(rrs) == 0 || rrs[0].Body == nil {
+		return b, errRRSetMismatch
+	}
+	first, typ := rrs[0].Header, rrs[0].Body.realType()
+	rdatas := make([][]byte, 0, len(rrs))
+	for _, rr := range rrs {
+		if rr.Body == nil {
+			return b, errNilResouceBody
+		}
+		if !equalNames(rr.Header.Name, first.Name) || rr.Header.Class != first.Class ||
+			rr.Body.realType() != typ {
+			return b, errRRSetMismatch
+		}
+		rdata, err := canonicalBody(rr.Body).pack(nil, nil, 0)
+		if err != nil {
+			return b, &nestedError{"content", err}
+		}
+		if len(rdata) > int(^uint16(0)) {
+			return b, errResTooLong
+		}
+		rdatas = append(rdatas, rdata)
+	}
+	slices.SortFunc(rdatas, bytes.Compare)
+	rdatas = slices.CompactFunc(rdatas, bytes.Equal)
+
+	owner := lowerName(first.Name)
+	for _, rdata := range rdatas {
+		var err error
+		if b, err = owner.pack(b, nil, 0); err != nil {
+			return b, &nestedError{"Name", err}
+		}
+		b = packType(b, typ)
+		b = packClass(b, first.Class)
+		b = packUint32(b, ttl)
+		b = packUint16(b, uint16(len(rdata)))
+		b = packBytes(b, rdata)
+	}
+	return b, nil
+}
+
+// SignedData returns the data that r signs for the RRset rrs: the data of
+// r without its signature, followed by the canonical form of the RRset
+// (RFC 4034 Section 3.1.8.1). If r has fewer labels than the owner name of
+// the RRset, the owner name is replaced by the wildcard name that it was
+// expanded from.
+func (r *RRSIGResource) SignedData(rrs []Resource) ([]byte, error) {
+	if len(rrs) == 0 || rrs[0].Body == nil || rrs[0].Body.realType() != r.TypeCovered {
+		return nil, errRRSetMismatch
+	}
+	labels := nameLabels(rrs[0].Header.Name)
+	if len(labels) > 0 && labels[0] == "*" {
+		labels = labels[1:]
+	}
+	if int(r.Labels) > len(labels) || !isSubdomain(rrs[0].Header.Name, r.SignerName) {
+		return nil, errRRSetMismatch
+	}
+	if int(r.Labels) < len(labels) {
+		wildcard, err := nameFromLabels(append([]string{"*"}, labels[len(labels)-int(r.Labels):]...))
+		if err != nil {
+			return nil, err
+		}
+		expanded := make([]Resource, len(rrs))
+		for i, rr := range rrs {
+			if !equalNames(rr.Header.Name, rrs[0].Header.Name) {
+				return nil, errRRSetMismatch
+			}
+			expanded[i] = rr
+			expanded[i].Header.Name = wildcard
+		}
+		rrs = expanded
+	}
+	sig := *r
+	sig.SignerName = lowerName(r.SignerName)
+	b, err := sig.packSigned(nil)
+	if err != nil {
+		return nil, err
+	}
+	return AppendCanonicalRRSet(b, rrs, r.OriginalTTL)
+}
+
+// Verify checks that r is a valid signature of the RRset rrs at the time
+// now, made with the DNSKEY Resource record key.
+//
+// The owner name of key must be the signer name of r; Verify only checks
+// that the key tag, algorithm and flags of key match r. Verify supports the
+// RSA/SHA-256, RSA/SHA-512, ECDSA P-256/SHA-256, ECDSA P-384/SHA-384 and
+// Ed25519 algorithms, and returns [ErrUnsupportedAlgorithm] for others.
+func (r *RRSIGResource) Verify(rrs []Resource, key *DNSKEYResource, now time.Time) error {
+	if key.Algorithm != r.Algorithm || key.Protocol != 3 ||
+		key.Flags&DNSKEYFlagZone == 0 || key.KeyTag() != r.KeyTag {
+		return errKeyMismatch
+	}
+	// Times are compared with serial number arithmetic (RFC 1982), as
+	// described in RFC 4034 Section 3.1.5.
+	t := uint32(now.Unix())
+	if int32(t-r.Inception) < 0 || int32(r.Expiration-t) < 0 {
+		return errSignatureNotCurrent
+	}
+	data, err := r.SignedData(rrs)
+	if err != nil {
+		return err
+	}
+	return verifySignature(r.Algorithm, key.PublicKey, data, r.Signature)
+}
+
+// verifySignature checks that sig is a signature of data with the public
+// key pub, in the formats defined for the DNSSEC algorithm alg.
+func verifySignature(alg DNSSECAlgorithm, pub, data, sig []byte) error {
+	switch alg {
+	case AlgorithmRSASHA256, AlgorithmRSASHA512:
+		key, err := parseRSAPublicKey(pub)
+		if err != nil {
+			return err
+		}
+		h, hash := crypto.SHA256, sha256.Sum256(data)
+		digest := hash[:]
+		if alg == AlgorithmRSASHA512 {
+			hash := sha512.Sum512(data)
+			h, digest = crypto.SHA512, hash[:]
+		}
+		if rsa.VerifyPKCS1v15(key, h, digest, sig) != nil {
+			return errBadSignature
+		}
+		return nil
+	case AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384:
+		curve, size := elliptic.P256(), 32
+		hash := sha256.Sum256(data)
+		digest := hash[:]
+		if alg == AlgorithmECDSAP384SHA384 {
+			hash := sha512.Sum384(data)
+			curve, size, digest = elliptic.P384(), 48, hash[:]
+		}
+		if len(pub) != 2*size {
+			return errInvalidPublicKey
+		}
+		if len(sig) != 2*size {
+			return errBadSignature
+		}
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(pub[:size]),
+			Y:     new(big.Int).SetBytes(pub[size:]),
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errBadSignature
+		}
+		return nil
+	case AlgorithmED25519:
+		if len(pub) != ed25519.PublicKeySize {
+			return errInvalidPublicKey
+		}
+		if !ed25519.Verify(ed25519.PublicKey(pub), data, sig) {
+			return errBadSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlgorithm
+}
+
+// parseRSAPublicKey parses an RSA public key in the format of RFC 3110
+// Section 2.
+func parseRSAPublicKey(b []byte) (*rsa.PublicKey, error) {
+	if len(b) < 1 {
+		return nil, errInvalidPublicKey
+	}
+	n, b := int(b[0]), b[1:]
+	if n == 0 {
+		if len(b) < 2 {
+			return nil, errInvalidPublicKey
+		}
+		n, b = int(b[0])<<8|int(b[1]), b[2:]
+	}
+	if n == 0 || n > 4 || len(b) <= n {
+		// Exponents larger than 32 bits are not supported by crypto/rsa.
+		return nil, errInvalidPublicKey
+	}
+	e := 0
+	for _, c := range b[:n] {
+		e = e<<8 | int(c)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(b[n:]), E: e}, nil
+}
+
+// DS returns the DS Resource record that refers to the key, which is
+// owned by owner, with a digest of type digestType (RFC 4034 Section 5.1.4).
+func (r *DNSKEYResource) DS(owner Name, digestType DigestType) (DSResource, error) {
+	owner = lowerName(owner)
+	data, err := owner.pack(nil, nil, 0)
+	if err != nil {
+		return DSResource{}, err
+	}
+	data, _ = r.pack(data, nil, 0)
+	var digest []byte
+	switch digestType {
+	case DigestSHA1:
+		h := sha1.Sum(data)
+		digest = h[:]
+	case DigestSHA256:
+		h := sha256.Sum256(data)
+		digest = h[:]
+	case DigestSHA384:
+		h := sha512.Sum384(data)
+		digest = h[:]
+	default:
+		return DSResource{}, ErrUnsupportedAlgorithm
+	}
+	return DSResource{
+		KeyTag:     r.KeyTag(),
+		Algorithm:  r.Algorithm,
+		DigestType: digestType,
+		Digest:     digest,
+	}, nil
+}
+
+// Covers reports whether the NSEC record r owned by owner proves that name
+// does not exist, because name sorts strictly between owner and
+// r.NextDomain in the canonical order. The last NSEC record of a zone,
+// whose next domain name is the apex of the zone, covers the names that
+// sort after its owner.
+//
+// Names below a delegation or a DNAME at owner are never covered, as their
+// existence is not decided by the zone of r.
+func (r *NSECResource) Covers(owner, name Name) bool {
+	if isSubdomain(name, owner) && !equalNames(name, owner) &&
+		(r.HasType(TypeNS) && !r.HasType(TypeSOA) || r.HasType(typeDNAME)) {
+		return false
+	}
+	if CompareNames(owner, name) >= 0 {
+		return false
+	}
+	// The next domain name of the last record is the apex, which sorts
+	// before the owner.
+	return CompareNames(owner, r.NextDomain) >= 0 || CompareNames(name, r.NextDomain) < 0
+}
+
+// nsecRecords returns the NSEC records in rrs.
+func nsecRecords(rrs []Resource) (owners []Name, nsecs []*NSECResource) {
+	for _, rr := range rrs {
+		if nsec, ok := rr.Body.(*NSECResource); ok {
+			owners = append(owners, rr.Header.Name)
+			nsecs = append(nsecs, nsec)
+		}
+	}
+	return owners, nsecs
+}
+
+// CheckNSECNameError checks that the NSEC records in rrs prove that name
+// does not exist (RFC 4035 Section 5.4): an NSEC record covers name, and
+// an NSEC record covers the wildcard name that could have been expanded to
+// name. Records of other types in rrs are ignored. The NSEC records must
+// have been verified.
+func CheckNSECNameError(rrs []Resource, name Name) error {
+	owners, nsecs := nsecRecords(rrs)
+	var encloser []string
+	found := false
+	for i, nsec := range nsecs {
+		if !nsec.Covers(owners[i], name) {
+			continue
+		}
+		found = true
+		// The closest encloser is the longest common ancestor of name
+		// and either end of the covering record.
+		for _, n := range []Name{owners[i], nsec.NextDomain} {
+			if ce := commonAncestor(name, n); len(ce) > len(encloser) {
+				encloser = ce
+			}
+		}
+	}
+	if !found {
+		return errDenialNotProven
+	}
+	wildcard, err := nameFromLabels(append([]string{"*"}, encloser...))
+	if err != nil {
+		return err
+	}
+	for i, nsec := range nsecs {
+		if nsec.Covers(owners[i], wildcard) {
+			return nil
+		}
+	}
+	return errDenialNotProven
+}
+
+// commonAncestor returns the labels of the longest common ancestor of a
+// and b.
+func commonAncestor(a, b Name) []string {
+	al, bl := nameLabels(a), nameLabels(b)
+	n := 0
+	for n < len(al) && n < len(bl) && lowerLabel(al[len(al)-1-n]) == lowerLabel(bl[len(bl)-1-n]) {
+		n++
+	}
+	return al[len(al)-n:]
+}
+
+// CheckNSECNoData checks that the NSEC records in rrs prove that name
+// exists but has no records of type t or CNAME (RFC 4035 Section 5.4).
+// Records of other types in rrs are ignored. The NSEC records must have
+// been verified.
+//
+// An NSEC record of a delegation from the parent zone only proves the
+// absence of DS records.
+func CheckNSECNoData(rrs []Resource, name Name, t Type) error {
+	owners, nsecs := nsecRecords(rrs)
+	for i, nsec := range nsecs {
+		if !equalNames(owners[i], name) {
+			continue
+		}
+		if nsec.HasType(t) || nsec.HasType(TypeCNAME) {
+			return errDenialNotProven
+		}
+		if t != TypeDS && nsec.HasType(TypeNS) && !nsec.HasType(TypeSOA) {
+			return errDenialNotProven
+		}
+		return nil
+	}
+	return errDenialNotProven
+}
+
+// NSEC3Hash returns the hash of name with the parameters of r
+// (RFC 5155 Section 5).
+func (r *NSEC3Resource) NSEC3Hash(name Name) ([]byte, error) {
+	if r.HashAlgorithm != NSEC3HashSHA1 {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if r.Iterations > maxNSEC3Iterations {
+		return nil, errTooManyIterations
+	}
+	name = lowerName(name)
+	b, err := name.pack(nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	for i := 0; i <= int(r.Iterations); i++ {
+		h.Reset()
+		h.Write(b)
+		h.Write(r.Salt)
+		b = h.Sum(b[:0])
+	}
+	return b, nil
+}
+
+// nsec3Encoding is the encoding of hashed owner names (RFC 5155
+// Section 3.3).
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// An nsec3Record is an NSEC3 Resource record with its decoded hashed owner
+// name.
+type nsec3Record struct {
+	*NSEC3Resource
+	hash []byte
+}
+
+// nsec3Records returns the NSEC3 records of zone in rrs. It ignores records
+// whose parameters differ from those of the first one.
+func nsec3Records(rrs []Resource, zone Name) ([]nsec3Record, error) {
+	var records []nsec3Record
+	for _, rr := range rrs {
+		nsec3, ok := rr.Body.(*NSEC3Resource)
+		if !ok {
+			continue
+		}
+		labels := nameLabels(rr.Header.Name)
+		if len(labels) == 0 {
+			continue
+		}
+		parent, err := nameFromLabels(labels[1:])
+		if err != nil || !equalNames(parent, zone) {
+			continue
+		}
+		hash, err := nsec3Encoding.DecodeString(strings.ToUpper(labels[0]))
+		if err != nil || len(hash) != len(nsec3.NextHashedOwner) {
+			continue
+		}
+		if len(records) > 0 {
+			first := records[0]
+			if nsec3.HashAlgorithm != first.HashAlgorithm || nsec3.Iterations != first.Iterations ||
+				!bytes.Equal(nsec3.Salt, first.Salt) {
+				continue
+			}
+		} else if nsec3.HashAlgorithm != NSEC3HashSHA1 {
+			return nil, ErrUnsupportedAlgorithm
+		} else if nsec3.Iterations > maxNSEC3Iterations {
+			return nil, errTooManyIterations
+		}
+		records = append(records, nsec3Record{nsec3, hash})
+	}
+	return records, nil
+}
+
+// matches reports whether the hashed owner name of r is hash.
+func (r nsec3Record) matches(hash []byte) bool {
+	return bytes.Equal(r.hash, hash)
+}
+
+// covers reports whether hash sorts strictly between the hashed owner name
+// of r and the next hashed owner name, wrapping around at the end of the
+// hash chain.
+func (r nsec3Record) covers(hash []byte) bool {
+	if bytes.Compare(r.hash, r.NextHashedOwner) < 0 {
+		return bytes.Compare(r.hash, hash) < 0 && bytes.Compare(hash, r.NextHashedOwner) < 0
+	}
+	return bytes.Compare(r.hash, hash) < 0 || bytes.Compare(hash, r.NextHashedOwner) < 0
+}
+
+// findNSEC3 returns the record of records for which f reports true for the
+// hash of name.
+func findNSEC3(records []nsec3Record, name Name, f func(nsec3Record, []byte) bool) (nsec3Record, bool) {
+	if len(records) == 0 {
+		return nsec3Record{}, false
+	}
+	hash, err := records[0].NSEC3Hash(name)
+	if err != nil {
+		return nsec3Record{}, false
+	}
+	for _, r := range records {
+		if f(r, hash) {
+			return r, true
+		}
+	}
+	return nsec3Record{}, false
+}
+
+// CheckNSEC3NameError checks that the NSEC3 records of zone in rrs prove
+// that name does not exist (RFC 5155 Section 8.4): they prove the closest
+// encloser of name, and an NSEC3 record covers the wildcard name below the
+// closest encloser. Records of other types or zones in rrs are ignored.
+// The NSEC3 records must have been verified.
+//
+// It returns [ErrUnsupportedAlgorithm] if the records use an unsupported
+// hash algorithm, and an error if they use more iterations than validators
+// are expected to perform (RFC 9276).
+func CheckNSEC3NameError(rrs []Resource, zone, name Name) error {
+	records, err := nsec3Records(rrs, zone)
+	if err != nil {
+		return err
+	}
+	if !isSubdomain(name, zone) {
+		return errDenialNotProven
+	}
+	// Find the closest encloser proof (RFC 5155 Section 7.2.1): an
+	// ancestor of name whose hash matches a record, and the next closer
+	// name below it whose hash is covered by a record.
+	labels := nameLabels(name)
+	for i := 1; i <= len(labels)-len(nameLabels(zone)); i++ {
+		encloser, err := nameFromLabels(labels[i:])
+		if err != nil {
+			return err
+		}
+		r, ok := findNSEC3(records, encloser, nsec3Record.matches)
+		if !ok {
+			continue
+		}
+		// A delegation point or a DNAME owner cannot be the closest
+		// encloser: the names below it belong to another zone or are
+		// redirected (RFC 5155 Section 8.3).
+		if r.HasType(TypeNS) && !r.HasType(TypeSOA) || r.HasType(typeDNAME) {
+			return errDenialNotProven
+		}
+		nextCloser, err := nameFromLabels(labels[i-1:])
+		if err != nil {
+			return err
+		}
+		if _, ok := findNSEC3(records, nextCloser, nsec3Record.covers); !ok {
+			return errDenialNotProven
+		}
+		wildcard, err := nameFromLabels(append([]string{"*"}, labels[i:]...))
+		if err != nil {
+			return err
+		}
+		if _, ok := findNSEC3(records, wildcard, nsec3Record.covers); !ok {
+			return errDenialNotProven
+		}
+		return nil
+	}
+	return errDenialNotProven
+}
+
+// CheckNSEC3NoData checks that the NSEC3 records of zone in rrs prove that
+// name exists but has no records of type t or CNAME (RFC 5155 Section 8.5):
+// the hash of name matches an NSEC3 record whose type bitmap includes
+// neither. Records of other types or zones in rrs are ignored. The NSEC3
+// records must have been verified.
+//
+// Opt-out proofs for insecure delegations (RFC 5155 Section 8.6) are not
+// accepted.
+func CheckNSEC3NoData(rrs []Resource, zone, name Name, t Type) error {
+	records, err := nsec3Records(rrs, zone)
+	if err != nil {
+		return err
+	}
+	r, ok := findNSEC3(records, name, nsec3Record.matches)
+	if !ok || r.HasType(t) || r.HasType(TypeCNAME) {
+		return errDenialNotProven
+	}
+	if t != TypeDS && r.HasType(TypeNS) && !r.HasType(TypeSOA) {
+		return errDenialNotProven
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dnstest provides utilities for DNS testing.
+package dnstest // import "golang.org/x/net/dns/dnstest"
+
+import (
+	"net"
+
+	"golang.org/x/net/dns"
+)
+
+// A Server is a DNS server listening on a system-chosen port of the
+// local loopback interface, for use in end-to-end tests.
+type Server struct {
+	// Addr is the address of the server, in the form "host:port". The
+	// server listens on the same port for UDP and TCP.
+	Addr string
+
+	// Server is the underlying server.
+	Server *dns.Server
+
+	udp net.PacketConn
+	tcp net.Listener
+}
+
+// NewServer starts and returns a new Server that answers queries with h.
+// The caller should call Close when finished, to shut it down.
+func NewServer(h dns.Handler) *Server {
+	udp, tcp := listen()
+	s := &Server{
+		Addr:   udp.LocalAddr().String(),
+		Server: &dns.Server{Handler: h},
+		udp:    udp,
+		tcp:    tcp,
+	}
+	go s.Server.ServeUDP(udp)
+	go s.Server.ServeTCP(tcp)
+	return s
+}
+
+// listen listens on the same loopback port for UDP and TCP.
+func listen() (net.PacketConn, net.Listener) {
+	var err error
+	for range 10 {
+		var udp net.PacketConn
+		udp, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			break
+		}
+		// The port may already be in use for TCP.
+		var tcp net.Listener
+		tcp, err = net.Listen("tcp", udp.LocalAddr().String())
+		if err == nil {
+			return udp, tcp
+		}
+		udp.Close()
+	}
+	panic("dnstest: failed to listen on a port: " + err.Error())
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.udp.Close()
+	s.tcp.Close()
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnszone
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// A token is a field of a zone file entry. Escape sequences are kept
+// as they appear in the file, since their meaning depends on the field:
+// an escaped dot is part of a label, not a label separator.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// An entry is a logical line of a zone file: the tokens of one record
+// or directive, which can span several physical lines in parentheses.
+type entry struct {
+	tokens []token
+	line   int
+
+	// blankOwner reports whether the entry starts with blank space,
+	// meaning that the owner name of the previous record is reused.
+	blankOwner bool
+}
+
+// A lexer splits a zone file into entries as described in RFC 1035
+// section 5.1.
+type lexer struct {
+	r    *bufio.Reader
+	file string
+	line int
+}
+
+func newLexer(r io.Reader, file string) *lexer {
+	return &lexer{r: bufio.NewReader(r), file: file, line: 1}
+}
+
+var (
+	errUnbalancedParens = errors.New("unbalanced parentheses")
+	errUnterminatedStr  = errors.New("unterminated quoted string")
+	errTrailingEscape   = errors.New("escape at end of file")
+)
+
+// next returns the next non-empty entry, or io.EOF at the end of the file.
+// On other errors, the line of the returned entry is still set.
+func (l *lexer) next() (entry, error) {
+	var (
+		e       entry
+		tok     []byte
+		inTok   bool
+		parens  int
+		atStart = true
+	)
+	e.line = l.line
+	endToken := func() {
+		if inTok {
+			e.tokens = append(e.tokens, token{text: string(tok)})
+			tok, inTok = tok[:0], false
+		}
+	}
+	for {
+		c, err := l.r.ReadByte()
+		if err == io.EOF {
+			endToken()
+			if parens > 0 {
+				return entry{line: e.line}, errUnbalancedParens
+			}
+			if len(e.tokens) == 0 {
+				return entry{}, io.EOF
+			}
+			return e, nil
+		}
+		if err != nil {
+			return entry{line: e.line}, err
+		}
+		if atStart {
+			atStart = false
+			e.blankOwner = c == ' ' || c == '\t'
+		}
+		switch c {
+		case ' ', '\t', '\r':
+			endToken()
+		case ';':
+			endToken()
+			// Skip to the end of the line, which still ends the entry.
+			for {
+				c, err := l.r.ReadByte()
+				if err != nil {
+					break
+				}
+				if c == '\n' {
+					l.r.UnreadByte()
+					break
+				}
+			}
+		case '\n':
+			endToken()
+			l.line++
+			if parens > 0 {
+				continue
+			}
+			if len(e.tokens) > 0 {
+				return e, nil
+			}
+			// Skip empty lines.
+			e.line = l.line
+			atStart = true
+		case '(':
+			endToken()
+			parens++
+		case ')':
+			endToken()
+			if parens == 0 {
+				return entry{line: e.line}, errUnbalancedParens
+			}
+			parens--
+		case '"':
+			endToken()
+			s, err := l.quoted()
+			if err != nil {
+				return entry{line: e.line}, err
+			}
+			e.tokens = append(e.tokens, token{text: s, quoted: true})
+		case '\\':
+			n, err := l.r.ReadByte()
+			if err != nil {
+				return entry{line: e.line}, errTrailingEscape
+			}
+			if n == '\n' {
+				l.line++
+			}
+			tok, inTok = append(tok, c, n), true
+		default:
+			tok, inTok = append(tok, c), true
+		}
+	}
+}
+
+// quoted reads the rest of a quoted string.
+func (l *lexer) quoted() (string, error) {
+	var s []byte
+	for {
+		c, err := l.r.ReadByte()
+		if err != nil {
+			return "", errUnterminatedStr
+		}
+		switch c {
+		case '"':
+			return string(s), nil
+		case '\n':
+			return "", errUnterminatedStr
+		case '\\':
+			n, err := l.r.ReadByte()
+			if err != nil {
+				return "", errUnterminatedStr
+			}
+			if n == '\n' {
+				l.line++
+			}
+			s = append(s, c, n)
+		default:
+			s = append(s, c)
+		}
+	}
+}
@@ -0,0 +1,782 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnszone
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// sigTimeLayout is the layout of the signature validity times of RRSIG
+// records (RFC 4034 section 3.2).
+const sigTimeLayout = "20060102150405"
+
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// fields iterates over the record data tokens of an entry.
+type fields struct {
+	toks   []token
+	origin dnsmessage.Name
+}
+
+func (f *fields) next() (token, error) {
+	if len(f.toks) == 0 {
+		return token{}, errMissingData
+	}
+	t := f.toks[0]
+	f.toks = f.toks[1:]
+	return t, nil
+}
+
+// word returns the next token, which must not be quoted.
+func (f *fields) word() (string, error) {
+	t, err := f.next()
+	if err == nil && t.quoted {
+		err = errInvalidData
+	}
+	return t.text, err
+}
+
+func (f *fields) name() (dnsmessage.Name, error) {
+	s, err := f.word()
+	if err != nil {
+		return dnsmessage.Name{}, err
+	}
+	return parseName(s, f.origin)
+}
+
+func (f *fields) uint(bits int) (uint64, error) {
+	s, err := f.word()
+	if err != nil {
+		return 0, err
+	}
+	return parseUint(s, bits)
+}
+
+func (f *fields) ttl() (uint32, error) {
+	s, err := f.word()
+	if err != nil {
+		return 0, err
+	}
+	return parseTTL(s)
+}
+
+// rest returns the concatenation of the remaining tokens, for encodings
+// such as base64 that may be split by blank space.
+func (f *fields) rest() (string, error) {
+	var b strings.Builder
+	for len(f.toks) > 0 {
+		s, err := f.word()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+func (f *fields) base64() ([]byte, error) {
+	s, err := f.rest()
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidData
+	}
+	return b, nil
+}
+
+func (f *fields) hex() ([]byte, error) {
+	s, err := f.rest()
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidData
+	}
+	return b, nil
+}
+
+func (f *fields) types() ([]dnsmessage.Type, error) {
+	var types []dnsmessage.Type
+	for len(f.toks) > 0 {
+		s, err := f.word()
+		if err != nil {
+			return nil, err
+		}
+		t, ok := parseType(s)
+		if !ok {
+			return nil, errUnknownType
+		}
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return slices.Compact(types), nil
+}
+
+func (f *fields) done() error {
+	if len(f.toks) > 0 {
+		return errTrailingData
+	}
+	return nil
+}
+
+// parseRData parses the data of a record of type t.
+func parseRData(t dnsmessage.Type, toks []token, origin dnsmessage.Name) (dnsmessage.ResourceBody, error) {
+	f := &fields{toks: toks, origin: origin}
+	if len(toks) > 0 && toks[0] == (token{text: `\#`}) {
+		f.next()
+		return f.generic(t)
+	}
+	var (
+		body dnsmessage.ResourceBody
+		err  error
+	)
+	switch t {
+	case dnsmessage.TypeA:
+		var r dnsmessage.AResource
+		var a netip.Addr
+		if a, err = f.addr(); err == nil {
+			if !a.Is4() {
+				return nil, errInvalidData
+			}
+			r.A = a.As4()
+		}
+		body = &r
+	case dnsmessage.TypeAAAA:
+		var r dnsmessage.AAAAResource
+		var a netip.Addr
+		if a, err = f.addr(); err == nil {
+			if !a.Is6() || a.Zone() != "" {
+				return nil, errInvalidData
+			}
+			r.AAAA = a.As16()
+		}
+		body = &r
+	case dnsmessage.TypeNS:
+		var r dnsmessage.NSResource
+		r.NS, err = f.name()
+		body = &r
+	case dnsmessage.TypeCNAME:
+		var r dnsmessage.CNAMEResource
+		r.CNAME, err = f.name()
+		body = &r
+	case dnsmessage.TypePTR:
+		var r dnsmessage.PTRResource
+		r.PTR, err = f.name()
+		body = &r
+	case dnsmessage.TypeMX:
+		var r dnsmessage.MXResource
+		r.Pref, err = uint16Field(f, err)
+		if err == nil {
+			r.MX, err = f.name()
+		}
+		body = &r
+	case dnsmessage.TypeSOA:
+		body, err = f.soa()
+	case dnsmessage.TypeTXT:
+		var r dnsmessage.TXTResource
+		for err == nil && len(f.toks) > 0 {
+			var s []byte
+			if s, err = parseString(f.toks[0].text); err == nil {
+				r.TXT = append(r.TXT, string(s))
+				f.next()
+			}
+		}
+		if err == nil && len(r.TXT) == 0 {
+			err = errMissingData
+		}
+		body = &r
+	case dnsmessage.TypeSRV:
+		var r dnsmessage.SRVResource
+		r.Priority, err = uint16Field(f, err)
+		r.Weight, err = uint16Field(f, err)
+		r.Port, err = uint16Field(f, err)
+		if err == nil {
+			r.Target, err = f.name()
+		}
+		body = &r
+	case dnsmessage.TypeDS:
+		var r dnsmessage.DSResource
+		r.KeyTag, err = uint16Field(f, err)
+		var alg, digest uint8
+		alg, err = uint8Field(f, err)
+		digest, err = uint8Field(f, err)
+		r.Algorithm, r.DigestType = dnsmessage.DNSSECAlgorithm(alg), dnsmessage.DigestType(digest)
+		if err == nil {
+			r.Digest, err = f.hex()
+		}
+		body = &r
+	case dnsmessage.TypeDNSKEY:
+		var r dnsmessage.DNSKEYResource
+		var alg uint8
+		r.Flags, err = uint16Field(f, err)
+		r.Protocol, err = uint8Field(f, err)
+		alg, err = uint8Field(f, err)
+		r.Algorithm = dnsmessage.DNSSECAlgorithm(alg)
+		if err == nil {
+			r.PublicKey, err = f.base64()
+		}
+		body = &r
+	case dnsmessage.TypeRRSIG:
+		body, err = f.rrsig()
+	case dnsmessage.TypeNSEC:
+		var r dnsmessage.NSECResource
+		if r.NextDomain, err = f.name(); err == nil {
+			r.Types, err = f.types()
+		}
+		body = &r
+	case dnsmessage.TypeNSEC3:
+		body, err = f.nsec3()
+	case dnsmessage.TypeSVCB:
+		var r dnsmessage.SVCBResource
+		err = f.svcb(&r)
+		body = &r
+	case dnsmessage.TypeHTTPS:
+		var r dnsmessage.HTTPSResource
+		err = f.svcb(&r.SVCBResource)
+		body = &r
+	case dnsmessage.TypeOPT:
+		return nil, errUnknownType
+	default:
+		// Other types only have the generic syntax.
+		return nil, errInvalidData
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := f.done(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// uint16Field and uint8Field parse the next integer field unless an
+// earlier field failed, to keep long records readable.
+func uint16Field(f *fields, err error) (uint16, error) {
+	if err != nil {
+		return 0, err
+	}
+	v, err := f.uint(16)
+	return uint16(v), err
+}
+
+func uint8Field(f *fields, err error) (uint8, error) {
+	if err != nil {
+		return 0, err
+	}
+	v, err := f.uint(8)
+	return uint8(v), err
+}
+
+func (f *fields) addr() (netip.Addr, error) {
+	s, err := f.word()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, errInvalidData
+	}
+	return a, nil
+}
+
+// generic parses the data of the RFC 3597 syntax "\# length hex".
+func (f *fields) generic(t dnsmessage.Type) (dnsmessage.ResourceBody, error) {
+	n, err := f.uint(16)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.hex()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(n) {
+		return nil, errInvalidData
+	}
+	if t == dnsmessage.TypeOPT {
+		return nil, errUnknownType
+	}
+	return bodyFromWire(t, data)
+}
+
+// bodyFromWire decodes the wire format data of a record of type t into
+// its dedicated body type, if any.
+func bodyFromWire(t dnsmessage.Type, data []byte) (dnsmessage.ResourceBody, error) {
+	m := dnsmessage.Message{Answers: []dnsmessage.Resource{{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("."), Class: dnsmessage.ClassINET},
+		Body:   &dnsmessage.UnknownResource{Type: t, Data: data},
+	}}}
+	b, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Unpack(b); err != nil {
+		return nil, errInvalidData
+	}
+	return m.Answers[0].Body, nil
+}
+
+func (f *fields) soa() (*dnsmessage.SOAResource, error) {
+	var r dnsmessage.SOAResource
+	var err error
+	if r.NS, err = f.name(); err != nil {
+		return nil, err
+	}
+	if r.MBox, err = f.name(); err != nil {
+		return nil, err
+	}
+	serial, err := f.uint(32)
+	if err != nil {
+		return nil, err
+	}
+	r.Serial = uint32(serial)
+	for _, p := range []*uint32{&r.Refresh, &r.Retry, &r.Expire, &r.MinTTL} {
+		if *p, err = f.ttl(); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+func (f *fields) rrsig() (*dnsmessage.RRSIGResource, error) {
+	var r dnsmessage.RRSIGResource
+	s, err := f.word()
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if r.TypeCovered, ok = parseType(s); !ok {
+		return nil, errUnknownType
+	}
+	var alg uint8
+	alg, err = uint8Field(f, err)
+	r.Algorithm = dnsmessage.DNSSECAlgorithm(alg)
+	r.Labels, err = uint8Field(f, err)
+	if err != nil {
+		return nil, err
+	}
+	if r.OriginalTTL, err = f.ttl(); err != nil {
+		return nil, err
+	}
+	if r.Expiration, err = f.sigTime(); err != nil {
+		return nil, err
+	}
+	if r.Inception, err = f.sigTime(); err != nil {
+		return nil, err
+	}
+	if r.KeyTag, err = uint16Field(f, err); err != nil {
+		return nil, err
+	}
+	if r.SignerName, err = f.name(); err != nil {
+		return nil, err
+	}
+	if r.Signature, err = f.base64(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// sigTime parses a signature validity time, either as YYYYMMDDHHmmSS in
+// UTC or as a number of seconds.
+func (f *fields) sigTime() (uint32, error) {
+	s, err := f.word()
+	if err != nil {
+		return 0, err
+	}
+	if len(s) == len(sigTimeLayout) {
+		t, err := time.Parse(sigTimeLayout, s)
+		if err != nil {
+			return 0, errInvalidData
+		}
+		// The time is a serial number modulo 2**32 (RFC 4034 section 3.1.5).
+		return uint32(t.Unix()), nil
+	}
+	v, err := parseUint(s, 32)
+	return uint32(v), err
+}
+
+func (f *fields) nsec3() (*dnsmessage.NSEC3Resource, error) {
+	var r dnsmessage.NSEC3Resource
+	var err error
+	r.HashAlgorithm, err = uint8Field(f, err)
+	r.Flags, err = uint8Field(f, err)
+	r.Iterations, err = uint16Field(f, err)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := f.word()
+	if err != nil {
+		return nil, err
+	}
+	if salt != "-" {
+		if r.Salt, err = hex.DecodeString(salt); err != nil {
+			return nil, errInvalidData
+		}
+	}
+	next, err := f.word()
+	if err != nil {
+		return nil, err
+	}
+	if r.NextHashedOwner, err = nsec3Encoding.DecodeString(strings.ToUpper(next)); err != nil {
+		return nil, errInvalidData
+	}
+	if r.Types, err = f.types(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+var svcParamKeyNames = map[dnsmessage.SVCParamKey]string{
+	dnsmessage.SVCParamMandatory:     "mandatory",
+	dnsmessage.SVCParamALPN:          "alpn",
+	dnsmessage.SVCParamNoDefaultALPN: "no-default-alpn",
+	dnsmessage.SVCParamPort:          "port",
+	dnsmessage.SVCParamIPv4Hint:      "ipv4hint",
+	dnsmessage.SVCParamECH:           "ech",
+	dnsmessage.SVCParamIPv6Hint:      "ipv6hint",
+}
+
+func svcParamKeyString(k dnsmessage.SVCParamKey) string {
+	if n, ok := svcParamKeyNames[k]; ok {
+		return n
+	}
+	return "key" + strconv.Itoa(int(k))
+}
+
+func parseSVCParamKey(s string) (dnsmessage.SVCParamKey, bool) {
+	for k, n := range svcParamKeyNames {
+		if n == s {
+			return k, true
+		}
+	}
+	if n, ok := strings.CutPrefix(s, "key"); ok {
+		if v, err := strconv.ParseUint(n, 10, 16); err == nil {
+			return dnsmessage.SVCParamKey(v), true
+		}
+	}
+	return 0, false
+}
+
+// svcb parses the data of SVCB and HTTPS records (RFC 9460 section 2.1).
+func (f *fields) svcb(r *dnsmessage.SVCBResource) error {
+	var err error
+	if r.Priority, err = uint16Field(f, err); err != nil {
+		return err
+	}
+	if r.Target, err = f.name(); err != nil {
+		return err
+	}
+	seen := make(map[dnsmessage.SVCParamKey]bool)
+	for len(f.toks) > 0 {
+		s, err := f.word()
+		if err != nil {
+			return err
+		}
+		name, value, hasValue := strings.Cut(s, "=")
+		if hasValue && value == "" && len(f.toks) > 0 && f.toks[0].quoted {
+			// key="quoted value"
+			value = f.toks[0].text
+			f.next()
+		}
+		key, ok := parseSVCParamKey(name)
+		if !ok || seen[key] {
+			return errInvalidData
+		}
+		seen[key] = true
+		v, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if err := setSVCParam(r, key, v, hasValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setSVCParam(r *dnsmessage.SVCBResource, key dnsmessage.SVCParamKey, v []byte, hasValue bool) error {
+	if !hasValue && key != dnsmessage.SVCParamNoDefaultALPN {
+		return errInvalidData
+	}
+	switch key {
+	case dnsmessage.SVCParamMandatory:
+		var keys []dnsmessage.SVCParamKey
+		for _, s := range strings.Split(string(v), ",") {
+			k, ok := parseSVCParamKey(s)
+			if !ok {
+				return errInvalidData
+			}
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		r.SetMandatoryParam(keys...)
+	case dnsmessage.SVCParamALPN:
+		r.SetALPNParam(splitValueList(v)...)
+	case dnsmessage.SVCParamNoDefaultALPN:
+		if hasValue {
+			return errInvalidData
+		}
+		r.SetNoDefaultALPNParam()
+	case dnsmessage.SVCParamPort:
+		port, err := parseUint(string(v), 16)
+		if err != nil {
+			return err
+		}
+		r.SetPortParam(uint16(port))
+	case dnsmessage.SVCParamIPv4Hint:
+		var addrs [][4]byte
+		for _, s := range strings.Split(string(v), ",") {
+			a, err := netip.ParseAddr(s)
+			if err != nil || !a.Is4() {
+				return errInvalidData
+			}
+			addrs = append(addrs, a.As4())
+		}
+		r.SetIPv4HintParam(addrs...)
+	case dnsmessage.SVCParamECH:
+		b, err := base64.StdEncoding.DecodeString(string(v))
+		if err != nil {
+			return errInvalidData
+		}
+		r.SetECHParam(b)
+	case dnsmessage.SVCParamIPv6Hint:
+		var addrs [][16]byte
+		for _, s := range strings.Split(string(v), ",") {
+			a, err := netip.ParseAddr(s)
+			if err != nil || !a.Is6() || a.Zone() != "" {
+				return errInvalidData
+			}
+			addrs = append(addrs, a.As16())
+		}
+		r.SetIPv6HintParam(addrs...)
+	default:
+		r.SetParam(key, v)
+	}
+	return nil
+}
+
+// splitValueList splits a comma-separated list in which commas and
+// backslashes of the items are escaped by a backslash (RFC 9460
+// appendix A.1).
+func splitValueList(v []byte) []string {
+	var (
+		items []string
+		item  []byte
+	)
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '\\' && i+1 < len(v):
+			i++
+			item = append(item, v[i])
+		case c == ',':
+			items = append(items, string(item))
+			item = item[:0]
+		default:
+			item = append(item, c)
+		}
+	}
+	return append(items, string(item))
+}
+
+// appendRData appends the presentation format of the data of body. Names
+// are written relative to origin.
+func appendRData(b []byte, body dnsmessage.ResourceBody, origin string) []byte {
+	switch r := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(r.A).AppendTo(b)
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(r.AAAA).AppendTo(b)
+	case *dnsmessage.NSResource:
+		return appendName(b, r.NS, origin)
+	case *dnsmessage.CNAMEResource:
+		return appendName(b, r.CNAME, origin)
+	case *dnsmessage.PTRResource:
+		return appendName(b, r.PTR, origin)
+	case *dnsmessage.MXResource:
+		b = strconv.AppendUint(b, uint64(r.Pref), 10)
+		return appendName(append(b, ' '), r.MX, origin)
+	case *dnsmessage.SOAResource:
+		b = appendName(b, r.NS, origin)
+		b = appendName(append(b, ' '), r.MBox, origin)
+		for _, v := range []uint32{r.Serial, r.Refresh, r.Retry, r.Expire, r.MinTTL} {
+			b = strconv.AppendUint(append(b, ' '), uint64(v), 10)
+		}
+		return b
+	case *dnsmessage.TXTResource:
+		for i, s := range r.TXT {
+			if i > 0 {
+				b = append(b, ' ')
+			}
+			b = appendString(b, []byte(s))
+		}
+		return b
+	case *dnsmessage.SRVResource:
+		for _, v := range []uint16{r.Priority, r.Weight, r.Port} {
+			b = strconv.AppendUint(b, uint64(v), 10)
+			b = append(b, ' ')
+		}
+		return appendName(b, r.Target, origin)
+	case *dnsmessage.DSResource:
+		b = appendUints(b, uint64(r.KeyTag), uint64(r.Algorithm), uint64(r.DigestType))
+		return hex.AppendEncode(append(b, ' '), r.Digest)
+	case *dnsmessage.DNSKEYResource:
+		b = appendUints(b, uint64(r.Flags), uint64(r.Protocol), uint64(r.Algorithm))
+		return base64.StdEncoding.AppendEncode(append(b, ' '), r.PublicKey)
+	case *dnsmessage.RRSIGResource:
+		b = append(b, typeString(r.TypeCovered)...)
+		b = appendUints(append(b, ' '), uint64(r.Algorithm), uint64(r.Labels), uint64(r.OriginalTTL))
+		for _, v := range []uint32{r.Expiration, r.Inception} {
+			b = time.Unix(int64(v), 0).UTC().AppendFormat(append(b, ' '), sigTimeLayout)
+		}
+		b = strconv.AppendUint(append(b, ' '), uint64(r.KeyTag), 10)
+		b = appendName(append(b, ' '), r.SignerName, origin)
+		return base64.StdEncoding.AppendEncode(append(b, ' '), r.Signature)
+	case *dnsmessage.NSECResource:
+		b = appendName(b, r.NextDomain, origin)
+		return appendTypes(b, r.Types)
+	case *dnsmessage.NSEC3Resource:
+		b = appendUints(b, uint64(r.HashAlgorithm), uint64(r.Flags), uint64(r.Iterations))
+		b = append(b, ' ')
+		if len(r.Salt) == 0 {
+			b = append(b, '-')
+		} else {
+			b = hex.AppendEncode(b, r.Salt)
+		}
+		b = append(b, ' ')
+		b = append(b, strings.ToLower(nsec3Encoding.EncodeToString(r.NextHashedOwner))...)
+		return appendTypes(b, r.Types)
+	case *dnsmessage.SVCBResource:
+		return appendSVCB(b, r, origin)
+	case *dnsmessage.HTTPSResource:
+		return appendSVCB(b, &r.SVCBResource, origin)
+	case *dnsmessage.UnknownResource:
+		return appendGeneric(b, r.Data)
+	}
+	return b
+}
+
+func appendUints(b []byte, vs ...uint64) []byte {
+	for i, v := range vs {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendUint(b, v, 10)
+	}
+	return b
+}
+
+func appendTypes(b []byte, types []dnsmessage.Type) []byte {
+	for _, t := range types {
+		b = append(b, ' ')
+		b = append(b, typeString(t)...)
+	}
+	return b
+}
+
+// appendGeneric appends data in the generic syntax of RFC 3597.
+func appendGeneric(b []byte, data []byte) []byte {
+	b = strconv.AppendInt(append(b, `\# `...), int64(len(data)), 10)
+	if len(data) > 0 {
+		b = hex.AppendEncode(append(b, ' '), data)
+	}
+	return b
+}
+
+func appendSVCB(b []byte, r *dnsmessage.SVCBResource, origin string) []byte {
+	b = strconv.AppendUint(b, uint64(r.Priority), 10)
+	b = appendName(append(b, ' '), r.Target, origin)
+	for _, p := range r.Params {
+		b = append(b, ' ')
+		b = appendSVCParam(b, p)
+	}
+	return b
+}
+
+// appendSVCParam appends a parameter in its specific syntax, or in the
+// generic keyNNNNN syntax if its value is malformed.
+func appendSVCParam(b []byte, p dnsmessage.SVCParam) []byte {
+	v := p.Value
+	switch p.Key {
+	case dnsmessage.SVCParamMandatory:
+		if len(v) == 0 || len(v)%2 != 0 {
+			break
+		}
+		b = append(b, "mandatory="...)
+		for i := 0; i < len(v); i += 2 {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, svcParamKeyString(dnsmessage.SVCParamKey(v[i])<<8|dnsmessage.SVCParamKey(v[i+1]))...)
+		}
+		return b
+	case dnsmessage.SVCParamALPN:
+		var list []byte
+		for rest := v; len(rest) > 0; {
+			n := int(rest[0])
+			if n == 0 || n >= len(rest) {
+				list = nil
+				break
+			}
+			if len(list) > 0 {
+				list = append(list, ',')
+			}
+			for _, c := range rest[1 : 1+n] {
+				if c == ',' || c == '\\' {
+					list = append(list, '\\')
+				}
+				list = append(list, c)
+			}
+			rest = rest[1+n:]
+		}
+		if list == nil {
+			break
+		}
+		return appendString(append(b, "alpn="...), list)
+	case dnsmessage.SVCParamNoDefaultALPN:
+		if len(v) != 0 {
+			break
+		}
+		return append(b, "no-default-alpn"...)
+	case dnsmessage.SVCParamPort:
+		if len(v) != 2 {
+			break
+		}
+		return strconv.AppendUint(append(b, "port="...), uint64(v[0])<<8|uint64(v[1]), 10)
+	case dnsmessage.SVCParamIPv4Hint, dnsmessage.SVCParamIPv6Hint:
+		n := 4
+		if p.Key == dnsmessage.SVCParamIPv6Hint {
+			n = 16
+		}
+		if len(v) == 0 || len(v)%n != 0 {
+			break
+		}
+		b = append(b, svcParamKeyString(p.Key)...)
+		b = append(b, '=')
+		for i := 0; i < len(v); i += n {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			a, _ := netip.AddrFromSlice(v[i : i+n])
+			b = a.AppendTo(b)
+		}
+		return b
+	case dnsmessage.SVCParamECH:
+		return base64.StdEncoding.AppendEncode(append(b, "ech="...), v)
+	}
+	b = append(b, "key"...)
+	b = strconv.AppendUint(b, uint64(p.Key), 10)
+	return appendString(append(b, '='), v)
+}
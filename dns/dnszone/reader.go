@@ -0,0 +1,301 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnszone
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// maxIncludeDepth limits the nesting of $INCLUDE directives, which could
+// otherwise include each other forever.
+const maxIncludeDepth = 8
+
+var (
+	errNoOwner          = errors.New("no owner name for record")
+	errNoTTL            = errors.New("no TTL for record")
+	errNoInclude        = errors.New("$INCLUDE is not allowed")
+	errIncludeDepth     = errors.New("too many nested $INCLUDE directives")
+	errUnknownDirective = errors.New("unknown directive")
+)
+
+// A Reader reads resource records from a zone file.
+//
+// The owner names of records, and the names in their data, can be
+// relative to the origin set by the $ORIGIN directive. A record without
+// a TTL gets the one set by the $TTL directive, or else the TTL of the
+// previous record; a record without a class gets the class of the
+// previous record, or else [dnsmessage.ClassINET].
+type Reader struct {
+	// FS is used to open the files named by $INCLUDE directives. If FS
+	// is nil, $INCLUDE directives are rejected.
+	FS fs.FS
+
+	lex     *lexer
+	origin  dnsmessage.Name
+	stack   []include
+	err     error
+	owner   dnsmessage.Name
+	class   dnsmessage.Class
+	ttl     uint32
+	hasTTL  bool
+	lastTTL uint32
+	hasLast bool
+}
+
+// An include holds the state of a file that contains a $INCLUDE
+// directive, which is restored at the end of the included file.
+type include struct {
+	lex    *lexer
+	origin dnsmessage.Name
+	close  func() error
+}
+
+// NewReader returns a Reader that reads from r. The file name is only
+// used in errors and may be empty. The origin must be an absolute name,
+// such as "example.com.", or empty if the zone file sets its origin.
+func NewReader(r io.Reader, file, origin string) *Reader {
+	zr := &Reader{lex: newLexer(r, file), class: dnsmessage.ClassINET}
+	if origin != "" {
+		o, err := parseName(origin, dnsmessage.Name{})
+		if err != nil {
+			zr.err = &ParseError{File: file, Err: err}
+		}
+		zr.origin = o
+	}
+	return zr
+}
+
+// Next returns the next record, or io.EOF at the end of the zone file.
+// The Type of the record header is set to the type of its body.
+func (r *Reader) Next() (dnsmessage.Resource, error) {
+	for r.err == nil {
+		e, err := r.lex.next()
+		if err == io.EOF && len(r.stack) > 0 {
+			r.err = r.endInclude()
+			continue
+		}
+		if err == io.EOF {
+			r.err = io.EOF
+			break
+		}
+		if err != nil {
+			r.err = r.parseError(e, err)
+			break
+		}
+		if !e.blankOwner && strings.HasPrefix(e.tokens[0].text, "$") && !e.tokens[0].quoted {
+			if err := r.directive(e.tokens); err != nil {
+				r.err = r.parseError(e, err)
+			}
+			continue
+		}
+		res, err := r.record(e)
+		if err != nil {
+			r.err = r.parseError(e, err)
+			break
+		}
+		return res, nil
+	}
+	// The error is sticky, so the included files are never read again.
+	r.closeIncludes()
+	return dnsmessage.Resource{}, r.err
+}
+
+// ReadAll reads all the remaining records.
+func (r *Reader) ReadAll() ([]dnsmessage.Resource, error) {
+	var rs []dnsmessage.Resource
+	for {
+		res, err := r.Next()
+		if err == io.EOF {
+			return rs, nil
+		}
+		if err != nil {
+			return rs, err
+		}
+		rs = append(rs, res)
+	}
+}
+
+func (r *Reader) parseError(e entry, err error) error {
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{File: r.lex.file, Line: e.line, Err: err}
+}
+
+func (r *Reader) directive(toks []token) error {
+	args := toks[1:]
+	switch strings.ToUpper(toks[0].text) {
+	case "$ORIGIN":
+		if len(args) != 1 {
+			return errInvalidData
+		}
+		o, err := parseName(args[0].text, r.origin)
+		if err != nil {
+			return err
+		}
+		r.origin = o
+	case "$TTL":
+		if len(args) != 1 {
+			return errInvalidData
+		}
+		ttl, err := parseTTL(args[0].text)
+		if err != nil {
+			return err
+		}
+		r.ttl, r.hasTTL = ttl, true
+	case "$INCLUDE":
+		if len(args) != 1 && len(args) != 2 {
+			return errInvalidData
+		}
+		return r.include(args)
+	default:
+		return errUnknownDirective
+	}
+	return nil
+}
+
+// include starts reading the file named by a $INCLUDE directive, with
+// the origin given by the directive if any (RFC 1035 section 5.1).
+func (r *Reader) include(args []token) error {
+	if r.FS == nil {
+		return errNoInclude
+	}
+	if len(r.stack) >= maxIncludeDepth {
+		return errIncludeDepth
+	}
+	origin := r.origin
+	if len(args) == 2 {
+		o, err := parseName(args[1].text, r.origin)
+		if err != nil {
+			return err
+		}
+		origin = o
+	}
+	file, err := parseString(args[0].text)
+	if err != nil {
+		return err
+	}
+	f, err := r.FS.Open(strings.TrimPrefix(string(file), "/"))
+	if err != nil {
+		return err
+	}
+	r.stack = append(r.stack, include{lex: r.lex, origin: r.origin, close: f.Close})
+	r.lex = newLexer(f, string(file))
+	r.origin = origin
+	return nil
+}
+
+func (r *Reader) endInclude() error {
+	inc := r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+	r.lex, r.origin = inc.lex, inc.origin
+	return inc.close()
+}
+
+// closeIncludes closes every file still open on the include stack.
+func (r *Reader) closeIncludes() {
+	for len(r.stack) > 0 {
+		r.endInclude()
+	}
+}
+
+// record parses an entry of the form
+//
+//	[<owner>] [<TTL>] [<class>] <type> <RDATA>
+//
+// in which the TTL and the class can appear in any order.
+func (r *Reader) record(e entry) (dnsmessage.Resource, error) {
+	toks := e.tokens
+	if e.blankOwner {
+		if r.owner.Length == 0 {
+			return dnsmessage.Resource{}, errNoOwner
+		}
+	} else {
+		owner, err := parseName(toks[0].text, r.origin)
+		if err != nil {
+			return dnsmessage.Resource{}, err
+		}
+		r.owner = owner
+		toks = toks[1:]
+	}
+
+	var (
+		ttl      uint32
+		hasTTL   bool
+		class    = r.class
+		hasClass bool
+		typ      dnsmessage.Type
+	)
+	for {
+		if len(toks) == 0 {
+			return dnsmessage.Resource{}, errMissingData
+		}
+		s := toks[0].text
+		toks = toks[1:]
+		if !hasTTL && s != "" && isDigit(s[0]) {
+			v, err := parseTTL(s)
+			if err != nil {
+				return dnsmessage.Resource{}, err
+			}
+			ttl, hasTTL = v, true
+			continue
+		}
+		if c, ok := parseClass(s); ok && !hasClass {
+			class, hasClass = c, true
+			continue
+		}
+		t, ok := parseType(s)
+		if !ok {
+			return dnsmessage.Resource{}, errUnknownType
+		}
+		typ = t
+		break
+	}
+
+	body, err := parseRData(typ, toks, r.origin)
+	if err != nil {
+		return dnsmessage.Resource{}, err
+	}
+	if !hasTTL {
+		switch {
+		case r.hasTTL:
+			ttl = r.ttl
+		case r.hasLast:
+			ttl = r.lastTTL
+		default:
+			// Without $TTL, BIND uses the minimum TTL of the SOA
+			// record for the zone.
+			soa, ok := body.(*dnsmessage.SOAResource)
+			if !ok {
+				return dnsmessage.Resource{}, errNoTTL
+			}
+			ttl = soa.MinTTL
+		}
+	}
+	r.class = class
+	r.lastTTL, r.hasLast = ttl, true
+
+	res := dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  r.owner,
+			Type:  typ,
+			Class: class,
+			TTL:   ttl,
+		},
+		Body: body,
+	}
+	// Check the record as a whole, for instance the length of the
+	// strings in TXT records, by packing it.
+	m := dnsmessage.Message{Answers: []dnsmessage.Resource{res}}
+	if _, err := m.Pack(); err != nil {
+		return dnsmessage.Resource{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnszone
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	errNilBody  = errors.New("nil resource body")
+	errOPT      = errors.New("OPT records cannot appear in zone files")
+	errRelative = errors.New("origin must be an absolute name")
+)
+
+// A Writer writes resource records in the zone file format.
+//
+// Each record is written on its own line with an explicit TTL and class,
+// so that the output does not depend on the order of the records. Writes
+// are buffered; Flush must be called to write the remaining data.
+type Writer struct {
+	w      *bufio.Writer
+	origin string
+	buf    []byte
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// SetOrigin writes an $ORIGIN directive. The names of the records that
+// follow are written relative to origin when possible.
+func (w *Writer) SetOrigin(origin dnsmessage.Name) error {
+	s := origin.String()
+	if s == "" || s[len(s)-1] != '.' {
+		return errRelative
+	}
+	w.origin = s
+	b := append(w.buf[:0], "$ORIGIN "...)
+	b = appendName(b, origin, "")
+	w.buf = append(b, '\n')
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// Write writes a record. The type of the record is given by its body,
+// not by the Type of its header.
+func (w *Writer) Write(r dnsmessage.Resource) error {
+	t, err := bodyType(r.Body)
+	if err != nil {
+		return err
+	}
+	b := appendName(w.buf[:0], r.Header.Name, w.origin)
+	b = append(b, '\t')
+	b = strconv.AppendUint(b, uint64(r.Header.TTL), 10)
+	b = append(b, '\t')
+	b = append(b, classString(r.Header.Class)...)
+	b = append(b, '\t')
+	b = append(b, typeString(t)...)
+	b = append(b, '\t')
+	b = appendRData(b, r.Body, w.origin)
+	w.buf = append(b, '\n')
+	_, err = w.w.Write(w.buf)
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// bodyType returns the type of the records with the given body.
+func bodyType(body dnsmessage.ResourceBody) (dnsmessage.Type, error) {
+	switch r := body.(type) {
+	case nil:
+		return 0, errNilBody
+	case *dnsmessage.AResource:
+		return dnsmessage.TypeA, nil
+	case *dnsmessage.AAAAResource:
+		return dnsmessage.TypeAAAA, nil
+	case *dnsmessage.NSResource:
+		return dnsmessage.TypeNS, nil
+	case *dnsmessage.CNAMEResource:
+		return dnsmessage.TypeCNAME, nil
+	case *dnsmessage.PTRResource:
+		return dnsmessage.TypePTR, nil
+	case *dnsmessage.MXResource:
+		return dnsmessage.TypeMX, nil
+	case *dnsmessage.SOAResource:
+		return dnsmessage.TypeSOA, nil
+	case *dnsmessage.TXTResource:
+		return dnsmessage.TypeTXT, nil
+	case *dnsmessage.SRVResource:
+		return dnsmessage.TypeSRV, nil
+	case *dnsmessage.DSResource:
+		return dnsmessage.TypeDS, nil
+	case *dnsmessage.DNSKEYResource:
+		return dnsmessage.TypeDNSKEY, nil
+	case *dnsmessage.RRSIGResource:
+		return dnsmessage.TypeRRSIG, nil
+	case *dnsmessage.NSECResource:
+		return dnsmessage.TypeNSEC, nil
+	case *dnsmessage.NSEC3Resource:
+		return dnsmessage.TypeNSEC3, nil
+	case *dnsmessage.SVCBResource:
+		return dnsmessage.TypeSVCB, nil
+	case *dnsmessage.HTTPSResource:
+		return dnsmessage.TypeHTTPS, nil
+	case *dnsmessage.UnknownResource:
+		if r.Type == dnsmessage.TypeOPT {
+			return 0, errOPT
+		}
+		return r.Type, nil
+	case *dnsmessage.OPTResource:
+		return 0, errOPT
+	}
+	return 0, errUnknownType
+}
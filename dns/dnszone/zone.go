@@ -0,0 +1,348 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dnszone reads and writes DNS zone files, the text format of
+// resource records defined in RFC 1035 section 5.
+//
+// Records are represented by the [dnsmessage.Resource] type, so that
+// they can be converted to and from the wire format. The supported record
+// types are those with a dedicated body type in package dnsmessage; other
+// records use the generic syntax of RFC 3597.
+package dnszone // import "golang.org/x/net/dns/dnszone"
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// A ParseError is returned for syntax or semantic errors in a zone file.
+type ParseError struct {
+	File string // file name, if known
+	Line int    // line of the start of the entry, 1-based
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	s := "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+	if e.File != "" {
+		s = e.File + ": " + s
+	}
+	return "dnszone: " + s
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+var (
+	errUnknownType  = errors.New("unknown record type")
+	errUnknownClass = errors.New("unknown class")
+	errInvalidTTL   = errors.New("invalid TTL")
+	errInvalidName  = errors.New("invalid domain name")
+	errInvalidInt   = errors.New("invalid integer")
+	errInvalidData  = errors.New("invalid record data")
+	errMissingData  = errors.New("missing record data")
+	errTrailingData = errors.New("unexpected data after record")
+	errDotInLabel   = errors.New("escaped dot in label is not supported")
+)
+
+var typeNames = map[dnsmessage.Type]string{
+	dnsmessage.TypeA:      "A",
+	dnsmessage.TypeNS:     "NS",
+	dnsmessage.TypeCNAME:  "CNAME",
+	dnsmessage.TypeSOA:    "SOA",
+	dnsmessage.TypePTR:    "PTR",
+	dnsmessage.TypeMX:     "MX",
+	dnsmessage.TypeTXT:    "TXT",
+	dnsmessage.TypeAAAA:   "AAAA",
+	dnsmessage.TypeSRV:    "SRV",
+	dnsmessage.TypeOPT:    "OPT",
+	dnsmessage.TypeDS:     "DS",
+	dnsmessage.TypeRRSIG:  "RRSIG",
+	dnsmessage.TypeNSEC:   "NSEC",
+	dnsmessage.TypeDNSKEY: "DNSKEY",
+	dnsmessage.TypeNSEC3:  "NSEC3",
+	dnsmessage.TypeSVCB:   "SVCB",
+	dnsmessage.TypeHTTPS:  "HTTPS",
+}
+
+var classNames = map[dnsmessage.Class]string{
+	dnsmessage.ClassINET:   "IN",
+	dnsmessage.ClassCSNET:  "CS",
+	dnsmessage.ClassCHAOS:  "CH",
+	dnsmessage.ClassHESIOD: "HS",
+	dnsmessage.ClassANY:    "ANY",
+}
+
+var (
+	typesByName   = make(map[string]dnsmessage.Type)
+	classesByName = make(map[string]dnsmessage.Class)
+)
+
+func init() {
+	for t, n := range typeNames {
+		typesByName[n] = t
+	}
+	for c, n := range classNames {
+		classesByName[n] = c
+	}
+}
+
+// typeString returns the mnemonic of t, or its generic form of RFC 3597.
+func typeString(t dnsmessage.Type) string {
+	if n, ok := typeNames[t]; ok {
+		return n
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+func classString(c dnsmessage.Class) string {
+	if n, ok := classNames[c]; ok {
+		return n
+	}
+	return "CLASS" + strconv.Itoa(int(c))
+}
+
+// parseType parses a type mnemonic or its generic form, case-insensitively.
+func parseType(s string) (dnsmessage.Type, bool) {
+	s = strings.ToUpper(s)
+	if t, ok := typesByName[s]; ok {
+		return t, true
+	}
+	if n, ok := strings.CutPrefix(s, "TYPE"); ok {
+		if v, err := strconv.ParseUint(n, 10, 16); err == nil {
+			return dnsmessage.Type(v), true
+		}
+	}
+	return 0, false
+}
+
+// parseClass parses a class mnemonic or its generic form, case-insensitively.
+func parseClass(s string) (dnsmessage.Class, bool) {
+	s = strings.ToUpper(s)
+	if c, ok := classesByName[s]; ok {
+		return c, true
+	}
+	if n, ok := strings.CutPrefix(s, "CLASS"); ok {
+		if v, err := strconv.ParseUint(n, 10, 16); err == nil {
+			return dnsmessage.Class(v), true
+		}
+	}
+	return 0, false
+}
+
+// parseTTL parses a TTL in seconds, also accepting the BIND unit syntax
+// such as "1h30m".
+func parseTTL(s string) (uint32, error) {
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(v), nil
+	}
+	var total, n uint64
+	digits := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if '0' <= c && c <= '9' {
+			n = n*10 + uint64(c-'0')
+			digits = true
+			if n > 1<<32 {
+				return 0, errInvalidTTL
+			}
+			continue
+		}
+		if !digits {
+			return 0, errInvalidTTL
+		}
+		switch c | 0x20 {
+		case 's':
+		case 'm':
+			n *= 60
+		case 'h':
+			n *= 60 * 60
+		case 'd':
+			n *= 24 * 60 * 60
+		case 'w':
+			n *= 7 * 24 * 60 * 60
+		default:
+			return 0, errInvalidTTL
+		}
+		total += n
+		n, digits = 0, false
+		if total > 1<<32-1 {
+			return 0, errInvalidTTL
+		}
+	}
+	if digits || total == 0 && len(s) == 0 {
+		// A number without a unit is only valid on its own.
+		return 0, errInvalidTTL
+	}
+	return uint32(total), nil
+}
+
+func parseUint(s string, bits int) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, errInvalidInt
+	}
+	return v, nil
+}
+
+// parseName parses a domain name, which is relative to origin unless it
+// ends with an unescaped dot. The name "@" denotes origin.
+func parseName(s string, origin dnsmessage.Name) (dnsmessage.Name, error) {
+	if s == "@" {
+		return origin, nil
+	}
+	if s == "." {
+		return dnsmessage.NewName(".")
+	}
+	var (
+		b     []byte
+		label int
+	)
+	absolute := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '.':
+			if label == 0 {
+				return dnsmessage.Name{}, errInvalidName
+			}
+			b = append(b, '.')
+			label = 0
+			absolute = i == len(s)-1
+			continue
+		case '\\':
+			var n int
+			c, n = unescape(s[i:])
+			if n == 0 {
+				return dnsmessage.Name{}, errInvalidName
+			}
+			if c == '.' {
+				return dnsmessage.Name{}, errDotInLabel
+			}
+			i += n - 1
+		}
+		b = append(b, c)
+		if label++; label > 63 {
+			return dnsmessage.Name{}, errInvalidName
+		}
+	}
+	if len(b) == 0 {
+		return dnsmessage.Name{}, errInvalidName
+	}
+	if !absolute {
+		if origin.Length == 0 {
+			return dnsmessage.Name{}, errInvalidName
+		}
+		b = append(b, '.')
+		if o := origin.String(); o != "." {
+			b = append(b, o...)
+		}
+	}
+	if len(b) > 254 {
+		return dnsmessage.Name{}, errInvalidName
+	}
+	return dnsmessage.NewName(string(b))
+}
+
+// unescape decodes the escape sequence \X or \DDD at the start of s,
+// returning the byte and the length of the sequence, or a length of 0 if
+// the sequence is invalid.
+func unescape(s string) (byte, int) {
+	if len(s) < 2 {
+		return 0, 0
+	}
+	if !isDigit(s[1]) {
+		return s[1], 2
+	}
+	if len(s) < 4 || !isDigit(s[2]) || !isDigit(s[3]) {
+		return 0, 0
+	}
+	v := int(s[1]-'0')*100 + int(s[2]-'0')*10 + int(s[3]-'0')
+	if v > 255 {
+		return 0, 0
+	}
+	return byte(v), 4
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// parseString decodes the escape sequences of a character-string.
+func parseString(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' {
+			var n int
+			c, n = unescape(s[i:])
+			if n == 0 {
+				return nil, errInvalidData
+			}
+			i += n - 1
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
+
+// appendName appends the presentation format of n, relative to origin
+// if n is a subdomain of it.
+func appendName(b []byte, n dnsmessage.Name, origin string) []byte {
+	s := n.String()
+	if origin != "" && origin != "." {
+		if s == origin {
+			return append(b, '@')
+		}
+		if rel, ok := strings.CutSuffix(s, "."+origin); ok {
+			return appendLabels(b, rel)
+		}
+	}
+	if s == "." {
+		return append(b, '.')
+	}
+	b = appendLabels(b, strings.TrimSuffix(s, "."))
+	if strings.HasSuffix(s, ".") {
+		b = append(b, '.')
+	}
+	return b
+}
+
+// appendLabels appends dot-separated labels, escaping their special
+// characters.
+func appendLabels(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '.':
+			b = append(b, c)
+		case c == ';' || c == '"' || c == '(' || c == ')' || c == '\\' || c == '@' || c == '$':
+			b = append(b, '\\', c)
+		case c <= ' ' || c >= 0x7f:
+			b = appendDecimalEscape(b, c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// appendString appends a quoted character-string.
+func appendString(b []byte, s []byte) []byte {
+	b = append(b, '"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < ' ' || c >= 0x7f:
+			b = appendDecimalEscape(b, c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
+
+func appendDecimalEscape(b []byte, c byte) []byte {
+	return append(b, '\\', '0'+c/100, '0'+c/10%10, '0'+c%10)
+}
@@ -0,0 +1,382 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnszone
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const testZone = `; A zone exercising the syntax of RFC 1035 section 5.
+$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2026010101 ; serial
+		2h         ; refresh
+		30m        ; retry
+		2w         ; expire
+		300 )      ; minimum
+	IN	NS	ns1
+	IN	NS	ns2.example.net.
+	IN	MX	10 mail
+ns1	60	A	192.0.2.1
+	IN 120	AAAA	2001:db8::1
+mail	A	\# 4 c0000202
+www	CNAME	@
+txt	TXT	"hello world" unquoted "a \"quoted\" \\ string" "\000\255"
+esc\059aped	TXT	"x"
+_sip._tcp	SRV	10 60 5060 sip
+1.2.0.192.in-addr.arpa.	PTR	ns1
+@	DNSKEY	257 3 15 ( l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4= )
+@	DS	3613 15 2 (
+		3aa5ab37efce57f737fc1627013fee07
+		bdf241bd10f44b1b6c8bf4ec2a2a8b0d )
+@	RRSIG	MX 15 2 3600 20300101000000 20250101000000 3613 @ (
+		g0Ns7zDEyJHkT1B3zJ0Uu0nQ1R8hdSOeLbSXC3U4y2y7
+		OBvxK0FVoQ2+CDdwlWSRBoSbtLuLqL2PmnE3HkYeAQ== )
+@	NSEC	ns1 SOA NS MX RRSIG NSEC DNSKEY
+0p9mhaveqvm6t7vbl5lop2u3t2rp3tom	NSEC3	1 1 12 aabbccdd 2t7b4g4vsa5smi47k61mv5bv1a22bojr MX DNSKEY NS SOA NSEC3PARAM
+_dns	SVCB	1 dot.example.net. alpn=dot port=853 ipv4hint=192.0.2.53
+www	HTTPS	1 . mandatory=ipv4hint,alpn alpn="h2,h3" ipv4hint=192.0.2.1,192.0.2.2 ipv6hint=2001:db8::1 ech=AQID key65000="opaque"
+alias	HTTPS	0 www
+opaque	3600 CH	TYPE65534	\# 3 010203
+empty	TYPE65535	\# 0
+`
+
+func name(s string) dnsmessage.Name {
+	return dnsmessage.MustNewName(s)
+}
+
+func readAll(t *testing.T, zr *Reader) []dnsmessage.Resource {
+	t.Helper()
+	rs, err := zr.ReadAll()
+	if err != nil {
+		t.Fatal("ReadAll() =", err)
+	}
+	return rs
+}
+
+func TestRead(t *testing.T) {
+	rs := readAll(t, NewReader(strings.NewReader(strings.Replace(testZone, "NSEC3PARAM", "", 1)), "test.zone", ""))
+	if len(rs) != 22 {
+		t.Fatalf("got %d records, want 22", len(rs))
+	}
+	for _, tt := range []struct {
+		i    int
+		want dnsmessage.Resource
+	}{
+		{0, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body: &dnsmessage.SOAResource{
+				NS:      name("ns1.example.com."),
+				MBox:    name("hostmaster.example.com."),
+				Serial:  2026010101,
+				Refresh: 7200,
+				Retry:   1800,
+				Expire:  1209600,
+				MinTTL:  300,
+			},
+		}},
+		{2, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: dnsmessage.TypeNS, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body:   &dnsmessage.NSResource{NS: name("ns2.example.net.")},
+		}},
+		{4, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("ns1.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+		}},
+		{5, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("ns1.example.com."), Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET, TTL: 120},
+			Body:   &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+		}},
+		{6, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("mail.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}},
+		}},
+		{7, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("www.example.com."), Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body:   &dnsmessage.CNAMEResource{CNAME: name("example.com.")},
+		}},
+		{8, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("txt.example.com."), Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body:   &dnsmessage.TXTResource{TXT: []string{"hello world", "unquoted", `a "quoted" \ string`, "\x00\xff"}},
+		}},
+		{11, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("1.2.0.192.in-addr.arpa."), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: 3600},
+			Body:   &dnsmessage.PTRResource{PTR: name("ns1.example.com.")},
+		}},
+		{20, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name("opaque.example.com."), Type: 65534, Class: dnsmessage.ClassCHAOS, TTL: 3600},
+			Body:   &dnsmessage.UnknownResource{Type: 65534, Data: []byte{1, 2, 3}},
+		}},
+		{21, dnsmessage.Resource{
+			// The class of the previous record is reused.
+			Header: dnsmessage.ResourceHeader{Name: name("empty.example.com."), Type: 65535, Class: dnsmessage.ClassCHAOS, TTL: 3600},
+			Body:   &dnsmessage.UnknownResource{Type: 65535, Data: []byte{}},
+		}},
+	} {
+		if got := rs[tt.i]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("record %d = %#v\nwant = %#v", tt.i, &got, &tt.want)
+		}
+	}
+
+	https := rs[18].Body.(*dnsmessage.HTTPSResource)
+	if alpn, _ := https.ALPNParam(); !reflect.DeepEqual(alpn, []string{"h2", "h3"}) {
+		t.Errorf("HTTPS alpn = %q", alpn)
+	}
+	if keys, _ := https.MandatoryParam(); !reflect.DeepEqual(keys, []dnsmessage.SVCParamKey{dnsmessage.SVCParamALPN, dnsmessage.SVCParamIPv4Hint}) {
+		t.Errorf("HTTPS mandatory = %v", keys)
+	}
+	if v, _ := https.Param(65000); string(v) != "opaque" {
+		t.Errorf("HTTPS key65000 = %q", v)
+	}
+	nsec := rs[15].Body.(*dnsmessage.NSECResource)
+	if want := []dnsmessage.Type{dnsmessage.TypeNS, dnsmessage.TypeSOA, dnsmessage.TypeMX, dnsmessage.TypeRRSIG, dnsmessage.TypeNSEC, dnsmessage.TypeDNSKEY}; !reflect.DeepEqual(nsec.Types, want) {
+		t.Errorf("NSEC types = %v, want = %v", nsec.Types, want)
+	}
+	rrsig := rs[14].Body.(*dnsmessage.RRSIGResource)
+	if rrsig.Expiration != 1893456000 || rrsig.Inception != 1735689600 {
+		t.Errorf("RRSIG validity = %d-%d", rrsig.Inception, rrsig.Expiration)
+	}
+}
+
+// TestRoundTrip checks that records survive being written, read back,
+// and converted to and from the wire format.
+func TestRoundTrip(t *testing.T) {
+	zone := strings.Replace(testZone, "NSEC3PARAM", "TYPE51", 1)
+	rs := readAll(t, NewReader(strings.NewReader(zone), "", ""))
+
+	for _, origin := range []string{"", "example.com.", "com."} {
+		var b strings.Builder
+		w := NewWriter(&b)
+		if origin != "" {
+			if err := w.SetOrigin(name(origin)); err != nil {
+				t.Fatal("SetOrigin() =", err)
+			}
+		}
+		for _, r := range rs {
+			if err := w.Write(r); err != nil {
+				t.Fatalf("Write(%#v) = %v", &r, err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal("Flush() =", err)
+		}
+		got := readAll(t, NewReader(strings.NewReader(b.String()), "", ""))
+		if !reflect.DeepEqual(got, rs) {
+			t.Errorf("origin %q: records changed after writing:\n%s", origin, b.String())
+		}
+	}
+
+	m := dnsmessage.Message{Header: dnsmessage.Header{Response: true}, Answers: slices.Clone(rs)}
+	msg, err := m.Pack()
+	if err != nil {
+		t.Fatal("Message.Pack() =", err)
+	}
+	var m2 dnsmessage.Message
+	if err := m2.Unpack(msg); err != nil {
+		t.Fatal("Message.Unpack() =", err)
+	}
+	for i := range m2.Answers {
+		m2.Answers[i].Header.Length = 0
+		if !reflect.DeepEqual(m2.Answers[i], rs[i]) {
+			t.Errorf("record %d changed in wire format: %#v\nwant = %#v", i, &m2.Answers[i], &rs[i])
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+	if err := w.SetOrigin(name("example.com.")); err != nil {
+		t.Fatal("SetOrigin() =", err)
+	}
+	for _, r := range []dnsmessage.Resource{
+		{
+			Header: dnsmessage.ResourceHeader{Name: name("example.com."), Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.MXResource{Pref: 10, MX: name("mail.example.net.")},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: name("a;b (c).example.com."), Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.TXTResource{TXT: []string{"tab\there", `"q"`}},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: name("x.example.org."), Class: 42, TTL: 0},
+			Body:   &dnsmessage.UnknownResource{Type: 1234, Data: []byte{0xab, 0xcd}},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: name("svc.example.com."), Class: dnsmessage.ClassINET, TTL: 60},
+			Body: &dnsmessage.SVCBResource{Priority: 1, Target: name("."), Params: []dnsmessage.SVCParam{
+				{Key: dnsmessage.SVCParamALPN, Value: []byte{4, 'a', ',', 'b', '\\'}},
+				{Key: dnsmessage.SVCParamPort, Value: []byte{1}},
+			}},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: name("n3.example.com."), Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.NSEC3Resource{HashAlgorithm: 1, NextHashedOwner: []byte{1, 2, 3, 4, 5}, Types: []dnsmessage.Type{dnsmessage.TypeA}},
+		},
+	} {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write(%#v) = %v", &r, err)
+		}
+	}
+	if err := w.Write(dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}); err != errOPT {
+		t.Errorf("Write(OPT record) = %v, want = %v", err, errOPT)
+	}
+	w.Flush()
+
+	const want = "$ORIGIN example.com.\n" +
+		"@\t60\tIN\tMX\t10 mail.example.net.\n" +
+		"a\\;b\\032\\(c\\)\t60\tIN\tTXT\t\"tab\\009here\" \"\\\"q\\\"\"\n" +
+		"x.example.org.\t0\tCLASS42\tTYPE1234\t\\# 2 abcd\n" +
+		"svc\t60\tIN\tSVCB\t1 . alpn=\"a\\\\,b\\\\\\\\\" key3=\"\\001\"\n" +
+		"n3\t60\tIN\tNSEC3\t1 0 0 - 04106105 A\n"
+	if got := b.String(); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hosts.zone": {Data: []byte("www A 192.0.2.1\n$INCLUDE more.zone sub\n")},
+		"more.zone":  {Data: []byte("db A 192.0.2.2\n")},
+		"loop.zone":  {Data: []byte("$INCLUDE loop.zone\n")},
+	}
+	const zone = "$TTL 60\n$INCLUDE hosts.zone\n@ A 192.0.2.3\n"
+	zr := NewReader(strings.NewReader(zone), "", "example.com.")
+	zr.FS = fsys
+	var names []string
+	for _, r := range readAll(t, zr) {
+		names = append(names, r.Header.Name.String())
+	}
+	if want := []string{"www.example.com.", "db.sub.example.com.", "example.com."}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want = %q", names, want)
+	}
+
+	zr = NewReader(strings.NewReader("$INCLUDE loop.zone\n"), "", "example.com.")
+	zr.FS = fsys
+	if _, err := zr.Next(); !errors.Is(err, errIncludeDepth) {
+		t.Errorf("Next() with recursive includes = %v, want %v", err, errIncludeDepth)
+	}
+
+	zr = NewReader(strings.NewReader("$INCLUDE hosts.zone\n"), "", "example.com.")
+	if _, err := zr.Next(); !errors.Is(err, errNoInclude) {
+		t.Errorf("Next() with $INCLUDE and no FS = %v, want %v", err, errNoInclude)
+	}
+}
+
+// closeFS counts the files opened from an fs.FS that are not closed yet.
+type closeFS struct {
+	fs.FS
+	open int
+}
+
+func (c *closeFS) Open(name string) (fs.File, error) {
+	f, err := c.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	c.open++
+	return closeFile{f, c}, nil
+}
+
+type closeFile struct {
+	fs.File
+	fs *closeFS
+}
+
+func (f closeFile) Close() error {
+	f.fs.open--
+	return f.File.Close()
+}
+
+func TestIncludeErrorCloses(t *testing.T) {
+	fsys := &closeFS{FS: fstest.MapFS{
+		"outer.zone": {Data: []byte("$INCLUDE inner.zone\nwww A 192.0.2.1\n")},
+		"inner.zone": {Data: []byte("db A 192.0.2.2\nbad 60 BOGUS x\n")},
+	}}
+	zr := NewReader(strings.NewReader("$TTL 60\n$INCLUDE outer.zone\n"), "", "example.com.")
+	zr.FS = fsys
+	_, err := zr.ReadAll()
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.File != "inner.zone" || pe.Line != 2 {
+		t.Fatalf("ReadAll() = %v, want an error on inner.zone line 2", err)
+	}
+	if fsys.open != 0 {
+		t.Errorf("%d included files left open after a parse error", fsys.open)
+	}
+	if _, err2 := zr.Next(); err2 != err {
+		t.Errorf("Next() after a parse error = %v, want %v", err2, err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		zone string
+		line int
+		want error
+	}{
+		{"a 60 A 192.0.2.1\n", 1, errInvalidName}, // relative name without origin
+		{"$ORIGIN example.\n a 60 A 192.0.2.1\n", 2, errNoOwner},
+		{"$ORIGIN example.\na A 192.0.2.1\n", 2, errNoTTL},
+		{"$ORIGIN example.\n\na 60 A 192.0.2.1 (\n", 3, errUnbalancedParens},
+		{"a. 60 A 192.0.2.1 )\n", 1, errUnbalancedParens},
+		{"a. 60 TXT \"open\n", 1, errUnterminatedStr},
+		{"a. 60 BOGUS x\n", 1, errUnknownType},
+		{"a. 60 A 2001:db8::1\n", 1, errInvalidData},
+		{"a. 60 A\n", 1, errMissingData},
+		{"a. 60 A 192.0.2.1 extra\n", 1, errTrailingData},
+		{"a. 60 A \\# 5 c0000201\n", 1, errInvalidData},
+		{"a. 60 OPT \\# 0\n", 1, errUnknownType},
+		{"a. 60 MX 70000 b.\n", 1, errInvalidInt},
+		{"a. 1x A 192.0.2.1\n", 1, errInvalidTTL},
+		{"a\\.b. 60 A 192.0.2.1\n", 1, errDotInLabel},
+		{"a..b. 60 A 192.0.2.1\n", 1, errInvalidName},
+		{"$GENERATE 1-2 a A 192.0.2.1\n", 1, errUnknownDirective},
+		{"a. 60 HTTPS 1 . port=1 port=2\n", 1, errInvalidData},
+		{"a. 60 HTTPS 1 . alpn\n", 1, errInvalidData},
+	} {
+		_, err := NewReader(strings.NewReader(tt.zone), "bad.zone", "").ReadAll()
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != tt.line || pe.File != "bad.zone" || !errors.Is(err, tt.want) {
+			t.Errorf("ReadAll(%q) = %v, want %v on line %d", tt.zone, err, tt.want, tt.line)
+		}
+	}
+
+	// Errors found when packing the record are reported too.
+	_, err := NewReader(strings.NewReader("a. 60 TXT "+strings.Repeat("x", 256)+"\n"), "", "").ReadAll()
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("ReadAll() of a long TXT string = %v, want a *ParseError", err)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want uint32
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"4294967295", 4294967295, true},
+		{"4294967296", 0, false},
+		{"1h30m", 5400, true},
+		{"1W2D3H4M5S", 788645, true},
+		{"1h30", 0, false},
+		{"h", 0, false},
+		{"", 0, false},
+	} {
+		got, err := parseTTL(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("parseTTL(%q) = %d, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/http2"
+)
+
+// dohMediaType is the media type of DNS messages sent over HTTPS (RFC 8484
+// section 6).
+const dohMediaType = "application/dns-message"
+
+var defaultDoHClient = &http.Client{Transport: &http2.Transport{}}
+
+// A DoHClient sends DNS queries over HTTPS (RFC 8484).
+type DoHClient struct {
+	// URL is the URI template of the server, such as
+	// "https://dns.example/dns-query", without the dns variable.
+	URL string
+
+	// HTTPClient sends the HTTP requests. If nil, a client with an
+	// HTTP/2 transport is used.
+	HTTPClient *http.Client
+
+	// UseGET makes the client send queries with GET requests, which
+	// are cache friendly, instead of POST requests.
+	UseGET bool
+}
+
+func (c *DoHClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultDoHClient
+}
+
+// Exchange sends the query q to the server and returns its response.
+//
+// The query is sent with an ID of 0, as recommended by RFC 8484 section
+// 4.1, and the response must have the questions of the query. q itself
+// is not modified.
+func (c *DoHClient) Exchange(ctx context.Context, q *dnsmessage.Message) (*dnsmessage.Message, error) {
+	query := *q
+	query.Header.ID = 0
+	query.Header.Response = false
+	b, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	if c.UseGET {
+		req, err = http.NewRequestWithContext(ctx, "GET", c.URL+"?dns="+base64.RawURLEncoding.EncodeToString(b), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, "POST", c.URL, bytes.NewReader(b))
+		if err == nil {
+			req.Header.Set("Content-Type", dohMediaType)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", dohMediaType)
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dns: DoH server returned status %q", res.Status)
+	}
+	if mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mt != dohMediaType {
+		return nil, fmt.Errorf("dns: DoH server returned content type %q", res.Header.Get("Content-Type"))
+	}
+	msg, err := io.ReadAll(io.LimitReader(res.Body, maxMsgSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) > maxMsgSize {
+		return nil, errMsgTooLong
+	}
+	resp, _, err := checkResponse(&query, msg)
+	return resp, err
+}
+
+// A DoHHandler is an HTTP handler that answers DNS queries received over
+// HTTPS (RFC 8484), in GET requests with a dns query parameter or in POST
+// requests with a body of type application/dns-message.
+//
+// The freshness lifetime of the responses is the smallest TTL of their
+// records, so that HTTP caches don't keep them longer than DNS caches
+// would.
+type DoHHandler struct {
+	// Handler answers the queries. If nil, queries are refused.
+	Handler Handler
+}
+
+func (h *DoHHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg []byte
+	switch r.Method {
+	case "GET", "HEAD":
+		var err error
+		msg, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		if err != nil || len(msg) == 0 {
+			http.Error(w, "invalid dns parameter", http.StatusBadRequest)
+			return
+		}
+	case "POST":
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != dohMediaType {
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		var err error
+		msg, err = io.ReadAll(io.LimitReader(r.Body, maxMsgSize+1))
+		if err != nil {
+			http.Error(w, "error reading body", http.StatusBadRequest)
+			return
+		}
+		if len(msg) > maxMsgSize {
+			http.Error(w, "query too long", http.StatusRequestEntityTooLarge)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var addr net.Addr
+	if ap, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		addr = net.TCPAddrFromAddrPort(ap)
+	}
+	written := false
+	ok := serveMsg(r.Context(), h.Handler, defaultUDPSize, msg, "https", addr, maxMsgSize, func(b []byte) error {
+		written = true
+		hdr := w.Header()
+		hdr.Set("Content-Type", dohMediaType)
+		hdr.Set("Content-Length", strconv.Itoa(len(b)))
+		if ttl, ok := minTTL(b); ok {
+			hdr.Set("Cache-Control", "max-age="+strconv.FormatUint(uint64(ttl), 10))
+		}
+		_, err := w.Write(b)
+		return err
+	})
+	switch {
+	case !ok:
+		http.Error(w, "invalid query", http.StatusBadRequest)
+	case !written:
+		// The handler dropped the query.
+		http.Error(w, "no response", http.StatusServiceUnavailable)
+	}
+}
+
+// minTTL returns the smallest TTL of the records of the response msg,
+// ignoring OPT records. It reports false if there are none.
+func minTTL(msg []byte) (uint32, bool) {
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil {
+		return 0, false
+	}
+	var ttl uint32
+	found := false
+	for _, rs := range [][]dnsmessage.Resource{m.Answers, m.Authorities, m.Additionals} {
+		for _, r := range rs {
+			if r.Header.Type == dnsmessage.TypeOPT {
+				continue
+			}
+			if !found || r.Header.TTL < ttl {
+				ttl, found = r.Header.TTL, true
+			}
+		}
+	}
+	return ttl, found
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/quic"
+)
+
+// doqALPN is the ALPN protocol of DNS over QUIC (RFC 9250 section 4.1.1).
+const doqALPN = "doq"
+
+// Error codes of DNS over QUIC (RFC 9250 section 4.3).
+const (
+	doqNoError       = 0x0
+	doqInternalError = 0x1
+	doqProtocolError = 0x2
+)
+
+var errNonZeroID = errors.New("dns: DoQ message with a non-zero ID")
+
+// ServeQUIC serves the queries received over QUIC (RFC 9250) on the
+// connections accepted by e, each stream of a connection carrying one
+// query. The TLS configuration of e should set NextProtos to "doq". It
+// always returns a non-nil error and closes e; after Close, the error is
+// ErrServerClosed.
+func (s *Server) ServeQUIC(e *quic.Endpoint) error {
+	ec := endpointCloser{e}
+	defer ec.Close()
+	ctx, ok := s.track(ec, true)
+	if !ok {
+		return ErrServerClosed
+	}
+	defer s.track(ec, false)
+	for {
+		c, err := e.Accept(ctx)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serveQUICConn(ctx, c)
+	}
+}
+
+// An endpointCloser closes a QUIC endpoint without waiting for the peers
+// to acknowledge that their connections are closed.
+type endpointCloser struct {
+	e *quic.Endpoint
+}
+
+func (c endpointCloser) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.e.Close(ctx)
+	return nil
+}
+
+func (s *Server) serveQUICConn(ctx context.Context, c *quic.Conn) {
+	for {
+		st, err := c.AcceptStream(ctx)
+		if err != nil {
+			c.Abort(nil)
+			return
+		}
+		go s.serveQUICStream(ctx, c, st)
+	}
+}
+
+func (s *Server) serveQUICStream(ctx context.Context, c *quic.Conn, st *quic.Stream) {
+	ctx, cancel := context.WithTimeout(ctx, s.idleTimeout())
+	defer cancel()
+	st.SetReadContext(ctx)
+	st.SetWriteContext(ctx)
+	msg, err := readStreamMsg(st)
+	if err != nil {
+		st.Reset(doqProtocolError)
+		return
+	}
+	st.CloseRead()
+	// Messages must have an ID of 0 (RFC 9250 section 4.2.1).
+	if len(msg) >= 2 && binary.BigEndian.Uint16(msg) != 0 {
+		c.Abort(&quic.ApplicationError{Code: doqProtocolError, Reason: errNonZeroID.Error()})
+		return
+	}
+	written := false
+	ok := s.serveMsg(ctx, msg, "quic", net.UDPAddrFromAddrPort(c.RemoteAddr()), maxMsgSize, func(b []byte) error {
+		written = true
+		if err := writeStreamMsg(st, b); err != nil {
+			return err
+		}
+		return st.Close()
+	})
+	switch {
+	case !ok:
+		c.Abort(&quic.ApplicationError{Code: doqProtocolError})
+	case !written:
+		// The handler dropped the query.
+		st.Reset(doqInternalError)
+	}
+}
+
+// A DoQClient sends DNS queries over QUIC (RFC 9250).
+//
+// Each exchange is made on a new connection.
+type DoQClient struct {
+	// Endpoint is the QUIC endpoint used to connect to servers. If nil,
+	// it is initialized by the first call to Exchange.
+	Endpoint *quic.Endpoint
+
+	// Config is the QUIC configuration of the connections. It may be
+	// nil. If its TLS configuration doesn't set NextProtos, "doq" is
+	// used.
+	//
+	// Exchange may clone and modify the Config. The Config must not be
+	// modified after calling Exchange.
+	Config *quic.Config
+
+	initOnce sync.Once
+	initErr  error
+}
+
+func (c *DoQClient) init() error {
+	c.initOnce.Do(func() {
+		c.Config = initQUICConfig(c.Config)
+		if c.Endpoint == nil {
+			c.Endpoint, c.initErr = quic.Listen("udp", ":0", nil)
+		}
+	})
+	return c.initErr
+}
+
+// initQUICConfig returns a copy of config with the TLS settings that DNS
+// over QUIC requires.
+func initQUICConfig(config *quic.Config) *quic.Config {
+	if config == nil {
+		config = &quic.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if config.TLSConfig == nil {
+		config.TLSConfig = &tls.Config{}
+	} else {
+		config.TLSConfig = config.TLSConfig.Clone()
+	}
+	if config.TLSConfig.MinVersion == 0 {
+		config.TLSConfig.MinVersion = tls.VersionTLS13
+	}
+	if config.TLSConfig.NextProtos == nil {
+		config.TLSConfig.NextProtos = []string{doqALPN}
+	}
+	return config
+}
+
+// Exchange sends the query q to the server at addr and returns its
+// response.
+//
+// The query is sent with an ID of 0, as required by RFC 9250, and the
+// response must have the questions of the query. q itself is not
+// modified.
+func (c *DoQClient) Exchange(ctx context.Context, addr string, q *dnsmessage.Message) (*dnsmessage.Message, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	query := *q
+	query.Header.ID = 0
+	query.Header.Response = false
+	b, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.Endpoint.Dial(ctx, "udp", addr, c.Config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Abort(&quic.ApplicationError{Code: doqNoError})
+	st, err := conn.NewStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st.SetReadContext(ctx)
+	st.SetWriteContext(ctx)
+	if err := writeStreamMsg(st, b); err != nil {
+		return nil, err
+	}
+	// The query must be followed by the end of the stream (RFC 9250
+	// section 4.2).
+	st.CloseWrite()
+	msg, err := readStreamMsg(st)
+	if err != nil {
+		return nil, err
+	}
+	resp, _, err := checkResponse(&query, msg)
+	return resp, err
+}
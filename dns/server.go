@@ -0,0 +1,404 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ErrServerClosed is returned by the Serve methods of a [Server] after a
+// call to Close.
+var ErrServerClosed = errors.New("dns: Server closed")
+
+var (
+	errAlreadyWritten = errors.New("dns: response already written")
+	errExtendedRCode  = errors.New("dns: extended RCode without OPT record")
+)
+
+// rcodeBadVersion is the extended RCode of responses to queries with an
+// unsupported EDNS version (RFC 6891 section 6.1.3).
+const rcodeBadVersion dnsmessage.RCode = 16
+
+// defaultIdleTimeout is how long a TCP connection is kept open without
+// queries (RFC 7766 section 6.2.3).
+const defaultIdleTimeout = 10 * time.Second
+
+// A Server answers DNS queries over UDP and TCP.
+type Server struct {
+	// Addr is the address that ListenAndServe listens on, ":53" if
+	// empty.
+	Addr string
+
+	// Handler answers the queries. If nil, queries are refused.
+	Handler Handler
+
+	// UDPSize is the UDP payload size advertised in EDNS(0) responses,
+	// which also bounds the size of UDP responses. If zero, 1232 is
+	// used.
+	UDPSize int
+
+	// IdleTimeout is how long a TCP connection may stay idle between
+	// queries. If zero, 10 seconds is used.
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	closed    bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	listeners map[io.Closer]struct{}
+	conns     map[net.Conn]struct{}
+}
+
+func (s *Server) udpSize() int {
+	if s.UDPSize > 0 {
+		return max(s.UDPSize, minUDPSize)
+	}
+	return defaultUDPSize
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout > 0 {
+		return s.IdleTimeout
+	}
+	return defaultIdleTimeout
+}
+
+// track adds or removes a listener, reporting false if the server is
+// closed. The returned context is canceled when the server is closed.
+func (s *Server) track(l io.Closer, add bool) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	if !add {
+		delete(s.listeners, l)
+		return s.ctx, true
+	}
+	if s.closed {
+		return nil, false
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[io.Closer]struct{})
+	}
+	s.listeners[l] = struct{}{}
+	return s.ctx, true
+}
+
+func (s *Server) trackConn(c net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !add {
+		delete(s.conns, c)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Close closes all the listeners and connections of the server, and
+// cancels the context of the handlers.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return err
+}
+
+// ListenAndServe listens on the UDP and TCP address s.Addr and serves
+// queries on both. It always returns a non-nil error; after Close, the
+// error is ErrServerClosed.
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":53"
+	}
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		pc.Close()
+		return err
+	}
+	errc := make(chan error, 2)
+	go func() { errc <- s.ServeUDP(pc) }()
+	go func() { errc <- s.ServeTCP(l) }()
+	err = <-errc
+	// Stop the other transport too.
+	pc.Close()
+	l.Close()
+	<-errc
+	return err
+}
+
+// ServeUDP serves the queries received on conn, each in a new goroutine.
+// It always returns a non-nil error and closes conn; after Close, the
+// error is ErrServerClosed.
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	defer conn.Close()
+	ctx, ok := s.track(conn, true)
+	if !ok {
+		return ErrServerClosed
+	}
+	defer s.track(conn, false)
+	buf := make([]byte, maxMsgSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		msg := bytes.Clone(buf[:n])
+		go s.serveMsg(ctx, msg, "udp", addr, 0, func(b []byte) error {
+			_, err := conn.WriteTo(b, addr)
+			return err
+		})
+	}
+}
+
+// ServeTCP serves the queries received on the connections accepted by
+// l, each connection in a new goroutine. The queries of a connection are
+// answered in order. It always returns a non-nil error and closes l;
+// after Close, the error is ErrServerClosed.
+func (s *Server) ServeTCP(l net.Listener) error {
+	return s.serveStream(l, "tcp")
+}
+
+// ServeTLS serves the queries received over TLS (RFC 7858) on the
+// connections accepted by l, like ServeTCP. The config must include at
+// least one certificate or set GetCertificate. It always returns a
+// non-nil error and closes l; after Close, the error is
+// ErrServerClosed.
+func (s *Server) ServeTLS(l net.Listener, config *tls.Config) error {
+	return s.serveStream(tls.NewListener(l, config), "tcp-tls")
+}
+
+func (s *Server) serveStream(l net.Listener, network string) error {
+	defer l.Close()
+	ctx, ok := s.track(l, true)
+	if !ok {
+		return ErrServerClosed
+	}
+	defer s.track(l, false)
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serveConn(ctx, c, network)
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, c net.Conn, network string) {
+	defer c.Close()
+	if !s.trackConn(c, true) {
+		return
+	}
+	defer s.trackConn(c, false)
+	for {
+		c.SetReadDeadline(time.Now().Add(s.idleTimeout()))
+		msg, err := readStreamMsg(c)
+		if err != nil {
+			return
+		}
+		s.serveMsg(ctx, msg, network, c.RemoteAddr(), maxMsgSize, func(b []byte) error {
+			c.SetWriteDeadline(time.Now().Add(s.idleTimeout()))
+			return writeStreamMsg(c, b)
+		})
+	}
+}
+
+// serveMsg answers the query msg, sending the response with send. If
+// maxSize is zero, it is negotiated with EDNS(0). It reports false if msg
+// is not a query that can be answered.
+func (s *Server) serveMsg(ctx context.Context, msg []byte, network string, addr net.Addr, maxSize int, send func([]byte) error) bool {
+	return serveMsg(ctx, s.Handler, s.udpSize(), msg, network, addr, maxSize, send)
+}
+
+// serveMsg is like Server.serveMsg, answering queries with h and
+// advertising udpSize in EDNS(0) responses.
+func serveMsg(ctx context.Context, h Handler, udpSize int, msg []byte, network string, addr net.Addr, maxSize int, send func([]byte) error) bool {
+	req, rcode, ok := parseRequest(msg)
+	if !ok {
+		return false
+	}
+	req.Network = network
+	req.RemoteAddr = addr
+	req.MaxSize = maxSize
+	if maxSize == 0 {
+		req.MaxSize = minUDPSize
+		if req.OPT != nil {
+			req.MaxSize = min(req.OPT.Header.UDPPayloadSize(), udpSize)
+		}
+	}
+	w := &responseWriter{udpSize: udpSize, req: req, send: send}
+	switch {
+	case rcode != dnsmessage.RCodeSuccess:
+		w.WriteMsg(req.NewResponse(rcode))
+	case req.OPT != nil && req.OPT.Header.EDNSVersion() != 0:
+		w.WriteMsg(req.NewResponse(rcodeBadVersion))
+	case h == nil:
+		w.WriteMsg(req.NewResponse(dnsmessage.RCodeRefused))
+	default:
+		h.ServeDNS(ctx, w, req)
+	}
+	return true
+}
+
+// parseRequest parses a query. It returns a format error RCode for
+// malformed queries that can still be answered, and false for messages
+// that must be ignored.
+func parseRequest(msg []byte) (*Request, dnsmessage.RCode, bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil || h.Response {
+		return nil, 0, false
+	}
+	req := &Request{Header: h}
+	formErr := func() (*Request, dnsmessage.RCode, bool) {
+		req.Questions = nil
+		req.OPT = nil
+		return req, dnsmessage.RCodeFormatError, true
+	}
+	if req.Questions, err = p.AllQuestions(); err != nil {
+		return formErr()
+	}
+	if err := p.SkipAllAnswers(); err != nil {
+		return formErr()
+	}
+	if err := p.SkipAllAuthorities(); err != nil {
+		return formErr()
+	}
+	for {
+		rh, err := p.AdditionalHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return formErr()
+		}
+		if rh.Type != dnsmessage.TypeOPT {
+			if err := p.SkipAdditional(); err != nil {
+				return formErr()
+			}
+			continue
+		}
+		// A query must not have more than one OPT record (RFC 6891
+		// section 6.1.1).
+		if req.OPT != nil {
+			return formErr()
+		}
+		opt, err := p.OPTResource()
+		if err != nil {
+			return formErr()
+		}
+		req.OPT = &dnsmessage.Resource{Header: rh, Body: &opt}
+	}
+	return req, dnsmessage.RCodeSuccess, true
+}
+
+type responseWriter struct {
+	udpSize int
+	req     *Request
+	send    func([]byte) error
+	written bool
+}
+
+func (w *responseWriter) WriteMsg(m *dnsmessage.Message) error {
+	if w.written {
+		return errAlreadyWritten
+	}
+	w.written = true
+	b, err := packResponse(w.req, m, w.udpSize)
+	if err != nil {
+		return err
+	}
+	return w.send(b)
+}
+
+// packResponse packs the response m to req. It adds an OPT record
+// advertising udpSize if the query has one, and truncates the response
+// if it is larger than req.MaxSize.
+func packResponse(req *Request, m *dnsmessage.Message, udpSize int) ([]byte, error) {
+	msg := *m
+	// The upper bits of an extended RCode are carried by the OPT record.
+	rcode := msg.Header.RCode
+	msg.Header.RCode &= 0xF
+	if req.OPT != nil && !hasOPT(msg.Additionals) {
+		var h dnsmessage.ResourceHeader
+		if err := h.SetEDNS0(udpSize, rcode, req.OPT.Header.DNSSECAllowed()); err != nil {
+			return nil, err
+		}
+		opt := dnsmessage.Resource{Header: h, Body: &dnsmessage.OPTResource{}}
+		msg.Additionals = append(slices.Clip(msg.Additionals), opt)
+	} else if rcode > 0xF && !hasOPT(msg.Additionals) {
+		return nil, errExtendedRCode
+	}
+	b, err := msg.Pack()
+	if err != nil || len(b) <= req.MaxSize {
+		return b, err
+	}
+
+	// Keep the questions, and the OPT record that tells the client the
+	// size of the responses that it can retry with.
+	tc := dnsmessage.Message{Header: msg.Header, Questions: msg.Questions}
+	tc.Header.Truncated = true
+	for _, r := range msg.Additionals {
+		if _, ok := r.Body.(*dnsmessage.OPTResource); ok {
+			tc.Additionals = append(tc.Additionals, r)
+		}
+	}
+	return tc.Pack()
+}
+
+func hasOPT(rs []dnsmessage.Resource) bool {
+	for _, r := range rs {
+		if _, ok := r.Body.(*dnsmessage.OPTResource); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dns_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/internal/testcert"
+	"golang.org/x/net/quic"
+)
+
+var testCert = func() tls.Certificate {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		panic(err)
+	}
+	return cert
+}()
+
+func testRootCAs() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(testcert.LocalhostCert)
+	return pool
+}
+
+func TestTLS(t *testing.T) {
+	h := &txtHandler{n: 20}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dns.Server{Handler: h}
+	defer s.Close()
+	go s.ServeTLS(l, &tls.Config{Certificates: []tls.Certificate{testCert}})
+
+	// The server name defaults to the host of the address, which the
+	// certificate is valid for.
+	c := &dns.Client{Net: "tcp-tls", TLSConfig: &tls.Config{RootCAs: testRootCAs()}}
+	for range 2 {
+		resp, err := c.Exchange(context.Background(), l.Addr().String(), query("example.com.", dnsmessage.TypeTXT))
+		if err != nil {
+			t.Fatal("Exchange() =", err)
+		}
+		if resp.Header.Truncated || len(resp.Answers) != 20 {
+			t.Errorf("got TC = %t and %d answers, want a complete response", resp.Header.Truncated, len(resp.Answers))
+		}
+	}
+	if got := h.networks(); len(got) != 2 || got[0] != "tcp-tls" {
+		t.Errorf("queries received over %q, want [tcp-tls tcp-tls]", got)
+	}
+
+	// The certificate is checked.
+	c.TLSConfig = nil
+	if _, err := c.Exchange(context.Background(), l.Addr().String(), query("example.com.", dnsmessage.TypeTXT)); err == nil {
+		t.Error("Exchange() with an untrusted certificate succeeded")
+	}
+}
+
+func newDoHServer(t *testing.T, h dns.Handler) (*httptest.Server, *http.Client) {
+	ts := httptest.NewUnstartedServer(&dns.DoHHandler{Handler: h})
+	ts.EnableHTTP2 = true
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{testCert}}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	tr := &http2.Transport{TLSClientConfig: &tls.Config{RootCAs: testRootCAs()}}
+	t.Cleanup(tr.CloseIdleConnections)
+	return ts, &http.Client{Transport: tr}
+}
+
+func TestDoH(t *testing.T) {
+	h := &txtHandler{n: 20}
+	ts, hc := newDoHServer(t, h)
+
+	for _, useGET := range []bool{false, true} {
+		h.reset(20)
+		c := &dns.DoHClient{URL: ts.URL + "/dns-query", HTTPClient: hc, UseGET: useGET}
+		q := query("example.com.", dnsmessage.TypeTXT)
+		q.Header.ID = 42
+		resp, err := c.Exchange(context.Background(), q)
+		if err != nil {
+			t.Fatalf("UseGET %t: Exchange() = %v", useGET, err)
+		}
+		if q.Header.ID != 42 {
+			t.Errorf("UseGET %t: Exchange() modified the query", useGET)
+		}
+		if resp.Header.ID != 0 || len(resp.Answers) != 20 {
+			t.Errorf("UseGET %t: got ID %d and %d answers, want ID 0 and 20 answers", useGET, resp.Header.ID, len(resp.Answers))
+		}
+		h.mu.Lock()
+		r := h.reqs[0]
+		h.mu.Unlock()
+		if r.Network != "https" || r.MaxSize != 65535 || r.RemoteAddr == nil {
+			t.Errorf("UseGET %t: Request = %+v, want an HTTPS request with a maximum size of 65535", useGET, r)
+		}
+	}
+}
+
+func TestDoHHandler(t *testing.T) {
+	ts, hc := newDoHServer(t, &txtHandler{n: 1})
+
+	b, err := query("example.com.", dnsmessage.TypeTXT).Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := hc.Get(ts.URL + "?dns=" + base64.RawURLEncoding.EncodeToString(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.ProtoMajor != 2 {
+		t.Errorf("response protocol = %s, want HTTP/2", res.Proto)
+	}
+	if got := res.Header.Get("Content-Type"); got != "application/dns-message" {
+		t.Errorf("Content-Type = %q, want application/dns-message", got)
+	}
+	if got := res.Header.Get("Cache-Control"); got != "max-age=60" {
+		t.Errorf("Cache-Control = %q, want the TTL of the answer", got)
+	}
+
+	for _, tt := range []struct {
+		method, query, contentType, body string
+		want                             int
+	}{
+		{"GET", "", "", "", http.StatusBadRequest},
+		{"GET", "?dns=not+base64", "", "", http.StatusBadRequest},
+		{"POST", "", "text/plain", string(b), http.StatusUnsupportedMediaType},
+		{"POST", "", "application/dns-message", "\x00\x01", http.StatusBadRequest},
+		{"PUT", "", "application/dns-message", string(b), http.StatusMethodNotAllowed},
+	} {
+		req, err := http.NewRequest(tt.method, ts.URL+tt.query, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		res, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("%s %q with type %q: status %d, want %d", tt.method, tt.query, tt.contentType, res.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestDoQ(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("ReadMsgUDP not supported on %s", runtime.GOOS)
+	}
+	h := &txtHandler{n: 20}
+	e, err := quic.Listen("udp", "127.0.0.1:0", &quic.Config{
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS13,
+			Certificates: []tls.Certificate{testCert},
+			NextProtos:   []string{"doq"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dns.Server{Handler: h}
+	errc := make(chan error, 1)
+	go func() { errc <- s.ServeQUIC(e) }()
+
+	c := &dns.DoQClient{Config: &quic.Config{TLSConfig: &tls.Config{RootCAs: testRootCAs()}}}
+	resp, err := c.Exchange(context.Background(), e.LocalAddr().String(), query("example.com.", dnsmessage.TypeTXT))
+	if err != nil {
+		t.Fatal("Exchange() =", err)
+	}
+	defer c.Endpoint.Close(context.Background())
+	if resp.Header.ID != 0 || len(resp.Answers) != 20 {
+		t.Errorf("got ID %d and %d answers, want ID 0 and 20 answers", resp.Header.ID, len(resp.Answers))
+	}
+	if got := h.networks(); len(got) != 1 || got[0] != "quic" {
+		t.Errorf("queries received over %q, want [quic]", got)
+	}
+
+	s.Close()
+	if err := <-errc; err != dns.ErrServerClosed {
+		t.Errorf("ServeQUIC() = %v, want %v", err, dns.ErrServerClosed)
+	}
+}
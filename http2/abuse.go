@@ -0,0 +1,185 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http2
+
+import (
+	"fmt"
+	"time"
+)
+
+// AbuseLimits configures limits on peer behavior which is permitted by
+// the HTTP/2 protocol, but which is commonly used in denial-of-service
+// attacks, such as rapidly resetting streams or flooding the connection
+// with control frames.
+//
+// When a peer exceeds a limit, the server closes the connection
+// with a GOAWAY frame with the error code ENHANCE_YOUR_CALM and debug
+// data naming the rule which was violated.
+//
+// The zero value of each limit disables it.
+type AbuseLimits struct {
+	// ResetStream limits the rate of RST_STREAM frames.
+	ResetStream FrameRate
+
+	// Settings limits the rate of SETTINGS frames, not including
+	// acknowledgements of SETTINGS sent by the server.
+	Settings FrameRate
+
+	// Ping limits the rate of PING frames, not including
+	// acknowledgements of PINGs sent by the server.
+	Ping FrameRate
+
+	// Priority limits the rate of PRIORITY frames.
+	Priority FrameRate
+
+	// EmptyData limits the rate of DATA frames with no payload
+	// which do not end a stream.
+	EmptyData FrameRate
+
+	// WindowUpdate limits the rate of WINDOW_UPDATE frames.
+	WindowUpdate FrameRate
+
+	// MaxContinuationFrames limits the number of CONTINUATION frames
+	// which may follow a HEADERS frame in a single header block.
+	MaxContinuationFrames int
+
+	// OnLimitExceeded, if non-nil, is called when a peer exceeds
+	// one of the limits, before the connection is closed.
+	// It's intended for logging and monitoring.
+	OnLimitExceeded func(AbuseEvent)
+}
+
+// FrameRate is a limit on the number of frames of some type a peer
+// may send over a period of time.
+type FrameRate struct {
+	// Frames is the number of frames permitted in each Interval.
+	// If zero or negative, there is no limit.
+	Frames int
+
+	// Interval is the period over which frames are counted.
+	// If zero or negative, a default of one second is used.
+	Interval time.Duration
+}
+
+// AbuseEvent describes a peer exceeding one of the limits in AbuseLimits.
+type AbuseEvent struct {
+	// Rule identifies the limit which was exceeded. It is one of
+	// "rst_stream", "settings", "ping", "priority", "empty_data",
+	// "window_update", or "continuation".
+	Rule string
+
+	// RemoteAddr is the network address of the peer.
+	RemoteAddr string
+}
+
+const (
+	abuseRuleResetStream  = "rst_stream"
+	abuseRuleSettings     = "settings"
+	abuseRulePing         = "ping"
+	abuseRulePriority     = "priority"
+	abuseRuleEmptyData    = "empty_data"
+	abuseRuleWindowUpdate = "window_update"
+	abuseRuleContinuation = "continuation"
+)
+
+// abuseError is returned when a peer exceeds a limit in AbuseLimits.
+// Its value is the rule which was exceeded.
+type abuseError string
+
+func (e abuseError) Error() string {
+	return fmt.Sprintf("http2: peer exceeded %s limit", string(e))
+}
+
+// rateCounter counts events in fixed windows of time.
+type rateCounter struct {
+	start time.Time
+	n     int
+}
+
+// add records an event at time now and reports whether r is exceeded.
+func (c *rateCounter) add(r FrameRate, now time.Time) bool {
+	if r.Frames <= 0 {
+		return false
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if now.Sub(c.start) >= interval {
+		c.start = now
+		c.n = 0
+	}
+	c.n++
+	return c.n > r.Frames
+}
+
+// abuseCounters tracks the rate-limited frames received on a connection.
+type abuseCounters struct {
+	resetStream  rateCounter
+	settings     rateCounter
+	ping         rateCounter
+	priority     rateCounter
+	emptyData    rateCounter
+	windowUpdate rateCounter
+}
+
+// checkAbuseLimits records the receipt of f, and returns an abuseError
+// if the peer has exceeded one of the server's AbuseLimits.
+func (sc *serverConn) checkAbuseLimits(f Frame) error {
+	sc.serveG.check()
+	lim := &sc.srv.AbuseLimits
+	var (
+		c    *rateCounter
+		r    FrameRate
+		rule string
+	)
+	switch f := f.(type) {
+	case *RSTStreamFrame:
+		c, r, rule = &sc.abuse.resetStream, lim.ResetStream, abuseRuleResetStream
+	case *SettingsFrame:
+		if f.IsAck() {
+			return nil
+		}
+		c, r, rule = &sc.abuse.settings, lim.Settings, abuseRuleSettings
+	case *PingFrame:
+		if f.IsAck() {
+			return nil
+		}
+		c, r, rule = &sc.abuse.ping, lim.Ping, abuseRulePing
+	case *PriorityFrame:
+		c, r, rule = &sc.abuse.priority, lim.Priority, abuseRulePriority
+	case *DataFrame:
+		if f.Length > 0 || f.StreamEnded() {
+			return nil
+		}
+		c, r, rule = &sc.abuse.emptyData, lim.EmptyData, abuseRuleEmptyData
+	case *WindowUpdateFrame:
+		c, r, rule = &sc.abuse.windowUpdate, lim.WindowUpdate, abuseRuleWindowUpdate
+	default:
+		return nil
+	}
+	if c.add(r, time.Now()) {
+		return abuseError(rule)
+	}
+	return nil
+}
+
+// abuseLimitExceeded closes the connection after the peer has
+// exceeded the limit identified by rule.
+func (sc *serverConn) abuseLimitExceeded(rule string) {
+	sc.serveG.check()
+	sc.vlogf("http2: server closing connection from %v: %s limit exceeded", sc.conn.RemoteAddr(), rule)
+	if f := sc.srv.AbuseLimits.OnLimitExceeded; f != nil {
+		f(AbuseEvent{
+			Rule:       rule,
+			RemoteAddr: sc.remoteAddrStr,
+		})
+	}
+	sc.countError("abuse_"+rule, ConnectionError(ErrCodeEnhanceYourCalm))
+	if sc.goAwayDebug == "" {
+		sc.goAwayDebug = rule + " limit exceeded"
+	}
+	sc.goAway(ErrCodeEnhanceYourCalm)
+}
@@ -42,12 +42,11 @@ var (
 type clientConnPool struct {
 	t *Transport
 
-	mu sync.Mutex // TODO: maybe switch to RWMutex
-	// TODO: add support for sharing conns based on cert names
-	// (e.g. share conn for googleapis.com and appspot.com)
+	mu           sync.Mutex               // TODO: maybe switch to RWMutex
 	conns        map[string][]*ClientConn // key is host:port
 	dialing      map[string]*dialCall     // currently in-flight dials
 	keys         map[*ClientConn][]string
+	order        []*ClientConn           // conns in keys, oldest first
 	addConnCalls map[string]*addConnCall // in-flight addConnIfNeeded calls
 }
 
@@ -87,6 +86,11 @@ func (p *clientConnPool) getClientConn(req *http.Request, addr string, dialOnMis
 				return cc, nil
 			}
 		}
+		if cc := p.coalescedConnLocked(addr); cc != nil {
+			traceGetConn(req, addr)
+			p.mu.Unlock()
+			return cc, nil
+		}
 		if !dialOnMiss {
 			p.mu.Unlock()
 			return nil, ErrNoCachedConn
@@ -108,6 +112,22 @@ func (p *clientConnPool) getClientConn(req *http.Request, addr string, dialOnMis
 	}
 }
 
+// coalescedConnLocked returns a connection to another host which
+// the server has declared, with an ORIGIN frame, to be authoritative
+// for addr. It reserves a request on the returned connection, and
+// adds the connection to the pool under addr. The oldest such
+// connection is used.
+// p.mu must be held.
+func (p *clientConnPool) coalescedConnLocked(addr string) *ClientConn {
+	for _, cc := range p.order {
+		if cc.canCoalesce(addr) && cc.ReserveNewRequest() {
+			p.addConnLocked(addr, cc)
+			return cc
+		}
+	}
+	return nil
+}
+
 // dialCall is an in-flight Transport dial call to a host.
 type dialCall struct {
 	_ incomparable
@@ -223,6 +243,9 @@ func (p *clientConnPool) addConnLocked(key string, cc *ClientConn) {
 	if p.keys == nil {
 		p.keys = make(map[*ClientConn][]string)
 	}
+	if _, ok := p.keys[cc]; !ok {
+		p.order = append(p.order, cc)
+	}
 	p.conns[key] = append(p.conns[key], cc)
 	p.keys[cc] = append(p.keys[cc], key)
 }
@@ -243,6 +266,7 @@ func (p *clientConnPool) MarkDead(cc *ClientConn) {
 		}
 	}
 	delete(p.keys, cc)
+	p.order = filterOutClientConn(p.order, cc)
 }
 
 func (p *clientConnPool) closeIdleConnections() {
@@ -36,6 +36,8 @@ type http2Config struct {
 	SendPingTimeout              time.Duration
 	PingTimeout                  time.Duration
 	WriteByteTimeout             time.Duration
+	ReadHeaderTimeout            time.Duration
+	StreamIdleTimeout            time.Duration
 	PermitProhibitedCipherSuites bool
 	CountError                   func(errType string)
 }
@@ -53,6 +55,8 @@ func configFromServer(h1 *http.Server, h2 *Server) http2Config {
 		SendPingTimeout:              h2.ReadIdleTimeout,
 		PingTimeout:                  h2.PingTimeout,
 		WriteByteTimeout:             h2.WriteByteTimeout,
+		ReadHeaderTimeout:            h2.ReadHeaderTimeout,
+		StreamIdleTimeout:            h2.StreamIdleTimeout,
 		PermitProhibitedCipherSuites: h2.PermitProhibitedCipherSuites,
 		CountError:                   h2.CountError,
 	}
@@ -37,6 +37,7 @@ const (
 	FrameGoAway       FrameType = 0x7
 	FrameWindowUpdate FrameType = 0x8
 	FrameContinuation FrameType = 0x9
+	FrameOrigin       FrameType = 0xc // RFC 8336
 )
 
 var frameNames = [...]string{
@@ -50,10 +51,11 @@ var frameNames = [...]string{
 	FrameGoAway:       "GOAWAY",
 	FrameWindowUpdate: "WINDOW_UPDATE",
 	FrameContinuation: "CONTINUATION",
+	FrameOrigin:       "ORIGIN",
 }
 
 func (t FrameType) String() string {
-	if int(t) < len(frameNames) {
+	if int(t) < len(frameNames) && frameNames[t] != "" {
 		return frameNames[t]
 	}
 	return fmt.Sprintf("UNKNOWN_FRAME_TYPE_%d", t)
@@ -135,10 +137,11 @@ var frameParsers = [...]frameParser{
 	FrameGoAway:       parseGoAwayFrame,
 	FrameWindowUpdate: parseWindowUpdateFrame,
 	FrameContinuation: parseContinuationFrame,
+	FrameOrigin:       parseOriginFrame,
 }
 
 func typeFrameParser(t FrameType) frameParser {
-	if int(t) < len(frameParsers) {
+	if int(t) < len(frameParsers) && frameParsers[t] != nil {
 		return frameParsers[t]
 	}
 	return parseUnknownFrame
@@ -243,13 +246,7 @@ func readFrameHeader(buf []byte, r io.Reader) (FrameHeader, error) {
 	if err != nil {
 		return FrameHeader{}, err
 	}
-	return FrameHeader{
-		Length:   (uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])),
-		Type:     FrameType(buf[3]),
-		Flags:    Flags(buf[4]),
-		StreamID: binary.BigEndian.Uint32(buf[5:]) & (1<<31 - 1),
-		valid:    true,
-	}, nil
+	return ParseFrameHeader(buf)
 }
 
 // A Frame is the base interface implemented by all frame types.
@@ -321,6 +318,16 @@ type Framer struct {
 	// If the limit is hit, MetaHeadersFrame.Truncated is set true.
 	MaxHeaderListSize uint32
 
+	// maxContinuationFrames is the maximum number of CONTINUATION
+	// frames permitted in a single header block.
+	// It's used only if ReadMetaHeaders is set; 0 means no limit.
+	maxContinuationFrames int
+
+	// headerBlockStart, if non-nil, is called when ReadFrame reads
+	// a HEADERS frame which will be followed by CONTINUATION frames.
+	// It's used only if ReadMetaHeaders is set.
+	headerBlockStart func(streamID uint32)
+
 	// TODO: track which type of frame & with which flags was sent
 	// last. Then return an error (unless AllowIllegalWrites) if
 	// we're in the middle of a header block and a
@@ -334,7 +341,9 @@ type Framer struct {
 	debugReadLoggerf  func(string, ...interface{})
 	debugWriteLoggerf func(string, ...interface{})
 
-	frameCache *frameCache // nil if frames aren't reused (default)
+	// codec parses frame payloads.
+	// Frames aren't reused by default; see SetReuseFrames.
+	codec FrameCodec
 }
 
 func (fr *Framer) maxHeaderListSize() uint32 {
@@ -414,23 +423,129 @@ const (
 // If called on a Framer, Frames returned by calls to ReadFrame are only
 // valid until the next call to ReadFrame.
 func (fr *Framer) SetReuseFrames() {
-	if fr.frameCache != nil {
+	if fr.codec.cache.reuse != reuseNoFrames {
 		return
 	}
-	fr.frameCache = &frameCache{}
+	fr.codec.cache.reuse = reuseDataFrames
 }
 
+// frameReuse is the set of frame types reused by a frameCache.
+type frameReuse uint8
+
+const (
+	reuseNoFrames   frameReuse = iota // the Framer default
+	reuseDataFrames                   // used by Framer.SetReuseFrames
+	reuseAllFrames                    // used by FrameCodec
+)
+
+// frameCache holds frames which are reused by successive parses,
+// to avoid allocating a new frame for each.
 type frameCache struct {
-	dataFrame DataFrame
+	reuse frameReuse
+
+	dataFrame         DataFrame
+	headersFrame      HeadersFrame
+	priorityFrame     PriorityFrame
+	rstStreamFrame    RSTStreamFrame
+	settingsFrame     SettingsFrame
+	pushPromiseFrame  PushPromiseFrame
+	pingFrame         PingFrame
+	goAwayFrame       GoAwayFrame
+	windowUpdateFrame WindowUpdateFrame
+	continuationFrame ContinuationFrame
+	originFrame       OriginFrame
+	unknownFrame      UnknownFrame
 }
 
 func (fc *frameCache) getDataFrame() *DataFrame {
-	if fc == nil {
+	if fc == nil || fc.reuse == reuseNoFrames {
 		return &DataFrame{}
 	}
 	return &fc.dataFrame
 }
 
+// reuseAll reports whether frames of types other than DATA are reused.
+func (fc *frameCache) reuseAll() bool {
+	return fc != nil && fc.reuse == reuseAllFrames
+}
+
+func (fc *frameCache) getHeadersFrame() *HeadersFrame {
+	if !fc.reuseAll() {
+		return &HeadersFrame{}
+	}
+	return &fc.headersFrame
+}
+
+func (fc *frameCache) getPriorityFrame() *PriorityFrame {
+	if !fc.reuseAll() {
+		return &PriorityFrame{}
+	}
+	return &fc.priorityFrame
+}
+
+func (fc *frameCache) getRSTStreamFrame() *RSTStreamFrame {
+	if !fc.reuseAll() {
+		return &RSTStreamFrame{}
+	}
+	return &fc.rstStreamFrame
+}
+
+func (fc *frameCache) getSettingsFrame() *SettingsFrame {
+	if !fc.reuseAll() {
+		return &SettingsFrame{}
+	}
+	return &fc.settingsFrame
+}
+
+func (fc *frameCache) getPushPromiseFrame() *PushPromiseFrame {
+	if !fc.reuseAll() {
+		return &PushPromiseFrame{}
+	}
+	return &fc.pushPromiseFrame
+}
+
+func (fc *frameCache) getPingFrame() *PingFrame {
+	if !fc.reuseAll() {
+		return &PingFrame{}
+	}
+	return &fc.pingFrame
+}
+
+func (fc *frameCache) getGoAwayFrame() *GoAwayFrame {
+	if !fc.reuseAll() {
+		return &GoAwayFrame{}
+	}
+	return &fc.goAwayFrame
+}
+
+func (fc *frameCache) getWindowUpdateFrame() *WindowUpdateFrame {
+	if !fc.reuseAll() {
+		return &WindowUpdateFrame{}
+	}
+	return &fc.windowUpdateFrame
+}
+
+func (fc *frameCache) getContinuationFrame() *ContinuationFrame {
+	if !fc.reuseAll() {
+		return &ContinuationFrame{}
+	}
+	return &fc.continuationFrame
+}
+
+func (fc *frameCache) getOriginFrame() *OriginFrame {
+	if !fc.reuseAll() {
+		return &OriginFrame{}
+	}
+	return &fc.originFrame
+}
+
+func (fc *frameCache) getUnknownFrame() *UnknownFrame {
+	if !fc.reuseAll() {
+		return &UnknownFrame{}
+	}
+	return &fc.unknownFrame
+}
+
 // NewFramer returns a Framer that writes frames to w and reads them from r.
 func NewFramer(w io.Writer, r io.Reader) *Framer {
 	fr := &Framer{
@@ -520,7 +635,7 @@ func (fr *Framer) ReadFrame() (Frame, error) {
 		}
 		return nil, err
 	}
-	f, err := typeFrameParser(fh.Type)(fr.frameCache, fh, fr.countError, payload)
+	f, err := fr.codec.parsePayload(fh, payload, fr.countError)
 	if err != nil {
 		if ce, ok := err.(connError); ok {
 			return nil, fr.connError(ce.Code, ce.Reason)
@@ -727,7 +842,7 @@ type SettingsFrame struct {
 	p []byte
 }
 
-func parseSettingsFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+func parseSettingsFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
 	if fh.Flags.Has(FlagSettingsAck) && fh.Length > 0 {
 		// When this (ACK 0x1) bit is set, the payload of the
 		// SETTINGS frame MUST be empty. Receipt of a
@@ -754,7 +869,8 @@ func parseSettingsFrame(_ *frameCache, fh FrameHeader, countError func(string),
 		// Expecting even number of 6 byte settings.
 		return nil, ConnectionError(ErrCodeFrameSize)
 	}
-	f := &SettingsFrame{FrameHeader: fh, p: p}
+	f := fc.getSettingsFrame()
+	*f = SettingsFrame{FrameHeader: fh, p: p}
 	if v, ok := f.Value(SettingInitialWindowSize); ok && v > (1<<31)-1 {
 		countError("frame_settings_window_size_too_big")
 		// Values above the maximum flow control window size of 2^31 - 1 MUST
@@ -868,7 +984,7 @@ type PingFrame struct {
 
 func (f *PingFrame) IsAck() bool { return f.Flags.Has(FlagPingAck) }
 
-func parsePingFrame(_ *frameCache, fh FrameHeader, countError func(string), payload []byte) (Frame, error) {
+func parsePingFrame(fc *frameCache, fh FrameHeader, countError func(string), payload []byte) (Frame, error) {
 	if len(payload) != 8 {
 		countError("frame_ping_length")
 		return nil, ConnectionError(ErrCodeFrameSize)
@@ -877,7 +993,8 @@ func parsePingFrame(_ *frameCache, fh FrameHeader, countError func(string), payl
 		countError("frame_ping_has_stream")
 		return nil, ConnectionError(ErrCodeProtocol)
 	}
-	f := &PingFrame{FrameHeader: fh}
+	f := fc.getPingFrame()
+	*f = PingFrame{FrameHeader: fh}
 	copy(f.Data[:], payload)
 	return f, nil
 }
@@ -910,7 +1027,7 @@ func (f *GoAwayFrame) DebugData() []byte {
 	return f.debugData
 }
 
-func parseGoAwayFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+func parseGoAwayFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
 	if fh.StreamID != 0 {
 		countError("frame_goaway_has_stream")
 		return nil, ConnectionError(ErrCodeProtocol)
@@ -919,12 +1036,14 @@ func parseGoAwayFrame(_ *frameCache, fh FrameHeader, countError func(string), p
 		countError("frame_goaway_short")
 		return nil, ConnectionError(ErrCodeFrameSize)
 	}
-	return &GoAwayFrame{
+	f := fc.getGoAwayFrame()
+	*f = GoAwayFrame{
 		FrameHeader:  fh,
 		LastStreamID: binary.BigEndian.Uint32(p[:4]) & (1<<31 - 1),
 		ErrCode:      ErrCode(binary.BigEndian.Uint32(p[4:8])),
 		debugData:    p[8:],
-	}, nil
+	}
+	return f, nil
 }
 
 func (f *Framer) WriteGoAway(maxStreamID uint32, code ErrCode, debugData []byte) error {
@@ -935,6 +1054,67 @@ func (f *Framer) WriteGoAway(maxStreamID uint32, code ErrCode, debugData []byte)
 	return f.endWrite()
 }
 
+// An OriginFrame lists the origins a server is authoritative for.
+// See https://www.rfc-editor.org/rfc/rfc8336.html
+type OriginFrame struct {
+	FrameHeader
+	p []byte
+}
+
+var errMalformedOrigin = errors.New("http2: malformed ORIGIN frame")
+
+// ForeachOrigin calls fn for each ASCII-serialized origin in the frame,
+// in order. If fn returns an error, ForeachOrigin stops and returns it.
+// ForeachOrigin returns an error if the frame payload is malformed.
+func (f *OriginFrame) ForeachOrigin(fn func(origin string) error) error {
+	f.checkValid()
+	p := f.p
+	for len(p) > 0 {
+		if len(p) < 2 {
+			return errMalformedOrigin
+		}
+		n := int(binary.BigEndian.Uint16(p))
+		p = p[2:]
+		if len(p) < n {
+			return errMalformedOrigin
+		}
+		if err := fn(string(p[:n])); err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+	return nil
+}
+
+func parseOriginFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+	// ORIGIN is a non-critical extension: malformed frames and frames
+	// on non-zero streams are ignored by the recipient, not rejected.
+	f := fc.getOriginFrame()
+	*f = OriginFrame{fh, p}
+	return f, nil
+}
+
+// WriteOrigin writes an ORIGIN frame listing the given
+// ASCII-serialized origins, such as "https://example.com".
+//
+// It will perform exactly one Write to the underlying Writer.
+// It is the caller's responsibility to not call other Write methods concurrently.
+func (f *Framer) WriteOrigin(origins ...string) error {
+	if !f.AllowIllegalWrites {
+		for _, o := range origins {
+			if len(o) > 0xffff {
+				return errMalformedOrigin
+			}
+		}
+	}
+	f.startWrite(FrameOrigin, 0, 0)
+	for _, o := range origins {
+		f.writeUint16(uint16(len(o)))
+		f.wbuf = append(f.wbuf, o...)
+	}
+	return f.endWrite()
+}
+
 // An UnknownFrame is the frame type returned when the frame type is unknown
 // or no specific frame type parser exists.
 type UnknownFrame struct {
@@ -952,8 +1132,10 @@ func (f *UnknownFrame) Payload() []byte {
 	return f.p
 }
 
-func parseUnknownFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
-	return &UnknownFrame{fh, p}, nil
+func parseUnknownFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+	f := fc.getUnknownFrame()
+	*f = UnknownFrame{fh, p}
+	return f, nil
 }
 
 // A WindowUpdateFrame is used to implement flow control.
@@ -963,7 +1145,7 @@ type WindowUpdateFrame struct {
 	Increment uint32 // never read with high bit set
 }
 
-func parseWindowUpdateFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+func parseWindowUpdateFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
 	if len(p) != 4 {
 		countError("frame_windowupdate_bad_len")
 		return nil, ConnectionError(ErrCodeFrameSize)
@@ -983,10 +1165,12 @@ func parseWindowUpdateFrame(_ *frameCache, fh FrameHeader, countError func(strin
 		countError("frame_windowupdate_zero_inc_stream")
 		return nil, streamError(fh.StreamID, ErrCodeProtocol)
 	}
-	return &WindowUpdateFrame{
+	f := fc.getWindowUpdateFrame()
+	*f = WindowUpdateFrame{
 		FrameHeader: fh,
 		Increment:   inc,
-	}, nil
+	}
+	return f, nil
 }
 
 // WriteWindowUpdate writes a WINDOW_UPDATE frame.
@@ -1031,10 +1215,7 @@ func (f *HeadersFrame) HasPriority() bool {
 	return f.FrameHeader.Flags.Has(FlagHeadersPriority)
 }
 
-func parseHeadersFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (_ Frame, err error) {
-	hf := &HeadersFrame{
-		FrameHeader: fh,
-	}
+func parseHeadersFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (_ Frame, err error) {
 	if fh.StreamID == 0 {
 		// HEADERS frames MUST be associated with a stream. If a HEADERS frame
 		// is received whose stream identifier field is 0x0, the recipient MUST
@@ -1043,6 +1224,10 @@ func parseHeadersFrame(_ *frameCache, fh FrameHeader, countError func(string), p
 		countError("frame_headers_zero_stream")
 		return nil, connError{ErrCodeProtocol, "HEADERS frame with stream ID 0"}
 	}
+	hf := fc.getHeadersFrame()
+	*hf = HeadersFrame{
+		FrameHeader: fh,
+	}
 	var padLength uint8
 	if fh.Flags.Has(FlagHeadersPadded) {
 		if p, padLength, err = readByte(p); err != nil {
@@ -1196,7 +1381,7 @@ func (p PriorityParam) IsZero() bool {
 	return p == PriorityParam{}
 }
 
-func parsePriorityFrame(_ *frameCache, fh FrameHeader, countError func(string), payload []byte) (Frame, error) {
+func parsePriorityFrame(fc *frameCache, fh FrameHeader, countError func(string), payload []byte) (Frame, error) {
 	if fh.StreamID == 0 {
 		countError("frame_priority_zero_stream")
 		return nil, connError{ErrCodeProtocol, "PRIORITY frame with stream ID 0"}
@@ -1207,14 +1392,16 @@ func parsePriorityFrame(_ *frameCache, fh FrameHeader, countError func(string),
 	}
 	v := binary.BigEndian.Uint32(payload[:4])
 	streamID := v & 0x7fffffff // mask off high bit
-	return &PriorityFrame{
+	f := fc.getPriorityFrame()
+	*f = PriorityFrame{
 		FrameHeader: fh,
 		PriorityParam: PriorityParam{
 			Weight:    payload[4],
 			StreamDep: streamID,
 			Exclusive: streamID != v, // was high bit set?
 		},
-	}, nil
+	}
+	return f, nil
 }
 
 // WritePriority writes a PRIORITY frame.
@@ -1245,7 +1432,7 @@ type RSTStreamFrame struct {
 	ErrCode ErrCode
 }
 
-func parseRSTStreamFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+func parseRSTStreamFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
 	if len(p) != 4 {
 		countError("frame_rststream_bad_len")
 		return nil, ConnectionError(ErrCodeFrameSize)
@@ -1254,7 +1441,9 @@ func parseRSTStreamFrame(_ *frameCache, fh FrameHeader, countError func(string),
 		countError("frame_rststream_zero_stream")
 		return nil, ConnectionError(ErrCodeProtocol)
 	}
-	return &RSTStreamFrame{fh, ErrCode(binary.BigEndian.Uint32(p[:4]))}, nil
+	f := fc.getRSTStreamFrame()
+	*f = RSTStreamFrame{fh, ErrCode(binary.BigEndian.Uint32(p[:4]))}
+	return f, nil
 }
 
 // WriteRSTStream writes a RST_STREAM frame.
@@ -1277,12 +1466,14 @@ type ContinuationFrame struct {
 	headerFragBuf []byte
 }
 
-func parseContinuationFrame(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
+func parseContinuationFrame(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (Frame, error) {
 	if fh.StreamID == 0 {
 		countError("frame_continuation_zero_stream")
 		return nil, connError{ErrCodeProtocol, "CONTINUATION frame with stream ID 0"}
 	}
-	return &ContinuationFrame{fh, p}, nil
+	f := fc.getContinuationFrame()
+	*f = ContinuationFrame{fh, p}
+	return f, nil
 }
 
 func (f *ContinuationFrame) HeaderBlockFragment() []byte {
@@ -1328,11 +1519,8 @@ func (f *PushPromiseFrame) HeadersEnded() bool {
 	return f.FrameHeader.Flags.Has(FlagPushPromiseEndHeaders)
 }
 
-func parsePushPromise(_ *frameCache, fh FrameHeader, countError func(string), p []byte) (_ Frame, err error) {
-	pp := &PushPromiseFrame{
-		FrameHeader: fh,
-	}
-	if pp.StreamID == 0 {
+func parsePushPromise(fc *frameCache, fh FrameHeader, countError func(string), p []byte) (_ Frame, err error) {
+	if fh.StreamID == 0 {
 		// PUSH_PROMISE frames MUST be associated with an existing,
 		// peer-initiated stream. The stream identifier of a
 		// PUSH_PROMISE frame indicates the stream it is associated
@@ -1342,6 +1530,10 @@ func parsePushPromise(_ *frameCache, fh FrameHeader, countError func(string), p
 		countError("frame_pushpromise_zero_stream")
 		return nil, ConnectionError(ErrCodeProtocol)
 	}
+	pp := fc.getPushPromiseFrame()
+	*pp = PushPromiseFrame{
+		FrameHeader: fh,
+	}
 	// The PUSH_PROMISE frame includes optional padding.
 	// Padding fields and flags are identical to those defined for DATA frames
 	var padLength uint8
@@ -1611,7 +1803,12 @@ func (fr *Framer) readMetaFrame(hf *HeadersFrame) (Frame, error) {
 	// Lose reference to MetaHeadersFrame:
 	defer hdec.SetEmitFunc(func(hf hpack.HeaderField) {})
 
+	if !hf.HeadersEnded() && fr.headerBlockStart != nil {
+		fr.headerBlockStart(hf.StreamID)
+	}
+
 	var hc headersOrContinuation = hf
+	var numContinuations int
 	for {
 		frag := hc.HeaderBlockFragment()
 
@@ -1656,6 +1853,13 @@ func (fr *Framer) readMetaFrame(hf *HeadersFrame) (Frame, error) {
 		} else {
 			hc = f.(*ContinuationFrame) // guaranteed by checkFrameOrder
 		}
+		numContinuations++
+		if max := fr.maxContinuationFrames; max > 0 && numContinuations > max {
+			if VerboseLogs {
+				log.Printf("http2: too many CONTINUATION frames")
+			}
+			return mh, abuseError(abuseRuleContinuation)
+		}
 	}
 
 	mh.HeadersFrame.headerFragBuf = nil
@@ -38,6 +38,8 @@ func TestFrameTypeString(t *testing.T) {
 		{FrameData, "DATA"},
 		{FramePing, "PING"},
 		{FrameGoAway, "GOAWAY"},
+		{FrameOrigin, "ORIGIN"},
+		{0xa, "UNKNOWN_FRAME_TYPE_10"},
 		{0xf, "UNKNOWN_FRAME_TYPE_15"},
 	}
 
@@ -710,6 +712,53 @@ func TestWriteGoAway(t *testing.T) {
 	}
 }
 
+func TestWriteOrigin(t *testing.T) {
+	fr, buf := testFramer()
+	if err := fr.WriteOrigin("https://a.example", "https://b.example:8443"); err != nil {
+		t.Fatal(err)
+	}
+	const wantEnc = "\x00\x00\x2b\x0c\x00\x00\x00\x00\x00" +
+		"\x00\x11https://a.example" +
+		"\x00\x16https://b.example:8443"
+	if buf.String() != wantEnc {
+		t.Errorf("encoded as %q; want %q", buf.Bytes(), wantEnc)
+	}
+	f, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	of, ok := f.(*OriginFrame)
+	if !ok {
+		t.Fatalf("got %T; want *OriginFrame", f)
+	}
+	var got []string
+	if err := of.ForeachOrigin(func(o string) error {
+		got = append(got, o)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example", "https://b.example:8443"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("origins = %q; want %q", got, want)
+	}
+}
+
+func TestOriginFrameMalformed(t *testing.T) {
+	fr, _ := testFramer()
+	// An origin entry which claims to be longer than the frame.
+	if err := fr.WriteRawFrame(FrameOrigin, 0, 0, []byte("\x00\x10https://a")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame = %v; want malformed ORIGIN frame to be returned", err)
+	}
+	err = f.(*OriginFrame).ForeachOrigin(func(string) error { return nil })
+	if err == nil {
+		t.Errorf("ForeachOrigin on malformed frame succeeded; want error")
+	}
+}
+
 func TestWritePushPromise(t *testing.T) {
 	pp := PushPromiseParam{
 		StreamID:      42,
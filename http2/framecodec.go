@@ -0,0 +1,217 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http2
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// A FrameCodec parses HTTP/2 frames from caller-owned buffers.
+//
+// Unlike a Framer, a FrameCodec does not read from a connection, does
+// not check the order of frames, and does not decode header blocks.
+// It is intended for programs such as proxies, which forward most
+// frames without interpreting them.
+//
+// A FrameCodec does not allocate when parsing a valid frame.
+// The Frame returned by Parse or ParsePayload is owned by the
+// FrameCodec, and refers to the memory of the caller's buffer.
+// It is only valid until the next call to Parse or ParsePayload,
+// and while the buffer is not modified.
+//
+// The zero value for FrameCodec is ready to use.
+// A FrameCodec must not be used concurrently.
+type FrameCodec struct {
+	// MaxFrameSize is the largest frame payload Parse accepts.
+	// If zero, the maximum payload size permitted by the protocol
+	// (16MB-1) is used.
+	MaxFrameSize uint32
+
+	// CountError, if non-nil, is called on frame parse errors
+	// with a unique error path token.
+	CountError func(errType string)
+
+	cache     frameCache
+	lastFrame Frame
+	errDetail error
+}
+
+// ParseFrameHeader parses a frame header from the first 9 bytes of buf.
+// It returns io.ErrUnexpectedEOF if buf is shorter than a frame header.
+func ParseFrameHeader(buf []byte) (FrameHeader, error) {
+	if len(buf) < frameHeaderLen {
+		return FrameHeader{}, io.ErrUnexpectedEOF
+	}
+	return FrameHeader{
+		Length:   (uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])),
+		Type:     FrameType(buf[3]),
+		Flags:    Flags(buf[4]),
+		StreamID: binary.BigEndian.Uint32(buf[5:]) & (1<<31 - 1),
+		valid:    true,
+	}, nil
+}
+
+// AppendFrameHeader appends the 9 byte encoding of fh to b.
+func AppendFrameHeader(b []byte, fh FrameHeader) []byte {
+	return append(b,
+		byte(fh.Length>>16),
+		byte(fh.Length>>8),
+		byte(fh.Length),
+		byte(fh.Type),
+		byte(fh.Flags),
+		byte(fh.StreamID>>24)&0x7f,
+		byte(fh.StreamID>>16),
+		byte(fh.StreamID>>8),
+		byte(fh.StreamID))
+}
+
+// Parse parses the frame at the start of buf, and returns it along
+// with the number of bytes of buf it occupies.
+//
+// If buf does not contain a complete frame, Parse returns
+// io.ErrUnexpectedEOF. If the frame is larger than MaxFrameSize,
+// Parse returns ErrFrameTooLarge. Other errors may be of type
+// ConnectionError or StreamError.
+func (c *FrameCodec) Parse(buf []byte) (f Frame, n int, err error) {
+	fh, err := ParseFrameHeader(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	if fh.Length > c.maxFrameSize() {
+		return nil, 0, ErrFrameTooLarge
+	}
+	n = frameHeaderLen + int(fh.Length)
+	if len(buf) < n {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	f, err = c.ParsePayload(fh, buf[frameHeaderLen:n])
+	return f, n, err
+}
+
+// ParsePayload parses a frame with header fh and the given payload.
+// The length of payload must equal fh.Length.
+func (c *FrameCodec) ParsePayload(fh FrameHeader, payload []byte) (Frame, error) {
+	c.errDetail = nil
+	if int(fh.Length) != len(payload) {
+		return nil, errors.New("http2: frame payload length does not match header")
+	}
+	countError := c.CountError
+	if countError == nil {
+		countError = func(string) {}
+	}
+	fh.valid = true
+	// Frames returned by a FrameCodec are only valid until the next
+	// call, so all of them can be reused.
+	c.cache.reuse = reuseAllFrames
+	f, err := c.parsePayload(fh, payload, countError)
+	if ce, ok := err.(connError); ok {
+		c.errDetail = errors.New(ce.Reason)
+		return nil, ConnectionError(ce.Code)
+	}
+	return f, err
+}
+
+// ErrorDetail returns a more detailed error of the last error
+// returned by Parse or ParsePayload, like Framer.ErrorDetail.
+func (c *FrameCodec) ErrorDetail() error {
+	return c.errDetail
+}
+
+// parsePayload parses a frame, invalidating the last frame returned.
+func (c *FrameCodec) parsePayload(fh FrameHeader, payload []byte, countError func(string)) (Frame, error) {
+	if c.lastFrame != nil {
+		c.lastFrame.invalidate()
+		c.lastFrame = nil
+	}
+	f, err := typeFrameParser(fh.Type)(&c.cache, fh, countError, payload)
+	if err != nil {
+		return nil, err
+	}
+	c.lastFrame = f
+	return f, nil
+}
+
+func (c *FrameCodec) maxFrameSize() uint32 {
+	if c.MaxFrameSize == 0 || c.MaxFrameSize > maxFrameSize {
+		return maxFrameSize
+	}
+	return c.MaxFrameSize
+}
+
+var errAppendMetaHeaders = errors.New("http2: cannot serialize a MetaHeadersFrame; serialize its HEADERS and CONTINUATION frames instead")
+
+// AppendFrame appends the wire encoding of f to b.
+//
+// f is typically a frame returned by FrameCodec.Parse or
+// Framer.ReadFrame. Padding is not preserved: the PADDED flag is
+// cleared, and the frame is encoded without padding. The frame
+// length is recomputed from the payload.
+func AppendFrame(b []byte, f Frame) ([]byte, error) {
+	fh := f.Header()
+	start := len(b)
+	b = AppendFrameHeader(b, fh)
+	flags := fh.Flags
+	switch f := f.(type) {
+	case *DataFrame:
+		flags &^= FlagDataPadded
+		b = append(b, f.Data()...)
+	case *MetaHeadersFrame:
+		return b[:start], errAppendMetaHeaders
+	case *HeadersFrame:
+		flags &^= FlagHeadersPadded
+		if f.HasPriority() {
+			b = appendPriorityParam(b, f.Priority)
+		}
+		b = append(b, f.HeaderBlockFragment()...)
+	case *PriorityFrame:
+		b = appendPriorityParam(b, f.PriorityParam)
+	case *RSTStreamFrame:
+		b = binary.BigEndian.AppendUint32(b, uint32(f.ErrCode))
+	case *SettingsFrame:
+		f.checkValid()
+		b = append(b, f.p...)
+	case *PushPromiseFrame:
+		flags &^= FlagPushPromisePadded
+		b = binary.BigEndian.AppendUint32(b, f.PromiseID&(1<<31-1))
+		b = append(b, f.HeaderBlockFragment()...)
+	case *PingFrame:
+		b = append(b, f.Data[:]...)
+	case *GoAwayFrame:
+		b = binary.BigEndian.AppendUint32(b, f.LastStreamID&(1<<31-1))
+		b = binary.BigEndian.AppendUint32(b, uint32(f.ErrCode))
+		b = append(b, f.DebugData()...)
+	case *WindowUpdateFrame:
+		b = binary.BigEndian.AppendUint32(b, f.Increment&0x7fffffff)
+	case *ContinuationFrame:
+		b = append(b, f.HeaderBlockFragment()...)
+	case *OriginFrame:
+		f.checkValid()
+		b = append(b, f.p...)
+	case *UnknownFrame:
+		b = append(b, f.Payload()...)
+	default:
+		return b[:start], errors.New("http2: cannot serialize frame of unknown type")
+	}
+	length := len(b) - start - frameHeaderLen
+	if length >= 1<<24 {
+		return b[:start], ErrFrameTooLarge
+	}
+	b[start] = byte(length >> 16)
+	b[start+1] = byte(length >> 8)
+	b[start+2] = byte(length)
+	b[start+4] = byte(flags)
+	return b, nil
+}
+
+func appendPriorityParam(b []byte, p PriorityParam) []byte {
+	v := p.StreamDep
+	if p.Exclusive {
+		v |= 1 << 31
+	}
+	b = binary.BigEndian.AppendUint32(b, v)
+	return append(b, p.Weight)
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http2
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestFrameCodecRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		write func(*Framer) error
+	}{{
+		name:  "data",
+		write: func(fr *Framer) error { return fr.WriteData(1, true, []byte("hello")) },
+	}, {
+		name: "headers",
+		write: func(fr *Framer) error {
+			return fr.WriteHeaders(HeadersFrameParam{
+				StreamID:      3,
+				BlockFragment: []byte("abc"),
+				EndHeaders:    true,
+				Priority:      PriorityParam{StreamDep: 1, Exclusive: true, Weight: 7},
+			})
+		},
+	}, {
+		name:  "priority",
+		write: func(fr *Framer) error { return fr.WritePriority(5, PriorityParam{StreamDep: 3, Weight: 2}) },
+	}, {
+		name:  "rst_stream",
+		write: func(fr *Framer) error { return fr.WriteRSTStream(1, ErrCodeCancel) },
+	}, {
+		name: "settings",
+		write: func(fr *Framer) error {
+			return fr.WriteSettings(Setting{SettingMaxFrameSize, 1 << 20}, Setting{SettingEnablePush, 0})
+		},
+	}, {
+		name:  "settings_ack",
+		write: func(fr *Framer) error { return fr.WriteSettingsAck() },
+	}, {
+		name: "push_promise",
+		write: func(fr *Framer) error {
+			return fr.WritePushPromise(PushPromiseParam{StreamID: 1, PromiseID: 2, BlockFragment: []byte("abc"), EndHeaders: true})
+		},
+	}, {
+		name:  "ping",
+		write: func(fr *Framer) error { return fr.WritePing(true, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}) },
+	}, {
+		name:  "goaway",
+		write: func(fr *Framer) error { return fr.WriteGoAway(7, ErrCodeEnhanceYourCalm, []byte("debug")) },
+	}, {
+		name:  "window_update",
+		write: func(fr *Framer) error { return fr.WriteWindowUpdate(0, 1000) },
+	}, {
+		name:  "continuation",
+		write: func(fr *Framer) error { return fr.WriteContinuation(1, true, []byte("abc")) },
+	}, {
+		name:  "origin",
+		write: func(fr *Framer) error { return fr.WriteOrigin("https://example.com") },
+	}, {
+		name:  "unknown",
+		write: func(fr *Framer) error { return fr.WriteRawFrame(0xfa, 0x3, 9, []byte("raw")) },
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			fr, buf := testFramer()
+			fr.AllowIllegalWrites = true
+			if err := test.write(fr); err != nil {
+				t.Fatal(err)
+			}
+			wire := buf.Bytes()
+
+			var c FrameCodec
+			f, n, err := c.Parse(wire)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if n != len(wire) {
+				t.Errorf("Parse consumed %v bytes, want %v", n, len(wire))
+			}
+			got, err := AppendFrame(nil, f)
+			if err != nil {
+				t.Fatalf("AppendFrame: %v", err)
+			}
+			if !bytes.Equal(got, wire) {
+				t.Errorf("AppendFrame:\ngot  %x\nwant %x", got, wire)
+			}
+		})
+	}
+}
+
+func TestFrameCodecPadding(t *testing.T) {
+	fr, buf := testFramer()
+	if err := fr.WriteDataPadded(1, false, []byte("data"), []byte{0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	var c FrameCodec
+	f, _, err := c.Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := AppendFrame(nil, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr, buf = testFramer()
+	fr.WriteData(1, false, []byte("data"))
+	if want := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("AppendFrame of padded frame:\ngot  %x\nwant %x", got, want)
+	}
+}
+
+func TestFrameCodecShortBuffer(t *testing.T) {
+	fr, buf := testFramer()
+	fr.WriteData(1, true, []byte("hello"))
+	wire := buf.Bytes()
+	var c FrameCodec
+	for _, n := range []int{0, frameHeaderLen - 1, len(wire) - 1} {
+		if _, _, err := c.Parse(wire[:n]); err != io.ErrUnexpectedEOF {
+			t.Errorf("Parse(%v bytes) = %v, want io.ErrUnexpectedEOF", n, err)
+		}
+	}
+}
+
+func TestFrameCodecMaxFrameSize(t *testing.T) {
+	fr, buf := testFramer()
+	fr.WriteData(1, true, make([]byte, 100))
+	c := FrameCodec{MaxFrameSize: 99}
+	if _, _, err := c.Parse(buf.Bytes()); err != ErrFrameTooLarge {
+		t.Errorf("Parse = %v, want ErrFrameTooLarge", err)
+	}
+}
+
+func TestFrameCodecConnError(t *testing.T) {
+	fr, buf := testFramer()
+	fr.AllowIllegalWrites = true
+	fr.WriteData(0, true, []byte("x"))
+	var c FrameCodec
+	var counted []string
+	c.CountError = func(errType string) { counted = append(counted, errType) }
+	if _, _, err := c.Parse(buf.Bytes()); err != ConnectionError(ErrCodeProtocol) {
+		t.Errorf("Parse = %v, want PROTOCOL_ERROR", err)
+	}
+	if c.ErrorDetail() == nil {
+		t.Errorf("ErrorDetail = nil, want detail")
+	}
+	if len(counted) != 1 || counted[0] != "frame_data_stream_0" {
+		t.Errorf("CountError calls = %q, want frame_data_stream_0", counted)
+	}
+}
+
+func TestFrameCodecInvalidatesFrames(t *testing.T) {
+	fr, buf := testFramer()
+	fr.WriteData(1, false, []byte("a"))
+	fr.WriteData(1, false, []byte("b"))
+	var c FrameCodec
+	wire := buf.Bytes()
+	f1, n, err := c.Parse(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Parse(wire[n:]); err != nil {
+		t.Fatal(err)
+	}
+	// The DATA frame is reused, and now holds the second frame.
+	if got := string(f1.(*DataFrame).Data()); got != "b" {
+		t.Errorf("reused DATA frame data = %q, want %q", got, "b")
+	}
+}
+
+func TestFrameCacheZeroValue(t *testing.T) {
+	// A frameCache which was not set up reuses no frames.
+	var fc frameCache
+	if fc.getDataFrame() == fc.getDataFrame() {
+		t.Errorf("zero frameCache reuses DATA frames")
+	}
+	if fc.getHeadersFrame() == fc.getHeadersFrame() {
+		t.Errorf("zero frameCache reuses HEADERS frames")
+	}
+	fc.reuse = reuseDataFrames
+	if fc.getDataFrame() != fc.getDataFrame() {
+		t.Errorf("frameCache with reuseDataFrames does not reuse DATA frames")
+	}
+	if fc.getHeadersFrame() == fc.getHeadersFrame() {
+		t.Errorf("frameCache with reuseDataFrames reuses HEADERS frames")
+	}
+}
+
+func TestFrameCodecAllocs(t *testing.T) {
+	fr, buf := testFramer()
+	fr.WriteData(1, false, []byte("data"))
+	fr.WriteHeaders(HeadersFrameParam{StreamID: 3, BlockFragment: []byte("abc"), EndHeaders: true})
+	fr.WriteWindowUpdate(0, 1000)
+	fr.WritePing(false, [8]byte{})
+	fr.WriteRSTStream(3, ErrCodeCancel)
+	wire := buf.Bytes()
+
+	var c FrameCodec
+	var out []byte
+	allocs := testing.AllocsPerRun(100, func() {
+		b := wire
+		out = out[:0]
+		for len(b) > 0 {
+			f, n, err := c.Parse(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err = AppendFrame(out, f); err != nil {
+				t.Fatal(err)
+			}
+			b = b[n:]
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("allocs per parse and append = %v, want 0", allocs)
+	}
+	if !bytes.Equal(out, wire) {
+		t.Errorf("reserialized frames differ from input")
+	}
+}
+
+func BenchmarkFrameCodecParseData(b *testing.B) {
+	fr, buf := testFramer()
+	fr.WriteData(1, false, make([]byte, 1024))
+	wire := buf.Bytes()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(wire)))
+	var c FrameCodec
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.Parse(wire); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFramerReadData(b *testing.B) {
+	fr, buf := testFramer()
+	fr.WriteData(1, false, make([]byte, 1024))
+	wire := bytes.Clone(buf.Bytes())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(wire)))
+	r := bytes.NewReader(wire)
+	fr = NewFramer(nil, r)
+	for i := 0; i < b.N; i++ {
+		r.Reset(wire)
+		if _, err := fr.ReadFrame(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
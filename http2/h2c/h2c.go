@@ -60,9 +60,12 @@ type h2cHandler struct {
 // to an HTTP/2 connection which is understandable to s.ServeConn. (s.ServeConn
 // understands HTTP/2 except for the h2c part of it.)
 //
-// The first request on an h2c connection is read entirely into memory before
-// the Handler is called. To limit the memory consumed by this request, wrap
-// the result of NewHandler in an http.MaxBytesHandler.
+// When a request upgrades to h2c, its body is read entirely into memory before
+// the connection is upgraded, and delivered to the Handler as stream 1.
+// Bodies larger than s.MaxUploadBufferPerStream (1MiB if unset) are not
+// upgraded; the request is served over HTTP/1.1 instead. To further limit
+// the memory consumed by this request, wrap the result of NewHandler in an
+// http.MaxBytesHandler.
 func NewHandler(h http.Handler, s *http2.Server) http.Handler {
 	return &h2cHandler{
 		Handler: h,
@@ -104,6 +107,21 @@ func (s h2cHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	// Handle Upgrade to h2c (RFC 7540 Section 3.2)
 	if isH2CUpgrade(r.Header) {
+		ok, err := s.bufferUpgradeBody(r)
+		if err != nil {
+			if http2VerboseLogs {
+				log.Printf("h2c: error reading upgrade request body: %v", err)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			if http2VerboseLogs {
+				log.Print("h2c: upgrade request body too large, serving over HTTP/1.1.")
+			}
+			s.Handler.ServeHTTP(w, r)
+			return
+		}
 		conn, settings, err := h2cUpgrade(w, r)
 		if err != nil {
 			if http2VerboseLogs {
@@ -154,18 +172,47 @@ func initH2CWithPriorKnowledge(w http.ResponseWriter) (net.Conn, error) {
 	return nil, errors.New("h2c: invalid client preface")
 }
 
-// h2cUpgrade establishes a h2c connection using the HTTP/1 upgrade (Section 3.2).
-func h2cUpgrade(w http.ResponseWriter, r *http.Request) (_ net.Conn, settings []byte, err error) {
-	settings, err = getH2Settings(r.Header)
+// defaultMaxUpgradeBody is the largest request body buffered during an
+// upgrade when the http2.Server does not set MaxUploadBufferPerStream.
+const defaultMaxUpgradeBody = 1 << 20
+
+// bufferUpgradeBody reads the body of an upgrade request into memory,
+// so it can be delivered on stream 1 after the connection is upgraded.
+// It reports false if the body is too large to buffer, in which case
+// r.Body is replaced with one that yields the complete body and the
+// request should be served over HTTP/1.1.
+func (s h2cHandler) bufferUpgradeBody(r *http.Request) (ok bool, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true, nil
+	}
+	limit := int64(s.s.MaxUploadBufferPerStream)
+	if limit <= 0 {
+		limit = defaultMaxUpgradeBody
+	}
+	if r.ContentLength > limit {
+		return false, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
 	if err != nil {
-		return nil, nil, err
+		return false, err
 	}
+	if int64(len(body)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return false, nil
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return true, nil
+}
 
-	body, err := io.ReadAll(r.Body)
+// h2cUpgrade establishes a h2c connection using the HTTP/1 upgrade (Section 3.2).
+func h2cUpgrade(w http.ResponseWriter, r *http.Request) (_ net.Conn, settings []byte, err error) {
+	settings, err = getH2Settings(r.Header)
 	if err != nil {
 		return nil, nil, err
 	}
-	r.Body = io.NopCloser(bytes.NewBuffer(body))
 
 	rc := http.NewResponseController(w)
 	conn, rw, err := rc.Hijack()
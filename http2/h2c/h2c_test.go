@@ -5,6 +5,7 @@
 package h2c
 
 import (
+	"bufio"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -169,3 +170,188 @@ func TestMaxBytesHandler(t *testing.T) {
 		t.Errorf("resp.StatusCode = %v, want %v", got, want)
 	}
 }
+
+func TestTransportUpgrade(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		fmt.Fprintf(w, "%v %v %q", r.ProtoMajor, r.Method, body)
+	})
+	h1s := httptest.NewServer(NewHandler(handler, &http2.Server{}))
+	defer h1s.Close()
+
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	for i, want := range []string{
+		`1 POST "request body"`, // sent over HTTP/1.1 before the upgrade
+		`2 POST "request body"`, // sent over the upgraded connection
+	} {
+		req, err := http.NewRequest("POST", h1s.URL, strings.NewReader("request body"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("request %v: %v", i, err)
+		}
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("request %v: got body %q, want %q", i, got, want)
+		}
+		if res.ProtoMajor != 2 {
+			t.Errorf("request %v: got proto %v, want HTTP/2", i, res.Proto)
+		}
+	}
+	if got := len(tr.conns); got != 1 {
+		t.Errorf("got %v cached connections, want 1", got)
+	}
+}
+
+func TestTransportPriorKnowledge(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v %v", r.ProtoMajor, r.URL.Path)
+	})
+	h1s := httptest.NewServer(NewHandler(handler, &http2.Server{}))
+	defer h1s.Close()
+
+	tr := &Transport{PriorKnowledge: true}
+	defer tr.CloseIdleConnections()
+	res, err := (&http.Client{Transport: tr}).Get(h1s.URL + "/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2 /path"; string(got) != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+func TestTransportUpgradeDeclined(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v", r.ProtoMajor)
+	})
+	// A plain HTTP/1.1 server ignores the upgrade.
+	h1s := httptest.NewServer(handler)
+	defer h1s.Close()
+
+	tr := &Transport{}
+	res, err := (&http.Client{Transport: tr}).Get(h1s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1"; string(got) != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+	if got := len(tr.conns); got != 0 {
+		t.Errorf("got %v cached connections, want 0", got)
+	}
+}
+
+func TestUpgradeLargeBody(t *testing.T) {
+	const limit = 16
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		fmt.Fprintf(w, "%v %v", r.ProtoMajor, len(body))
+	})
+	h1s := httptest.NewServer(NewHandler(handler, &http2.Server{
+		MaxUploadBufferPerStream: limit,
+	}))
+	defer h1s.Close()
+
+	for _, test := range []struct {
+		size      int
+		want      string
+		wantProto int
+	}{
+		{limit, "1 16", 2}, // upgraded
+		{limit + 1, "1 17", 1},
+		{4 * limit, "1 64", 1},
+	} {
+		// Wrap the body in a struct{io.Reader} to send it without a Content-Length.
+		body := struct{ io.Reader }{strings.NewReader(strings.Repeat("x", test.size))}
+		req, err := http.NewRequest("POST", h1s.URL, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := &Transport{}
+		res, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("size %v: %v", test.size, err)
+		}
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		tr.CloseIdleConnections()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("size %v: got body %q, want %q", test.size, got, test.want)
+		}
+		if res.ProtoMajor != test.wantProto {
+			t.Errorf("size %v: got proto %v, want HTTP/%v", test.size, res.Proto, test.wantProto)
+		}
+	}
+}
+
+func TestTransportUpgradeOtherProtocol(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	after := make(chan []byte, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			close(after)
+			return
+		}
+		defer c.Close()
+		br := bufio.NewReader(c)
+		if _, err := http.ReadRequest(br); err != nil {
+			t.Error(err)
+		}
+		io.WriteString(c, "HTTP/1.1 101 Switching Protocols\r\n"+
+			"Connection: Upgrade\r\n"+
+			"Upgrade: websocket\r\n\r\n")
+		// The client should close the connection without sending
+		// any HTTP/2 frames.
+		b, _ := io.ReadAll(br)
+		after <- b
+	}()
+
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	req, err := http.NewRequest("GET", "http://"+ln.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.RoundTrip(req); err != errUnexpectedUpgrade {
+		t.Errorf("RoundTrip() = %v, want %v", err, errUnexpectedUpgrade)
+	}
+	if b := <-after; len(b) > 0 {
+		t.Errorf("client sent %q after a switch to another protocol", b)
+	}
+	if got := len(tr.conns); got != 0 {
+		t.Errorf("got %v cached connections, want 0", got)
+	}
+}
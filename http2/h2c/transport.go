@@ -0,0 +1,255 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package h2c
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+)
+
+// Transport is an http.RoundTripper that makes unencrypted HTTP/2
+// ("h2c") requests to "http" URLs.
+//
+// By default, a Transport begins each new connection with an HTTP/1.1
+// request carrying an "Upgrade: h2c" header (RFC 7540, Section 3.2).
+// If the server agrees to the upgrade, the response to that request and
+// all subsequent requests on the connection use HTTP/2. If it does not,
+// the HTTP/1.1 response is returned and the connection is closed once
+// its body has been read.
+//
+// When PriorKnowledge is set, the Transport instead assumes the server
+// supports h2c and starts each connection with the HTTP/2 connection
+// preface (RFC 7540, Section 3.4).
+type Transport struct {
+	// DialContext specifies the dial function for creating
+	// unencrypted TCP connections.
+	// If DialContext is nil, a net.Dialer is used.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// PriorKnowledge, if true, skips the HTTP/1.1 upgrade and
+	// speaks HTTP/2 immediately on new connections.
+	PriorKnowledge bool
+
+	// HTTP2 configures the HTTP/2 connections created by the Transport.
+	// If nil, a zero http2.Transport is used.
+	// Its dial and TLS settings are ignored.
+	HTTP2 *http2.Transport
+
+	mu    sync.Mutex
+	conns map[string][]*http2.ClientConn // keyed by host:port
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL == nil || req.URL.Scheme != "http" {
+		return nil, errors.New("h2c: unsupported scheme")
+	}
+	addr := authorityAddr(req.URL.Host)
+	if cc := t.cachedConn(addr); cc != nil {
+		return cc.RoundTrip(req)
+	}
+	c, err := t.dial(req.Context(), addr)
+	if err != nil {
+		return nil, err
+	}
+	if t.PriorKnowledge {
+		cc, err := t.http2().NewClientConn(c)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if !cc.ReserveNewRequest() {
+			// A new connection always has room for one request.
+			return nil, errors.New("h2c: new connection refused request")
+		}
+		t.addConn(addr, cc)
+		return cc.RoundTrip(req)
+	}
+	return t.upgrade(req, addr, c)
+}
+
+// CloseIdleConnections closes any connections which were previously
+// connected from previous requests but are now sitting idle.
+// It does not interrupt any connections currently in use.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for addr, conns := range t.conns {
+		kept := conns[:0]
+		for _, cc := range conns {
+			if cc.State().StreamsActive == 0 {
+				cc.Close()
+				continue
+			}
+			kept = append(kept, cc)
+		}
+		if len(kept) == 0 {
+			delete(t.conns, addr)
+		} else {
+			t.conns[addr] = kept
+		}
+	}
+}
+
+func (t *Transport) http2() *http2.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.HTTP2 == nil {
+		t.HTTP2 = &http2.Transport{}
+	}
+	return t.HTTP2
+}
+
+func (t *Transport) dial(ctx context.Context, addr string) (net.Conn, error) {
+	if t.DialContext != nil {
+		return t.DialContext(ctx, "tcp", addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// cachedConn returns an existing connection to addr with room for
+// a new request, or nil if there is none.
+func (t *Transport) cachedConn(addr string) *http2.ClientConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conns := t.conns[addr]
+	kept := conns[:0]
+	var found *http2.ClientConn
+	for _, cc := range conns {
+		st := cc.State()
+		if st.Closed || st.Closing {
+			continue
+		}
+		kept = append(kept, cc)
+		if found == nil && cc.ReserveNewRequest() {
+			found = cc
+		}
+	}
+	if len(kept) == 0 {
+		delete(t.conns, addr)
+	} else {
+		t.conns[addr] = kept
+	}
+	return found
+}
+
+func (t *Transport) addConn(addr string, cc *http2.ClientConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[string][]*http2.ClientConn)
+	}
+	t.conns[addr] = append(t.conns[addr], cc)
+}
+
+var errUnexpectedUpgrade = errors.New("h2c: server switched to a protocol other than h2c")
+
+// upgradeSettings is the HTTP2-Settings header sent with upgrade requests:
+// a SETTINGS payload containing SETTINGS_ENABLE_PUSH = 0.
+var upgradeSettings = base64.RawURLEncoding.EncodeToString([]byte{0, 2, 0, 0, 0, 0})
+
+// upgrade sends req on c as an HTTP/1.1 request asking to upgrade to h2c.
+func (t *Transport) upgrade(req *http.Request, addr string, c net.Conn) (*http.Response, error) {
+	ctx := req.Context()
+	stop := context.AfterFunc(ctx, func() {
+		// Unblock any pending reads or writes.
+		c.SetDeadline(aLongTimeAgo)
+	})
+	res, br, err := sendUpgrade(req, c)
+	if !stop() {
+		c.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		// The server declined the upgrade; the connection
+		// can't be used for any further h2c requests.
+		res.Body = &closeConnBody{ReadCloser: res.Body, c: c}
+		return res, nil
+	}
+	res.Body.Close()
+	if !httpguts.HeaderValuesContainsToken(res.Header["Upgrade"], "h2c") {
+		// The server switched to some other protocol.
+		c.Close()
+		return nil, errUnexpectedUpgrade
+	}
+	if br.Buffered() > 0 {
+		c = &bufConn{c, br}
+	}
+	cc, res, err := t.http2().NewUpgradedClientConn(c, req)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	t.addConn(addr, cc)
+	return res, nil
+}
+
+// sendUpgrade writes req to c with h2c upgrade headers and reads the
+// final response, skipping any 1xx informational responses.
+func sendUpgrade(req *http.Request, c net.Conn) (*http.Response, *bufio.Reader, error) {
+	ureq := req.Clone(req.Context())
+	ureq.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+	ureq.Header.Set("Upgrade", "h2c")
+	ureq.Header.Set("HTTP2-Settings", upgradeSettings)
+	bw := bufio.NewWriter(c)
+	if err := ureq.Write(bw); err != nil {
+		return nil, nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(c)
+	for {
+		res, err := http.ReadResponse(br, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		if res.StatusCode == http.StatusSwitchingProtocols || res.StatusCode >= 200 {
+			return res, br, nil
+		}
+		res.Body.Close()
+	}
+}
+
+// closeConnBody closes the underlying connection when the body is closed.
+type closeConnBody struct {
+	io.ReadCloser
+	c net.Conn
+}
+
+func (b *closeConnBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.c.Close()
+	return err
+}
+
+// authorityAddr returns a host:port for host, adding the default
+// "http" port if host does not specify one.
+func authorityAddr(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if len(host) > 0 && host[0] == '[' && host[len(host)-1] == ']' {
+		host = host[1 : len(host)-1]
+	}
+	return net.JoinHostPort(host, "80")
+}
+
+var aLongTimeAgo = time.Unix(1, 0)
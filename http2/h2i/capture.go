@@ -0,0 +1,387 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// A capture file records the frames exchanged with a server, one
+// record per frame, in the order they were sent or received.
+//
+// Each record starts with a line describing the frame, prefixed
+// with "> " for frames sent by h2i and "< " for frames received
+// from the server. The remaining lines of the record have the same
+// prefix followed by two spaces, and describe the frame's payload.
+// HPACK-encoded header blocks are decoded:
+//
+//	> HEADERS stream=1 flags=END_STREAM|END_HEADERS
+//	>   :authority = "go.dev"
+//	>   :method = "GET"
+//	< HEADERS stream=1 flags=END_HEADERS
+//	<   :status = "200"
+//	< DATA stream=1 flags=END_STREAM
+//	<   data = "hello"
+//
+// Lines starting with "#" are comments.
+
+// A record is the description of a single frame.
+type record struct {
+	sent     bool
+	streamID uint32
+	lines    []string // frame summary, followed by payload lines
+}
+
+func (r record) write(w io.Writer) {
+	prefix := "< "
+	if r.sent {
+		prefix = "> "
+	}
+	for i, line := range r.lines {
+		if i > 0 {
+			line = "  " + line
+		}
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+// readCapture reads the records in a capture file.
+func readCapture(r io.Reader) ([]record, error) {
+	var recs []record
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var sent bool
+		switch {
+		case strings.HasPrefix(line, "> "):
+			sent = true
+		case strings.HasPrefix(line, "< "):
+			sent = false
+		default:
+			return nil, fmt.Errorf("line %d: missing direction prefix", lineNum)
+		}
+		line = line[2:]
+		if body, ok := strings.CutPrefix(line, "  "); ok {
+			if len(recs) == 0 || recs[len(recs)-1].sent != sent {
+				return nil, fmt.Errorf("line %d: payload line without frame", lineNum)
+			}
+			r := &recs[len(recs)-1]
+			r.lines = append(r.lines, body)
+			continue
+		}
+		var typ string
+		var streamID uint32
+		if _, err := fmt.Sscanf(line, "%s stream=%d", &typ, &streamID); err != nil {
+			return nil, fmt.Errorf("line %d: invalid frame summary %q", lineNum, line)
+		}
+		recs = append(recs, record{
+			sent:     sent,
+			streamID: streamID,
+			lines:    []string{line},
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// frameLog tracks the frames exchanged with the server.
+// It records them to the capture file, if any, and keeps track of
+// which streams opened by h2i are still awaiting a response.
+type frameLog struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	capture   *bufio.Writer // nil if not capturing
+	recv      []record      // received frames
+	open      map[uint32]bool
+	sawGoAway bool
+
+	sendBuf bytes.Buffer
+	sendFr  *http2.Framer
+	sendDec *hpack.Decoder
+	recvDec *hpack.Decoder
+	fields  []hpack.HeaderField // scratch space for decoded headers
+}
+
+func newFrameLog(capture io.Writer) *frameLog {
+	l := &frameLog{
+		open: make(map[uint32]bool),
+	}
+	l.cond = sync.NewCond(&l.mu)
+	if capture != nil {
+		l.capture = bufio.NewWriter(capture)
+	}
+	l.sendFr = http2.NewFramer(nil, &l.sendBuf)
+	l.sendFr.SetMaxReadFrameSize(16<<20 - 1)
+	// TODO: respect SETTINGS_HEADER_TABLE_SIZE, as with h2i.hdec.
+	tableSize := uint32(4 << 10)
+	emit := func(f hpack.HeaderField) { l.fields = append(l.fields, f) }
+	l.sendDec = hpack.NewDecoder(tableSize, emit)
+	l.recvDec = hpack.NewDecoder(tableSize, emit)
+	return l
+}
+
+// sentWriter is an io.Writer which logs the frames written to w.
+type sentWriter struct {
+	w   io.Writer
+	log *frameLog
+}
+
+func (sw sentWriter) Write(p []byte) (int, error) {
+	// Log frames before writing them, so the capture file records
+	// them before any response to them.
+	if err := sw.log.sent(p); err != nil {
+		return 0, err
+	}
+	return sw.w.Write(p)
+}
+
+// sent logs the frames in p, which is about to be written to the server.
+// p may contain partial frames, which are logged once complete.
+func (l *frameLog) sent(p []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sendBuf.Write(p)
+	for {
+		buf := l.sendBuf.Bytes()
+		if len(buf) < 9 {
+			return nil
+		}
+		fh, err := http2.ReadFrameHeader(bytes.NewReader(buf))
+		if err != nil {
+			return err
+		}
+		if len(buf) < 9+int(fh.Length) {
+			return nil
+		}
+		f, err := l.sendFr.ReadFrame()
+		if err != nil {
+			return fmt.Errorf("capturing sent frame: %v", err)
+		}
+		switch f.Header().Type {
+		case http2.FrameHeaders:
+			if f.Header().StreamID%2 == 1 {
+				l.open[f.Header().StreamID] = true
+			}
+		}
+		l.add(record{
+			sent:     true,
+			streamID: f.Header().StreamID,
+			lines:    l.describe(f, l.sendDec),
+		})
+	}
+}
+
+// received logs a frame received from the server.
+func (l *frameLog) received(f http2.Frame) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fh := f.Header()
+	switch f := f.(type) {
+	case *http2.DataFrame:
+		if f.StreamEnded() {
+			delete(l.open, fh.StreamID)
+		}
+	case *http2.HeadersFrame:
+		if f.StreamEnded() {
+			delete(l.open, fh.StreamID)
+		}
+	case *http2.RSTStreamFrame:
+		delete(l.open, fh.StreamID)
+	case *http2.GoAwayFrame:
+		l.sawGoAway = true
+	}
+	r := record{
+		streamID: fh.StreamID,
+		lines:    l.describe(f, l.recvDec),
+	}
+	l.recv = append(l.recv, r)
+	l.add(r)
+	l.cond.Broadcast()
+}
+
+// add writes r to the capture file.
+func (l *frameLog) add(r record) {
+	if l.capture == nil {
+		return
+	}
+	r.write(l.capture)
+	l.capture.Flush()
+}
+
+// receivedRecords returns a copy of the records of the received frames.
+func (l *frameLog) receivedRecords() []record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]record(nil), l.recv...)
+}
+
+// waitFor waits until cond returns true, or until the timeout expires.
+// cond is called with l.mu held. waitFor reports whether cond became true.
+func (l *frameLog) waitFor(timeout time.Duration, cond func() bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expired := false
+	t := time.AfterFunc(timeout, func() {
+		l.mu.Lock()
+		expired = true
+		l.mu.Unlock()
+		l.cond.Broadcast()
+	})
+	defer t.Stop()
+	for !cond() {
+		if expired || l.sawGoAway {
+			return false
+		}
+		l.cond.Wait()
+	}
+	return true
+}
+
+// waitStreams waits until all streams opened by h2i have been closed
+// by the server, or until the timeout expires.
+func (l *frameLog) waitStreams(timeout time.Duration) bool {
+	return l.waitFor(timeout, func() bool { return len(l.open) == 0 })
+}
+
+// describe returns the record lines for f.
+// Header blocks are decoded with dec.
+func (l *frameLog) describe(f http2.Frame, dec *hpack.Decoder) []string {
+	fh := f.Header()
+	lines := []string{fmt.Sprintf("%v stream=%d flags=%s", fh.Type, fh.StreamID, flagString(fh))}
+	addf := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	addHeaders := func(frag []byte) {
+		l.fields = l.fields[:0]
+		if _, err := dec.Write(frag); err != nil {
+			addf("hpack error = %q", err.Error())
+		}
+		for _, hf := range l.fields {
+			addf("%s = %q", hf.Name, hf.Value)
+		}
+	}
+	switch f := f.(type) {
+	case *http2.SettingsFrame:
+		f.ForeachSetting(func(s http2.Setting) error {
+			addf("%v = %d", s.ID, s.Val)
+			return nil
+		})
+	case *http2.PingFrame:
+		addf("data = %q", f.Data[:])
+	case *http2.WindowUpdateFrame:
+		addf("increment = %d", f.Increment)
+	case *http2.GoAwayFrame:
+		addf("last_stream = %d", f.LastStreamID)
+		addf("code = %d (%v)", f.ErrCode, f.ErrCode)
+		if debug := f.DebugData(); len(debug) > 0 {
+			addf("debug = %q", debug)
+		}
+	case *http2.RSTStreamFrame:
+		addf("code = %d (%v)", f.ErrCode, f.ErrCode)
+	case *http2.DataFrame:
+		addf("data = %q", f.Data())
+	case *http2.PriorityFrame:
+		addf("priority = %d %d %v", f.StreamDep, f.Weight, f.Exclusive)
+	case *http2.HeadersFrame:
+		if f.HasPriority() {
+			addf("priority = %d %d %v", f.Priority.StreamDep, f.Priority.Weight, f.Priority.Exclusive)
+		}
+		addHeaders(f.HeaderBlockFragment())
+	case *http2.ContinuationFrame:
+		addHeaders(f.HeaderBlockFragment())
+	case *http2.PushPromiseFrame:
+		addf("promise_stream = %d", f.PromiseID)
+		addHeaders(f.HeaderBlockFragment())
+	case *http2.OriginFrame:
+		if err := f.ForeachOrigin(func(o string) error {
+			addf("origin = %q", o)
+			return nil
+		}); err != nil {
+			addf("error = %q", err.Error())
+		}
+	case *http2.UnknownFrame:
+		addf("payload = %q", f.Payload())
+	}
+	return lines
+}
+
+var flagNames = map[http2.FrameType][]struct {
+	flag http2.Flags
+	name string
+}{
+	http2.FrameData: {
+		{http2.FlagDataEndStream, "END_STREAM"},
+		{http2.FlagDataPadded, "PADDED"},
+	},
+	http2.FrameHeaders: {
+		{http2.FlagHeadersEndStream, "END_STREAM"},
+		{http2.FlagHeadersEndHeaders, "END_HEADERS"},
+		{http2.FlagHeadersPadded, "PADDED"},
+		{http2.FlagHeadersPriority, "PRIORITY"},
+	},
+	http2.FrameSettings: {
+		{http2.FlagSettingsAck, "ACK"},
+	},
+	http2.FramePing: {
+		{http2.FlagPingAck, "ACK"},
+	},
+	http2.FrameContinuation: {
+		{http2.FlagContinuationEndHeaders, "END_HEADERS"},
+	},
+	http2.FramePushPromise: {
+		{http2.FlagPushPromiseEndHeaders, "END_HEADERS"},
+		{http2.FlagPushPromisePadded, "PADDED"},
+	},
+}
+
+// flagString returns the names of the flags set in fh, separated by "|".
+func flagString(fh http2.FrameHeader) string {
+	var names []string
+	rest := fh.Flags
+	for _, fl := range flagNames[fh.Type] {
+		if fh.Flags.Has(fl.flag) {
+			names = append(names, fl.name)
+			rest &^= fl.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint8(rest)))
+	}
+	return strings.Join(names, "|")
+}
+
+// openCapture creates the capture file named by the -capture flag.
+// It returns a nil file if no capture was requested.
+func openCapture(host string) (*os.File, error) {
+	if *flagCapture == "" {
+		return nil, nil
+	}
+	f, err := os.Create(*flagCapture)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(f, "# h2i capture of %s at %v\n", host, time.Now().UTC().Format(time.RFC3339))
+	return f, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCaptureRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		recs []record
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "request and response",
+			recs: []record{
+				{sent: true, streamID: 1, lines: []string{
+					"HEADERS stream=1 flags=END_STREAM|END_HEADERS",
+					`:authority = "go.dev"`,
+					`:method = "GET"`,
+				}},
+				{sent: false, streamID: 1, lines: []string{
+					"HEADERS stream=1 flags=END_HEADERS",
+					`:status = "200"`,
+				}},
+				{sent: false, streamID: 1, lines: []string{
+					"DATA stream=1 flags=END_STREAM",
+					`data = "hello"`,
+				}},
+			},
+		},
+		{
+			name: "frames without payload",
+			recs: []record{
+				{sent: true, streamID: 0, lines: []string{"SETTINGS stream=0 flags=ACK"}},
+				{sent: false, streamID: 0, lines: []string{"SETTINGS stream=0 flags=ACK"}},
+			},
+		},
+		{
+			name: "payload lines with leading spaces",
+			recs: []record{
+				{sent: false, streamID: 3, lines: []string{
+					"DATA stream=3 flags=",
+					`  indented = "x"`,
+				}},
+			},
+		},
+	} {
+		var buf bytes.Buffer
+		for _, r := range test.recs {
+			r.write(&buf)
+		}
+		got, err := readCapture(&buf)
+		if err != nil {
+			t.Errorf("%s: readCapture: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.recs) {
+			t.Errorf("%s: readCapture of\n%s\ngot  %+v\nwant %+v", test.name, buf.String(), got, test.recs)
+		}
+	}
+}
+
+func TestReadCapture(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		in      string
+		want    []record
+		wantErr string
+	}{
+		{
+			name: "comments and blank lines",
+			in: "# a comment\n" +
+				"\n" +
+				"> PING stream=0 flags=\n" +
+				">   data = \"12345678\"\n" +
+				"# another comment\n" +
+				"< PING stream=0 flags=ACK\n",
+			want: []record{
+				{sent: true, streamID: 0, lines: []string{"PING stream=0 flags=", `data = "12345678"`}},
+				{sent: false, streamID: 0, lines: []string{"PING stream=0 flags=ACK"}},
+			},
+		},
+		{
+			name:    "missing direction",
+			in:      "PING stream=0 flags=\n",
+			wantErr: "line 1: missing direction prefix",
+		},
+		{
+			name:    "payload without frame",
+			in:      ">   data = \"x\"\n",
+			wantErr: "line 1: payload line without frame",
+		},
+		{
+			name:    "payload in the other direction",
+			in:      "> PING stream=0 flags=\n<   data = \"x\"\n",
+			wantErr: "line 2: payload line without frame",
+		},
+		{
+			name:    "invalid summary",
+			in:      "> PING\n",
+			wantErr: `line 1: invalid frame summary "PING"`,
+		},
+	} {
+		got, err := readCapture(strings.NewReader(test.in))
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s: readCapture: got error %v, want %q", test.name, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: readCapture: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: readCapture:\ngot  %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -17,6 +17,19 @@ Interactive commands in the console: (all parts case-insensitive)
 	settings ack
 	settings FOO=n BAR=z
 	headers      (open a new stream by typing HTTP/1.1)
+	wait [duration] (wait for the server to end all open streams)
+
+With -script, commands are read from a file instead of the console.
+The lines of the HTTP/1.1 request for a headers command follow it,
+ending with a blank line.
+
+With -capture, every frame sent and received is recorded to a file,
+with header blocks decoded. With -replay, the frames sent in a capture
+file are sent again, and the frames received in response are compared
+with those in the capture:
+
+	$ h2i -script=req.txt -capture=golden.txt go.dev
+	$ h2i -replay=golden.txt -dial=staging:443 go.dev
 */
 package main
 
@@ -35,6 +48,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/hpack"
@@ -47,6 +61,11 @@ var (
 	flagInsecure  = flag.Bool("insecure", false, "Whether to skip TLS cert validation")
 	flagSettings  = flag.String("settings", "empty", "comma-separated list of KEY=value settings for the initial SETTINGS frame. The magic value 'empty' sends an empty initial settings frame, and the magic value 'omit' causes no initial settings frame to be sent.")
 	flagDial      = flag.String("dial", "", "optional ip:port to dial, to connect to a host:port but use a different SNI name (including a SNI name without DNS)")
+	flagScript    = flag.String("script", "", "optional file to read commands from, instead of the interactive console")
+	flagCapture   = flag.String("capture", "", "optional file to record all frames sent and received to")
+	flagReplay    = flag.String("replay", "", "optional capture file to replay; the frames received are compared with those in the capture")
+	flagIgnore    = flag.String("ignore", "date", "comma-separated list of header names whose values are not compared by -replay")
+	flagTimeout   = flag.Duration("timeout", 5*time.Second, "how long -replay and the wait command wait for responses from the server")
 )
 
 type command struct {
@@ -75,6 +94,7 @@ var commands = map[string]command{
 	},
 	"quit":    {run: (*h2i).cmdQuit},
 	"headers": {run: (*h2i).cmdHeaders},
+	"wait":    {run: (*h2i).cmdWait},
 }
 
 func usage() {
@@ -103,7 +123,9 @@ type h2i struct {
 	host   string
 	tc     *tls.Conn
 	framer *http2.Framer
-	term   *term.Terminal
+	term   *term.Terminal // nil if not interactive
+	script *bufio.Scanner // nil if interactive
+	log    *frameLog
 
 	// owned by the command loop:
 	streamID uint32
@@ -179,7 +201,43 @@ func (app *h2i) Main() error {
 		return err
 	}
 
-	app.framer = http2.NewFramer(tc, tc)
+	capture, err := openCapture(app.host)
+	if err != nil {
+		return err
+	}
+	if capture != nil {
+		defer capture.Close()
+		app.log = newFrameLog(capture)
+	} else {
+		app.log = newFrameLog(nil)
+	}
+	app.framer = http2.NewFramer(sentWriter{tc, app.log}, tc)
+
+	switch {
+	case *flagReplay != "":
+		f, err := os.Open(*flagReplay)
+		if err != nil {
+			return err
+		}
+		recs, err := readCapture(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", *flagReplay, err)
+		}
+		go app.readFrames()
+		return app.replay(recs)
+	case *flagScript != "":
+		f, err := os.Open(*flagScript)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		app.script = bufio.NewScanner(f)
+		errc := make(chan error, 2)
+		go func() { errc <- app.readFrames() }()
+		go func() { errc <- app.readConsole() }()
+		return <-errc
+	}
 
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
 	if err != nil {
@@ -251,9 +309,30 @@ func (app *h2i) Main() error {
 }
 
 func (app *h2i) logf(format string, args ...interface{}) {
+	if app.term == nil {
+		fmt.Fprintf(os.Stdout, format+"\n", args...)
+		return
+	}
 	fmt.Fprintf(app.term, format+"\r\n", args...)
 }
 
+// readLine reads a line from the script, or from the console
+// if there is no script.
+func (app *h2i) readLine() (string, error) {
+	if app.script == nil {
+		return app.term.ReadLine()
+	}
+	if !app.script.Scan() {
+		if err := app.script.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	line := app.script.Text()
+	app.logf("h2i> %s", line)
+	return line, nil
+}
+
 func (app *h2i) readConsole() error {
 	if s := *flagSettings; s != "omit" {
 		var args []string
@@ -268,15 +347,19 @@ func (app *h2i) readConsole() error {
 	}
 
 	for {
-		line, err := app.term.ReadLine()
+		line, err := app.readLine()
 		if err == io.EOF {
+			if app.script != nil {
+				// Give the server a chance to respond to the end of the script.
+				return app.cmdWait(nil)
+			}
 			return nil
 		}
 		if err != nil {
-			return fmt.Errorf("term.ReadLine: %v", err)
+			return fmt.Errorf("reading command: %v", err)
 		}
 		f := strings.Fields(line)
-		if len(f) == 0 {
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
 			continue
 		}
 		cmd, args := f[0], f[1:]
@@ -357,6 +440,10 @@ func (a *h2i) cmdSettings(args []string) error {
 }
 
 func settingByName(name string) (http2.SettingID, bool) {
+	if n, ok := strings.CutPrefix(name, "UNKNOWN_SETTING_"); ok {
+		id, err := strconv.ParseUint(n, 10, 16)
+		return http2.SettingID(id), err == nil
+	}
 	for _, sid := range [...]http2.SettingID{
 		http2.SettingHeaderTableSize,
 		http2.SettingEnablePush,
@@ -394,10 +481,12 @@ func (app *h2i) cmdHeaders(args []string) error {
 		return nil
 	}
 	var h1req bytes.Buffer
-	app.term.SetPrompt("(as HTTP/1.1)> ")
-	defer app.term.SetPrompt("h2i> ")
+	if app.term != nil {
+		app.term.SetPrompt("(as HTTP/1.1)> ")
+		defer app.term.SetPrompt("h2i> ")
+	}
 	for {
-		line, err := app.term.ReadLine()
+		line, err := app.readLine()
 		if err != nil {
 			return err
 		}
@@ -431,12 +520,33 @@ func (app *h2i) cmdHeaders(args []string) error {
 	})
 }
 
+func (app *h2i) cmdWait(args []string) error {
+	if len(args) > 1 {
+		app.logf("invalid WAIT usage: only accepts 0 or 1 args")
+		return nil
+	}
+	timeout := *flagTimeout
+	if len(args) == 1 {
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			app.logf("Error: invalid duration %q", args[0])
+			return nil
+		}
+		timeout = d
+	}
+	if !app.log.waitStreams(timeout) {
+		app.logf("Streams still open after waiting %v", timeout)
+	}
+	return nil
+}
+
 func (app *h2i) readFrames() error {
 	for {
 		f, err := app.framer.ReadFrame()
 		if err != nil {
 			return fmt.Errorf("ReadFrame: %v", err)
 		}
+		app.log.received(f)
 		app.logf("%v", f)
 		switch f := f.(type) {
 		case *http2.PingFrame:
@@ -0,0 +1,314 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// errReplayDiffers is returned by replay when the server's responses
+// differ from those in the capture.
+var errReplayDiffers = fmt.Errorf("replayed responses differ from capture")
+
+// replay sends the frames sent in a capture file, and compares the
+// frames received in response with those in the capture.
+//
+// Before sending each frame, replay waits until as many frames have
+// been received as had been when the frame was originally sent,
+// or until the -timeout expires.
+func (app *h2i) replay(recs []record) error {
+	var want []record
+	for _, r := range recs {
+		if !r.sent {
+			want = append(want, r)
+			continue
+		}
+		n := len(want)
+		if !app.log.waitFor(*flagTimeout, func() bool { return len(app.log.recv) >= n }) {
+			app.logf("timeout waiting for %d frames before sending %v", n, r.lines[0])
+		}
+		if err := app.writeRecord(r); err != nil {
+			return fmt.Errorf("replaying %q: %v", r.lines[0], err)
+		}
+	}
+	n := len(want)
+	app.log.waitFor(*flagTimeout, func() bool {
+		return len(app.log.recv) >= n && len(app.log.open) == 0
+	})
+
+	ignore := make(map[string]bool)
+	for _, name := range strings.Split(*flagIgnore, ",") {
+		if name != "" {
+			ignore[strings.ToLower(name)] = true
+		}
+	}
+	if diffRecords(os.Stdout, want, app.log.receivedRecords(), ignore) {
+		return errReplayDiffers
+	}
+	app.logf("replayed responses match capture")
+	return nil
+}
+
+// writeRecord writes the frame described by r.
+func (app *h2i) writeRecord(r record) error {
+	var typ, flags string
+	var streamID uint32
+	if _, err := fmt.Sscanf(r.lines[0], "%s stream=%d flags=%s", &typ, &streamID, &flags); err != nil {
+		// flags= may be empty.
+		if _, err := fmt.Sscanf(r.lines[0], "%s stream=%d", &typ, &streamID); err != nil {
+			return err
+		}
+	}
+	hasFlag := func(name string) bool {
+		return slices.Contains(strings.Split(flags, "|"), name)
+	}
+	fields := make(map[string]string)
+	var headers []hpack.HeaderField
+	for _, line := range r.lines[1:] {
+		k, v, ok := strings.Cut(line, " = ")
+		if !ok {
+			return fmt.Errorf("invalid payload line %q", line)
+		}
+		fields[k] = v
+		if s, err := strconv.Unquote(v); err == nil {
+			headers = append(headers, hpack.HeaderField{Name: k, Value: s})
+		}
+	}
+	unquote := func(k string) ([]byte, error) {
+		s, err := strconv.Unquote(fields[k])
+		return []byte(s), err
+	}
+	parseUint := func(k string) (uint32, error) {
+		v, _, _ := strings.Cut(fields[k], " ")
+		n, err := strconv.ParseUint(v, 10, 32)
+		return uint32(n), err
+	}
+	// encodeHeaders returns the header block fragment of the frame.
+	// Each frame of a header block split across several frames
+	// records the fields decoded from its own fragment, so encoding
+	// them in turn with the same encoder rebuilds a valid block.
+	encodeHeaders := func() []byte {
+		app.hbuf.Reset()
+		for _, hf := range headers {
+			app.henc.WriteField(hf)
+		}
+		return app.hbuf.Bytes()
+	}
+
+	fr := app.framer
+	switch typ {
+	case "SETTINGS":
+		if hasFlag("ACK") {
+			return fr.WriteSettingsAck()
+		}
+		var settings []http2.Setting
+		for _, line := range r.lines[1:] {
+			name, val, _ := strings.Cut(line, " = ")
+			sid, ok := settingByName(name)
+			if !ok {
+				return fmt.Errorf("unknown setting %q", name)
+			}
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return err
+			}
+			settings = append(settings, http2.Setting{ID: sid, Val: uint32(n)})
+		}
+		return fr.WriteSettings(settings...)
+	case "PING":
+		b, err := unquote("data")
+		if err != nil {
+			return err
+		}
+		var data [8]byte
+		copy(data[:], b)
+		return fr.WritePing(hasFlag("ACK"), data)
+	case "HEADERS":
+		if streamID > app.streamID {
+			app.streamID = streamID
+		}
+		p := http2.HeadersFrameParam{
+			StreamID:      streamID,
+			BlockFragment: encodeHeaders(),
+			EndStream:     hasFlag("END_STREAM"),
+			EndHeaders:    hasFlag("END_HEADERS"),
+		}
+		if hasFlag("PRIORITY") {
+			var err error
+			if p.Priority, err = parsePriority(fields["priority"]); err != nil {
+				return err
+			}
+		}
+		return fr.WriteHeaders(p)
+	case "CONTINUATION":
+		return fr.WriteContinuation(streamID, hasFlag("END_HEADERS"), encodeHeaders())
+	case "PUSH_PROMISE":
+		promiseID, err := parseUint("promise_stream")
+		if err != nil {
+			return err
+		}
+		return fr.WritePushPromise(http2.PushPromiseParam{
+			StreamID:      streamID,
+			PromiseID:     promiseID,
+			BlockFragment: encodeHeaders(),
+			EndHeaders:    hasFlag("END_HEADERS"),
+		})
+	case "PRIORITY":
+		p, err := parsePriority(fields["priority"])
+		if err != nil {
+			return err
+		}
+		return fr.WritePriority(streamID, p)
+	case "DATA":
+		b, err := unquote("data")
+		if err != nil {
+			return err
+		}
+		return fr.WriteData(streamID, hasFlag("END_STREAM"), b)
+	case "WINDOW_UPDATE":
+		n, err := parseUint("increment")
+		if err != nil {
+			return err
+		}
+		return fr.WriteWindowUpdate(streamID, n)
+	case "RST_STREAM":
+		code, err := parseUint("code")
+		if err != nil {
+			return err
+		}
+		return fr.WriteRSTStream(streamID, http2.ErrCode(code))
+	case "GOAWAY":
+		last, err := parseUint("last_stream")
+		if err != nil {
+			return err
+		}
+		code, err := parseUint("code")
+		if err != nil {
+			return err
+		}
+		var debug []byte
+		if _, ok := fields["debug"]; ok {
+			if debug, err = unquote("debug"); err != nil {
+				return err
+			}
+		}
+		return fr.WriteGoAway(last, http2.ErrCode(code), debug)
+	}
+	return fmt.Errorf("unsupported frame type %q", typ)
+}
+
+// parsePriority parses the value of a "priority" payload line.
+func parsePriority(v string) (http2.PriorityParam, error) {
+	var p http2.PriorityParam
+	if _, err := fmt.Sscanf(v, "%d %d %t", &p.StreamDep, &p.Weight, &p.Exclusive); err != nil {
+		return p, fmt.Errorf("invalid priority %q", v)
+	}
+	return p, nil
+}
+
+// diffRecords writes the differences between the received frames in
+// want and got to w, and reports whether there were any.
+//
+// Frames are compared stream by stream, since the server may
+// interleave frames from different streams differently.
+// Header values for names in ignore are not compared.
+func diffRecords(w io.Writer, want, got []record, ignore map[string]bool) bool {
+	byStream := func(recs []record) map[uint32][]string {
+		m := make(map[uint32][]string)
+		for _, r := range recs {
+			for i, line := range r.lines {
+				if i > 0 {
+					if k, _, ok := strings.Cut(line, " = "); ok && ignore[k] {
+						line = k + " = *"
+					}
+					line = "  " + line
+				}
+				m[r.streamID] = append(m[r.streamID], line)
+			}
+		}
+		return m
+	}
+	wantStreams, gotStreams := byStream(want), byStream(got)
+	var ids []uint32
+	for id := range wantStreams {
+		ids = append(ids, id)
+	}
+	for id := range gotStreams {
+		if _, ok := wantStreams[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	differs := false
+	for _, id := range ids {
+		a, b := wantStreams[id], gotStreams[id]
+		if slices.Equal(a, b) {
+			continue
+		}
+		if !differs {
+			fmt.Fprintf(w, "--- capture\n+++ replay\n")
+			differs = true
+		}
+		fmt.Fprintf(w, "@@ stream %d @@\n", id)
+		for _, line := range diffLines(a, b) {
+			fmt.Fprintln(w, line)
+		}
+	}
+	return differs
+}
+
+// diffLines returns a line-by-line diff of a and b, using the longest
+// common subsequence. Lines only in a are prefixed with "-", lines
+// only in b with "+", and common lines with " ".
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestDiffLines(t *testing.T) {
+	for _, test := range []struct {
+		a, b []string
+		want []string
+	}{
+		{nil, nil, nil},
+		{[]string{"x"}, []string{"x"}, []string{" x"}},
+		{[]string{"x"}, nil, []string{"-x"}},
+		{nil, []string{"x"}, []string{"+x"}},
+		{[]string{"x", "y", "z"}, []string{"x", "z"}, []string{" x", "-y", " z"}},
+		{[]string{"x", "z"}, []string{"x", "y", "z"}, []string{" x", "+y", " z"}},
+		{[]string{"x", "y"}, []string{"x", "w"}, []string{" x", "-y", "+w"}},
+		{[]string{"a", "b", "c", "d"}, []string{"b", "c", "e"}, []string{"-a", " b", " c", "-d", "+e"}},
+	} {
+		if got := diffLines(test.a, test.b); !slices.Equal(got, test.want) {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	headers := func(streamID uint32, summary string, fields ...string) record {
+		return record{streamID: streamID, lines: append([]string{summary}, fields...)}
+	}
+	want := []record{
+		headers(1, "HEADERS stream=1 flags=END_HEADERS", `:status = "200"`, `date = "Mon, 01 Jan 2026 00:00:00 GMT"`),
+		headers(3, "HEADERS stream=3 flags=END_HEADERS", `:status = "404"`),
+		headers(1, "DATA stream=1 flags=END_STREAM", `data = "hello"`),
+	}
+	for _, test := range []struct {
+		name   string
+		got    []record
+		ignore map[string]bool
+		want   string
+	}{
+		{
+			name: "identical",
+			got:  want,
+		},
+		{
+			name: "streams interleaved differently",
+			got:  []record{want[1], want[0], want[2]},
+		},
+		{
+			name: "ignored header",
+			got: []record{
+				headers(1, "HEADERS stream=1 flags=END_HEADERS", `:status = "200"`, `date = "Tue, 02 Jan 2026 00:00:00 GMT"`),
+				want[1],
+				want[2],
+			},
+			ignore: map[string]bool{"date": true},
+		},
+		{
+			name: "header not ignored",
+			got: []record{
+				headers(1, "HEADERS stream=1 flags=END_HEADERS", `:status = "200"`, `date = "Tue, 02 Jan 2026 00:00:00 GMT"`),
+				want[1],
+				want[2],
+			},
+			want: "--- capture\n+++ replay\n" +
+				"@@ stream 1 @@\n" +
+				" HEADERS stream=1 flags=END_HEADERS\n" +
+				`   :status = "200"` + "\n" +
+				`-  date = "Mon, 01 Jan 2026 00:00:00 GMT"` + "\n" +
+				`+  date = "Tue, 02 Jan 2026 00:00:00 GMT"` + "\n" +
+				" DATA stream=1 flags=END_STREAM\n" +
+				`   data = "hello"` + "\n",
+		},
+		{
+			name:   "ignore set only applies to header fields",
+			got:    []record{want[0], want[1], headers(1, "DATA stream=1 flags=END_STREAM", `data = "bye"`)},
+			ignore: map[string]bool{"date": true},
+			want: "--- capture\n+++ replay\n" +
+				"@@ stream 1 @@\n" +
+				" HEADERS stream=1 flags=END_HEADERS\n" +
+				`   :status = "200"` + "\n" +
+				`   date = *` + "\n" +
+				" DATA stream=1 flags=END_STREAM\n" +
+				`-  data = "hello"` + "\n" +
+				`+  data = "bye"` + "\n",
+		},
+		{
+			name: "missing and extra streams",
+			got:  []record{want[0], want[2], headers(5, "RST_STREAM stream=5 flags=")},
+			want: "--- capture\n+++ replay\n" +
+				"@@ stream 3 @@\n" +
+				"-HEADERS stream=3 flags=END_HEADERS\n" +
+				`-  :status = "404"` + "\n" +
+				"@@ stream 5 @@\n" +
+				"+RST_STREAM stream=5 flags=\n",
+		},
+	} {
+		var buf strings.Builder
+		differs := diffRecords(&buf, want, test.got, test.ignore)
+		if differs != (test.want != "") {
+			t.Errorf("%s: diffRecords = %t, want %t", test.name, differs, test.want != "")
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: diffRecords output:\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	recs := []record{
+		{sent: true, streamID: 1, lines: []string{
+			"HEADERS stream=1 flags=PRIORITY",
+			"priority = 0 32 true",
+			`:method = "GET"`,
+			`:scheme = "https"`,
+		}},
+		{sent: true, streamID: 1, lines: []string{
+			"CONTINUATION stream=1 flags=",
+			`:authority = "go.dev"`,
+		}},
+		{sent: true, streamID: 1, lines: []string{
+			"CONTINUATION stream=1 flags=END_HEADERS",
+			`:path = "/"`,
+		}},
+		{sent: true, streamID: 3, lines: []string{
+			"PRIORITY stream=3 flags=",
+			"priority = 1 16 false",
+		}},
+		{sent: true, streamID: 1, lines: []string{
+			"PUSH_PROMISE stream=1 flags=END_HEADERS",
+			"promise_stream = 2",
+			`:method = "GET"`,
+		}},
+	}
+
+	var buf bytes.Buffer
+	app := &h2i{framer: http2.NewFramer(&buf, nil)}
+	app.henc = hpack.NewEncoder(&app.hbuf)
+	for _, r := range recs {
+		if err := app.writeRecord(r); err != nil {
+			t.Fatalf("writeRecord(%q): %v", r.lines[0], err)
+		}
+	}
+
+	l := newFrameLog(nil)
+	fr := http2.NewFramer(nil, &buf)
+	for _, r := range recs {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("reading frame replayed from %q: %v", r.lines[0], err)
+		}
+		if got := l.describe(f, l.recvDec); !slices.Equal(got, r.lines) {
+			t.Errorf("replayed frame = %q, want %q", got, r.lines)
+		}
+	}
+}
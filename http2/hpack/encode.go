@@ -28,6 +28,25 @@ type Encoder struct {
 	tableSizeUpdate bool
 	w               io.Writer
 	buf             []byte
+	policy          IndexingPolicy // nil means index all fields that fit
+	stats           Stats
+}
+
+// An IndexingPolicy reports whether an Encoder should add a header
+// field to the dynamic table.
+//
+// It is consulted only for fields which are not Sensitive, are not
+// already present in the static or dynamic table, and which fit in
+// the dynamic table. A policy might decline to index fields with
+// high-entropy values, such as request IDs, which are unlikely to be
+// sent again.
+type IndexingPolicy func(f HeaderField) bool
+
+// SetIndexingPolicy sets the policy used to decide which header
+// fields are added to the dynamic table. A nil policy, the default,
+// indexes every field which fits in the table.
+func (e *Encoder) SetIndexingPolicy(p IndexingPolicy) {
+	e.policy = p
 }
 
 // NewEncoder returns a new Encoder which performs HPACK encoding. An
@@ -61,26 +80,38 @@ func (e *Encoder) WriteField(f HeaderField) error {
 
 	idx, nameValueMatch := e.searchTable(f)
 	if nameValueMatch {
+		e.stats.addHit(idx)
 		e.buf = appendIndexed(e.buf, idx)
 	} else {
 		indexing := e.shouldIndex(f)
 		if indexing {
 			e.dynTab.add(f)
+			e.stats.Insertions++
 		}
 
 		if idx == 0 {
 			e.buf = appendNewName(e.buf, f, indexing)
 		} else {
+			e.stats.NameHits++
 			e.buf = appendIndexedName(e.buf, f, idx, indexing)
 		}
 	}
+	e.stats.addField(f)
 	n, err := e.w.Write(e.buf)
+	e.stats.EncodedBytes += uint64(n)
 	if err == nil && n != len(e.buf) {
 		err = io.ErrShortWrite
 	}
 	return err
 }
 
+// Stats returns counters of the fields encoded by e.
+func (e *Encoder) Stats() Stats {
+	s := e.stats
+	s.Evictions = e.dynTab.table.evictCount
+	return s
+}
+
 // searchTable searches f in both stable and dynamic header tables.
 // The static header table is searched first. Only when there is no
 // exact match for both name and value, the dynamic header table is
@@ -138,7 +169,10 @@ func (e *Encoder) SetMaxDynamicTableSizeLimit(v uint32) {
 
 // shouldIndex reports whether f should be indexed.
 func (e *Encoder) shouldIndex(f HeaderField) bool {
-	return !f.Sensitive && f.Size() <= e.dynTab.maxSize
+	if f.Sensitive || f.Size() > e.dynTab.maxSize {
+		return false
+	}
+	return e.policy == nil || e.policy(f)
 }
 
 // appendIndexed appends index i, as encoded in "Indexed Header Field"
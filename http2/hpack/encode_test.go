@@ -384,3 +384,74 @@ func BenchmarkEncoderSearchTable(b *testing.B) {
 		}
 	}
 }
+
+func TestEncoderIndexingPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetIndexingPolicy(func(f HeaderField) bool {
+		return f.Name != "x-request-id"
+	})
+	fields := []HeaderField{
+		pair("x-request-id", "1234"),
+		pair("x-tenant", "foo"),
+	}
+	for _, f := range fields {
+		if err := e.WriteField(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := e.dynTab.table.len(), 1; got != want {
+		t.Fatalf("dynamic table has %v entries; want %v", got, want)
+	}
+	if got, want := e.dynTab.table.ents[0], pair("x-tenant", "foo"); got != want {
+		t.Errorf("dynamic table entry = %v; want %v", got, want)
+	}
+
+	// The policy doesn't override Sensitive.
+	e.SetIndexingPolicy(func(HeaderField) bool { return true })
+	if err := e.WriteField(HeaderField{Name: "authorization", Value: "secret", Sensitive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.dynTab.table.len(), 1; got != want {
+		t.Errorf("after sensitive field, dynamic table has %v entries; want %v", got, want)
+	}
+}
+
+func TestEncoderDecoderStats(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetMaxDynamicTableSizeLimit(64)
+	e.SetMaxDynamicTableSize(64)
+	fields := []HeaderField{
+		pair(":method", "GET"),  // static hit
+		pair("x-a", "aaaa"),     // inserted
+		pair("x-a", "aaaa"),     // dynamic hit
+		pair(":path", "/about"), // name hit, inserted, evicts x-a
+	}
+	for _, f := range fields {
+		if err := e.WriteField(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Stats{
+		Fields:       4,
+		HeaderBytes:  7 + 3 + 3 + 4 + 3 + 4 + 5 + 6,
+		EncodedBytes: uint64(buf.Len()),
+		StaticHits:   1,
+		DynamicHits:  1,
+		NameHits:     1,
+		Insertions:   2,
+		Evictions:    1,
+	}
+	if got := e.Stats(); got != want {
+		t.Errorf("Encoder.Stats() = %+v\nwant %+v", got, want)
+	}
+
+	d := NewDecoder(64, nil)
+	if _, err := d.DecodeFull(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Stats(); got != want {
+		t.Errorf("Decoder.Stats() = %+v\nwant %+v", got, want)
+	}
+}
@@ -74,6 +74,51 @@ func (hf HeaderField) Size() uint32 {
 	return uint32(len(hf.Name) + len(hf.Value) + 32)
 }
 
+// Stats are counters of the header fields processed by an Encoder
+// or Decoder. The ratio of EncodedBytes to HeaderBytes is the
+// compression ratio achieved.
+type Stats struct {
+	// Fields is the number of header fields encoded or decoded.
+	// A Decoder does not count fields decoded while emitting is disabled.
+	Fields uint64
+
+	// HeaderBytes is the sum of the lengths of the names and
+	// values of the counted fields, before encoding.
+	HeaderBytes uint64
+
+	// EncodedBytes is the number of bytes written by an Encoder,
+	// or passed to a Decoder's Write method.
+	EncodedBytes uint64
+
+	// StaticHits and DynamicHits are the number of fields
+	// represented as a reference to a static or dynamic table entry.
+	StaticHits  uint64
+	DynamicHits uint64
+
+	// NameHits is the number of literal fields whose name was
+	// represented as a reference to a table entry.
+	NameHits uint64
+
+	// Insertions is the number of fields added to the dynamic table.
+	Insertions uint64
+
+	// Evictions is the number of entries evicted from the dynamic table.
+	Evictions uint64
+}
+
+func (s *Stats) addField(f HeaderField) {
+	s.Fields++
+	s.HeaderBytes += uint64(len(f.Name) + len(f.Value))
+}
+
+func (s *Stats) addHit(idx uint64) {
+	if idx <= uint64(staticTable.len()) {
+		s.StaticHits++
+	} else {
+		s.DynamicHits++
+	}
+}
+
 // A Decoder is the decoding context for incremental processing of
 // header blocks.
 type Decoder struct {
@@ -94,6 +139,8 @@ type Decoder struct {
 	saveBuf bytes.Buffer
 
 	firstField bool // processing the first field of the header block
+
+	stats Stats
 }
 
 // NewDecoder returns a new decoder with the provided maximum dynamic
@@ -143,6 +190,13 @@ func (d *Decoder) SetEmitEnabled(v bool) { d.emitEnabled = v }
 // are currently enabled. The default is true.
 func (d *Decoder) EmitEnabled() bool { return d.emitEnabled }
 
+// Stats returns counters of the fields decoded by d.
+func (d *Decoder) Stats() Stats {
+	s := d.stats
+	s.Evictions = d.dynTab.table.evictCount
+	return s
+}
+
 // TODO: add method *Decoder.Reset(maxSize, emitFunc) to let callers re-use Decoders and their
 // underlying buffers for garbage reasons.
 
@@ -248,6 +302,7 @@ func (d *Decoder) Write(p []byte) (n int, err error) {
 		// enough data)
 		return
 	}
+	d.stats.EncodedBytes += uint64(len(p))
 	// Only copy the data if we have to. Optimistically assume
 	// that p will contain a complete header block.
 	if d.saveBuf.Len() == 0 {
@@ -346,6 +401,7 @@ func (d *Decoder) parseFieldIndexed() error {
 		return DecodingError{InvalidIndexError(idx)}
 	}
 	d.buf = buf
+	d.stats.addHit(idx)
 	return d.callEmit(HeaderField{Name: hf.Name, Value: hf.Value})
 }
 
@@ -389,8 +445,12 @@ func (d *Decoder) parseFieldLiteral(n uint8, it indexType) error {
 		}
 	}
 	d.buf = buf
+	if nameIdx > 0 {
+		d.stats.NameHits++
+	}
 	if it.indexed() {
 		d.dynTab.add(hf)
+		d.stats.Insertions++
 	}
 	hf.Sensitive = it.sensitive()
 	return d.callEmit(hf)
@@ -403,6 +463,7 @@ func (d *Decoder) callEmit(hf HeaderField) error {
 		}
 	}
 	if d.emitEnabled {
+		d.stats.addField(hf)
 		d.emit(hf)
 	}
 	return nil
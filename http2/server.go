@@ -117,6 +117,17 @@ type Server struct {
 	// the default value of 4096 is used.
 	MaxEncoderHeaderTableSize uint32
 
+	// HPACKIndexingPolicy, if non-nil, decides which response header
+	// fields are added to the header compression table of each
+	// connection. See hpack.Encoder.SetIndexingPolicy.
+	HPACKIndexingPolicy hpack.IndexingPolicy
+
+	// HPACKStats, if non-nil, is called when a connection is closed with
+	// the header compression counters of the headers sent and received on
+	// it, which can guide the choice of MaxEncoderHeaderTableSize and
+	// MaxDecoderHeaderTableSize.
+	HPACKStats func(c net.Conn, encoder, decoder hpack.Stats)
+
 	// MaxReadFrameSize optionally specifies the largest frame
 	// this server is willing to read. A valid value is between
 	// 16k and 16M, inclusive. If zero or otherwise invalid, a
@@ -149,6 +160,25 @@ type Server struct {
 	// If zero or negative, there is no timeout.
 	WriteByteTimeout time.Duration
 
+	// ReadHeaderTimeout is the amount of time allowed to read a
+	// request header block which the client has split across
+	// HEADERS and CONTINUATION frames. The timeout begins when the
+	// HEADERS frame is received. If the header block is incomplete
+	// when the timeout expires, the stream is reset with
+	// ENHANCE_YOUR_CALM and the connection is closed, since the
+	// connection's header compression state is no longer usable.
+	// If zero or negative, there is no timeout.
+	ReadHeaderTimeout time.Duration
+
+	// StreamIdleTimeout is the maximum amount of time a stream may
+	// go without receiving request body data while the client is
+	// permitted by flow control to send it. When the timeout
+	// expires, the stream is reset with ENHANCE_YOUR_CALM and reads
+	// from the request body return an error wrapping
+	// os.ErrDeadlineExceeded.
+	// If zero or negative, there is no timeout.
+	StreamIdleTimeout time.Duration
+
 	// MaxUploadBufferPerConnection is the size of the initial flow
 	// control window for each connections. The HTTP/2 spec does not
 	// allow this to be smaller than 65535 or larger than 2^32-1.
@@ -172,6 +202,24 @@ type Server struct {
 	// The errType consists of only ASCII word characters.
 	CountError func(errType string)
 
+	// AbuseLimits limits peer behavior commonly used in
+	// denial-of-service attacks, such as rapid stream resets and
+	// control frame floods. By default, there are no limits
+	// beyond those always enforced by the server.
+	AbuseLimits AbuseLimits
+
+	// Origins, if non-empty, is sent to clients in ORIGIN frames
+	// (RFC 8336) at the start of each TLS connection. Each entry is
+	// an ASCII-serialized origin, such as "https://example.com".
+	// Clients which support ORIGIN frames may use the connection
+	// for requests to any of the listed origins for which the
+	// server's certificate is valid.
+	//
+	// The list is split across as many frames as needed to respect the
+	// client's default maximum frame size. Origins too long to fit in
+	// a frame of that size are not sent.
+	Origins []string
+
 	// Internal state. This is a pointer (rather than embedded directly)
 	// so that we don't embed a Mutex in this struct, which will make the
 	// struct non-copyable, which might break some callers.
@@ -451,6 +499,7 @@ func (s *Server) serveConn(c net.Conn, opts *ServeConnOpts, newf func(*serverCon
 		initialStreamRecvWindowSize: conf.MaxUploadBufferPerStream,
 		maxFrameSize:                initialMaxFrameSize,
 		pingTimeout:                 conf.PingTimeout,
+		streamIdleTimeout:           conf.StreamIdleTimeout,
 		countErrorFunc:              conf.CountError,
 		serveG:                      newGoroutineLock(),
 		pushEnabled:                 true,
@@ -485,13 +534,22 @@ func (s *Server) serveConn(c net.Conn, opts *ServeConnOpts, newf func(*serverCon
 	sc.inflow.init(initialWindowSize)
 	sc.hpackEncoder = hpack.NewEncoder(&sc.headerWriteBuf)
 	sc.hpackEncoder.SetMaxDynamicTableSizeLimit(conf.MaxEncoderHeaderTableSize)
+	sc.hpackEncoder.SetIndexingPolicy(s.HPACKIndexingPolicy)
 
 	fr := NewFramer(sc.bw, c)
+	// DATA frames are consumed by processData before the next frame
+	// is read, so they may be reused.
+	fr.SetReuseFrames()
 	if conf.CountError != nil {
 		fr.countError = conf.CountError
 	}
 	fr.ReadMetaHeaders = hpack.NewDecoder(conf.MaxDecoderHeaderTableSize, nil)
 	fr.MaxHeaderListSize = sc.maxHeaderListSize()
+	fr.maxContinuationFrames = s.AbuseLimits.MaxContinuationFrames
+	if conf.ReadHeaderTimeout > 0 {
+		sc.readHeaderTimeout = conf.ReadHeaderTimeout
+		fr.headerBlockStart = sc.startHeaderTimer
+	}
 	fr.SetMaxReadFrameSize(conf.MaxReadFrameSize)
 	sc.framer = fr
 
@@ -636,16 +694,35 @@ type serverConn struct {
 	pingSent                    bool
 	sentPingData                [8]byte
 	goAwayCode                  ErrCode
+	goAwayDebug                 string // debug data for the GOAWAY frame
+	abuse                       abuseCounters
 	shutdownTimer               *time.Timer // nil until used
 	idleTimer                   *time.Timer // nil if unused
 	readIdleTimeout             time.Duration
 	pingTimeout                 time.Duration
 	readIdleTimer               *time.Timer // nil if unused
+	streamIdleTimeout           time.Duration
+	readHeaderTimeout           time.Duration
+
+	// Owned by the readFrames goroutine:
+	headerTimer *time.Timer // nil if unused
+
+	// The header block being read by the readFrames goroutine,
+	// if it continues in CONTINUATION frames.
+	headerBlockMu       sync.Mutex
+	headerBlockStream   uint32 // zero if none
+	headerBlockDeadline time.Time
 
 	// Owned by the writeFrameAsync goroutine:
 	headerWriteBuf bytes.Buffer
 	hpackEncoder   *hpack.Encoder
 
+	// Counters of hpackEncoder and of the decoder of framer, copied by
+	// the serve goroutine when it is their owner, if srv.HPACKStats is
+	// set.
+	hpackEncoderStats hpack.Stats
+	hpackDecoderStats hpack.Stats
+
 	// Used by startGracefulShutdown.
 	shutdownOnce sync.Once
 }
@@ -690,6 +767,8 @@ type stream struct {
 	wroteHeaders     bool        // whether we wrote headers (not status 100)
 	readDeadline     *time.Timer // nil if unused
 	writeDeadline    *time.Timer // nil if unused
+	idleTimer        *time.Timer // nil if unused
+	idleDeadline     time.Time   // when idleTimer should fire
 	closeErr         error       // set before cw is closed
 
 	trailer    http.Header // accumulated trailers
@@ -850,6 +929,9 @@ func (sc *serverConn) readFrames() {
 	gateDone := func() { gate <- struct{}{} }
 	for {
 		f, err := sc.framer.ReadFrame()
+		if sc.headerTimer != nil {
+			sc.stopHeaderTimer()
+		}
 		select {
 		case sc.readFrameCh <- readFrameResult{f, err, gateDone}:
 		case <-sc.doneServing:
@@ -866,6 +948,28 @@ func (sc *serverConn) readFrames() {
 	}
 }
 
+// startHeaderTimer is called by the readFrames goroutine when it
+// begins reading a header block which continues in CONTINUATION frames.
+func (sc *serverConn) startHeaderTimer(streamID uint32) {
+	sc.headerBlockMu.Lock()
+	sc.headerBlockStream = streamID
+	sc.headerBlockDeadline = time.Now().Add(sc.readHeaderTimeout)
+	sc.headerBlockMu.Unlock()
+	if sc.headerTimer == nil {
+		sc.headerTimer = time.AfterFunc(sc.readHeaderTimeout, sc.onHeaderTimer)
+	} else {
+		sc.headerTimer.Reset(sc.readHeaderTimeout)
+	}
+}
+
+// stopHeaderTimer is called by the readFrames goroutine after reading a frame.
+func (sc *serverConn) stopHeaderTimer() {
+	sc.headerTimer.Stop()
+	sc.headerBlockMu.Lock()
+	sc.headerBlockStream = 0
+	sc.headerBlockMu.Unlock()
+}
+
 // frameWriteResult is the message passed from writeFrameAsync to the serve goroutine.
 type frameWriteResult struct {
 	_   incomparable
@@ -923,6 +1027,9 @@ func (sc *serverConn) serve(conf http2Config) {
 	defer sc.closeAllStreamsOnConnClose()
 	defer sc.stopShutdownTimer()
 	defer close(sc.doneServing) // unblocks handlers trying to send
+	if f := sc.srv.HPACKStats; f != nil {
+		defer func() { f(sc.conn, sc.hpackEncoderStats, sc.hpackDecoderStats) }()
+	}
 
 	if VerboseLogs {
 		sc.vlogf("http2: server connection from %v on %p", sc.conn.RemoteAddr(), sc.hs)
@@ -949,6 +1056,18 @@ func (sc *serverConn) serve(conf http2Config) {
 		sc.sendWindowUpdate(nil, int(diff))
 	}
 
+	// ORIGIN frames are only meaningful on TLS connections,
+	// where the client can verify the server's authority.
+	if len(sc.srv.Origins) > 0 && sc.tlsState != nil {
+		// The client's SETTINGS have not been read yet, so the frames
+		// must fit in the initial SETTINGS_MAX_FRAME_SIZE.
+		for _, o := range splitOrigins(sc.srv.Origins, initialMaxFrameSize) {
+			sc.writeFrame(FrameWriteRequest{
+				write: o,
+			})
+		}
+	}
+
 	if err := sc.readPreface(); err != nil {
 		sc.condlogf(err, "http2: server: error reading preface from client %v: %v", sc.conn.RemoteAddr(), err)
 		return
@@ -1031,6 +1150,8 @@ func (sc *serverConn) serve(conf http2Config) {
 					sc.startGracefulShutdownInternal()
 				case handlerDoneMsg:
 					sc.handlerDone()
+				case headerTimerMsg:
+					sc.handleHeaderTimer()
 				default:
 					panic("unknown timer")
 				}
@@ -1101,12 +1222,14 @@ var (
 	shutdownTimerMsg    = new(serverMessage)
 	gracefulShutdownMsg = new(serverMessage)
 	handlerDoneMsg      = new(serverMessage)
+	headerTimerMsg      = new(serverMessage)
 )
 
 func (sc *serverConn) onSettingsTimer() { sc.sendServeMsg(settingsTimerMsg) }
 func (sc *serverConn) onIdleTimer()     { sc.sendServeMsg(idleTimerMsg) }
 func (sc *serverConn) onReadIdleTimer() { sc.sendServeMsg(readIdleTimerMsg) }
 func (sc *serverConn) onShutdownTimer() { sc.sendServeMsg(shutdownTimerMsg) }
+func (sc *serverConn) onHeaderTimer()   { sc.sendServeMsg(headerTimerMsg) }
 
 func (sc *serverConn) sendServeMsg(msg interface{}) {
 	sc.serveG.checkNotOn() // NOT
@@ -1353,6 +1476,9 @@ func (sc *serverConn) wroteFrame(res frameWriteResult) {
 	}
 	sc.writingFrame = false
 	sc.writingFrameAsync = false
+	if sc.srv.HPACKStats != nil {
+		sc.hpackEncoderStats = sc.hpackEncoder.Stats()
+	}
 
 	if res.err != nil {
 		sc.conn.Close()
@@ -1427,6 +1553,7 @@ func (sc *serverConn) scheduleFrameWrite() {
 				write: &writeGoAway{
 					maxStreamID: sc.maxClientStreamID,
 					code:        sc.goAwayCode,
+					debug:       sc.goAwayDebug,
 				},
 			})
 			continue
@@ -1503,6 +1630,30 @@ func (sc *serverConn) goAway(code ErrCode) {
 	sc.scheduleFrameWrite()
 }
 
+// handleHeaderTimer is called when the ReadHeaderTimeout for a
+// header block has expired.
+func (sc *serverConn) handleHeaderTimer() {
+	sc.serveG.check()
+	sc.headerBlockMu.Lock()
+	id, deadline := sc.headerBlockStream, sc.headerBlockDeadline
+	sc.headerBlockMu.Unlock()
+	if id == 0 || time.Now().Before(deadline) {
+		// The header block was completed before the timer fired,
+		// and possibly another one started.
+		return
+	}
+	sc.vlogf("http2: timeout reading headers for stream %v from %v", id, sc.conn.RemoteAddr())
+	// The rest of the header block will never be decoded, leaving
+	// the connection's HPACK state unusable. Reset the stream,
+	// and close the connection.
+	se := streamError(id, ErrCodeEnhanceYourCalm)
+	se.Cause = os.ErrDeadlineExceeded
+	sc.countError("header_timeout", se)
+	sc.resetStream(se)
+	sc.goAwayDebug = "header read timeout"
+	sc.goAway(ErrCodeEnhanceYourCalm)
+}
+
 func (sc *serverConn) shutDownIn(d time.Duration) {
 	sc.serveG.check()
 	sc.shutdownTimer = time.AfterFunc(d, sc.onShutdownTimer)
@@ -1557,6 +1708,9 @@ func (sc *serverConn) processFrameFromReader(res readFrameResult) bool {
 	case goAwayFlowError:
 		sc.goAway(ErrCodeFlowControl)
 		return true
+	case abuseError:
+		sc.abuseLimitExceeded(string(ev))
+		return true // goAway will handle shutdown
 	case ConnectionError:
 		if res.f != nil {
 			if id := res.f.Header().StreamID; id > sc.maxClientStreamID {
@@ -1602,10 +1756,19 @@ func (sc *serverConn) processFrame(f Frame) error {
 		return nil
 	}
 
+	if err := sc.checkAbuseLimits(f); err != nil {
+		return err
+	}
+
 	switch f := f.(type) {
 	case *SettingsFrame:
 		return sc.processSettings(f)
 	case *MetaHeadersFrame:
+		if sc.srv.HPACKStats != nil {
+			// The frame was decoded by the readFrames goroutine,
+			// which waits for it to be processed.
+			sc.hpackDecoderStats = sc.framer.ReadMetaHeaders.Stats()
+		}
 		return sc.processHeaders(f)
 	case *WindowUpdateFrame:
 		return sc.processWindowUpdate(f)
@@ -1716,6 +1879,9 @@ func (sc *serverConn) closeStream(st *stream, err error) {
 	if st.writeDeadline != nil {
 		st.writeDeadline.Stop()
 	}
+	if st.idleTimer != nil {
+		st.idleTimer.Stop()
+	}
 	if st.isPushed() {
 		sc.curPushedStreams--
 	} else {
@@ -1910,6 +2076,7 @@ func (sc *serverConn) processData(f *DataFrame) error {
 		}
 
 		if len(data) > 0 {
+			st.resetIdleTimer()
 			st.bodyBytes += int64(len(data))
 			wrote, err := st.body.Write(data)
 			if err != nil {
@@ -1972,6 +2139,9 @@ func (st *stream) endStream() {
 		st.body.CloseWithError(io.EOF)
 	}
 	st.state = stateHalfClosedRemote
+	if st.idleTimer != nil {
+		st.idleTimer.Stop()
+	}
 }
 
 // copyTrailersToHandlerRequest is run in the Handler's goroutine in
@@ -1995,6 +2165,47 @@ func (st *stream) onReadTimeout() {
 	}
 }
 
+// resetIdleTimer restarts the stream's StreamIdleTimeout timer.
+// It is called when the client sends request body data, or when
+// the client is permitted to send more.
+func (st *stream) resetIdleTimer() {
+	if st.idleTimer == nil {
+		return
+	}
+	st.idleDeadline = time.Now().Add(st.sc.streamIdleTimeout)
+	st.idleTimer.Reset(st.sc.streamIdleTimeout)
+}
+
+// onIdleTimeout is run on its own goroutine (from time.AfterFunc)
+// when the stream's StreamIdleTimeout has fired.
+func (st *stream) onIdleTimeout() {
+	st.sc.sendServeMsg(func(sc *serverConn) {
+		sc.handleStreamIdleTimeout(st)
+	})
+}
+
+// handleStreamIdleTimeout resets st if it has received no request
+// body data within the StreamIdleTimeout.
+func (sc *serverConn) handleStreamIdleTimeout(st *stream) {
+	sc.serveG.check()
+	if st.state != stateOpen || st.resetQueued || time.Now().Before(st.idleDeadline) {
+		// The body is complete, or more data arrived after the timer fired.
+		return
+	}
+	if st.inflow.avail <= 0 {
+		// The client can't send more data until the handler reads the body.
+		// The timer restarts when the client's window is updated.
+		return
+	}
+	if st.body != nil {
+		st.body.CloseWithError(fmt.Errorf("%w", os.ErrDeadlineExceeded))
+	}
+	se := streamError(st.id, ErrCodeEnhanceYourCalm)
+	se.Cause = os.ErrDeadlineExceeded
+	sc.countError("stream_idle_timeout", se)
+	sc.resetStream(se)
+}
+
 // onWriteTimeout is run on its own goroutine (from time.AfterFunc)
 // when the stream's WriteTimeout has fired.
 func (st *stream) onWriteTimeout() {
@@ -2092,6 +2303,10 @@ func (sc *serverConn) processHeaders(f *MetaHeadersFrame) error {
 	}
 	st.body = req.Body.(*requestBody).pipe // may be nil
 	st.declBodyBytes = req.ContentLength
+	if st.body != nil && sc.streamIdleTimeout > 0 {
+		st.idleDeadline = time.Now().Add(sc.streamIdleTimeout)
+		st.idleTimer = time.AfterFunc(sc.streamIdleTimeout, st.onIdleTimeout)
+	}
 
 	handler := sc.handler.ServeHTTP
 	if f.Truncated {
@@ -2525,6 +2740,9 @@ func (sc *serverConn) sendWindowUpdate(st *stream, n int) {
 	if send == 0 {
 		return
 	}
+	if st != nil {
+		st.resetIdleTimer()
+	}
 	sc.writeFrame(FrameWriteRequest{
 		write:  writeWindowUpdate{streamID: streamID, n: uint32(send)},
 		stream: st,
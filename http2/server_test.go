@@ -24,6 +24,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -3558,6 +3559,36 @@ func BenchmarkServer_GetRequest(b *testing.B) {
 	}
 }
 
+// BenchmarkServerDataFrames measures the cost of the server reading
+// a request body sent in many small DATA frames.
+func BenchmarkServerDataFrames(b *testing.B) {
+	disableGoroutineTracking(b)
+	b.ReportAllocs()
+	ts := newTestServer(b, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			b.Errorf("Copy error: %v", err)
+		}
+	}, optQuiet)
+	tr := &Transport{TLSClientConfig: tlsConfigInsecure}
+	defer tr.CloseIdleConnections()
+
+	pr, pw := io.Pipe()
+	go func() {
+		// Each write to the request body is sent in its own DATA frame.
+		data := []byte("x")
+		for i := 0; i < b.N; i++ {
+			pw.Write(data)
+		}
+		pw.Close()
+	}()
+	b.ResetTimer()
+	res, err := tr.RoundTrip(must(http.NewRequest("POST", ts.URL, pr)))
+	if err != nil {
+		b.Fatalf("RoundTrip err = %v; want nil", err)
+	}
+	res.Body.Close()
+}
+
 func BenchmarkServer_PostRequest(b *testing.B) {
 	disableGoroutineTracking(b)
 	b.ReportAllocs()
@@ -5124,3 +5155,317 @@ func testServerSendDataAfterRequestBodyClose(t testing.TB) {
 	})
 	st.wantIdle()
 }
+
+func TestServerAbuseLimitPing(t *testing.T) { synctestTest(t, testServerAbuseLimitPing) }
+func testServerAbuseLimitPing(t testing.TB) {
+	var events []AbuseEvent
+	st := newServerTester(t, nil, func(s *Server) {
+		s.AbuseLimits.Ping = FrameRate{Frames: 3, Interval: 1 * time.Second}
+		s.AbuseLimits.OnLimitExceeded = func(ev AbuseEvent) {
+			events = append(events, ev)
+		}
+	})
+	defer st.Close()
+	st.greet()
+
+	sendPings := func(n int) {
+		for i := 0; i < n; i++ {
+			st.fr.WritePing(false, [8]byte{byte(i)})
+		}
+	}
+
+	// Pings within the limit are acknowledged.
+	sendPings(3)
+	for i := 0; i < 3; i++ {
+		readFrame[*PingFrame](t, st)
+	}
+
+	// The limit is reset after the interval has passed.
+	st.advance(1 * time.Second)
+	sendPings(3)
+	for i := 0; i < 3; i++ {
+		readFrame[*PingFrame](t, st)
+	}
+	if len(events) != 0 {
+		t.Fatalf("OnLimitExceeded called %v times, want none", len(events))
+	}
+
+	sendPings(1)
+	fr := readFrame[*GoAwayFrame](t, st)
+	if fr.ErrCode != ErrCodeEnhanceYourCalm {
+		t.Errorf("GOAWAY code = %v; want %v", fr.ErrCode, ErrCodeEnhanceYourCalm)
+	}
+	if got, want := string(fr.DebugData()), "ping limit exceeded"; got != want {
+		t.Errorf("GOAWAY debug data = %q; want %q", got, want)
+	}
+	if len(events) != 1 || events[0].Rule != "ping" {
+		t.Errorf("OnLimitExceeded events = %v; want one for rule %q", events, "ping")
+	}
+}
+
+func TestServerAbuseLimitResetStream(t *testing.T) {
+	synctestTest(t, testServerAbuseLimitResetStream)
+}
+func testServerAbuseLimitResetStream(t testing.TB) {
+	var counted []string
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, func(s *Server) {
+		s.AbuseLimits.ResetStream = FrameRate{Frames: 5}
+		s.CountError = func(errType string) {
+			counted = append(counted, errType)
+		}
+	})
+	defer st.Close()
+	st.greet()
+
+	for i := 0; i <= 5; i++ {
+		streamID := uint32(1 + 2*i)
+		st.writeHeaders(HeadersFrameParam{
+			StreamID:      streamID,
+			BlockFragment: st.encodeHeader(),
+			EndStream:     true,
+			EndHeaders:    true,
+		})
+		st.fr.WriteRSTStream(streamID, ErrCodeCancel)
+	}
+	fr := readFrame[*GoAwayFrame](t, st)
+	if fr.ErrCode != ErrCodeEnhanceYourCalm {
+		t.Errorf("GOAWAY code = %v; want %v", fr.ErrCode, ErrCodeEnhanceYourCalm)
+	}
+	if got, want := string(fr.DebugData()), "rst_stream limit exceeded"; got != want {
+		t.Errorf("GOAWAY debug data = %q; want %q", got, want)
+	}
+	if !slices.Contains(counted, "conn_ENHANCE_YOUR_CALM_abuse_rst_stream") {
+		t.Errorf("CountError calls = %q; want conn_ENHANCE_YOUR_CALM_abuse_rst_stream", counted)
+	}
+}
+
+func TestServerAbuseLimitEmptyData(t *testing.T) {
+	synctestTest(t, testServerAbuseLimitEmptyData)
+}
+func testServerAbuseLimitEmptyData(t testing.TB) {
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, func(s *Server) {
+		s.AbuseLimits.EmptyData = FrameRate{Frames: 10}
+	})
+	defer st.Close()
+	st.greet()
+
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: st.encodeHeader(":method", "POST"),
+		EndHeaders:    true,
+	})
+	for i := 0; i <= 10; i++ {
+		st.writeData(1, false, nil)
+	}
+	fr := readFrame[*GoAwayFrame](t, st)
+	if got, want := string(fr.DebugData()), "empty_data limit exceeded"; got != want {
+		t.Errorf("GOAWAY debug data = %q; want %q", got, want)
+	}
+}
+
+func TestServerAbuseLimitContinuation(t *testing.T) {
+	synctestTest(t, testServerAbuseLimitContinuation)
+}
+func testServerAbuseLimitContinuation(t testing.TB) {
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected handler call")
+	}, func(s *Server) {
+		s.AbuseLimits.MaxContinuationFrames = 4
+	})
+	defer st.Close()
+	st.greet()
+
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: st.encodeHeader(),
+		EndStream:     true,
+	})
+	for i := 0; i < 5; i++ {
+		st.fr.WriteContinuation(1, false, st.encodeHeaderRaw(
+			fmt.Sprintf("x-%v", i), "1",
+		))
+	}
+	fr := readFrame[*GoAwayFrame](t, st)
+	if fr.ErrCode != ErrCodeEnhanceYourCalm {
+		t.Errorf("GOAWAY code = %v; want %v", fr.ErrCode, ErrCodeEnhanceYourCalm)
+	}
+	if got, want := string(fr.DebugData()), "continuation limit exceeded"; got != want {
+		t.Errorf("GOAWAY debug data = %q; want %q", got, want)
+	}
+}
+
+func TestServerSendsOriginFrame(t *testing.T) { synctestTest(t, testServerSendsOriginFrame) }
+func testServerSendsOriginFrame(t testing.TB) {
+	origins := []string{"https://example.com", "https://example.net:8443"}
+	st := newServerTester(t, nil, func(s *Server) {
+		s.Origins = origins
+	})
+	defer st.Close()
+	st.writePreface()
+	st.writeSettings()
+	st.sync()
+	readFrame[*SettingsFrame](t, st)
+	readFrame[*WindowUpdateFrame](t, st)
+	f := readFrame[*OriginFrame](t, st)
+	if f.StreamID != 0 {
+		t.Errorf("ORIGIN frame stream = %v; want 0", f.StreamID)
+	}
+	var got []string
+	f.ForeachOrigin(func(o string) error {
+		got = append(got, o)
+		return nil
+	})
+	if !slices.Equal(got, origins) {
+		t.Errorf("ORIGIN frame origins = %q; want %q", got, origins)
+	}
+	st.writeSettingsAck()
+	readFrame[*SettingsFrame](t, st) // ack
+}
+
+func TestServerSplitsOriginFrames(t *testing.T) { synctestTest(t, testServerSplitsOriginFrames) }
+func testServerSplitsOriginFrames(t testing.TB) {
+	long := func(c string, n int) string {
+		return "https://" + strings.Repeat(c, n) + ".example"
+	}
+	a, b, c := long("a", 6000), long("b", 6000), long("c", 6000)
+	st := newServerTester(t, nil, func(s *Server) {
+		// The origin of 20000 bytes can't be sent.
+		s.Origins = []string{a, b, long("x", 20000), c}
+	})
+	defer st.Close()
+	st.writePreface()
+	st.writeSettings()
+	st.sync()
+	readFrame[*SettingsFrame](t, st)
+	readFrame[*WindowUpdateFrame](t, st)
+	for _, want := range [][]string{{a, b}, {c}} {
+		f := readFrame[*OriginFrame](t, st)
+		if f.Length > initialMaxFrameSize {
+			t.Errorf("ORIGIN frame length = %v; want at most %v", f.Length, initialMaxFrameSize)
+		}
+		var got []string
+		f.ForeachOrigin(func(o string) error {
+			got = append(got, o)
+			return nil
+		})
+		if !slices.Equal(got, want) {
+			t.Errorf("ORIGIN frame has %v origins; want %v", len(got), len(want))
+		}
+	}
+	st.writeSettingsAck()
+	readFrame[*SettingsFrame](t, st) // ack
+}
+
+func TestServerReadHeaderTimeout(t *testing.T) { synctestTest(t, testServerReadHeaderTimeout) }
+func testServerReadHeaderTimeout(t testing.TB) {
+	const timeout = 5 * time.Second
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {}, func(s *Server) {
+		s.ReadHeaderTimeout = timeout
+	})
+	defer st.Close()
+	st.greet()
+
+	// A header block split across CONTINUATION frames which completes in time.
+	headerBlock := st.encodeHeader()
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: headerBlock[:3],
+		EndStream:     true,
+		EndHeaders:    false,
+	})
+	st.advance(timeout - 1)
+	if err := st.fr.WriteContinuation(1, true, headerBlock[3:]); err != nil {
+		t.Fatal(err)
+	}
+	st.wantHeaders(wantHeader{
+		streamID:  1,
+		endStream: true,
+	})
+
+	// A header block which does not.
+	headerBlock = st.encodeHeader()
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      3,
+		BlockFragment: headerBlock[:3],
+		EndStream:     true,
+		EndHeaders:    false,
+	})
+	st.advance(timeout - 1)
+	st.wantIdle()
+	st.advance(1)
+	st.wantRSTStream(3, ErrCodeEnhanceYourCalm)
+	st.wantGoAway(1, ErrCodeEnhanceYourCalm)
+}
+
+func TestServerStreamIdleTimeout(t *testing.T) { synctestTest(t, testServerStreamIdleTimeout) }
+func testServerStreamIdleTimeout(t testing.TB) {
+	const timeout = 5 * time.Second
+	errc := make(chan error, 1)
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		errc <- err
+	}, func(s *Server) {
+		s.StreamIdleTimeout = timeout
+	})
+	defer st.Close()
+	st.greet()
+
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: st.encodeHeader(":method", "POST"),
+		EndStream:     false,
+		EndHeaders:    true,
+	})
+	// Each DATA frame restarts the timer.
+	for i := 0; i < 3; i++ {
+		st.advance(timeout - 1)
+		st.writeData(1, false, []byte("a"))
+	}
+	// Empty DATA frames don't.
+	st.advance(timeout - 1)
+	st.writeData(1, false, nil)
+	st.advance(1)
+	st.wantRSTStream(1, ErrCodeEnhanceYourCalm)
+	if err := <-errc; !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("reading request body: %v; want os.ErrDeadlineExceeded", err)
+	}
+}
+
+func TestServerStreamIdleTimeoutFlowControl(t *testing.T) {
+	synctestTest(t, testServerStreamIdleTimeoutFlowControl)
+}
+func testServerStreamIdleTimeoutFlowControl(t testing.TB) {
+	const timeout = 5 * time.Second
+	readBody := make(chan struct{})
+	st := newServerTester(t, func(w http.ResponseWriter, r *http.Request) {
+		<-readBody
+		io.ReadAll(r.Body)
+	}, func(s *Server) {
+		s.StreamIdleTimeout = timeout
+		s.MaxUploadBufferPerStream = 1
+	})
+	defer st.Close()
+	st.greet()
+
+	st.writeHeaders(HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: st.encodeHeader(":method", "POST"),
+		EndStream:     false,
+		EndHeaders:    true,
+	})
+	// The client has used its flow control window, and is waiting
+	// for the handler to read the body. The stream isn't idle.
+	st.writeData(1, false, []byte("a"))
+	st.advance(2 * timeout)
+	st.wantIdle()
+
+	// Once the handler reads the body, the client must send more.
+	close(readBody)
+	st.wantWindowUpdate(1, 1)
+	st.advance(timeout)
+	st.wantRSTStream(1, ErrCodeEnhanceYourCalm)
+}
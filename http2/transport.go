@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"net/http/httptrace"
 	"net/textproto"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -136,6 +137,14 @@ type Transport struct {
 	// the default value of 4096 is used.
 	MaxEncoderHeaderTableSize uint32
 
+	// HPACKIndexingPolicy, if non-nil, decides which request header
+	// fields are added to the header compression table of each
+	// connection. See hpack.Encoder.SetIndexingPolicy.
+	//
+	// The header compression counters of a connection are reported by
+	// ClientConn.State.
+	HPACKIndexingPolicy hpack.IndexingPolicy
+
 	// StrictMaxConcurrentStreams controls whether the server's
 	// SETTINGS_MAX_CONCURRENT_STREAMS should be respected
 	// globally. If false, new TCP connections are created to the
@@ -342,9 +351,13 @@ type ClientConn struct {
 	nextStreamID     uint32
 	pendingRequests  int                       // requests blocked and waiting to be sent because len(streams) == maxConcurrentStreams
 	pings            map[[8]byte]chan struct{} // in flight ping data to notification channel
+	origins          map[string]bool           // host:port of https origins from ORIGIN frames
 	br               *bufio.Reader
 	lastActive       time.Time
 	lastIdle         time.Time // time last idle
+	// hpackDecoderStats are the counters of the decoder of fr, copied by
+	// the read loop after each header block.
+	hpackDecoderStats hpack.Stats
 	// Settings from peer: (also guarded by wmu)
 	maxFrameSize                uint32
 	maxConcurrentStreams        uint32
@@ -405,6 +418,7 @@ type clientStream struct {
 	bufPipe       pipe // buffered pipe with the flow-controlled response payload
 	requestedGzip bool
 	isHead        bool
+	upgraded      bool // request was sent over HTTP/1.1 before an h2c upgrade
 
 	abortOnce sync.Once
 	abort     chan struct{} // closed to signal stream should end immediately
@@ -774,7 +788,54 @@ func (t *Transport) NewClientConn(c net.Conn) (*ClientConn, error) {
 	return t.newClientConn(c, t.disableKeepAlives())
 }
 
+// NewUpgradedClientConn creates a ClientConn on c, a connection which
+// has been upgraded from HTTP/1.1 to HTTP/2 in response to req, as
+// described in RFC 7540, Section 3.2. The caller must already have
+// sent req and its body over HTTP/1.1, and read the server's
+// "101 Switching Protocols" response.
+//
+// The server sends its response to req on stream 1.
+// NewUpgradedClientConn waits for the response headers and returns
+// them, along with the new ClientConn.
+func (t *Transport) NewUpgradedClientConn(c net.Conn, req *http.Request) (*ClientConn, *http.Response, error) {
+	cc, err := t.initClientConn(c, t.disableKeepAlives())
+	if err != nil {
+		return nil, nil, err
+	}
+	// The request body has already been sent.
+	sent := *req
+	sent.Body = nil
+	cs := cc.newClientStream(&sent)
+	cs.upgraded = true
+	// Register the stream before starting the read loop,
+	// which may read the response immediately.
+	cc.mu.Lock()
+	cc.addStreamLocked(cs)
+	cc.mu.Unlock()
+	go cc.readLoop()
+
+	go cs.doRequest(&sent, nil)
+	res, err := cs.awaitResponse(&sent)
+	if err != nil {
+		cc.Close()
+		return nil, nil, err
+	}
+	res.Request = req
+	return cc, res, nil
+}
+
 func (t *Transport) newClientConn(c net.Conn, singleUse bool) (*ClientConn, error) {
+	cc, err := t.initClientConn(c, singleUse)
+	if err != nil {
+		return nil, err
+	}
+	go cc.readLoop()
+	return cc, nil
+}
+
+// initClientConn creates a ClientConn and sends the connection preface,
+// but does not start its read loop.
+func (t *Transport) initClientConn(c net.Conn, singleUse bool) (*ClientConn, error) {
 	conf := configFromTransport(t)
 	cc := &ClientConn{
 		t:                           t,
@@ -817,6 +878,9 @@ func (t *Transport) newClientConn(c net.Conn, singleUse bool) (*ClientConn, erro
 	})
 	cc.br = bufio.NewReader(c)
 	cc.fr = NewFramer(cc.bw, cc.br)
+	// DATA frames are consumed by processData before the next frame
+	// is read, so they may be reused.
+	cc.fr.SetReuseFrames()
 	cc.fr.SetMaxReadFrameSize(conf.MaxReadFrameSize)
 	if t.CountError != nil {
 		cc.fr.countError = t.CountError
@@ -827,6 +891,7 @@ func (t *Transport) newClientConn(c net.Conn, singleUse bool) (*ClientConn, erro
 
 	cc.henc = hpack.NewEncoder(&cc.hbuf)
 	cc.henc.SetMaxDynamicTableSizeLimit(conf.MaxEncoderHeaderTableSize)
+	cc.henc.SetIndexingPolicy(t.HPACKIndexingPolicy)
 	cc.peerMaxHeaderTableSize = initialHeaderTableSize
 
 	if cs, ok := c.(connectionStater); ok {
@@ -861,8 +926,6 @@ func (t *Transport) newClientConn(c net.Conn, singleUse bool) (*ClientConn, erro
 		cc.idleTimeout = d
 		cc.idleTimer = time.AfterFunc(d, cc.onIdleTimeout)
 	}
-
-	go cc.readLoop()
 	return cc, nil
 }
 
@@ -979,6 +1042,13 @@ type ClientConnState struct {
 	// LastIdle, if non-zero, is when the connection last
 	// transitioned to idle state.
 	LastIdle time.Time
+
+	// HPACKEncoder and HPACKDecoder are the header compression counters
+	// of the headers sent and received on the connection, which can
+	// guide the choice of MaxEncoderHeaderTableSize and
+	// MaxDecoderHeaderTableSize.
+	HPACKEncoder hpack.Stats
+	HPACKDecoder hpack.Stats
 }
 
 // State returns a snapshot of cc's state.
@@ -988,6 +1058,7 @@ func (cc *ClientConn) State() ClientConnState {
 	if !cc.seenSettings {
 		maxConcurrent = 0
 	}
+	encoderStats := cc.henc.Stats()
 	cc.wmu.Unlock()
 
 	cc.mu.Lock()
@@ -1000,6 +1071,8 @@ func (cc *ClientConn) State() ClientConnState {
 		StreamsPending:       cc.pendingRequests,
 		LastIdle:             cc.lastIdle,
 		MaxConcurrentStreams: maxConcurrent,
+		HPACKEncoder:         encoderStats,
+		HPACKDecoder:         cc.hpackDecoderStats,
 	}
 }
 
@@ -1267,6 +1340,12 @@ func (cc *ClientConn) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func (cc *ClientConn) roundTrip(req *http.Request, streamf func(*clientStream)) (*http.Response, error) {
+	cs := cc.newClientStream(req)
+	go cs.doRequest(req, streamf)
+	return cs.awaitResponse(req)
+}
+
+func (cc *ClientConn) newClientStream(req *http.Request) *clientStream {
 	ctx := req.Context()
 	cs := &clientStream{
 		cc:                   cc,
@@ -1283,9 +1362,13 @@ func (cc *ClientConn) roundTrip(req *http.Request, streamf func(*clientStream))
 	}
 
 	cs.requestedGzip = httpcommon.IsRequestGzip(req.Method, req.Header, cc.t.disableCompression())
+	return cs
+}
 
-	go cs.doRequest(req, streamf)
-
+// awaitResponse waits for the response headers to the request sent on cs.
+func (cs *clientStream) awaitResponse(req *http.Request) (*http.Response, error) {
+	cc := cs.cc
+	ctx := cs.ctx
 	waitDone := func() error {
 		select {
 		case <-cs.donec:
@@ -1395,6 +1478,14 @@ func (cs *clientStream) writeRequest(req *http.Request, streamf func(*clientStre
 	cc := cs.cc
 	ctx := cs.ctx
 
+	if cs.upgraded {
+		// The request was sent over HTTP/1.1 before the connection
+		// was upgraded, and the stream was created with the connection.
+		cs.sentHeaders = true
+		cs.sentEndStream = true
+		return cs.awaitPeerClosed()
+	}
+
 	// wait for setting frames to be received, a server can change this value later,
 	// but we just wait for the first settings frame
 	var isExtendedConnect bool
@@ -1504,7 +1595,14 @@ func (cs *clientStream) writeRequest(req *http.Request, streamf func(*clientStre
 	}
 
 	traceWroteRequest(cs.trace, err)
+	return cs.awaitPeerClosed()
+}
 
+// awaitPeerClosed waits for the peer to half-close the stream
+// after a request has been sent.
+func (cs *clientStream) awaitPeerClosed() error {
+	cc := cs.cc
+	ctx := cs.ctx
 	var respHeaderTimer <-chan time.Time
 	var respHeaderRecv chan struct{}
 	if d := cc.responseHeaderTimeout(); d != 0 {
@@ -2236,6 +2334,9 @@ func (rl *clientConnReadLoop) run() error {
 
 		switch f := f.(type) {
 		case *MetaHeadersFrame:
+			cc.mu.Lock()
+			cc.hpackDecoderStats = cc.fr.ReadMetaHeaders.Stats()
+			cc.mu.Unlock()
 			err = rl.processHeaders(f)
 		case *DataFrame:
 			err = rl.processData(f)
@@ -2251,6 +2352,8 @@ func (rl *clientConnReadLoop) run() error {
 			err = rl.processWindowUpdate(f)
 		case *PingFrame:
 			err = rl.processPing(f)
+		case *OriginFrame:
+			rl.processOrigin(f)
 		default:
 			cc.logf("Transport: unhandled response frame type %T", f)
 		}
@@ -2998,6 +3101,61 @@ func (rl *clientConnReadLoop) processPing(f *PingFrame) error {
 	return cc.bw.Flush()
 }
 
+// processOrigin adds the origins in an ORIGIN frame to the
+// connection's origin set. See RFC 8336.
+func (rl *clientConnReadLoop) processOrigin(f *OriginFrame) {
+	cc := rl.cc
+	if f.StreamID != 0 || cc.tlsState == nil {
+		// "The ORIGIN frame MUST be sent on stream 0; an ORIGIN frame
+		// on any other stream is invalid and MUST be ignored."
+		// ORIGIN frames on cleartext connections are also ignored.
+		return
+	}
+	var addrs []string
+	err := f.ForeachOrigin(func(origin string) error {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			// Origins we can't use are ignored.
+			return nil
+		}
+		addrs = append(addrs, authorityAddr("https", u.Host))
+		return nil
+	})
+	if err != nil {
+		// Malformed ORIGIN frames are ignored.
+		return
+	}
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.origins == nil {
+		cc.origins = make(map[string]bool)
+	}
+	for _, addr := range addrs {
+		cc.origins[addr] = true
+	}
+}
+
+// canCoalesce reports whether cc may be used for requests to addr,
+// a host:port, on the basis of the ORIGIN frames sent by the server.
+// The server must have listed addr's origin, and the server's
+// certificate must be valid for addr's host. Connections whose
+// certificate chain was not verified, as with InsecureSkipVerify,
+// are never coalesced.
+func (cc *ClientConn) canCoalesce(addr string) bool {
+	cc.mu.Lock()
+	listed := cc.origins[addr]
+	cc.mu.Unlock()
+	if !listed || cc.tlsState == nil || len(cc.tlsState.PeerCertificates) == 0 ||
+		len(cc.tlsState.VerifiedChains) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return cc.tlsState.PeerCertificates[0].VerifyHostname(host) == nil
+}
+
 func (rl *clientConnReadLoop) processPushPromise(f *PushPromiseFrame) error {
 	// We told the peer we don't want them.
 	// Spec says:
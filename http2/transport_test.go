@@ -12,6 +12,7 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"flag"
@@ -38,6 +39,7 @@ import (
 	"time"
 
 	"golang.org/x/net/http2/hpack"
+	"golang.org/x/net/internal/testcert"
 )
 
 var (
@@ -3812,6 +3814,33 @@ func BenchmarkClientResponseHeaders(b *testing.B) {
 	b.Run("1000 Headers", func(b *testing.B) { benchSimpleRoundTrip(b, 0, 1000) })
 }
 
+// BenchmarkClientDataFrames measures the cost of the client reading
+// a response body sent in many small DATA frames.
+func BenchmarkClientDataFrames(b *testing.B) {
+	disableGoroutineTracking(b)
+	b.ReportAllocs()
+	n := b.N
+	ts := newTestServer(b, func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		for i := 0; i < n; i++ {
+			w.Write([]byte("x"))
+			rc.Flush()
+		}
+	}, optQuiet)
+	tr := &Transport{TLSClientConfig: tlsConfigInsecure}
+	defer tr.CloseIdleConnections()
+
+	b.ResetTimer()
+	res, err := tr.RoundTrip(must(http.NewRequest("GET", ts.URL, nil)))
+	if err != nil {
+		b.Fatalf("RoundTrip err = %v; want nil", err)
+	}
+	defer res.Body.Close()
+	if got, err := io.Copy(io.Discard, res.Body); err != nil || got != int64(n) {
+		b.Fatalf("read %v bytes, err %v; want %v bytes", got, err, n)
+	}
+}
+
 func BenchmarkDownloadFrameSize(b *testing.B) {
 	b.Run(" 16k Frame", func(b *testing.B) { benchLargeDownloadRoundTrip(b, 16*1024) })
 	b.Run(" 64k Frame", func(b *testing.B) { benchLargeDownloadRoundTrip(b, 64*1024) })
@@ -5959,3 +5988,206 @@ func testExtendedConnectReadFrameError(t testing.TB) {
 		t.Fatalf("after connection closed: RoundTrip succeeded; want error")
 	}
 }
+
+func TestTransportCoalescesConnsByOrigin(t *testing.T) {
+	synctestTest(t, testTransportCoalescesConnsByOrigin)
+}
+func testTransportCoalescesConnsByOrigin(t testing.TB) {
+	// testcert.LocalhostCert is valid for 127.0.0.1 and example.com.
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := newTestTransport(t)
+
+	req := must(http.NewRequest("GET", "https://127.0.0.1/", nil))
+	rt1 := tt.roundTrip(req)
+	tc := tt.getConn()
+	tc.cc.tlsState = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert.Leaf},
+		VerifiedChains:   [][]*x509.Certificate{{cert.Leaf}},
+	}
+	tc.wantFrameType(FrameSettings)
+	tc.wantFrameType(FrameWindowUpdate)
+	tc.wantHeaders(wantHeader{
+		streamID:  1,
+		endStream: true,
+	})
+	tc.writeSettings()
+	tc.wantFrameType(FrameSettings) // ack
+	// other.tld is listed, but is not covered by the server's certificate.
+	if err := tc.fr.WriteOrigin("https://example.com", "https://other.tld"); err != nil {
+		t.Fatal(err)
+	}
+	tc.writeHeaders(HeadersFrameParam{
+		StreamID:   1,
+		EndHeaders: true,
+		EndStream:  true,
+		BlockFragment: tc.makeHeaderBlockFragment(
+			":status", "200",
+		),
+	})
+	rt1.wantStatus(200)
+
+	// example.com is in the origin set, so the connection is reused.
+	req = must(http.NewRequest("GET", "https://example.com/", nil))
+	rt2 := tt.roundTrip(req)
+	if tt.hasConn() {
+		t.Fatalf("new connection created for example.com; want coalesced conn")
+	}
+	tc.wantHeaders(wantHeader{
+		streamID:  3,
+		endStream: true,
+		header: http.Header{
+			":authority": []string{"example.com"},
+		},
+	})
+	tc.writeHeaders(HeadersFrameParam{
+		StreamID:   3,
+		EndHeaders: true,
+		EndStream:  true,
+		BlockFragment: tc.makeHeaderBlockFragment(
+			":status", "200",
+		),
+	})
+	rt2.wantStatus(200)
+
+	req = must(http.NewRequest("GET", "https://other.tld/", nil))
+	tt.roundTrip(req)
+	if !tt.hasConn() {
+		t.Fatalf("other.tld reused conn; want new connection")
+	}
+	tt.getConn()
+}
+
+func TestTransportCoalescingRequiresVerifiedChain(t *testing.T) {
+	synctestTest(t, testTransportCoalescingRequiresVerifiedChain)
+}
+func testTransportCoalescingRequiresVerifiedChain(t testing.TB) {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := newTestTransport(t)
+
+	req := must(http.NewRequest("GET", "https://127.0.0.1/", nil))
+	rt1 := tt.roundTrip(req)
+	tc := tt.getConn()
+	// The certificate was not verified, as with InsecureSkipVerify.
+	tc.cc.tlsState = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert.Leaf},
+	}
+	tc.wantFrameType(FrameSettings)
+	tc.wantFrameType(FrameWindowUpdate)
+	tc.wantHeaders(wantHeader{
+		streamID:  1,
+		endStream: true,
+	})
+	tc.writeSettings()
+	tc.wantFrameType(FrameSettings) // ack
+	if err := tc.fr.WriteOrigin("https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	tc.writeHeaders(HeadersFrameParam{
+		StreamID:   1,
+		EndHeaders: true,
+		EndStream:  true,
+		BlockFragment: tc.makeHeaderBlockFragment(
+			":status", "200",
+		),
+	})
+	rt1.wantStatus(200)
+
+	req = must(http.NewRequest("GET", "https://example.com/", nil))
+	tt.roundTrip(req)
+	if !tt.hasConn() {
+		t.Fatalf("example.com reused a conn with an unverified certificate; want new connection")
+	}
+	tt.getConn()
+}
+
+func TestCoalescedConnIsOldest(t *testing.T) {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newConn := func() *ClientConn {
+		return &ClientConn{
+			nextStreamID:         1,
+			maxConcurrentStreams: 100,
+			origins:              map[string]bool{"example.com:443": true},
+			tlsState: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert.Leaf},
+				VerifiedChains:   [][]*x509.Certificate{{cert.Leaf}},
+			},
+		}
+	}
+	p := &clientConnPool{}
+	var conns []*ClientConn
+	for i := range 10 {
+		cc := newConn()
+		conns = append(conns, cc)
+		p.addConnLocked(fmt.Sprintf("host%v:443", i), cc)
+	}
+	for i := range 3 {
+		if got := p.coalescedConnLocked("example.com:443"); got != conns[0] {
+			t.Fatalf("coalescedConnLocked() #%v did not return the oldest conn", i)
+		}
+	}
+	p.MarkDead(conns[0])
+	if got := p.coalescedConnLocked("example.com:443"); got != conns[1] {
+		t.Fatalf("coalescedConnLocked() after MarkDead did not return the oldest live conn")
+	}
+}
+
+func TestTransportHPACKPolicyAndStats(t *testing.T) {
+	noIndexing := func(f hpack.HeaderField) bool { return false }
+	serverStats := make(chan [2]hpack.Stats, 1)
+	ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Id", r.Header.Get("X-Request-Id"))
+	}, func(s *Server) {
+		s.HPACKIndexingPolicy = noIndexing
+		s.HPACKStats = func(c net.Conn, encoder, decoder hpack.Stats) {
+			serverStats <- [2]hpack.Stats{encoder, decoder}
+		}
+	})
+
+	tr := &Transport{HPACKIndexingPolicy: noIndexing}
+	c, err := tls.Dial("tcp", ts.Listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{NextProtoTLS},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := tr.NewClientConn(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2"} {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		req.Header.Set("X-Request-Id", id)
+		res, err := cc.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip: %v", err)
+		}
+		res.Body.Close()
+	}
+
+	// Neither side indexes any field, so the peers' decoders see no
+	// insertions either.
+	checkStats := func(side string, encoder, decoder hpack.Stats) {
+		t.Helper()
+		if encoder.Fields == 0 || encoder.EncodedBytes == 0 || encoder.Insertions != 0 || encoder.DynamicHits != 0 {
+			t.Errorf("%s encoder stats = %+v, want fields encoded without insertions", side, encoder)
+		}
+		if decoder.Fields == 0 || decoder.EncodedBytes == 0 || decoder.Insertions != 0 {
+			t.Errorf("%s decoder stats = %+v, want fields decoded without insertions", side, decoder)
+		}
+	}
+	st := cc.State()
+	checkStats("client", st.HPACKEncoder, st.HPACKDecoder)
+	cc.Close()
+	stats := <-serverStats
+	checkStats("server", stats[0], stats[1])
+}
@@ -83,13 +83,52 @@ func (s writeSettings) writeFrame(ctx writeContext) error {
 	return ctx.Framer().WriteSettings([]Setting(s)...)
 }
 
+type writeOrigin []string
+
+func (o writeOrigin) staysWithinBuffer(max int) bool {
+	n := frameHeaderLen
+	for _, origin := range o {
+		n += 2 + len(origin)
+	}
+	return n <= max
+}
+
+func (o writeOrigin) writeFrame(ctx writeContext) error {
+	return ctx.Framer().WriteOrigin([]string(o)...)
+}
+
+// splitOrigins splits origins into ORIGIN frames whose payloads are at most
+// maxSize bytes long. Origins which do not fit in a frame are dropped.
+func splitOrigins(origins []string, maxSize int) []writeOrigin {
+	var frames []writeOrigin
+	var cur writeOrigin
+	n := 0
+	for _, origin := range origins {
+		size := 2 + len(origin)
+		if size > maxSize {
+			continue
+		}
+		if n+size > maxSize {
+			frames = append(frames, cur)
+			cur, n = nil, 0
+		}
+		cur = append(cur, origin)
+		n += size
+	}
+	if len(cur) > 0 {
+		frames = append(frames, cur)
+	}
+	return frames
+}
+
 type writeGoAway struct {
 	maxStreamID uint32
 	code        ErrCode
+	debug       string
 }
 
 func (p *writeGoAway) writeFrame(ctx writeContext) error {
-	err := ctx.Framer().WriteGoAway(p.maxStreamID, p.code, nil)
+	err := ctx.Framer().WriteGoAway(p.maxStreamID, p.code, []byte(p.debug))
 	ctx.Flush() // ignore error: we're hanging up on them anyway
 	return err
 }
@@ -0,0 +1,220 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPConnectError is returned by the HTTP dialers when the proxy doesn't
+// accept a CONNECT request.
+type HTTPConnectError struct {
+	// StatusCode and Status are the status of the response, such as 407
+	// and "407 Proxy Authentication Required".
+	StatusCode int
+	Status     string
+
+	// Header is the header of the response, which includes for example
+	// the Proxy-Authenticate challenges of the proxy.
+	Header http.Header
+}
+
+func (e *HTTPConnectError) Error() string {
+	return "proxy: CONNECT failed: " + e.Status
+}
+
+// An HTTPDialer makes connections through an HTTP/1.1 proxy, sending one
+// CONNECT request (RFC 9110 section 9.3.6) on a new connection to the
+// proxy for each connection.
+type HTTPDialer struct {
+	// Address is the address of the proxy, in the form "host:port".
+	Address string
+
+	// TLSConfig configures the TLS connections to the proxy. If nil, the
+	// connections to the proxy aren't encrypted. If its ServerName is
+	// empty, the host of Address is used.
+	TLSConfig *tls.Config
+
+	// Auth holds the credentials sent to the proxy with the Basic
+	// authentication scheme. It may be nil.
+	Auth *Auth
+
+	// Header holds the additional headers of the CONNECT requests, such
+	// as a Proxy-Authorization header for other authentication schemes.
+	Header http.Header
+
+	// Forward makes the connections to the proxy. If nil, Direct is
+	// used.
+	Forward Dialer
+}
+
+var (
+	_ Dialer        = (*HTTPDialer)(nil)
+	_ ContextDialer = (*HTTPDialer)(nil)
+)
+
+// HTTP returns a Dialer that makes connections through the HTTP proxy at
+// address with an optional username and password. If tlsConfig is
+// non-nil, the connections to the proxy use TLS.
+func HTTP(address string, auth *Auth, tlsConfig *tls.Config, forward Dialer) (*HTTPDialer, error) {
+	return &HTTPDialer{
+		Address:   address,
+		TLSConfig: tlsConfig,
+		Auth:      auth,
+		Forward:   forward,
+	}, nil
+}
+
+// Dial connects to the address addr on the network "tcp", "tcp4" or
+// "tcp6" through the proxy.
+func (d *HTTPDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to the address addr on the network "tcp", "tcp4"
+// or "tcp6" through the proxy. The context is only used for establishing
+// the connection.
+func (d *HTTPDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if err := checkConnectNetwork(network); err != nil {
+		return nil, err
+	}
+	c, err := dialProxy(ctx, d.Forward, d.Address, d.TLSConfig, "http/1.1")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := d.connect(ctx, c, addr)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connect sends a CONNECT request for addr on the connection c to the
+// proxy, and returns the tunnel.
+func (d *HTTPDialer) connect(ctx context.Context, c net.Conn, addr string) (net.Conn, error) {
+	stop := context.AfterFunc(ctx, func() {
+		c.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	req := newConnectRequest(addr, d.Auth, d.Header)
+	if err := req.Write(c); err != nil {
+		return nil, contextError(ctx, err)
+	}
+	br := bufio.NewReader(c)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, &HTTPConnectError{StatusCode: res.StatusCode, Status: res.Status, Header: res.Header}
+	}
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if br.Buffered() > 0 {
+		// The proxy already sent data from the tunnel.
+		return &bufferedConn{Conn: c, br: br}, nil
+	}
+	return c, nil
+}
+
+// checkConnectNetwork returns an error if network can't be tunneled with
+// a CONNECT request.
+func checkConnectNetwork(network string) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return nil
+	}
+	return errors.New("proxy: no support for HTTP proxy connections of type " + network)
+}
+
+// dialProxy connects to the proxy at address with forward, and starts TLS
+// on the connection if tlsConfig is non-nil, negotiating the protocol
+// proto.
+func dialProxy(ctx context.Context, forward Dialer, address string, tlsConfig *tls.Config, proto string) (net.Conn, error) {
+	if forward == nil {
+		forward = Direct
+	}
+	var c net.Conn
+	var err error
+	if f, ok := forward.(ContextDialer); ok {
+		c, err = f.DialContext(ctx, "tcp", address)
+	} else {
+		c, err = dialContext(ctx, forward, "tcp", address)
+	}
+	if err != nil || tlsConfig == nil {
+		return c, err
+	}
+	config := tlsConfig.Clone()
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		config.ServerName = host
+	}
+	if config.NextProtos == nil {
+		config.NextProtos = []string{proto}
+	}
+	tc := tls.Client(c, config)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// newConnectRequest returns a CONNECT request for addr, with the Basic
+// credentials of auth and the headers h.
+func newConnectRequest(addr string, auth *Auth, h http.Header) *http.Request {
+	header := h.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if auth != nil {
+		cred := base64.StdEncoding.EncodeToString([]byte(auth.User + ":" + auth.Password))
+		header.Set("Proxy-Authorization", "Basic "+cred)
+	}
+	return &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: header,
+	}
+}
+
+// contextError returns the error of ctx if it is done, since it is the
+// cause of I/O errors after the deadline of the connection is set.
+func contextError(ctx context.Context, err error) error {
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// A bufferedConn is a connection whose first bytes were read into br.
+type bufferedConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	if c.br.Buffered() > 0 {
+		return c.br.Read(b)
+	}
+	return c.Conn.Read(b)
+}
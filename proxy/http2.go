@@ -0,0 +1,159 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// An HTTP2Dialer makes connections through an HTTP/2 proxy. The
+// connections are tunneled by CONNECT requests (RFC 9113 section 8.5)
+// multiplexed over a single connection to the proxy, which is made when
+// needed.
+type HTTP2Dialer struct {
+	// Address is the address of the proxy, in the form "host:port".
+	Address string
+
+	// TLSConfig configures the TLS connection to the proxy, which must
+	// negotiate HTTP/2 with ALPN. If its ServerName is empty, the host
+	// of Address is used. If nil, the connection to the proxy isn't
+	// encrypted and uses HTTP/2 with prior knowledge.
+	TLSConfig *tls.Config
+
+	// Auth holds the credentials sent to the proxy with the Basic
+	// authentication scheme. It may be nil.
+	Auth *Auth
+
+	// Header holds the additional headers of the CONNECT requests, such
+	// as a Proxy-Authorization header for other authentication schemes.
+	Header http.Header
+
+	// Forward makes the connection to the proxy. If nil, Direct is used.
+	Forward Dialer
+
+	mu sync.Mutex
+	t  *http2.Transport
+	cc *http2.ClientConn
+	pc net.Conn // the connection of cc
+}
+
+var (
+	_ Dialer        = (*HTTP2Dialer)(nil)
+	_ ContextDialer = (*HTTP2Dialer)(nil)
+)
+
+// Dial connects to the address addr on the network "tcp", "tcp4" or
+// "tcp6" through the proxy.
+func (d *HTTP2Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to the address addr on the network "tcp", "tcp4"
+// or "tcp6" through the proxy. The context is only used for establishing
+// the connection.
+func (d *HTTP2Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if err := checkConnectNetwork(network); err != nil {
+		return nil, err
+	}
+	cc, pc, err := d.clientConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request lives as long as the tunnel, so it doesn't use ctx.
+	reqCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	// The body of the request is written to the tunnel by the caller
+	// through a pipe, which also implements the deadlines of the
+	// connection.
+	conn, body := net.Pipe()
+	req := newConnectRequest(addr, d.Auth, d.Header).WithContext(reqCtx)
+	req.Body = body
+	req.ContentLength = -1
+	res, err := cc.RoundTrip(req)
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		cancel()
+		res.Body.Close()
+		conn.Close()
+		return nil, &HTTPConnectError{StatusCode: res.StatusCode, Status: res.Status, Header: res.Header}
+	}
+	go func() {
+		io.Copy(body, res.Body)
+		res.Body.Close()
+		body.Close()
+		cancel()
+	}()
+	return &tunnelConn{Conn: conn, local: pc.LocalAddr(), remote: pc.RemoteAddr()}, nil
+}
+
+// clientConn returns the connection to the proxy, making a new one if
+// there is none that can take new requests.
+func (d *HTTP2Dialer) clientConn(ctx context.Context) (*http2.ClientConn, net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cc != nil && d.cc.CanTakeNewRequest() {
+		return d.cc, d.pc, nil
+	}
+	c, err := dialProxy(ctx, d.Forward, d.Address, d.TLSConfig, "h2")
+	if err != nil {
+		return nil, nil, err
+	}
+	if tc, ok := c.(*tls.Conn); ok && tc.ConnectionState().NegotiatedProtocol != "h2" {
+		c.Close()
+		return nil, nil, errors.New("proxy: HTTP/2 not negotiated with the proxy")
+	}
+	if d.t == nil {
+		d.t = &http2.Transport{}
+	}
+	cc, err := d.t.NewClientConn(c)
+	if err != nil {
+		c.Close()
+		return nil, nil, err
+	}
+	if d.cc != nil {
+		// The previous connection is closed once its tunnels are.
+		go d.cc.Shutdown(context.Background())
+	}
+	d.cc, d.pc = cc, c
+	return cc, c, nil
+}
+
+// Close closes the connection to the proxy, and with it all the
+// tunnels.
+func (d *HTTP2Dialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cc == nil {
+		return nil
+	}
+	err := d.cc.Close()
+	d.cc, d.pc = nil, nil
+	return err
+}
+
+// A tunnelConn is a connection tunneled through a connection to a proxy.
+type tunnelConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *tunnelConn) LocalAddr() net.Addr  { return c.local }
+func (c *tunnelConn) RemoteAddr() net.Addr { return c.remote }
@@ -0,0 +1,261 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/internal/testcert"
+)
+
+// newEchoServer returns the address of a server that echoes what it
+// receives.
+func newEchoServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// connectHandler is a proxy that tunnels the CONNECT requests with the
+// Basic credentials "user" and "pass", over HTTP/1.1 or HTTP/2.
+func connectHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "CONNECT" {
+		http.Error(w, "not a CONNECT request", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="test"`)
+		http.Error(w, "unauthorized", http.StatusProxyAuthRequired)
+		return
+	}
+	target, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer target.Close()
+
+	if r.ProtoMajor == 1 {
+		c, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		brw.WriteString("HTTP/1.1 200 Connection established\r\n\r\n")
+		brw.Flush()
+		go io.Copy(target, brw)
+		io.Copy(c, target)
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+	go func() {
+		io.Copy(target, r.Body)
+		target.(*net.TCPConn).CloseWrite()
+	}()
+	buf := make([]byte, 1024)
+	for {
+		n, err := target.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func testTLSConfig() (server, client *tls.Config) {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		panic(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(testcert.LocalhostCert)
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, &tls.Config{RootCAs: pool}
+}
+
+// checkEcho checks that the connection c is tunneled to an echo server.
+func checkEcho(t *testing.T, c net.Conn) {
+	t.Helper()
+	msg := "hello, world"
+	if _, err := io.WriteString(c, msg); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != msg {
+		t.Errorf("read %q from the tunnel, want %q", buf, msg)
+	}
+}
+
+func TestHTTPDialer(t *testing.T) {
+	echo := newEchoServer(t)
+	serverConfig, clientConfig := testTLSConfig()
+	for _, useTLS := range []bool{false, true} {
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(connectHandler))
+		if useTLS {
+			ts.TLS = serverConfig
+			ts.StartTLS()
+		} else {
+			ts.Start()
+		}
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d := &HTTPDialer{Address: u.Host, Auth: &Auth{User: "user", Password: "pass"}}
+		if useTLS {
+			d.TLSConfig = clientConfig
+		}
+		c, err := d.DialContext(context.Background(), "tcp", echo)
+		if err != nil {
+			t.Fatalf("TLS %t: DialContext() = %v", useTLS, err)
+		}
+		checkEcho(t, c)
+		c.Close()
+
+		d.Auth = nil
+		_, err = d.Dial("tcp", echo)
+		var ce *HTTPConnectError
+		if !errors.As(err, &ce) || ce.StatusCode != http.StatusProxyAuthRequired || ce.Header.Get("Proxy-Authenticate") == "" {
+			t.Errorf("TLS %t: Dial() without credentials = %v, want a 407 error with a Proxy-Authenticate header", useTLS, err)
+		}
+
+		// Other authentication schemes go through Header.
+		d.Header = http.Header{"Proxy-Authorization": {"Basic dXNlcjpwYXNz"}}
+		c, err = d.Dial("tcp", echo)
+		if err != nil {
+			t.Fatalf("TLS %t: Dial() with a Proxy-Authorization header = %v", useTLS, err)
+		}
+		c.Close()
+	}
+}
+
+func TestHTTPFromURL(t *testing.T) {
+	echo := newEchoServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(connectHandler))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.User = url.UserPassword("user", "pass")
+	d, err := FromURL(u, Direct)
+	if err != nil {
+		t.Fatal("FromURL() =", err)
+	}
+	c, err := d.Dial("tcp", echo)
+	if err != nil {
+		t.Fatal("Dial() =", err)
+	}
+	defer c.Close()
+	checkEcho(t, c)
+
+	for _, tt := range []struct {
+		url, addr string
+		tls       bool
+	}{
+		{"http://proxy.example", "proxy.example:80", false},
+		{"https://proxy.example", "proxy.example:443", true},
+		{"https://proxy.example:8443", "proxy.example:8443", true},
+	} {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := FromURL(u, Direct)
+		if err != nil {
+			t.Fatalf("FromURL(%q) = %v", tt.url, err)
+		}
+		hd, ok := d.(*HTTPDialer)
+		if !ok || hd.Address != tt.addr || (hd.TLSConfig != nil) != tt.tls {
+			t.Errorf("FromURL(%q) = %#v, want an HTTPDialer for %s with TLS %t", tt.url, d, tt.addr, tt.tls)
+		}
+	}
+}
+
+func TestHTTP2Dialer(t *testing.T) {
+	echo := newEchoServer(t)
+	serverConfig, clientConfig := testTLSConfig()
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(connectHandler))
+	var conns atomic.Int32
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	ts.EnableHTTP2 = true
+	ts.TLS = serverConfig
+	ts.StartTLS()
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &HTTP2Dialer{Address: u.Host, TLSConfig: clientConfig, Auth: &Auth{User: "user", Password: "pass"}}
+	defer d.Close()
+	var tunnels []net.Conn
+	for range 3 {
+		c, err := d.DialContext(context.Background(), "tcp", echo)
+		if err != nil {
+			t.Fatal("DialContext() =", err)
+		}
+		defer c.Close()
+		tunnels = append(tunnels, c)
+	}
+	for _, c := range tunnels {
+		checkEcho(t, c)
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("tunnels made over %d connections, want 1", got)
+	}
+	if got, want := tunnels[0].RemoteAddr().String(), u.Host; got != want {
+		t.Errorf("RemoteAddr() = %s, want the address of the proxy %s", got, want)
+	}
+
+	// Closing a tunnel leaves the others open.
+	tunnels[0].Close()
+	checkEcho(t, tunnels[1])
+
+	d.Auth = nil
+	_, err = d.Dial("tcp", echo)
+	var ce *HTTPConnectError
+	if !errors.As(err, &ce) || ce.StatusCode != http.StatusProxyAuthRequired || ce.Header.Get("Proxy-Authenticate") == "" {
+		t.Errorf("Dial() without credentials = %v, want a 407 error with a Proxy-Authenticate header", err)
+	}
+}
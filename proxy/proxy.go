@@ -7,6 +7,7 @@
 package proxy // import "golang.org/x/net/proxy"
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/url"
@@ -78,6 +79,11 @@ func RegisterDialerType(scheme string, f func(*url.URL, Dialer) (Dialer, error))
 
 // FromURL returns a Dialer given a URL specification and an underlying
 // Dialer for it to make network requests.
+//
+// The "socks5" and "socks5h" schemes are SOCKS proxies, and the "http" and
+// "https" schemes HTTP proxies that tunnel connections with the CONNECT
+// method, over TLS for "https". Other schemes must have been registered
+// with RegisterDialerType, which can also override the HTTP schemes.
 func FromURL(u *url.URL, forward Dialer) (Dialer, error) {
 	var auth *Auth
 	if u.User != nil {
@@ -106,6 +112,24 @@ func FromURL(u *url.URL, forward Dialer) (Dialer, error) {
 		}
 	}
 
+	// The HTTP proxies come last, since other packages used to register
+	// their own dialers for them.
+	switch u.Scheme {
+	case "http", "https":
+		addr := u.Hostname()
+		port := u.Port()
+		var tlsConfig *tls.Config
+		if u.Scheme == "https" {
+			tlsConfig = &tls.Config{}
+			if port == "" {
+				port = "443"
+			}
+		} else if port == "" {
+			port = "80"
+		}
+		return HTTP(net.JoinHostPort(addr, port), auth, tlsConfig, forward)
+	}
+
 	return nil, errors.New("proxy: unknown scheme: " + u.Scheme)
 }
 
@@ -59,6 +59,7 @@ func TestFromEnvironment(t *testing.T) {
 		{allProxyEnv: "socks5://example.com:8080", noProxyEnv: "localhost, 127.0.0.1", wantTypeOf: &PerHost{}},
 		{allProxyEnv: "socks5h://example.com", wantTypeOf: &socks.Dialer{}},
 		{allProxyEnv: "irc://example.com:8000", wantTypeOf: dummyDialer{}},
+		{allProxyEnv: "https://example.com", wantTypeOf: &HTTPDialer{}},
 		{noProxyEnv: "localhost, 127.0.0.1", wantTypeOf: direct{}},
 		{wantTypeOf: direct{}},
 	}
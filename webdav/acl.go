@@ -0,0 +1,357 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// A Privilege is the local name of a privilege in the DAV: namespace, which
+// controls access to a resource.
+//
+// See http://www.webdav.org/specs/rfc3744.html#privileges
+type Privilege string
+
+const (
+	PrivilegeAll                         Privilege = "all"
+	PrivilegeRead                        Privilege = "read"
+	PrivilegeWrite                       Privilege = "write"
+	PrivilegeWriteProperties             Privilege = "write-properties"
+	PrivilegeWriteContent                Privilege = "write-content"
+	PrivilegeBind                        Privilege = "bind"
+	PrivilegeUnbind                      Privilege = "unbind"
+	PrivilegeUnlock                      Privilege = "unlock"
+	PrivilegeReadACL                     Privilege = "read-acl"
+	PrivilegeReadCurrentUserPrivilegeSet Privilege = "read-current-user-privilege-set"
+	PrivilegeWriteACL                    Privilege = "write-acl"
+)
+
+// aggregatePrivileges holds the privileges contained by aggregate privileges.
+// See http://www.webdav.org/specs/rfc3744.html#privileges
+var aggregatePrivileges = map[Privilege][]Privilege{
+	PrivilegeAll: {
+		PrivilegeRead,
+		PrivilegeWrite,
+		PrivilegeUnlock,
+		PrivilegeReadACL,
+		PrivilegeReadCurrentUserPrivilegeSet,
+		PrivilegeWriteACL,
+	},
+	PrivilegeWrite: {
+		PrivilegeWriteProperties,
+		PrivilegeWriteContent,
+		PrivilegeBind,
+		PrivilegeUnbind,
+	},
+}
+
+// expandPrivileges returns privs and the privileges they contain, without
+// duplicates.
+func expandPrivileges(privs []Privilege) []Privilege {
+	var all []Privilege
+	seen := make(map[Privilege]bool)
+	var expand func(privs []Privilege)
+	expand = func(privs []Privilege) {
+		for _, p := range privs {
+			if !seen[p] {
+				seen[p] = true
+				all = append(all, p)
+				expand(aggregatePrivileges[p])
+			}
+		}
+	}
+	expand(privs)
+	return all
+}
+
+// hasPrivilege reports whether privs grant p, directly or by aggregation.
+func hasPrivilege(privs []Privilege, p Privilege) bool {
+	for _, q := range expandPrivileges(privs) {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// An ACE is an access control entry, which grants or denies privileges to a
+// principal.
+//
+// See http://www.webdav.org/specs/rfc3744.html#PROPERTY_acl
+type ACE struct {
+	// Principal is the href of the principal to which the ACE applies, or
+	// one of "DAV:all", "DAV:authenticated", "DAV:unauthenticated" and
+	// "DAV:self".
+	Principal string
+	// Deny is whether the ACE denies rather than grants Privileges.
+	Deny       bool
+	Privileges []Privilege
+	// Protected is whether the ACE cannot be modified or removed.
+	Protected bool
+	// Inherited is the href of the resource from which the ACE is
+	// inherited. It is empty if the ACE is not inherited.
+	Inherited string
+}
+
+// An Authorizer decides what the principal making a request may do with the
+// resources of a Handler.
+//
+// A Handler with an Authorizer checks that the principal has the privileges
+// that each method requires on the resources it reads or changes, as listed
+// in http://www.webdav.org/specs/rfc3744.html#rfc.section.B, and responds
+// with 403 Forbidden and a DAV:need-privileges error otherwise. It also
+// serves the DAV:current-user-privilege-set and DAV:acl properties.
+type Authorizer interface {
+	// Privileges returns the privileges on the resource name of the
+	// principal making the request r. Aggregate privileges, such as
+	// PrivilegeWrite, imply the privileges they contain.
+	Privileges(r *http.Request, name string) ([]Privilege, error)
+
+	// ACL returns the access control list of the resource name, which is
+	// served to principals with PrivilegeReadACL. If it returns
+	// ErrNotImplemented, the DAV:acl property is undefined.
+	ACL(r *http.Request, name string) ([]ACE, error)
+}
+
+// authContextKey is the context key of the authContext of a request.
+type authContextKey struct{}
+
+// An authContext holds what the properties that describe the privileges of
+// the principal making a request are computed from.
+type authContext struct {
+	a Authorizer
+	r *http.Request
+}
+
+// A neededPrivilege is a privilege that a request needs on a resource.
+type neededPrivilege struct {
+	name string
+	priv Privilege
+}
+
+// neededPrivileges returns the privileges that the request r needs. If r is
+// invalid, it returns those that can be determined, and the request fails
+// when it is handled.
+func (h *Handler) neededPrivileges(r *http.Request) []neededPrivilege {
+	name, _, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return nil
+	}
+	name = slashClean(name)
+	ctx := r.Context()
+	exists := func(name string) bool {
+		_, err := h.FileSystem.Stat(ctx, name)
+		return err == nil
+	}
+	// writeOrBind returns the privilege needed to write to the resource
+	// name, or to create it if it does not exist.
+	writeOrBind := func(name string) neededPrivilege {
+		if exists(name) {
+			return neededPrivilege{name, PrivilegeWriteContent}
+		}
+		return neededPrivilege{path.Dir(name), PrivilegeBind}
+	}
+
+	switch r.Method {
+	case "GET", "HEAD", "POST", "PROPFIND", "REPORT":
+		return []neededPrivilege{{name, PrivilegeRead}}
+	case "PROPPATCH":
+		return []neededPrivilege{{name, PrivilegeWriteProperties}}
+	case "PUT", "LOCK":
+		return []neededPrivilege{writeOrBind(name)}
+	case "MKCOL":
+		return []neededPrivilege{{path.Dir(name), PrivilegeBind}}
+	case "DELETE":
+		return []neededPrivilege{{path.Dir(name), PrivilegeUnbind}}
+	case "UNLOCK":
+		return []neededPrivilege{{name, PrivilegeUnlock}}
+	case "VERSION-CONTROL", "CHECKOUT", "CHECKIN":
+		return []neededPrivilege{{name, PrivilegeWriteContent}}
+	case "COPY", "MOVE":
+		var needs []neededPrivilege
+		if r.Method == "MOVE" {
+			needs = []neededPrivilege{{path.Dir(name), PrivilegeUnbind}}
+		} else {
+			needs = h.readSubtree(ctx, name, r.Header.Get("Depth") != "0")
+		}
+		u, err := url.Parse(r.Header.Get("Destination"))
+		if err != nil {
+			return needs
+		}
+		dst, _, err := h.stripPrefix(u.Path)
+		if err != nil || dst == "" {
+			return needs
+		}
+		dst = slashClean(dst)
+		switch {
+		case !exists(dst):
+			needs = append(needs, neededPrivilege{path.Dir(dst), PrivilegeBind})
+		case r.Method == "COPY":
+			needs = append(needs,
+				neededPrivilege{dst, PrivilegeWriteContent},
+				neededPrivilege{dst, PrivilegeWriteProperties})
+		default:
+			needs = append(needs,
+				neededPrivilege{path.Dir(dst), PrivilegeUnbind},
+				neededPrivilege{path.Dir(dst), PrivilegeBind})
+		}
+		return needs
+	}
+	if h.Methods[r.Method] != nil {
+		return []neededPrivilege{{path.Dir(name), PrivilegeBind}}
+	}
+	return nil
+}
+
+// readSubtree returns the read privileges needed to copy the resource name
+// and, if members is true, all its members.
+func (h *Handler) readSubtree(ctx context.Context, name string, members bool) []neededPrivilege {
+	needs := []neededPrivilege{{name, PrivilegeRead}}
+	if !members {
+		return needs
+	}
+	fi, err := h.FileSystem.Stat(ctx, name)
+	if err != nil || !fi.IsDir() {
+		return needs
+	}
+	walkFS(ctx, h.FileSystem, infiniteDepth, name, fi, func(member string, info os.FileInfo, err error) error {
+		if err == nil && member != name {
+			needs = append(needs, neededPrivilege{member, PrivilegeRead})
+		}
+		return nil
+	})
+	return needs
+}
+
+// authorize checks that the principal making the request r has the
+// privileges it needs, and returns r with a context from which the ACL
+// properties can be computed. If the principal lacks privileges, authorize
+// writes a 403 Forbidden response listing them, and returns
+// errNeedPrivileges. It does nothing if h has no Authorizer.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request) (_ *http.Request, status int, err error) {
+	if h.Authorizer == nil {
+		return r, 0, nil
+	}
+	var missing []neededPrivilege
+	privs := make(map[string][]Privilege)
+	for _, n := range h.neededPrivileges(r) {
+		p, ok := privs[n.name]
+		if !ok {
+			if p, err = h.Authorizer.Privileges(r, n.name); err != nil {
+				return r, http.StatusInternalServerError, err
+			}
+			privs[n.name] = p
+		}
+		if !hasPrivilege(p, n.priv) {
+			missing = append(missing, n)
+		}
+	}
+	if len(missing) == 0 {
+		ctx := context.WithValue(r.Context(), authContextKey{}, &authContext{h.Authorizer, r})
+		return r.WithContext(ctx), 0, nil
+	}
+	// http://www.webdav.org/specs/rfc3744.html#rfc.section.7.1.1
+	var b strings.Builder
+	b.WriteString("<D:need-privileges>")
+	for _, n := range missing {
+		href := (&url.URL{Path: path.Join(h.Prefix, n.name)}).EscapedPath()
+		b.WriteString("<D:resource><D:href>" + escape(href) + "</D:href>" +
+			"<D:privilege><D:" + string(n.priv) + "/></D:privilege></D:resource>")
+	}
+	b.WriteString("</D:need-privileges>")
+	if err := writeError(w, http.StatusForbidden, b.String()); err != nil {
+		return r, 0, err
+	}
+	return r, 0, errNeedPrivileges
+}
+
+// canRead reports whether the principal making the request described by ctx
+// may read the resource name. It is true if the Handler has no Authorizer.
+func canRead(ctx context.Context, name string) (bool, error) {
+	ac, ok := ctx.Value(authContextKey{}).(*authContext)
+	if !ok {
+		return true, nil
+	}
+	privs, err := ac.a.Privileges(ac.r, name)
+	if err != nil {
+		return false, err
+	}
+	return hasPrivilege(privs, PrivilegeRead), nil
+}
+
+// http://www.webdav.org/specs/rfc3744.html#PROPERTY_current-user-privilege-set
+func findCurrentUserPrivilegeSet(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	ac, ok := ctx.Value(authContextKey{}).(*authContext)
+	if !ok {
+		return "", errUndefinedProperty
+	}
+	privs, err := ac.a.Privileges(ac.r, name)
+	if err != nil {
+		return "", err
+	}
+	if !hasPrivilege(privs, PrivilegeReadCurrentUserPrivilegeSet) {
+		return "", errNeedPrivileges
+	}
+	var b strings.Builder
+	for _, p := range expandPrivileges(privs) {
+		b.WriteString(`<D:privilege xmlns:D="DAV:"><D:` + string(p) + `/></D:privilege>`)
+	}
+	return b.String(), nil
+}
+
+// http://www.webdav.org/specs/rfc3744.html#PROPERTY_acl
+func findACL(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	ac, ok := ctx.Value(authContextKey{}).(*authContext)
+	if !ok {
+		return "", errUndefinedProperty
+	}
+	privs, err := ac.a.Privileges(ac.r, name)
+	if err != nil {
+		return "", err
+	}
+	if !hasPrivilege(privs, PrivilegeReadACL) {
+		return "", errNeedPrivileges
+	}
+	acl, err := ac.a.ACL(ac.r, name)
+	if err == ErrNotImplemented {
+		return "", errUndefinedProperty
+	}
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, ace := range acl {
+		b.WriteString(`<D:ace xmlns:D="DAV:"><D:principal>`)
+		switch ace.Principal {
+		case "DAV:all", "DAV:authenticated", "DAV:unauthenticated", "DAV:self":
+			b.WriteString("<D:" + strings.TrimPrefix(ace.Principal, "DAV:") + "/>")
+		default:
+			b.WriteString("<D:href>" + escape(ace.Principal) + "</D:href>")
+		}
+		b.WriteString("</D:principal>")
+		op := "grant"
+		if ace.Deny {
+			op = "deny"
+		}
+		b.WriteString("<D:" + op + ">")
+		for _, p := range ace.Privileges {
+			b.WriteString("<D:privilege><D:" + string(p) + "/></D:privilege>")
+		}
+		b.WriteString("</D:" + op + ">")
+		if ace.Protected {
+			b.WriteString("<D:protected/>")
+		}
+		if ace.Inherited != "" {
+			b.WriteString("<D:inherited><D:href>" + escape(ace.Inherited) + "</D:href></D:inherited>")
+		}
+		b.WriteString("</D:ace>")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testAuthorizer grants each user the privileges listed for the longest
+// prefix of a resource name in rights.
+type testAuthorizer struct {
+	rights map[string]map[string][]Privilege
+}
+
+func (a *testAuthorizer) Privileges(r *http.Request, name string) ([]Privilege, error) {
+	user, _, _ := r.BasicAuth()
+	var privs []Privilege
+	best := -1
+	for prefix, p := range a.rights[user] {
+		if (name == prefix || strings.HasPrefix(name, strings.TrimSuffix(prefix, "/")+"/")) && len(prefix) > best {
+			privs, best = p, len(prefix)
+		}
+	}
+	return privs, nil
+}
+
+func (a *testAuthorizer) ACL(r *http.Request, name string) ([]ACE, error) {
+	return []ACE{
+		{Principal: "/principals/alice", Privileges: []Privilege{PrivilegeAll}, Protected: true},
+		{Principal: "DAV:all", Deny: true, Privileges: []Privilege{PrivilegeWriteACL}},
+	}, nil
+}
+
+// userTransport sends requests with the credentials of a user.
+type userTransport struct {
+	user string
+	rt   http.RoundTripper
+}
+
+func (t *userTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.user, "")
+	return t.rt.RoundTrip(req)
+}
+
+// checkStatuses checks the status of each response, which is the status of
+// its first propstat if it has any.
+func checkStatuses(t *testing.T, what string, resps []Response, want map[string]int) {
+	t.Helper()
+	got := map[string]int{}
+	for _, r := range resps {
+		got[r.Name] = r.Status
+		if len(r.Propstats) != 0 {
+			got[r.Name] = r.Propstats[0].Status
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s %s: got status %d, want %d", what, name, got[name], status)
+		}
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(&Handler{
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+		Authorizer: &testAuthorizer{rights: map[string]map[string][]Privilege{
+			"alice": {"/": {PrivilegeAll}},
+			"bob": {
+				"/":       {PrivilegeRead, PrivilegeReadCurrentUserPrivilegeSet},
+				"/shared": {PrivilegeRead, PrivilegeWrite, PrivilegeReadCurrentUserPrivilegeSet},
+				"/secret": nil,
+			},
+		}},
+	})
+	defer srv.Close()
+	client := func(user string) *Client {
+		c, err := NewClient(&http.Client{Transport: &userTransport{user, srv.Client().Transport}}, srv.URL)
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		return c
+	}
+	alice, bob := client("alice"), client("bob")
+
+	for _, name := range []string{"/shared", "/secret"} {
+		if err := alice.MkCol(ctx, name); err != nil {
+			t.Fatalf("MkCol(%q): %v", name, err)
+		}
+	}
+	if err := alice.Put(ctx, "/x", strings.NewReader("x")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	denied := func(what string, err error, want string) {
+		t.Helper()
+		var e *StatusError
+		if !errors.As(err, &e) || e.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: got error %v, want status 403", what, err)
+			return
+		}
+		if !strings.Contains(e.XMLError, want) {
+			t.Errorf("%s: got error body %q, want it to contain %q", what, e.XMLError, want)
+		}
+	}
+	denied("Put of a new resource", bob.Put(ctx, "/y", strings.NewReader("y")),
+		`<_:resource><_:href>/</_:href><_:privilege><_:bind></_:bind></_:privilege></_:resource>`)
+	denied("Put of an existing resource", bob.Put(ctx, "/x", strings.NewReader("y")), "<_:write-content>")
+	denied("Delete", bob.Delete(ctx, "/x"), "<_:unbind>")
+	denied("Move out of a writable collection", bob.Move(ctx, "/x", "/shared/x", false), "<_:unbind>")
+	_, err := bob.PropPatch(ctx, "/x", []Proppatch{{
+		Props: []Property{{XMLName: xml.Name{Space: "urn:x", Local: "y"}}},
+	}})
+	denied("PropPatch", err, "<_:write-properties>")
+	_, err = bob.Get(ctx, "/secret")
+	denied("Get of an unreadable resource", err, "<_:read>")
+
+	if err := bob.Put(ctx, "/shared/y", strings.NewReader("y")); err != nil {
+		t.Errorf("Put in a writable collection: %v", err)
+	}
+	if err := bob.Copy(ctx, "/x", "/shared/x", 0, false); err != nil {
+		t.Errorf("Copy into a writable collection: %v", err)
+	}
+	if r, err := bob.Get(ctx, "/x"); err != nil {
+		t.Errorf("Get: %v", err)
+	} else {
+		r.Close()
+	}
+
+	// Members that cannot be read are listed without properties.
+	resps, err := bob.PropFind(ctx, "/", 1, []xml.Name{{Space: "DAV:", Local: "resourcetype"}})
+	if err != nil {
+		t.Fatalf("PropFind: %v", err)
+	}
+	checkStatuses(t, "PropFind", resps, map[string]int{"/": 200, "/secret/": 403, "/shared/": 200, "/x": 200})
+
+	cups := xml.Name{Space: "DAV:", Local: "current-user-privilege-set"}
+	acl := xml.Name{Space: "DAV:", Local: "acl"}
+	props := func(c *Client, name string) map[xml.Name]Propstat {
+		t.Helper()
+		resps, err := c.PropFind(ctx, name, 0, []xml.Name{cups, acl})
+		if err != nil || len(resps) != 1 {
+			t.Fatalf("PropFind: got %+v, %v", resps, err)
+		}
+		m := map[xml.Name]Propstat{}
+		for _, ps := range resps[0].Propstats {
+			for _, p := range ps.Props {
+				m[p.XMLName] = Propstat{Status: ps.Status, Props: []Property{p}}
+			}
+		}
+		return m
+	}
+	m := props(bob, "/shared")
+	if ps := m[cups]; ps.Status != http.StatusOK ||
+		!strings.Contains(string(ps.Props[0].InnerXML), "write-content") {
+		t.Errorf("bob's current-user-privilege-set: got %+v", ps)
+	}
+	if ps := m[acl]; ps.Status != http.StatusForbidden {
+		t.Errorf("bob's acl: got status %d, want 403", ps.Status)
+	}
+	m = props(alice, "/")
+	if ps := m[acl]; ps.Status != http.StatusOK ||
+		!strings.Contains(string(ps.Props[0].InnerXML), "/principals/alice") ||
+		!strings.Contains(string(ps.Props[0].InnerXML), "<D:deny>") {
+		t.Errorf("alice's acl: got %+v", ps)
+	}
+}
+
+// report sends a REPORT request with the body body for the resource name.
+func report(ctx context.Context, c *Client, name string, depth int, body string) ([]Response, error) {
+	req, err := c.newRequest(ctx, "REPORT", name, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", depthHeader(depth))
+	res, err := c.do(req, name, StatusMulti)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	ms, err := readMultistatus(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return c.responses(ms), nil
+}
+
+func TestAuthorizerMembers(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemFS()
+	for _, name := range []string{"/pub", "/pub/secret", "/dst"} {
+		if err := fs.Mkdir(ctx, name, 0777); err != nil {
+			t.Fatalf("Mkdir(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"/pub/a", "/pub/secret/s"} {
+		f, err := fs.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE, 0666)
+		if err != nil {
+			t.Fatalf("OpenFile(%q): %v", name, err)
+		}
+		f.Close()
+	}
+	testReport := xml.Name{Space: "urn:x", Local: "test"}
+	srv := httptest.NewServer(&Handler{
+		FileSystem: fs,
+		LockSystem: NewMemLS(),
+		Authorizer: &testAuthorizer{rights: map[string]map[string][]Privilege{
+			"bob": {
+				"/":           {PrivilegeRead},
+				"/pub/secret": nil,
+				"/dst":        {PrivilegeAll},
+			},
+		}},
+		Reports: map[xml.Name]ReportFunc{
+			testReport: func(ctx context.Context, name string, depth int, body io.Reader) ([]Response, int, error) {
+				ok := []Propstat{{Status: http.StatusOK, Props: []Property{{
+					XMLName:  xml.Name{Space: "urn:x", Local: "p"},
+					InnerXML: []byte("v"),
+				}}}}
+				return []Response{
+					{Name: "/pub/a", Propstats: ok},
+					{Name: "/pub/secret/s", Propstats: ok},
+				}, 0, nil
+			},
+		},
+	})
+	defer srv.Close()
+	bob, err := NewClient(&http.Client{Transport: &userTransport{"bob", srv.Client().Transport}}, srv.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	resps, err := bob.PropFind(ctx, "/pub", infiniteDepth, []xml.Name{{Space: "DAV:", Local: "resourcetype"}})
+	if err != nil {
+		t.Fatalf("PropFind: %v", err)
+	}
+	checkStatuses(t, "PropFind", resps, map[string]int{"/pub/": 200, "/pub/a": 200, "/pub/secret/": 403})
+
+	resps, err = report(ctx, bob, "/pub", 0, `<x:test xmlns:x="urn:x"/>`)
+	if err != nil {
+		t.Fatalf("REPORT: %v", err)
+	}
+	checkStatuses(t, "REPORT", resps, map[string]int{"/pub/a": 200, "/pub/secret/s": 403})
+	for _, r := range resps {
+		if r.Name == "/pub/secret/s" && len(r.Propstats) != 0 {
+			t.Errorf("REPORT %s: got properties %+v of an unreadable resource", r.Name, r.Propstats)
+		}
+	}
+
+	resps, err = report(ctx, bob, "/pub", 0, `<D:sync-collection xmlns:D="DAV:">`+
+		`<D:sync-token/><D:sync-level>infinite</D:sync-level>`+
+		`<D:prop><D:getetag/></D:prop></D:sync-collection>`)
+	if err != nil {
+		t.Fatalf("sync-collection REPORT: %v", err)
+	}
+	checkStatuses(t, "sync-collection REPORT", resps, map[string]int{
+		"/pub/a":        200,
+		"/pub/secret/":  403,
+		"/pub/secret/s": 403,
+	})
+
+	err = bob.Copy(ctx, "/pub", "/dst/pub", infiniteDepth, false)
+	var e *StatusError
+	if !errors.As(err, &e) || e.StatusCode != http.StatusForbidden ||
+		!strings.Contains(e.XMLError, `<_:resource><_:href>/pub/secret/s</_:href><_:privilege><_:read></_:read></_:privilege></_:resource>`) {
+		t.Errorf("Copy of a collection with unreadable members: got error %v, want a need-privileges error for /pub/secret/s", err)
+	}
+	if _, err := fs.Stat(ctx, "/dst/pub"); !os.IsNotExist(err) {
+		t.Errorf("Copy of a collection with unreadable members: Stat of the destination = %v, want it not to exist", err)
+	}
+	if err := bob.Copy(ctx, "/pub", "/dst/pub", 0, false); err != nil {
+		t.Errorf("Copy with Depth 0: %v", err)
+	}
+}
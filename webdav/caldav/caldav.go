@@ -0,0 +1,507 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package caldav provides a CalDAV server, as described in RFC 4791, built
+// on the webdav package.
+//
+// Calendars and their calendar objects are stored by a Backend. A Handler
+// serves them with the WebDAV methods of a webdav.Handler, plus the
+// MKCALENDAR method and the calendar-query and calendar-multiget reports.
+//
+// See https://www.rfc-editor.org/rfc/rfc4791
+package caldav // import "golang.org/x/net/webdav/caldav"
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/webdav"
+	"golang.org/x/net/webdav/internal/objfs"
+)
+
+// Namespace is the XML namespace of CalDAV elements.
+const Namespace = "urn:ietf:params:xml:ns:caldav"
+
+// A Calendar is a calendar collection.
+type Calendar struct {
+	// Path is the name of the calendar, such as "/alice/work". Calendars
+	// cannot be nested within other calendars.
+	Path string
+	// Name is the DAV:displayname of the calendar, if not empty.
+	Name string
+	// Description is the CALDAV:calendar-description, if not empty.
+	Description string
+	// SupportedComponents lists the names of the calendar components,
+	// such as "VEVENT" and "VTODO", that the calendar can hold. If empty,
+	// VEVENT and VTODO are supported.
+	SupportedComponents []string
+	// CTag changes whenever a calendar object in the calendar changes.
+	// It is reported as the CS:getctag property, if not empty.
+	CTag    string
+	ModTime time.Time
+}
+
+// A CalendarObject is a resource in a calendar, holding an iCalendar
+// object (RFC 5545).
+type CalendarObject struct {
+	// Path is the name of the calendar object, such as
+	// "/alice/work/meeting.ics".
+	Path string
+	// ETag is the entity tag of the calendar object. If empty, one is
+	// derived from ModTime and the size of Data.
+	ETag    string
+	ModTime time.Time
+	Data    []byte
+}
+
+// A Backend stores calendars and calendar objects. Paths are slash-separated
+// and cleaned, without a trailing slash.
+//
+// Methods return an error satisfying errors.Is(err, fs.ErrNotExist) for a
+// missing calendar or calendar object.
+type Backend interface {
+	// Calendars returns all calendars.
+	Calendars(ctx context.Context) ([]Calendar, error)
+	// Calendar returns the calendar at path.
+	Calendar(ctx context.Context, path string) (*Calendar, error)
+	// CreateCalendar creates a new, empty calendar. It returns an error
+	// satisfying errors.Is(err, fs.ErrExist) if there is already a
+	// resource at cal.Path.
+	CreateCalendar(ctx context.Context, cal *Calendar) error
+	// DeleteCalendar deletes the calendar at path and its objects.
+	DeleteCalendar(ctx context.Context, path string) error
+
+	// CalendarObjects returns the objects of the calendar at path.
+	CalendarObjects(ctx context.Context, path string) ([]CalendarObject, error)
+	// CalendarObject returns the calendar object at path.
+	CalendarObject(ctx context.Context, path string) (*CalendarObject, error)
+	// PutCalendarObject creates or replaces the calendar object at path,
+	// in an existing calendar, with data, which the Handler has checked
+	// holds a VCALENDAR component.
+	PutCalendarObject(ctx context.Context, path string, data []byte) error
+	// DeleteCalendarObject deletes the calendar object at path.
+	DeleteCalendarObject(ctx context.Context, path string) error
+}
+
+// A Handler serves CalDAV requests for the calendars of a Backend.
+type Handler struct {
+	// Prefix is the URL path prefix to strip from resource paths.
+	Prefix string
+	// Backend stores the calendars.
+	Backend Backend
+	// LockSystem is the lock management system. If nil, an in-memory
+	// LockSystem is used.
+	LockSystem webdav.LockSystem
+	// Logger is an optional error logger. If non-nil, it will be called
+	// for all HTTP requests.
+	Logger func(*http.Request, error)
+
+	once sync.Once
+	fs   *objfs.FS
+	dav  *webdav.Handler
+}
+
+// MaxObjectSize is the maximum size in bytes of a calendar object that a
+// Handler accepts.
+const MaxObjectSize = 1 << 20
+
+var (
+	errInvalidCalendarData = errors.New("caldav: invalid calendar data")
+	errInvalidFilter       = errors.New("caldav: invalid filter")
+	errNoBackend           = errors.New("caldav: no backend")
+	errObjectTooLarge      = errors.New("caldav: calendar object too large")
+)
+
+var (
+	calendarDataName   = xml.Name{Space: Namespace, Local: "calendar-data"}
+	calendarQueryName  = xml.Name{Space: Namespace, Local: "calendar-query"}
+	calendarMultiget   = xml.Name{Space: Namespace, Local: "calendar-multiget"}
+	displayNameName    = xml.Name{Space: "DAV:", Local: "displayname"}
+	descriptionName    = xml.Name{Space: Namespace, Local: "calendar-description"}
+	supportedCompsName = xml.Name{Space: Namespace, Local: "supported-calendar-component-set"}
+	getCTagName        = xml.Name{Space: "http://calendarserver.org/ns/", Local: "getctag"}
+)
+
+const calendarResourceType = `<D:collection xmlns:D="DAV:"/><C:calendar xmlns:C="urn:ietf:params:xml:ns:caldav"/>`
+
+func (h *Handler) init() {
+	h.fs = &objfs.FS{
+		Backend:     backend{h.Backend},
+		ContentType: "text/calendar; charset=utf-8",
+	}
+	ls := h.LockSystem
+	if ls == nil {
+		ls = webdav.NewMemLS()
+	}
+	h.dav = &webdav.Handler{
+		Prefix:     h.Prefix,
+		FileSystem: h.fs,
+		LockSystem: ls,
+		Logger:     h.Logger,
+		Reports: map[xml.Name]webdav.ReportFunc{
+			calendarQueryName: h.reportCalendarQuery,
+			calendarMultiget:  h.reportCalendarMultiget,
+		},
+		Methods: map[string]webdav.MethodFunc{
+			"MKCALENDAR": h.handleMkcalendar,
+		},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Backend == nil {
+		http.Error(w, webdav.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if h.Logger != nil {
+			h.Logger(r, errNoBackend)
+		}
+		return
+	}
+	h.once.Do(h.init)
+	switch r.Method {
+	case "OPTIONS":
+		ow := &objfs.OptionsWriter{ResponseWriter: w, Class: "calendar-access", Methods: "MKCALENDAR"}
+		h.dav.ServeHTTP(ow, r)
+		ow.AddHeaders()
+	case "PUT":
+		if err := h.checkPut(w, r); err != nil {
+			if h.Logger != nil {
+				h.Logger(r, err)
+			}
+			return
+		}
+		h.dav.ServeHTTP(w, r)
+	default:
+		h.dav.ServeHTTP(w, r)
+	}
+}
+
+func (h *Handler) stripPrefix(p string) (string, bool) {
+	if h.Prefix == "" {
+		return p, true
+	}
+	if r := strings.TrimPrefix(p, h.Prefix); len(r) < len(p) {
+		return r, true
+	}
+	return p, false
+}
+
+// mkcalendar is the body of a MKCALENDAR request.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.3.1
+type mkcalendar struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav mkcalendar"`
+	Prop    struct {
+		DisplayName    string `xml:"DAV: displayname"`
+		Description    string `xml:"urn:ietf:params:xml:ns:caldav calendar-description"`
+		SupportedComps *struct {
+			Comps []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"urn:ietf:params:xml:ns:caldav comp"`
+		} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+	} `xml:"DAV: set>prop"`
+}
+
+// handleMkcalendar creates a calendar. It runs as a method of h.dav, which
+// confirms the locks of the request and checks its privileges.
+// https://www.rfc-editor.org/rfc/rfc4791#section-5.3.1
+func (h *Handler) handleMkcalendar(w http.ResponseWriter, r *http.Request, reqPath string) (status int, err error) {
+	ctx := r.Context()
+	cal := &Calendar{Path: cleanPath(reqPath), ModTime: time.Now()}
+	if cal.Path == "/" {
+		return http.StatusForbidden, fs.ErrExist
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxObjectSize))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		var mk mkcalendar
+		if err := xml.Unmarshal(body, &mk); err != nil {
+			return http.StatusBadRequest, err
+		}
+		cal.Name = mk.Prop.DisplayName
+		cal.Description = mk.Prop.Description
+		if sc := mk.Prop.SupportedComps; sc != nil {
+			for _, c := range sc.Comps {
+				cal.SupportedComponents = append(cal.SupportedComponents, strings.ToUpper(c.Name))
+			}
+		}
+	}
+	if _, err := h.fs.Stat(ctx, cal.Path); err == nil {
+		objfs.WriteError(w, http.StatusForbidden, `<D:resource-must-be-null/>`)
+		return 0, fs.ErrExist
+	}
+	if parent, err := h.fs.Stat(ctx, parentPath(cal.Path)); err == nil && !parent.IsDir() {
+		return http.StatusConflict, fs.ErrNotExist
+	} else if err == nil {
+		if _, err := h.Backend.Calendar(ctx, parentPath(cal.Path)); err == nil {
+			// Calendars cannot be nested.
+			objfs.WriteError(w, http.StatusForbidden, `<C:calendar-collection-location-ok xmlns:C="urn:ietf:params:xml:ns:caldav"/>`)
+			return 0, fs.ErrInvalid
+		}
+	}
+	if err := h.Backend.CreateCalendar(ctx, cal); err != nil {
+		switch {
+		case errors.Is(err, fs.ErrExist):
+			objfs.WriteError(w, http.StatusForbidden, `<D:resource-must-be-null/>`)
+			return 0, err
+		case errors.Is(err, fs.ErrNotExist):
+			return http.StatusConflict, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	return http.StatusCreated, nil
+}
+
+// checkPut checks that the body of a PUT request is a calendar object that
+// the target calendar supports, as required by RFC 4791 section 5.3.2.1.
+// If not, it writes an error response. Otherwise, it replaces r.Body with
+// the checked content.
+func (h *Handler) checkPut(w http.ResponseWriter, r *http.Request) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxObjectSize+1))
+	if err != nil {
+		http.Error(w, webdav.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return err
+	}
+	if len(data) > MaxObjectSize {
+		objfs.WriteError(w, http.StatusForbidden, `<C:max-resource-size xmlns:C="urn:ietf:params:xml:ns:caldav"/>`)
+		return errObjectTooLarge
+	}
+	root, err := objfs.Parse(data)
+	if err != nil || root.Name != "VCALENDAR" {
+		objfs.WriteError(w, http.StatusForbidden, `<C:valid-calendar-data xmlns:C="urn:ietf:params:xml:ns:caldav"/>`)
+		return errInvalidCalendarData
+	}
+	if reqPath, ok := h.stripPrefix(r.URL.Path); ok {
+		cal, err := h.Backend.Calendar(r.Context(), parentPath(cleanPath(reqPath)))
+		if err == nil {
+			supported := supportedComponents(cal)
+			for _, c := range root.Children {
+				if c.Name != "VTIMEZONE" && !slices.Contains(supported, c.Name) {
+					objfs.WriteError(w, http.StatusForbidden, `<C:supported-calendar-component xmlns:C="urn:ietf:params:xml:ns:caldav"/>`)
+					return errInvalidCalendarData
+				}
+			}
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	return nil
+}
+
+func supportedComponents(cal *Calendar) []string {
+	if len(cal.SupportedComponents) == 0 {
+		return []string{"VEVENT", "VTODO"}
+	}
+	return cal.SupportedComponents
+}
+
+// calendarQuery is the body of a calendar-query REPORT.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.5
+type calendarQuery struct {
+	XMLName xml.Name        `xml:"urn:ietf:params:xml:ns:caldav calendar-query"`
+	Prop    objfs.PropNames `xml:"DAV: prop"`
+	Filter  struct {
+		CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// calendarMultigetBody is the body of a calendar-multiget REPORT.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.10
+type calendarMultigetBody struct {
+	XMLName xml.Name        `xml:"urn:ietf:params:xml:ns:caldav calendar-multiget"`
+	Prop    objfs.PropNames `xml:"DAV: prop"`
+	Hrefs   []string        `xml:"DAV: href"`
+}
+
+// reportCalendarQuery reports the calendar objects matching a filter.
+// https://www.rfc-editor.org/rfc/rfc4791#section-7.8
+func (h *Handler) reportCalendarQuery(ctx context.Context, name string, depth int, body io.Reader) ([]webdav.Response, int, error) {
+	var q calendarQuery
+	if err := xml.NewDecoder(body).Decode(&q); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if q.Filter.CompFilter.Name != "VCALENDAR" {
+		return nil, http.StatusForbidden, errInvalidFilter
+	}
+	if err := q.Filter.CompFilter.validate(); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	objs, err := h.fs.Objects(ctx, name, depth)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, http.StatusNotFound, err
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	var responses []webdav.Response
+	for i := range objs {
+		root, err := objfs.Parse(objs[i].Data)
+		if err != nil || !q.Filter.CompFilter.matchRoot(root) {
+			continue
+		}
+		resp, err := h.objectResponse(ctx, &objs[i], q.Prop)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, 0, nil
+}
+
+// reportCalendarMultiget reports the calendar objects named by hrefs.
+// https://www.rfc-editor.org/rfc/rfc4791#section-7.9
+func (h *Handler) reportCalendarMultiget(ctx context.Context, name string, depth int, body io.Reader) ([]webdav.Response, int, error) {
+	var mg calendarMultigetBody
+	if err := xml.NewDecoder(body).Decode(&mg); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	var responses []webdav.Response
+	for _, href := range mg.Hrefs {
+		objName, ok := objfs.HrefName(h.Prefix, href)
+		if !ok {
+			responses = append(responses, webdav.Response{Name: href, Status: http.StatusNotFound})
+			continue
+		}
+		obj, err := h.fs.Backend.Object(ctx, objName)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, http.StatusInternalServerError, err
+			}
+			responses = append(responses, webdav.Response{Name: objName, Status: http.StatusNotFound})
+			continue
+		}
+		resp, err := h.objectResponse(ctx, obj, mg.Prop)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, 0, nil
+}
+
+// objectResponse returns the properties named pnames of obj, including its
+// calendar-data if requested.
+func (h *Handler) objectResponse(ctx context.Context, obj *objfs.Object, pnames objfs.PropNames) (webdav.Response, error) {
+	rest, wantData := pnames.Split(calendarDataName)
+	pstats, err := h.dav.Props(ctx, obj.Path, rest)
+	if err != nil {
+		return webdav.Response{}, err
+	}
+	if wantData {
+		pstats = objfs.AddProp(pstats, objfs.TextProp(calendarDataName, string(obj.Data)))
+	}
+	return webdav.Response{Name: obj.Path, Propstats: pstats}, nil
+}
+
+// backend adapts a Backend to an objfs.Backend.
+type backend struct {
+	b Backend
+}
+
+func calendarCollection(cal *Calendar) objfs.Collection {
+	c := objfs.Collection{
+		Path:         cal.Path,
+		ModTime:      cal.ModTime,
+		ResourceType: calendarResourceType,
+	}
+	var comps strings.Builder
+	for _, name := range supportedComponents(cal) {
+		comps.WriteString(`<C:comp xmlns:C="urn:ietf:params:xml:ns:caldav" name="`)
+		xml.EscapeText(&comps, []byte(name))
+		comps.WriteString(`"/>`)
+	}
+	c.Props = append(c.Props, webdav.Property{XMLName: supportedCompsName, InnerXML: []byte(comps.String())})
+	if cal.Name != "" {
+		c.Props = append(c.Props, objfs.TextProp(displayNameName, cal.Name))
+	}
+	if cal.Description != "" {
+		c.Props = append(c.Props, objfs.TextProp(descriptionName, cal.Description))
+	}
+	if cal.CTag != "" {
+		c.Props = append(c.Props, objfs.TextProp(getCTagName, cal.CTag))
+	}
+	return c
+}
+
+func (b backend) Collections(ctx context.Context) ([]objfs.Collection, error) {
+	cals, err := b.b.Calendars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	colls := make([]objfs.Collection, len(cals))
+	for i := range cals {
+		colls[i] = calendarCollection(&cals[i])
+	}
+	return colls, nil
+}
+
+func (b backend) Collection(ctx context.Context, path string) (*objfs.Collection, error) {
+	cal, err := b.b.Calendar(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c := calendarCollection(cal)
+	return &c, nil
+}
+
+func (b backend) DeleteCollection(ctx context.Context, path string) error {
+	return b.b.DeleteCalendar(ctx, path)
+}
+
+func (b backend) Objects(ctx context.Context, path string) ([]objfs.Object, error) {
+	objs, err := b.b.CalendarObjects(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]objfs.Object, len(objs))
+	for i, o := range objs {
+		ret[i] = objfs.Object(o)
+	}
+	return ret, nil
+}
+
+func (b backend) Object(ctx context.Context, path string) (*objfs.Object, error) {
+	obj, err := b.b.CalendarObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return (*objfs.Object)(obj), nil
+}
+
+func (b backend) PutObject(ctx context.Context, path string, data []byte) error {
+	return b.b.PutCalendarObject(ctx, path, data)
+}
+
+func (b backend) DeleteObject(ctx context.Context, path string) error {
+	return b.b.DeleteCalendarObject(ctx, path)
+}
+
+func cleanPath(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	return p
+}
+
+func parentPath(p string) string {
+	i := strings.LastIndexByte(p, '/')
+	if i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
@@ -0,0 +1,392 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// memBackend is an in-memory Backend.
+type memBackend struct {
+	mu   sync.Mutex
+	cals map[string]*Calendar
+	objs map[string]*CalendarObject
+	seq  int
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{
+		cals: make(map[string]*Calendar),
+		objs: make(map[string]*CalendarObject),
+	}
+}
+
+func (b *memBackend) Calendars(ctx context.Context) ([]Calendar, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var cals []Calendar
+	for _, c := range b.cals {
+		cals = append(cals, *c)
+	}
+	sort.Slice(cals, func(i, j int) bool { return cals[i].Path < cals[j].Path })
+	return cals, nil
+}
+
+func (b *memBackend) Calendar(ctx context.Context, p string) (*Calendar, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.cals[p]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	cc := *c
+	return &cc, nil
+}
+
+func (b *memBackend) CreateCalendar(ctx context.Context, cal *Calendar) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.cals[cal.Path]; ok {
+		return fs.ErrExist
+	}
+	c := *cal
+	c.CTag = "0"
+	b.cals[cal.Path] = &c
+	return nil
+}
+
+func (b *memBackend) DeleteCalendar(ctx context.Context, p string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.cals[p]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(b.cals, p)
+	for name := range b.objs {
+		if path.Dir(name) == p {
+			delete(b.objs, name)
+		}
+	}
+	return nil
+}
+
+func (b *memBackend) CalendarObjects(ctx context.Context, p string) ([]CalendarObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.cals[p]; !ok {
+		return nil, fs.ErrNotExist
+	}
+	var objs []CalendarObject
+	for name, o := range b.objs {
+		if path.Dir(name) == p {
+			objs = append(objs, *o)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Path < objs[j].Path })
+	return objs, nil
+}
+
+func (b *memBackend) CalendarObject(ctx context.Context, p string) (*CalendarObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	o, ok := b.objs[p]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	oo := *o
+	return &oo, nil
+}
+
+func (b *memBackend) PutCalendarObject(ctx context.Context, p string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cal, ok := b.cals[path.Dir(p)]
+	if !ok {
+		return fs.ErrNotExist
+	}
+	b.seq++
+	b.objs[p] = &CalendarObject{
+		Path:    p,
+		ETag:    fmt.Sprintf(`"%d"`, b.seq),
+		ModTime: time.Now(),
+		Data:    slices.Clone(data),
+	}
+	cal.CTag = fmt.Sprint(b.seq)
+	return nil
+}
+
+func (b *memBackend) DeleteCalendarObject(ctx context.Context, p string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.objs[p]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(b.objs, p)
+	b.seq++
+	b.cals[path.Dir(p)].CTag = fmt.Sprint(b.seq)
+	return nil
+}
+
+func event(uid, dtstart, dtend, summary string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:" + uid + "\r\nDTSTAMP:20260101T000000Z\r\n" +
+		"DTSTART" + dtstart + "\r\n" + dtend +
+		"SUMMARY:" + summary + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Status   string `xml:"DAV: status"`
+		Propstat []struct {
+			Prop struct {
+				ETag         string `xml:"DAV: getetag"`
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+				DisplayName  string `xml:"DAV: displayname"`
+				Description  string `xml:"urn:ietf:params:xml:ns:caldav calendar-description"`
+				CTag         string `xml:"http://calendarserver.org/ns/ getctag"`
+				ResourceType struct {
+					Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+				} `xml:"DAV: resourcetype"`
+				Comps []struct {
+					Name string `xml:"name,attr"`
+				} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set>comp"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+func TestHandler(t *testing.T) {
+	backend := newMemBackend()
+	srv := httptest.NewServer(&Handler{Prefix: "/cal", Backend: backend})
+	defer srv.Close()
+
+	do := func(method, p, body string, hdr map[string]string, wantStatus int) string {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+p, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, p, err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != wantStatus {
+			t.Fatalf("%s %s: got status %d, want %d\n%s", method, p, res.StatusCode, wantStatus, b)
+		}
+		if method == "OPTIONS" {
+			return res.Header.Get("DAV") + "|" + res.Header.Get("Allow")
+		}
+		return string(b)
+	}
+	report := func(p, depth, body string) multistatus {
+		t.Helper()
+		var ms multistatus
+		out := do("REPORT", p, body, map[string]string{"Depth": depth}, webdav.StatusMulti)
+		if err := xml.Unmarshal([]byte(out), &ms); err != nil {
+			t.Fatalf("REPORT %s: %v\n%s", p, err, out)
+		}
+		return ms
+	}
+	hrefs := func(ms multistatus) []string {
+		var hrefs []string
+		for _, r := range ms.Responses {
+			hrefs = append(hrefs, r.Href)
+		}
+		return hrefs
+	}
+
+	do("MKCALENDAR", "/cal/alice/work", `<?xml version="1.0" encoding="utf-8" ?>
+		<C:mkcalendar xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+			<D:set><D:prop>
+				<D:displayname>Work</D:displayname>
+				<C:calendar-description>Meetings</C:calendar-description>
+				<C:supported-calendar-component-set><C:comp name="VEVENT"/></C:supported-calendar-component-set>
+			</D:prop></D:set>
+		</C:mkcalendar>`, nil, http.StatusCreated)
+	do("MKCALENDAR", "/cal/alice/work", "", nil, http.StatusForbidden)
+	do("MKCALENDAR", "/cal/alice/work/nested", "", nil, http.StatusForbidden)
+	do("MKCALENDAR", "/cal/alice/home", "", nil, http.StatusCreated)
+
+	// MKCALENDAR must satisfy the locks on the new calendar.
+	req, err := http.NewRequest("LOCK", srv.URL+"/cal/alice", strings.NewReader(`<?xml version="1.0" encoding="utf-8" ?>
+		<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockinfo>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	token := res.Header.Get("Lock-Token")
+	if res.StatusCode != http.StatusOK || token == "" {
+		t.Fatalf("LOCK: got status %d, Lock-Token %q", res.StatusCode, token)
+	}
+	do("MKCALENDAR", "/cal/alice/team", "", nil, webdav.StatusLocked)
+	do("MKCALENDAR", "/cal/alice/team", "", map[string]string{"If": "(" + token + ")"}, http.StatusCreated)
+	do("UNLOCK", "/cal/alice", "", map[string]string{"Lock-Token": token}, http.StatusNoContent)
+
+	if got := do("OPTIONS", "/cal/alice/work", "", nil, http.StatusOK); !strings.Contains(got, "calendar-access") || !strings.Contains(got, "MKCALENDAR") {
+		t.Errorf("OPTIONS headers: got %q, want calendar-access class and MKCALENDAR method", got)
+	}
+
+	do("PUT", "/cal/alice/work/a.ics", event("a", ":20260310T090000Z", "DTEND:20260310T100000Z\r\n", "Standup"), nil, http.StatusCreated)
+	do("PUT", "/cal/alice/work/b.ics", event("b", ";VALUE=DATE:20260315", "", "Offsite"), nil, http.StatusCreated)
+	do("PUT", "/cal/alice/work/c.ics", event("c", ";TZID=Europe/Paris:20260320T140000", "DURATION:PT2H\r\n", "Review"), nil, http.StatusCreated)
+	do("PUT", "/cal/alice/work/bad.ics", "not a calendar", nil, http.StatusForbidden)
+	do("PUT", "/cal/alice/work/todo.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:t\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", nil, http.StatusForbidden)
+	do("PUT", "/cal/alice/home/todo.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:t\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", nil, http.StatusCreated)
+	do("PUT", "/cal/alice/elsewhere.ics", event("x", ":20260310T090000Z", "", "x"), nil, http.StatusConflict)
+
+	// Calendar properties.
+	ms := func() multistatus {
+		var ms multistatus
+		out := do("PROPFIND", "/cal/alice/work", `<?xml version="1.0" encoding="utf-8" ?>
+			<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:CS="http://calendarserver.org/ns/">
+				<D:prop><D:resourcetype/><D:displayname/><C:calendar-description/><C:supported-calendar-component-set/><CS:getctag/></D:prop>
+			</D:propfind>`, map[string]string{"Depth": "0"}, webdav.StatusMulti)
+		if err := xml.Unmarshal([]byte(out), &ms); err != nil {
+			t.Fatalf("PROPFIND: %v\n%s", err, out)
+		}
+		return ms
+	}()
+	if len(ms.Responses) != 1 || len(ms.Responses[0].Propstat) != 1 {
+		t.Fatalf("PROPFIND: got %+v, want a single propstat", ms)
+	}
+	prop := ms.Responses[0].Propstat[0].Prop
+	if prop.ResourceType.Calendar == nil || prop.DisplayName != "Work" || prop.Description != "Meetings" || prop.CTag != "3" {
+		t.Errorf("PROPFIND: got %+v, want calendar Work with ctag 3", prop)
+	}
+	if len(prop.Comps) != 1 || prop.Comps[0].Name != "VEVENT" {
+		t.Errorf("supported-calendar-component-set: got %+v, want VEVENT", prop.Comps)
+	}
+	do("PROPPATCH", "/cal/alice/work", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propertyupdate xmlns:D="DAV:"><D:set><D:prop><D:displayname>x</D:displayname></D:prop></D:set></D:propertyupdate>`,
+		nil, webdav.StatusMulti)
+
+	// calendar-query with a time range.
+	query := func(filter string) []string {
+		t.Helper()
+		return hrefs(report("/cal/alice/work", "1", `<?xml version="1.0" encoding="utf-8" ?>
+			<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+				<D:prop><D:getetag/></D:prop>
+				<C:filter><C:comp-filter name="VCALENDAR">`+filter+`</C:comp-filter></C:filter>
+			</C:calendar-query>`))
+	}
+	for _, tc := range []struct {
+		filter string
+		want   []string
+	}{{
+		`<C:comp-filter name="VEVENT"/>`,
+		[]string{"/cal/alice/work/a.ics", "/cal/alice/work/b.ics", "/cal/alice/work/c.ics"},
+	}, {
+		`<C:comp-filter name="VEVENT"><C:time-range start="20260310T093000Z" end="20260316T000000Z"/></C:comp-filter>`,
+		[]string{"/cal/alice/work/a.ics", "/cal/alice/work/b.ics"},
+	}, {
+		`<C:comp-filter name="VEVENT"><C:time-range start="20260310T100000Z" end="20260315T000000Z"/></C:comp-filter>`,
+		nil,
+	}, {
+		// 14:00 in Paris is 13:00 UTC.
+		`<C:comp-filter name="VEVENT"><C:time-range start="20260320T145900Z" end="20260320T150000Z"/></C:comp-filter>`,
+		[]string{"/cal/alice/work/c.ics"},
+	}, {
+		`<C:comp-filter name="VEVENT"><C:prop-filter name="SUMMARY"><C:text-match>STAND</C:text-match></C:prop-filter></C:comp-filter>`,
+		[]string{"/cal/alice/work/a.ics"},
+	}, {
+		`<C:comp-filter name="VEVENT"><C:prop-filter name="DTSTART"><C:param-filter name="VALUE"><C:text-match negate-condition="yes">DATE</C:text-match></C:param-filter></C:prop-filter></C:comp-filter>`,
+		nil,
+	}, {
+		`<C:comp-filter name="VEVENT"><C:prop-filter name="DTEND"><C:is-not-defined/></C:prop-filter></C:comp-filter>`,
+		[]string{"/cal/alice/work/b.ics", "/cal/alice/work/c.ics"},
+	}, {
+		`<C:comp-filter name="VTODO"/>`,
+		nil,
+	}} {
+		if got := query(tc.filter); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("calendar-query %s:\ngot  %q\nwant %q", tc.filter, got, tc.want)
+		}
+	}
+	do("REPORT", "/cal/alice/work", `<C:calendar-query xmlns:C="urn:ietf:params:xml:ns:caldav"><C:filter><C:comp-filter name="VEVENT"/></C:filter></C:calendar-query>`,
+		map[string]string{"Depth": "1"}, http.StatusForbidden)
+	do("REPORT", "/cal/alice/work", `<C:calendar-query xmlns:C="urn:ietf:params:xml:ns:caldav"><C:filter><C:comp-filter name="VCALENDAR"><C:prop-filter name="X"><C:text-match collation="i;bogus">x</C:text-match></C:prop-filter></C:comp-filter></C:filter></C:calendar-query>`,
+		map[string]string{"Depth": "1"}, http.StatusForbidden)
+
+	// calendar-multiget, including calendar-data.
+	ms = report("/cal/alice/work", "0", `<?xml version="1.0" encoding="utf-8" ?>
+		<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+			<D:prop><D:getetag/><C:calendar-data/></D:prop>
+			<D:href>/cal/alice/work/a.ics</D:href>
+			<D:href>/cal/alice/work/missing.ics</D:href>
+		</C:calendar-multiget>`)
+	if len(ms.Responses) != 2 {
+		t.Fatalf("calendar-multiget: got %d responses, want 2", len(ms.Responses))
+	}
+	a := ms.Responses[0]
+	if a.Href != "/cal/alice/work/a.ics" || len(a.Propstat) != 1 || a.Propstat[0].Prop.ETag != `"1"` || !strings.Contains(a.Propstat[0].Prop.CalendarData, "SUMMARY:Standup") {
+		t.Errorf("calendar-multiget a.ics: got %+v", a)
+	}
+	if m := ms.Responses[1]; m.Href != "/cal/alice/work/missing.ics" || !strings.Contains(m.Status, " 404 ") {
+		t.Errorf("calendar-multiget missing.ics: got %+v, want 404", m)
+	}
+
+	// GET and DELETE of calendar objects, and DELETE of a calendar.
+	if got := do("GET", "/cal/alice/work/b.ics", "", nil, http.StatusOK); !strings.Contains(got, "SUMMARY:Offsite") {
+		t.Errorf("GET b.ics: got %q", got)
+	}
+	do("DELETE", "/cal/alice/work/b.ics", "", nil, http.StatusNoContent)
+	do("GET", "/cal/alice/work/b.ics", "", nil, http.StatusNotFound)
+	do("DELETE", "/cal/alice/home", "", nil, http.StatusNoContent)
+	if _, err := backend.Calendar(context.Background(), "/alice/home"); err == nil {
+		t.Errorf("calendar /alice/home still exists after DELETE")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"P1W", 7 * 24 * time.Hour},
+		{"-P1DT2S", -(24*time.Hour + 2*time.Second)},
+		{"+PT15M", 15 * time.Minute},
+	} {
+		got, err := parseDuration(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "P", "1H", "PT1D", "P1H", "PTH"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q): got nil error", in)
+		}
+	}
+}
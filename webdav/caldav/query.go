@@ -0,0 +1,374 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caldav
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav/internal/objfs"
+)
+
+// compFilter is a CALDAV:comp-filter element.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.7.1
+type compFilter struct {
+	Name         string       `xml:"name,attr"`
+	IsNotDefined *struct{}    `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	PropFilters  []propFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+	CompFilters  []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// propFilter is a CALDAV:prop-filter element.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.7.2
+type propFilter struct {
+	Name         string        `xml:"name,attr"`
+	IsNotDefined *struct{}     `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *timeRange    `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	TextMatch    *textMatch    `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+	ParamFilters []paramFilter `xml:"urn:ietf:params:xml:ns:caldav param-filter"`
+}
+
+// paramFilter is a CALDAV:param-filter element.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.7.3
+type paramFilter struct {
+	Name         string     `xml:"name,attr"`
+	IsNotDefined *struct{}  `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *textMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+// textMatch is a CALDAV:text-match element.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.7.5
+type textMatch struct {
+	Collation       string `xml:"collation,attr"`
+	NegateCondition string `xml:"negate-condition,attr"`
+	Text            string `xml:",chardata"`
+
+	m objfs.TextMatch
+}
+
+// timeRange is a CALDAV:time-range element. Its start and end attributes
+// are date-times in UTC.
+// https://www.rfc-editor.org/rfc/rfc4791#section-9.9
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+
+	start, end time.Time
+}
+
+// validate checks the filter and prepares it for matching.
+func (f *compFilter) validate() error {
+	if f.Name == "" {
+		return errInvalidFilter
+	}
+	f.Name = strings.ToUpper(f.Name)
+	if f.TimeRange != nil {
+		if err := f.TimeRange.validate(); err != nil {
+			return err
+		}
+	}
+	for i := range f.PropFilters {
+		if err := f.PropFilters[i].validate(); err != nil {
+			return err
+		}
+	}
+	for i := range f.CompFilters {
+		if err := f.CompFilters[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *propFilter) validate() error {
+	if f.Name == "" {
+		return errInvalidFilter
+	}
+	f.Name = strings.ToUpper(f.Name)
+	if f.TimeRange != nil {
+		if err := f.TimeRange.validate(); err != nil {
+			return err
+		}
+	}
+	if f.TextMatch != nil {
+		if err := f.TextMatch.validate(); err != nil {
+			return err
+		}
+	}
+	for i := range f.ParamFilters {
+		pf := &f.ParamFilters[i]
+		if pf.Name == "" {
+			return errInvalidFilter
+		}
+		pf.Name = strings.ToUpper(pf.Name)
+		if pf.TextMatch != nil {
+			if err := pf.TextMatch.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *textMatch) validate() error {
+	t.m = objfs.TextMatch{
+		Text:      t.Text,
+		Collation: t.Collation,
+		MatchType: "contains",
+		Negate:    t.NegateCondition == "yes",
+	}
+	if t.m.Collation == "" {
+		t.m.Collation = "i;ascii-casemap"
+	}
+	if !objfs.ValidCollation(t.m.Collation) {
+		return errInvalidFilter
+	}
+	return nil
+}
+
+func (t *timeRange) validate() error {
+	if t.Start == "" && t.End == "" {
+		return errInvalidFilter
+	}
+	var err error
+	if t.Start != "" {
+		if t.start, err = time.Parse(utcLayout, t.Start); err != nil {
+			return errInvalidFilter
+		}
+	}
+	if t.End != "" {
+		if t.end, err = time.Parse(utcLayout, t.End); err != nil {
+			return errInvalidFilter
+		}
+	}
+	return nil
+}
+
+// overlaps reports whether the period from start to end overlaps t. An
+// empty period is a single instant.
+func (t *timeRange) overlaps(start, end time.Time) bool {
+	if !t.end.IsZero() && !start.Before(t.end) {
+		return false
+	}
+	if t.start.IsZero() {
+		return true
+	}
+	if end.Equal(start) {
+		return !start.Before(t.start)
+	}
+	return end.After(t.start)
+}
+
+// matchRoot reports whether the VCALENDAR component root matches f.
+func (f *compFilter) matchRoot(root *objfs.Component) bool {
+	return root.Name == f.Name && f.match(root)
+}
+
+// match reports whether the component c, whose name matches f, matches
+// the other conditions of f.
+func (f *compFilter) match(c *objfs.Component) bool {
+	if f.TimeRange != nil && !componentOverlaps(c, f.TimeRange) {
+		return false
+	}
+	for i := range f.PropFilters {
+		if !f.PropFilters[i].matchIn(c) {
+			return false
+		}
+	}
+	for i := range f.CompFilters {
+		if !f.CompFilters[i].matchIn(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchIn reports whether the children of parent satisfy f.
+func (f *compFilter) matchIn(parent *objfs.Component) bool {
+	children := parent.ChildrenNamed(f.Name)
+	if f.IsNotDefined != nil {
+		return len(children) == 0
+	}
+	for _, c := range children {
+		if f.match(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIn reports whether the properties of c satisfy f.
+func (f *propFilter) matchIn(c *objfs.Component) bool {
+	props := c.PropsNamed(f.Name)
+	if f.IsNotDefined != nil {
+		return len(props) == 0
+	}
+	for _, p := range props {
+		if f.match(&p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *propFilter) match(p *objfs.Prop) bool {
+	if f.TimeRange != nil {
+		t, _, err := parseDateTime(p)
+		if err != nil || !f.TimeRange.overlaps(t, t) {
+			return false
+		}
+	}
+	if f.TextMatch != nil && !f.TextMatch.m.Match(p.Value) {
+		return false
+	}
+	for _, pf := range f.ParamFilters {
+		values, ok := p.Params[pf.Name]
+		if pf.IsNotDefined != nil {
+			if ok {
+				return false
+			}
+			continue
+		}
+		if !ok {
+			return false
+		}
+		if pf.TextMatch != nil && !matchAny(&pf.TextMatch.m, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(m *objfs.TextMatch, values []string) bool {
+	for _, v := range values {
+		if m.Match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// componentOverlaps reports whether the component c overlaps t, as
+// described in RFC 4791 section 9.9. A recurring component overlaps t if
+// its first instance starts before the end of t.
+func componentOverlaps(c *objfs.Component, t *timeRange) bool {
+	startProp := c.Prop("DTSTART")
+	if startProp == nil && c.Name == "VTODO" {
+		startProp = c.Prop("DUE")
+	}
+	if startProp == nil {
+		// A to-do without dates, or a component without
+		// a start, cannot be excluded.
+		return c.Name != "VEVENT"
+	}
+	start, isDate, err := parseDateTime(startProp)
+	if err != nil {
+		return false
+	}
+	if c.Prop("RRULE") != nil || c.Prop("RDATE") != nil {
+		return t.end.IsZero() || start.Before(t.end)
+	}
+	end := start
+	if p := c.Prop("DTEND"); p != nil {
+		if end, _, err = parseDateTime(p); err != nil {
+			return false
+		}
+	} else if p := c.Prop("DURATION"); p != nil {
+		d, err := parseDuration(p.Value)
+		if err != nil {
+			return false
+		}
+		end = start.Add(d)
+	} else if isDate {
+		end = start.AddDate(0, 0, 1)
+	}
+	return t.overlaps(start, end)
+}
+
+const (
+	utcLayout      = "20060102T150405Z"
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+// parseDateTime parses the DATE or DATE-TIME value of p, reporting whether
+// it is a DATE. Floating times, and times with an unknown TZID, are
+// interpreted in UTC.
+func parseDateTime(p *objfs.Prop) (t time.Time, isDate bool, err error) {
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; len(tzid) > 0 {
+		if l, err := time.LoadLocation(tzid[0]); err == nil {
+			loc = l
+		}
+	}
+	switch v := p.Value; {
+	case len(v) == len(dateLayout):
+		t, err = time.ParseInLocation(dateLayout, v, loc)
+		return t, true, err
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse(utcLayout, v)
+	default:
+		t, err = time.ParseInLocation(dateTimeLayout, v, loc)
+	}
+	return t, false, err
+}
+
+// parseDuration parses an RFC 5545 duration value, such as "PT1H30M" or
+// "-P1W".
+func parseDuration(s string) (time.Duration, error) {
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	s, ok := strings.CutPrefix(s, "P")
+	if !ok || s == "" {
+		return 0, errInvalidCalendarData
+	}
+	var d time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime, s = true, s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, errInvalidCalendarData
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, errInvalidCalendarData
+		}
+		var unit time.Duration
+		switch c := s[i]; {
+		case c == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case c == 'H' && inTime:
+			unit = time.Hour
+		case c == 'M' && inTime:
+			unit = time.Minute
+		case c == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, errInvalidCalendarData
+		}
+		d += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
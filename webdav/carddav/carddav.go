@@ -0,0 +1,367 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package carddav provides a CardDAV server, as described in RFC 6352, built
+// on the webdav package.
+//
+// Address books and their address objects are stored by a Backend. A Handler
+// serves them with the WebDAV methods of a webdav.Handler, plus the
+// addressbook-query and addressbook-multiget reports.
+//
+// See https://www.rfc-editor.org/rfc/rfc6352
+package carddav // import "golang.org/x/net/webdav/carddav"
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/webdav"
+	"golang.org/x/net/webdav/internal/objfs"
+)
+
+// Namespace is the XML namespace of CardDAV elements.
+const Namespace = "urn:ietf:params:xml:ns:carddav"
+
+// An AddressBook is an address book collection.
+type AddressBook struct {
+	// Path is the name of the address book, such as "/alice/contacts".
+	// Address books cannot be nested within other address books.
+	Path string
+	// Name is the DAV:displayname of the address book, if not empty.
+	Name string
+	// Description is the CARDDAV:addressbook-description, if not empty.
+	Description string
+	// CTag changes whenever an address object in the address book changes.
+	// It is reported as the CS:getctag property, if not empty.
+	CTag    string
+	ModTime time.Time
+}
+
+// An AddressObject is a resource in an address book, holding a vCard
+// (RFC 6350).
+type AddressObject struct {
+	// Path is the name of the address object, such as
+	// "/alice/contacts/bob.vcf".
+	Path string
+	// ETag is the entity tag of the address object. If empty, one is
+	// derived from ModTime and the size of Data.
+	ETag    string
+	ModTime time.Time
+	Data    []byte
+}
+
+// A Backend stores address books and address objects. Paths are
+// slash-separated and cleaned, without a trailing slash.
+//
+// Methods return an error satisfying errors.Is(err, fs.ErrNotExist) for a
+// missing address book or address object.
+type Backend interface {
+	// AddressBooks returns all address books.
+	AddressBooks(ctx context.Context) ([]AddressBook, error)
+	// AddressBook returns the address book at path.
+	AddressBook(ctx context.Context, path string) (*AddressBook, error)
+
+	// AddressObjects returns the objects of the address book at path.
+	AddressObjects(ctx context.Context, path string) ([]AddressObject, error)
+	// AddressObject returns the address object at path.
+	AddressObject(ctx context.Context, path string) (*AddressObject, error)
+	// PutAddressObject creates or replaces the address object at path, in
+	// an existing address book, with data, which the Handler has checked
+	// holds a VCARD component.
+	PutAddressObject(ctx context.Context, path string, data []byte) error
+	// DeleteAddressObject deletes the address object at path.
+	DeleteAddressObject(ctx context.Context, path string) error
+}
+
+// A Handler serves CardDAV requests for the address books of a Backend.
+type Handler struct {
+	// Prefix is the URL path prefix to strip from resource paths.
+	Prefix string
+	// Backend stores the address books.
+	Backend Backend
+	// LockSystem is the lock management system. If nil, an in-memory
+	// LockSystem is used.
+	LockSystem webdav.LockSystem
+	// Logger is an optional error logger. If non-nil, it will be called
+	// for all HTTP requests.
+	Logger func(*http.Request, error)
+
+	once sync.Once
+	fs   *objfs.FS
+	dav  *webdav.Handler
+}
+
+// MaxObjectSize is the maximum size in bytes of an address object that a
+// Handler accepts.
+const MaxObjectSize = 1 << 20
+
+var (
+	errInvalidAddressData = errors.New("carddav: invalid address data")
+	errInvalidFilter      = errors.New("carddav: invalid filter")
+	errNoBackend          = errors.New("carddav: no backend")
+	errObjectTooLarge     = errors.New("carddav: address object too large")
+)
+
+var (
+	addressDataName      = xml.Name{Space: Namespace, Local: "address-data"}
+	addressbookQueryName = xml.Name{Space: Namespace, Local: "addressbook-query"}
+	addressbookMultiget  = xml.Name{Space: Namespace, Local: "addressbook-multiget"}
+	displayNameName      = xml.Name{Space: "DAV:", Local: "displayname"}
+	descriptionName      = xml.Name{Space: Namespace, Local: "addressbook-description"}
+	supportedDataName    = xml.Name{Space: Namespace, Local: "supported-address-data"}
+	getCTagName          = xml.Name{Space: "http://calendarserver.org/ns/", Local: "getctag"}
+)
+
+const (
+	addressbookResourceType = `<D:collection xmlns:D="DAV:"/><A:addressbook xmlns:A="urn:ietf:params:xml:ns:carddav"/>`
+	supportedAddressData    = `<A:address-data-type xmlns:A="urn:ietf:params:xml:ns:carddav" content-type="text/vcard" version="3.0"/>` +
+		`<A:address-data-type xmlns:A="urn:ietf:params:xml:ns:carddav" content-type="text/vcard" version="4.0"/>`
+)
+
+func (h *Handler) init() {
+	h.fs = &objfs.FS{
+		Backend:     backend{h.Backend},
+		ContentType: "text/vcard; charset=utf-8",
+	}
+	ls := h.LockSystem
+	if ls == nil {
+		ls = webdav.NewMemLS()
+	}
+	h.dav = &webdav.Handler{
+		Prefix:     h.Prefix,
+		FileSystem: h.fs,
+		LockSystem: ls,
+		Logger:     h.Logger,
+		Reports: map[xml.Name]webdav.ReportFunc{
+			addressbookQueryName: h.reportAddressbookQuery,
+			addressbookMultiget:  h.reportAddressbookMultiget,
+		},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Backend == nil {
+		http.Error(w, webdav.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if h.Logger != nil {
+			h.Logger(r, errNoBackend)
+		}
+		return
+	}
+	h.once.Do(h.init)
+	switch r.Method {
+	case "OPTIONS":
+		ow := &objfs.OptionsWriter{ResponseWriter: w, Class: "addressbook"}
+		h.dav.ServeHTTP(ow, r)
+		ow.AddHeaders()
+	case "PUT":
+		if err := checkPut(w, r); err != nil {
+			if h.Logger != nil {
+				h.Logger(r, err)
+			}
+			return
+		}
+		h.dav.ServeHTTP(w, r)
+	default:
+		h.dav.ServeHTTP(w, r)
+	}
+}
+
+// checkPut checks that the body of a PUT request is a vCard, as required by
+// RFC 6352 section 6.3.2.1. If not, it writes an error response. Otherwise,
+// it replaces r.Body with the checked content.
+func checkPut(w http.ResponseWriter, r *http.Request) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxObjectSize+1))
+	if err != nil {
+		http.Error(w, webdav.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return err
+	}
+	if len(data) > MaxObjectSize {
+		objfs.WriteError(w, http.StatusForbidden, `<A:max-resource-size xmlns:A="urn:ietf:params:xml:ns:carddav"/>`)
+		return errObjectTooLarge
+	}
+	if root, err := objfs.Parse(data); err != nil || root.Name != "VCARD" {
+		objfs.WriteError(w, http.StatusForbidden, `<A:valid-address-data xmlns:A="urn:ietf:params:xml:ns:carddav"/>`)
+		return errInvalidAddressData
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	return nil
+}
+
+// addressbookMultigetBody is the body of an addressbook-multiget REPORT.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.7
+type addressbookMultigetBody struct {
+	XMLName xml.Name        `xml:"urn:ietf:params:xml:ns:carddav addressbook-multiget"`
+	Prop    objfs.PropNames `xml:"DAV: prop"`
+	Hrefs   []string        `xml:"DAV: href"`
+}
+
+// reportAddressbookQuery reports the address objects matching a filter.
+// https://www.rfc-editor.org/rfc/rfc6352#section-8.6
+func (h *Handler) reportAddressbookQuery(ctx context.Context, name string, depth int, body io.Reader) ([]webdav.Response, int, error) {
+	var q addressbookQuery
+	if err := xml.NewDecoder(body).Decode(&q); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := q.Filter.validate(); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	objs, err := h.fs.Objects(ctx, name, depth)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, http.StatusNotFound, err
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	var responses []webdav.Response
+	for i := range objs {
+		if q.Limit != nil && len(responses) == q.Limit.NResults {
+			// Report the truncation as described in RFC 6352 section 8.6.1.
+			responses = append(responses, webdav.Response{Name: name, Status: http.StatusInsufficientStorage})
+			break
+		}
+		card, err := objfs.Parse(objs[i].Data)
+		if err != nil || !q.Filter.match(card) {
+			continue
+		}
+		resp, err := h.objectResponse(ctx, &objs[i], q.Prop)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, 0, nil
+}
+
+// reportAddressbookMultiget reports the address objects named by hrefs.
+// https://www.rfc-editor.org/rfc/rfc6352#section-8.7
+func (h *Handler) reportAddressbookMultiget(ctx context.Context, name string, depth int, body io.Reader) ([]webdav.Response, int, error) {
+	var mg addressbookMultigetBody
+	if err := xml.NewDecoder(body).Decode(&mg); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	var responses []webdav.Response
+	for _, href := range mg.Hrefs {
+		objName, ok := objfs.HrefName(h.Prefix, href)
+		if !ok {
+			responses = append(responses, webdav.Response{Name: href, Status: http.StatusNotFound})
+			continue
+		}
+		obj, err := h.fs.Backend.Object(ctx, objName)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, http.StatusInternalServerError, err
+			}
+			responses = append(responses, webdav.Response{Name: objName, Status: http.StatusNotFound})
+			continue
+		}
+		resp, err := h.objectResponse(ctx, obj, mg.Prop)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, 0, nil
+}
+
+// objectResponse returns the properties named pnames of obj, including its
+// address-data if requested.
+func (h *Handler) objectResponse(ctx context.Context, obj *objfs.Object, pnames objfs.PropNames) (webdav.Response, error) {
+	rest, wantData := pnames.Split(addressDataName)
+	pstats, err := h.dav.Props(ctx, obj.Path, rest)
+	if err != nil {
+		return webdav.Response{}, err
+	}
+	if wantData {
+		pstats = objfs.AddProp(pstats, objfs.TextProp(addressDataName, string(obj.Data)))
+	}
+	return webdav.Response{Name: obj.Path, Propstats: pstats}, nil
+}
+
+// backend adapts a Backend to an objfs.Backend.
+type backend struct {
+	b Backend
+}
+
+func addressBookCollection(ab *AddressBook) objfs.Collection {
+	c := objfs.Collection{
+		Path:         ab.Path,
+		ModTime:      ab.ModTime,
+		ResourceType: addressbookResourceType,
+		Props: []webdav.Property{
+			{XMLName: supportedDataName, InnerXML: []byte(supportedAddressData)},
+		},
+	}
+	if ab.Name != "" {
+		c.Props = append(c.Props, objfs.TextProp(displayNameName, ab.Name))
+	}
+	if ab.Description != "" {
+		c.Props = append(c.Props, objfs.TextProp(descriptionName, ab.Description))
+	}
+	if ab.CTag != "" {
+		c.Props = append(c.Props, objfs.TextProp(getCTagName, ab.CTag))
+	}
+	return c
+}
+
+func (b backend) Collections(ctx context.Context) ([]objfs.Collection, error) {
+	abs, err := b.b.AddressBooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	colls := make([]objfs.Collection, len(abs))
+	for i := range abs {
+		colls[i] = addressBookCollection(&abs[i])
+	}
+	return colls, nil
+}
+
+func (b backend) Collection(ctx context.Context, path string) (*objfs.Collection, error) {
+	ab, err := b.b.AddressBook(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c := addressBookCollection(ab)
+	return &c, nil
+}
+
+// DeleteCollection always fails; address books are managed by the Backend.
+func (b backend) DeleteCollection(ctx context.Context, path string) error {
+	return fs.ErrPermission
+}
+
+func (b backend) Objects(ctx context.Context, path string) ([]objfs.Object, error) {
+	objs, err := b.b.AddressObjects(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]objfs.Object, len(objs))
+	for i, o := range objs {
+		ret[i] = objfs.Object(o)
+	}
+	return ret, nil
+}
+
+func (b backend) Object(ctx context.Context, path string) (*objfs.Object, error) {
+	obj, err := b.b.AddressObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return (*objfs.Object)(obj), nil
+}
+
+func (b backend) PutObject(ctx context.Context, path string, data []byte) error {
+	return b.b.PutAddressObject(ctx, path, data)
+}
+
+func (b backend) DeleteObject(ctx context.Context, path string) error {
+	return b.b.DeleteAddressObject(ctx, path)
+}
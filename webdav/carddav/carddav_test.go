@@ -0,0 +1,271 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package carddav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// memBackend is an in-memory Backend.
+type memBackend struct {
+	mu   sync.Mutex
+	abs  map[string]*AddressBook
+	objs map[string]*AddressObject
+	seq  int
+}
+
+func (b *memBackend) AddressBooks(ctx context.Context) ([]AddressBook, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var abs []AddressBook
+	for _, ab := range b.abs {
+		abs = append(abs, *ab)
+	}
+	sort.Slice(abs, func(i, j int) bool { return abs[i].Path < abs[j].Path })
+	return abs, nil
+}
+
+func (b *memBackend) AddressBook(ctx context.Context, p string) (*AddressBook, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ab, ok := b.abs[p]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	abab := *ab
+	return &abab, nil
+}
+
+func (b *memBackend) AddressObjects(ctx context.Context, p string) ([]AddressObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.abs[p]; !ok {
+		return nil, fs.ErrNotExist
+	}
+	var objs []AddressObject
+	for name, o := range b.objs {
+		if path.Dir(name) == p {
+			objs = append(objs, *o)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Path < objs[j].Path })
+	return objs, nil
+}
+
+func (b *memBackend) AddressObject(ctx context.Context, p string) (*AddressObject, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	o, ok := b.objs[p]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	oo := *o
+	return &oo, nil
+}
+
+func (b *memBackend) PutAddressObject(ctx context.Context, p string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ab, ok := b.abs[path.Dir(p)]
+	if !ok {
+		return fs.ErrNotExist
+	}
+	b.seq++
+	b.objs[p] = &AddressObject{
+		Path:    p,
+		ETag:    fmt.Sprintf(`"%d"`, b.seq),
+		ModTime: time.Now(),
+		Data:    slices.Clone(data),
+	}
+	ab.CTag = fmt.Sprint(b.seq)
+	return nil
+}
+
+func (b *memBackend) DeleteAddressObject(ctx context.Context, p string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.objs[p]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(b.objs, p)
+	b.seq++
+	b.abs[path.Dir(p)].CTag = fmt.Sprint(b.seq)
+	return nil
+}
+
+func vcard(lines ...string) string {
+	return "BEGIN:VCARD\r\nVERSION:4.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCARD\r\n"
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Status   string `xml:"DAV: status"`
+		Propstat []struct {
+			Prop struct {
+				ETag         string `xml:"DAV: getetag"`
+				AddressData  string `xml:"urn:ietf:params:xml:ns:carddav address-data"`
+				DisplayName  string `xml:"DAV: displayname"`
+				CTag         string `xml:"http://calendarserver.org/ns/ getctag"`
+				ResourceType struct {
+					AddressBook *struct{} `xml:"urn:ietf:params:xml:ns:carddav addressbook"`
+				} `xml:"DAV: resourcetype"`
+				DataTypes []struct {
+					Version string `xml:"version,attr"`
+				} `xml:"urn:ietf:params:xml:ns:carddav supported-address-data>address-data-type"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+func TestHandler(t *testing.T) {
+	backend := &memBackend{
+		abs: map[string]*AddressBook{
+			"/alice/contacts": {Path: "/alice/contacts", Name: "Contacts"},
+		},
+		objs: make(map[string]*AddressObject),
+	}
+	srv := httptest.NewServer(&Handler{Backend: backend})
+	defer srv.Close()
+
+	do := func(method, p, body string, depth string, wantStatus int) (string, http.Header) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+p, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if depth != "" {
+			req.Header.Set("Depth", depth)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, p, err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != wantStatus {
+			t.Fatalf("%s %s: got status %d, want %d\n%s", method, p, res.StatusCode, wantStatus, b)
+		}
+		return string(b), res.Header
+	}
+	multi := func(method, p, depth, body string) multistatus {
+		t.Helper()
+		out, _ := do(method, p, body, depth, webdav.StatusMulti)
+		var ms multistatus
+		if err := xml.Unmarshal([]byte(out), &ms); err != nil {
+			t.Fatalf("%s %s: %v\n%s", method, p, err, out)
+		}
+		return ms
+	}
+
+	if _, hdr := do("OPTIONS", "/alice/contacts", "", "", http.StatusOK); !strings.Contains(hdr.Get("DAV"), "addressbook") {
+		t.Errorf("OPTIONS DAV header: got %q, want addressbook class", hdr.Get("DAV"))
+	}
+	do("PUT", "/alice/contacts/bob.vcf", vcard("UID:bob", "FN:Bob Smith", "EMAIL;TYPE=work:bob@example.com", "TEL;TYPE=cell:+1 555 0100"), "", http.StatusCreated)
+	do("PUT", "/alice/contacts/carol.vcf", vcard("UID:carol", "FN:Carol Jones", "item1.EMAIL:carol@example.org"), "", http.StatusCreated)
+	do("PUT", "/alice/contacts/dave.vcf", vcard("UID:dave", "FN:Dave"), "", http.StatusCreated)
+	do("PUT", "/alice/contacts/bad.vcf", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", "", http.StatusForbidden)
+	do("PUT", "/alice/bob.vcf", vcard("UID:x", "FN:x"), "", http.StatusConflict)
+	do("MKCOL", "/alice/other", "", "", http.StatusMethodNotAllowed)
+	do("DELETE", "/alice/contacts", "", "", http.StatusMethodNotAllowed)
+
+	ms := multi("PROPFIND", "/alice/contacts", "0", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propfind xmlns:D="DAV:" xmlns:A="urn:ietf:params:xml:ns:carddav" xmlns:CS="http://calendarserver.org/ns/">
+			<D:prop><D:resourcetype/><D:displayname/><A:supported-address-data/><CS:getctag/></D:prop>
+		</D:propfind>`)
+	if len(ms.Responses) != 1 || len(ms.Responses[0].Propstat) != 1 {
+		t.Fatalf("PROPFIND: got %+v, want a single propstat", ms)
+	}
+	prop := ms.Responses[0].Propstat[0].Prop
+	if prop.ResourceType.AddressBook == nil || prop.DisplayName != "Contacts" || prop.CTag != "3" || len(prop.DataTypes) != 2 {
+		t.Errorf("PROPFIND: got %+v, want address book Contacts with ctag 3", prop)
+	}
+
+	query := func(filter, limit string) []string {
+		t.Helper()
+		ms := multi("REPORT", "/alice/contacts", "1", `<?xml version="1.0" encoding="utf-8" ?>
+			<A:addressbook-query xmlns:D="DAV:" xmlns:A="urn:ietf:params:xml:ns:carddav">
+				<D:prop><D:getetag/></D:prop>`+filter+limit+`
+			</A:addressbook-query>`)
+		var hrefs []string
+		for _, r := range ms.Responses {
+			hrefs = append(hrefs, r.Href)
+		}
+		return hrefs
+	}
+	for _, tc := range []struct {
+		filter, limit string
+		want          []string
+	}{{
+		`<A:filter/>`, "",
+		[]string{"/alice/contacts/bob.vcf", "/alice/contacts/carol.vcf", "/alice/contacts/dave.vcf"},
+	}, {
+		`<A:filter><A:prop-filter name="FN"><A:text-match match-type="starts-with">carol</A:text-match></A:prop-filter></A:filter>`, "",
+		[]string{"/alice/contacts/carol.vcf"},
+	}, {
+		`<A:filter><A:prop-filter name="EMAIL"/></A:filter>`, "",
+		[]string{"/alice/contacts/bob.vcf", "/alice/contacts/carol.vcf"},
+	}, {
+		`<A:filter><A:prop-filter name="EMAIL"><A:is-not-defined/></A:prop-filter></A:filter>`, "",
+		[]string{"/alice/contacts/dave.vcf"},
+	}, {
+		`<A:filter test="allof"><A:prop-filter name="EMAIL"/><A:prop-filter name="TEL"/></A:filter>`, "",
+		[]string{"/alice/contacts/bob.vcf"},
+	}, {
+		`<A:filter><A:prop-filter name="EMAIL"><A:param-filter name="TYPE"><A:text-match match-type="equals">WORK</A:text-match></A:param-filter></A:prop-filter></A:filter>`, "",
+		[]string{"/alice/contacts/bob.vcf"},
+	}, {
+		`<A:filter><A:prop-filter name="FN" test="allof"><A:text-match>o</A:text-match><A:text-match negate-condition="yes" match-type="ends-with">smith</A:text-match></A:prop-filter></A:filter>`, "",
+		[]string{"/alice/contacts/carol.vcf"},
+	}, {
+		`<A:filter/>`, `<A:limit><A:nresults>1</A:nresults></A:limit>`,
+		[]string{"/alice/contacts/bob.vcf", "/alice/contacts"},
+	}} {
+		if got := query(tc.filter, tc.limit); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("addressbook-query %s%s:\ngot  %q\nwant %q", tc.filter, tc.limit, got, tc.want)
+		}
+	}
+	do("REPORT", "/alice/contacts", `<A:addressbook-query xmlns:A="urn:ietf:params:xml:ns:carddav"><A:filter><A:prop-filter name="FN"><A:text-match match-type="regex">x</A:text-match></A:prop-filter></A:filter></A:addressbook-query>`,
+		"1", http.StatusForbidden)
+
+	ms = multi("REPORT", "/alice/contacts", "0", `<?xml version="1.0" encoding="utf-8" ?>
+		<A:addressbook-multiget xmlns:D="DAV:" xmlns:A="urn:ietf:params:xml:ns:carddav">
+			<D:prop><D:getetag/><A:address-data/></D:prop>
+			<D:href>/alice/contacts/dave.vcf</D:href>
+			<D:href>/alice/contacts/nobody.vcf</D:href>
+		</A:addressbook-multiget>`)
+	if len(ms.Responses) != 2 {
+		t.Fatalf("addressbook-multiget: got %d responses, want 2", len(ms.Responses))
+	}
+	d := ms.Responses[0]
+	if d.Href != "/alice/contacts/dave.vcf" || len(d.Propstat) != 1 || d.Propstat[0].Prop.ETag != `"3"` || !strings.Contains(d.Propstat[0].Prop.AddressData, "FN:Dave") {
+		t.Errorf("addressbook-multiget dave.vcf: got %+v", d)
+	}
+	if n := ms.Responses[1]; n.Href != "/alice/contacts/nobody.vcf" || !strings.Contains(n.Status, " 404 ") {
+		t.Errorf("addressbook-multiget nobody.vcf: got %+v, want 404", n)
+	}
+
+	do("DELETE", "/alice/contacts/dave.vcf", "", "", http.StatusNoContent)
+	do("GET", "/alice/contacts/dave.vcf", "", "", http.StatusNotFound)
+}
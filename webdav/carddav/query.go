@@ -0,0 +1,206 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package carddav
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"golang.org/x/net/webdav/internal/objfs"
+)
+
+// addressbookQuery is the body of an addressbook-query REPORT.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.3
+type addressbookQuery struct {
+	XMLName xml.Name        `xml:"urn:ietf:params:xml:ns:carddav addressbook-query"`
+	Prop    objfs.PropNames `xml:"DAV: prop"`
+	Filter  filter          `xml:"urn:ietf:params:xml:ns:carddav filter"`
+	Limit   *struct {
+		NResults int `xml:"urn:ietf:params:xml:ns:carddav nresults"`
+	} `xml:"urn:ietf:params:xml:ns:carddav limit"`
+}
+
+// filter is a CARDDAV:filter element.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.5
+type filter struct {
+	Test        string       `xml:"test,attr"`
+	PropFilters []propFilter `xml:"urn:ietf:params:xml:ns:carddav prop-filter"`
+}
+
+// propFilter is a CARDDAV:prop-filter element.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.5.1
+type propFilter struct {
+	Name         string        `xml:"name,attr"`
+	Test         string        `xml:"test,attr"`
+	IsNotDefined *struct{}     `xml:"urn:ietf:params:xml:ns:carddav is-not-defined"`
+	TextMatches  []textMatch   `xml:"urn:ietf:params:xml:ns:carddav text-match"`
+	ParamFilters []paramFilter `xml:"urn:ietf:params:xml:ns:carddav param-filter"`
+}
+
+// paramFilter is a CARDDAV:param-filter element.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.5.2
+type paramFilter struct {
+	Name         string     `xml:"name,attr"`
+	IsNotDefined *struct{}  `xml:"urn:ietf:params:xml:ns:carddav is-not-defined"`
+	TextMatch    *textMatch `xml:"urn:ietf:params:xml:ns:carddav text-match"`
+}
+
+// textMatch is a CARDDAV:text-match element.
+// https://www.rfc-editor.org/rfc/rfc6352#section-10.5.4
+type textMatch struct {
+	Collation       string `xml:"collation,attr"`
+	NegateCondition string `xml:"negate-condition,attr"`
+	MatchType       string `xml:"match-type,attr"`
+	Text            string `xml:",chardata"`
+
+	m objfs.TextMatch
+}
+
+func validTest(test string) bool {
+	return test == "" || test == "anyof" || test == "allof"
+}
+
+// validate checks the filter and prepares it for matching.
+func (f *filter) validate() error {
+	if !validTest(f.Test) {
+		return errInvalidFilter
+	}
+	for i := range f.PropFilters {
+		pf := &f.PropFilters[i]
+		if pf.Name == "" || !validTest(pf.Test) {
+			return errInvalidFilter
+		}
+		pf.Name = strings.ToUpper(pf.Name)
+		for j := range pf.TextMatches {
+			if err := pf.TextMatches[j].validate(); err != nil {
+				return err
+			}
+		}
+		for j := range pf.ParamFilters {
+			pa := &pf.ParamFilters[j]
+			if pa.Name == "" {
+				return errInvalidFilter
+			}
+			pa.Name = strings.ToUpper(pa.Name)
+			if pa.TextMatch != nil {
+				if err := pa.TextMatch.validate(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (t *textMatch) validate() error {
+	t.m = objfs.TextMatch{
+		Text:      t.Text,
+		Collation: t.Collation,
+		MatchType: t.MatchType,
+		Negate:    t.NegateCondition == "yes",
+	}
+	if t.m.Collation == "" {
+		t.m.Collation = "i;unicode-casemap"
+	}
+	if t.m.MatchType == "" {
+		t.m.MatchType = "contains"
+	}
+	if !objfs.ValidCollation(t.m.Collation) || !objfs.ValidMatchType(t.m.MatchType) {
+		return errInvalidFilter
+	}
+	return nil
+}
+
+// combine returns the result of the tests, given as functions, combined as
+// described by test, which is "anyof" (the default) or "allof". With no
+// tests, it returns true.
+func combine[T any](test string, tests []T, match func(*T) bool) bool {
+	if len(tests) == 0 {
+		return true
+	}
+	for i := range tests {
+		ok := match(&tests[i])
+		if ok && test != "allof" {
+			return true
+		}
+		if !ok && test == "allof" {
+			return false
+		}
+	}
+	return test == "allof"
+}
+
+// match reports whether the vCard card matches f.
+func (f *filter) match(card *objfs.Component) bool {
+	return combine(f.Test, f.PropFilters, func(pf *propFilter) bool {
+		return pf.matchIn(card)
+	})
+}
+
+// matchIn reports whether the properties of card satisfy f.
+func (f *propFilter) matchIn(card *objfs.Component) bool {
+	var props []*objfs.Prop
+	for i := range card.Props {
+		// Ignore the group of grouped properties, such as "item1.EMAIL".
+		name := card.Props[i].Name
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			name = name[j+1:]
+		}
+		if name == f.Name {
+			props = append(props, &card.Props[i])
+		}
+	}
+	if f.IsNotDefined != nil {
+		return len(props) == 0
+	}
+	for _, p := range props {
+		if f.match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *propFilter) match(p *objfs.Prop) bool {
+	if len(f.TextMatches) == 0 {
+		return combine(f.Test, f.ParamFilters, func(pf *paramFilter) bool {
+			return pf.match(p)
+		})
+	}
+	if len(f.ParamFilters) == 0 {
+		return combine(f.Test, f.TextMatches, func(tm *textMatch) bool {
+			return tm.m.Match(p.Value)
+		})
+	}
+	texts := combine(f.Test, f.TextMatches, func(tm *textMatch) bool {
+		return tm.m.Match(p.Value)
+	})
+	params := combine(f.Test, f.ParamFilters, func(pf *paramFilter) bool {
+		return pf.match(p)
+	})
+	if f.Test == "allof" {
+		return texts && params
+	}
+	return texts || params
+}
+
+func (f *paramFilter) match(p *objfs.Prop) bool {
+	values, ok := p.Params[f.Name]
+	if f.IsNotDefined != nil {
+		return !ok
+	}
+	if !ok {
+		return false
+	}
+	if f.TextMatch == nil {
+		return true
+	}
+	for _, v := range values {
+		if f.TextMatch.m.Match(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,646 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A StatusError is returned by a Client when the server responds to a
+// request with an unexpected HTTP status.
+type StatusError struct {
+	// Method is the method of the request.
+	Method string
+	// Name is the name of the resource that the status applies to.
+	Name string
+	// StatusCode is the HTTP status code.
+	StatusCode int
+	// XMLError contains the XML representation of the contents of the
+	// DAV:error element of the response, such as a precondition element.
+	// It is empty if there is no such element.
+	XMLError string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webdav: %s %s: %d %s", e.Method, e.Name, e.StatusCode, StatusText(e.StatusCode))
+}
+
+// A Client is a WebDAV client.
+//
+// Its methods name resources with slash-separated paths, like the names of a
+// FileSystem, which are relative to the URL passed to NewClient. A trailing
+// slash may be used to name a collection.
+//
+// A Client remembers the locks that it created, and submits their tokens in
+// the If header of requests that change the resources they lock.
+type Client struct {
+	httpClient *http.Client
+	endpoint   *url.URL
+
+	mu sync.Mutex
+	// locks holds the details of the locks created by Lock, by token.
+	locks map[string]LockDetails
+}
+
+// NewClient returns a Client for the WebDAV resources below the http or
+// https URL endpoint. Requests are sent with c, or http.DefaultClient if c
+// is nil.
+func NewClient(c *http.Client, endpoint string) (*Client, error) {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, errInvalidEndpoint
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath, u.RawQuery, u.Fragment = "", "", ""
+	return &Client{
+		httpClient: c,
+		endpoint:   u,
+		locks:      make(map[string]LockDetails),
+	}, nil
+}
+
+// url returns the URL of the resource name.
+func (c *Client) url(name string) string {
+	p := slashClean(name)
+	if p != "/" && strings.HasSuffix(name, "/") {
+		p += "/"
+	}
+	u := *c.endpoint
+	u.Path += p
+	return u.String()
+}
+
+// name returns the name of the resource at href, which is relative to the
+// endpoint. It returns false if the resource is not below the endpoint.
+func (c *Client) name(href string) (string, bool) {
+	u, err := c.endpoint.Parse(href)
+	if err != nil || u.Host != c.endpoint.Host {
+		return "", false
+	}
+	p, ok := strings.CutPrefix(u.Path, c.endpoint.Path)
+	if !ok || p != "" && p[0] != '/' {
+		return "", false
+	}
+	if p == "" {
+		p = "/"
+	}
+	return p, true
+}
+
+func (c *Client) newRequest(ctx context.Context, method, name string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := body.(*bytes.Buffer); ok {
+		// Only the XML request bodies built by the Client are buffers.
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	}
+	return req, nil
+}
+
+// do sends req, for the resource name, and returns the response if its status
+// is one of want. Otherwise, it returns a *StatusError.
+func (c *Client) do(req *http.Request, name string, want ...int) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(want, res.StatusCode) {
+		return res, nil
+	}
+	defer res.Body.Close()
+	e := &StatusError{
+		Method:     req.Method,
+		Name:       name,
+		StatusCode: res.StatusCode,
+	}
+	if res.StatusCode == StatusMulti {
+		return nil, c.multistatusError(req.Method, e, res.Body)
+	}
+	e.XMLError = readXMLError(io.LimitReader(res.Body, maxErrorBody))
+	return nil, e
+}
+
+// maxErrorBody is the number of bytes of an error response body that are
+// read to find a DAV:error element.
+const maxErrorBody = 64 << 10
+
+// multistatusError returns the error for the first failed resource of the
+// multistatus response body r to a request that only reports failures, such
+// as DELETE, COPY and MOVE. If there is none, it returns e.
+func (c *Client) multistatusError(method string, e *StatusError, r io.Reader) error {
+	ms, err := readMultistatus(r)
+	if err != nil {
+		return err
+	}
+	for _, resp := range ms.Responses {
+		code := parseStatus(resp.Status)
+		if code < 300 || len(resp.Href) == 0 {
+			continue
+		}
+		name, _ := c.name(resp.Href[0])
+		se := &StatusError{Method: method, Name: name, StatusCode: code}
+		if resp.Error != nil {
+			se.XMLError = string(*resp.Error)
+		}
+		return se
+	}
+	return e
+}
+
+// depthHeader returns the Depth header for depth, which is 0, 1 or -1 for
+// infinity.
+func depthHeader(depth int) string {
+	if depth == infiniteDepth {
+		return "infinity"
+	}
+	return strconv.Itoa(depth)
+}
+
+// covers reports whether the lock ld applies to the resource name.
+func (ld *LockDetails) covers(name string) bool {
+	if name == ld.Root {
+		return true
+	}
+	return !ld.ZeroDepth && (ld.Root == "/" || strings.HasPrefix(name, ld.Root+"/"))
+}
+
+// within reports whether the lock ld is on a member of the collection name.
+func (ld *LockDetails) within(name string) bool {
+	return name != ld.Root && (name == "/" || strings.HasPrefix(ld.Root, name+"/"))
+}
+
+// setIf sets the If header of req to submit the tokens of the locks that
+// cover the named resources, the first of which is the request URI. If
+// members is true, the request also changes the members of the resources,
+// so the tokens of the locks on those members are submitted too.
+func (c *Client) setIf(req *http.Request, members bool, names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type list struct{ name, token string }
+	var lists []list
+	tagged := false
+	for i, name := range names {
+		name = slashClean(name)
+		for token, ld := range c.locks {
+			switch {
+			case ld.covers(name):
+				lists = append(lists, list{name, token})
+				tagged = tagged || i > 0
+			case members && ld.within(name):
+				// The list is tagged with the locked member.
+				lists = append(lists, list{ld.Root, token})
+				tagged = true
+			}
+		}
+	}
+	if len(lists) == 0 {
+		return
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].name != lists[j].name {
+			return lists[i].name < lists[j].name
+		}
+		return lists[i].token < lists[j].token
+	})
+	// An untagged list applies to the request URI. Tagged lists are needed
+	// for the destination of COPY and MOVE, and can't be mixed with
+	// untagged ones. See http://www.webdav.org/specs/rfc4918.html#HEADER_If
+	var b strings.Builder
+	for i, l := range lists {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if tagged {
+			b.WriteString("<" + c.url(l.name) + "> ")
+		}
+		b.WriteString("(<" + l.token + ">)")
+	}
+	req.Header.Set("If", b.String())
+}
+
+// writePropName writes the unterminated start tag of the element for a
+// property to b, declaring the namespace of the property so that the element
+// is self-contained. It returns the qualified name of the element.
+func writePropName(b *bytes.Buffer, name xml.Name, lang string) string {
+	elem := name.Local
+	if name.Space == "" {
+		b.WriteString("<" + elem + ` xmlns=""`)
+	} else {
+		elem = "P:" + elem
+		b.WriteString("<" + elem + ` xmlns:P="` + escape(name.Space) + `"`)
+	}
+	if lang != "" {
+		b.WriteString(` xml:lang="` + escape(lang) + `"`)
+	}
+	return elem
+}
+
+// PropFind returns the properties named pnames of the resource name, and of
+// its members up to the given depth, which is 0, 1 or -1 for infinity. If
+// pnames is nil, it returns all the properties that the server reports for
+// DAV:allprop.
+//
+// The Name of each Response is relative to the Client's URL, and has a
+// trailing slash for collections if the server reports one.
+func (c *Client) PropFind(ctx context.Context, name string, depth int, pnames []xml.Name) ([]Response, error) {
+	b := new(bytes.Buffer)
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:propfind xmlns:D="DAV:">`)
+	if pnames == nil {
+		b.WriteString("<D:allprop/>")
+	} else {
+		b.WriteString("<D:prop>")
+		for _, pn := range pnames {
+			writePropName(b, pn, "")
+			b.WriteString("/>")
+		}
+		b.WriteString("</D:prop>")
+	}
+	b.WriteString("</D:propfind>")
+
+	req, err := c.newRequest(ctx, "PROPFIND", name, b)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", depthHeader(depth))
+	res, err := c.do(req, name, StatusMulti)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	ms, err := readMultistatus(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return c.responses(ms), nil
+}
+
+// responses converts the responses of ms to Responses, skipping those for
+// resources that are not below the Client's URL.
+func (c *Client) responses(ms multistatus) []Response {
+	var resps []Response
+	for _, r := range ms.Responses {
+		for _, href := range r.Href {
+			name, ok := c.name(href)
+			if !ok {
+				continue
+			}
+			resp := Response{
+				Name:   name,
+				Status: parseStatus(r.Status),
+			}
+			for _, ps := range r.Propstat {
+				pstat := Propstat{
+					Props:               ps.Prop,
+					Status:              parseStatus(ps.Status),
+					ResponseDescription: ps.ResponseDescription,
+				}
+				if ps.Error != nil {
+					pstat.XMLError = string(*ps.Error)
+				}
+				resp.Propstats = append(resp.Propstats, pstat)
+			}
+			resps = append(resps, resp)
+		}
+	}
+	return resps
+}
+
+// PropPatch sets and removes properties of the resource name, and returns
+// the status of each property. Patches are applied in order.
+func (c *Client) PropPatch(ctx context.Context, name string, patches []Proppatch) ([]Propstat, error) {
+	b := new(bytes.Buffer)
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:propertyupdate xmlns:D="DAV:">`)
+	for _, patch := range patches {
+		op := "D:set"
+		if patch.Remove {
+			op = "D:remove"
+		}
+		b.WriteString("<" + op + "><D:prop>")
+		for _, p := range patch.Props {
+			elem := writePropName(b, p.XMLName, p.Lang)
+			if len(p.InnerXML) == 0 {
+				b.WriteString("/>")
+				continue
+			}
+			b.WriteString(">")
+			b.Write(p.InnerXML)
+			b.WriteString("</" + elem + ">")
+		}
+		b.WriteString("</D:prop></" + op + ">")
+	}
+	b.WriteString("</D:propertyupdate>")
+
+	req, err := c.newRequest(ctx, "PROPPATCH", name, b)
+	if err != nil {
+		return nil, err
+	}
+	c.setIf(req, false, name)
+	res, err := c.do(req, name, StatusMulti)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	ms, err := readMultistatus(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var pstats []Propstat
+	for _, r := range c.responses(ms) {
+		pstats = append(pstats, r.Propstats...)
+	}
+	return pstats, nil
+}
+
+// MkCol creates the collection name.
+func (c *Client) MkCol(ctx context.Context, name string) error {
+	req, err := c.newRequest(ctx, "MKCOL", name, nil)
+	if err != nil {
+		return err
+	}
+	c.setIf(req, false, name)
+	res, err := c.do(req, name, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Delete removes the resource name and, if it is a collection, its members.
+func (c *Client) Delete(ctx context.Context, name string) error {
+	req, err := c.newRequest(ctx, "DELETE", name, nil)
+	if err != nil {
+		return err
+	}
+	c.setIf(req, true, name)
+	res, err := c.do(req, name, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Copy copies the resource src to dst. The depth, which is 0 or -1 for
+// infinity, tells whether the members of a collection are copied too. If
+// overwrite is false, Copy fails if dst exists.
+func (c *Client) Copy(ctx context.Context, src, dst string, depth int, overwrite bool) error {
+	return c.copyMove(ctx, "COPY", src, dst, depthHeader(depth), overwrite)
+}
+
+// Move moves the resource src, and its members if it is a collection, to
+// dst. If overwrite is false, Move fails if dst exists.
+func (c *Client) Move(ctx context.Context, src, dst string, overwrite bool) error {
+	return c.copyMove(ctx, "MOVE", src, dst, "infinity", overwrite)
+}
+
+func (c *Client) copyMove(ctx context.Context, method, src, dst, depth string, overwrite bool) error {
+	req, err := c.newRequest(ctx, method, src, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Destination", c.url(dst))
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Overwrite", "F")
+	if overwrite {
+		req.Header.Set("Overwrite", "T")
+	}
+	// Overwriting dst deletes its members.
+	if method == "MOVE" {
+		c.setIf(req, true, src, dst)
+	} else {
+		c.setIf(req, overwrite, dst)
+	}
+	res, err := c.do(req, src, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Put stores the contents read from r as the resource name. The contents are
+// streamed, so r may be of any length.
+func (c *Client) Put(ctx context.Context, name string, r io.Reader) error {
+	req, err := c.newRequest(ctx, "PUT", name, r)
+	if err != nil {
+		return err
+	}
+	c.setIf(req, false, name)
+	res, err := c.do(req, name, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Get returns the contents of the resource name. The caller must close it.
+func (c *Client) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return c.get(ctx, name, 0)
+}
+
+// get returns the contents of the resource name from offset off. It returns
+// io.EOF if off is beyond the end of the contents.
+func (c *Client) get(ctx context.Context, name string, off int64) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, "GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if off > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	}
+	res, err := c.do(req, name, http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable)
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		res.Body.Close()
+		return nil, io.EOF
+	case http.StatusOK:
+		// The server ignored the Range header.
+		if _, err := io.CopyN(io.Discard, res.Body, off); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
+	return res.Body, nil
+}
+
+// Lock creates a lock as described by details, and returns its token along
+// with the details granted by the server, whose Duration may differ from
+// the one requested. A negative Duration requests an infinite timeout.
+//
+// Until it is unlocked with Unlock, the token is submitted with requests
+// that change the resources the lock applies to.
+func (c *Client) Lock(ctx context.Context, details LockDetails) (token string, granted LockDetails, err error) {
+	b := new(bytes.Buffer)
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:lockinfo xmlns:D="DAV:">` +
+		`<D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype>`)
+	if details.OwnerXML != "" {
+		b.WriteString("<D:owner>" + details.OwnerXML + "</D:owner>")
+	}
+	b.WriteString("</D:lockinfo>")
+
+	req, err := c.newRequest(ctx, "LOCK", details.Root, b)
+	if err != nil {
+		return "", LockDetails{}, err
+	}
+	req.Header.Set("Depth", "infinity")
+	if details.ZeroDepth {
+		req.Header.Set("Depth", "0")
+	}
+	req.Header.Set("Timeout", timeoutHeader(details.Duration))
+	c.setIf(req, false, details.Root)
+	res, err := c.do(req, details.Root, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return "", LockDetails{}, err
+	}
+	defer res.Body.Close()
+
+	token = strings.TrimSuffix(strings.TrimPrefix(res.Header.Get("Lock-Token"), "<"), ">")
+	if token == "" {
+		return "", LockDetails{}, errInvalidLockToken
+	}
+	granted = details
+	granted.Root = slashClean(details.Root)
+	if err := c.readLock(res.Body, token, &granted); err != nil {
+		return "", LockDetails{}, err
+	}
+	c.mu.Lock()
+	c.locks[token] = granted
+	c.mu.Unlock()
+	return token, granted, nil
+}
+
+// RefreshLock refreshes the lock token, created by Lock, with a new timeout,
+// and returns its details. If the server no longer holds the lock, the
+// token is no longer submitted with requests.
+func (c *Client) RefreshLock(ctx context.Context, token string, duration time.Duration) (LockDetails, error) {
+	c.mu.Lock()
+	details, ok := c.locks[token]
+	c.mu.Unlock()
+	if !ok {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	req, err := c.newRequest(ctx, "LOCK", details.Root, nil)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	req.Header.Set("If", "(<"+token+">)")
+	req.Header.Set("Timeout", timeoutHeader(duration))
+	res, err := c.do(req, details.Root, http.StatusOK)
+	if err != nil {
+		if lockGone(err, http.StatusPreconditionFailed) {
+			c.forgetLock(token)
+		}
+		return LockDetails{}, err
+	}
+	defer res.Body.Close()
+	details.Duration = duration
+	if err := c.readLock(res.Body, token, &details); err != nil {
+		return LockDetails{}, err
+	}
+	c.mu.Lock()
+	c.locks[token] = details
+	c.mu.Unlock()
+	return details, nil
+}
+
+// Unlock removes the lock token, created by Lock. The token is no longer
+// submitted with requests, even if Unlock fails because the server no
+// longer holds the lock.
+func (c *Client) Unlock(ctx context.Context, token string) error {
+	c.mu.Lock()
+	details, ok := c.locks[token]
+	c.mu.Unlock()
+	if !ok {
+		return ErrNoSuchLock
+	}
+	req, err := c.newRequest(ctx, "UNLOCK", details.Root, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Lock-Token", "<"+token+">")
+	res, err := c.do(req, details.Root, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		if lockGone(err, http.StatusConflict, http.StatusPreconditionFailed) {
+			c.forgetLock(token)
+		}
+		return err
+	}
+	c.forgetLock(token)
+	return res.Body.Close()
+}
+
+// lockGone reports whether err is a StatusError with one of the codes,
+// which tell that the server no longer holds the lock, for instance
+// because it expired. Submitting its token would make requests fail.
+func lockGone(err error, codes ...int) bool {
+	var e *StatusError
+	return errors.As(err, &e) && slices.Contains(codes, e.StatusCode)
+}
+
+// forgetLock stops submitting the lock token with requests.
+func (c *Client) forgetLock(token string) {
+	c.mu.Lock()
+	delete(c.locks, token)
+	c.mu.Unlock()
+}
+
+// readLock updates details with the lock token described by the body of a
+// response to a LOCK request.
+func (c *Client) readLock(r io.Reader, token string, details *LockDetails) error {
+	ld, err := readLockDiscovery(r)
+	if err != nil {
+		return err
+	}
+	for _, al := range ld.ActiveLocks {
+		if strings.TrimSpace(al.LockToken) != token {
+			continue
+		}
+		if al.Timeout != "" {
+			if details.Duration, err = parseTimeout(al.Timeout); err != nil {
+				return err
+			}
+		}
+		if al.Depth != "" {
+			details.ZeroDepth = al.Depth == "0"
+		}
+		if name, ok := c.name(strings.TrimSpace(al.LockRoot)); ok {
+			details.Root = slashClean(name)
+		}
+		break
+	}
+	return nil
+}
+
+// timeoutHeader returns the Timeout header for duration, which is infinite
+// if negative.
+func timeoutHeader(duration time.Duration) string {
+	if duration < 0 {
+		return "Infinite"
+	}
+	return "Second-" + strconv.FormatInt(int64(duration/time.Second), 10)
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h *Handler, endpoint string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(srv.Client(), srv.URL+endpoint)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func wantStatus(t *testing.T, what string, err error, code int) {
+	t.Helper()
+	var e *StatusError
+	if !errors.As(err, &e) || e.StatusCode != code {
+		t.Errorf("%s: got error %v, want status %d", what, err, code)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	h := &Handler{
+		Prefix:     "/dav",
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+	}
+	c := newTestClient(t, h, "/dav/")
+	other, err := NewClient(c.httpClient, c.endpoint.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.MkCol(ctx, "/a"); err != nil {
+		t.Fatalf("MkCol: %v", err)
+	}
+	wantStatus(t, "MkCol of an existing collection", c.MkCol(ctx, "/a"), http.StatusMethodNotAllowed)
+	if err := c.Put(ctx, "/a/x", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	r, err := c.Get(ctx, "/a/x")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	b, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(b) != "hello" {
+		t.Errorf("Get: got %q, %v, want %q", b, err, "hello")
+	}
+	_, err = c.Get(ctx, "/a/missing")
+	wantStatus(t, "Get of a missing resource", err, http.StatusNotFound)
+
+	length := xml.Name{Space: "DAV:", Local: "getcontentlength"}
+	custom := xml.Name{Space: "urn:x", Local: "color"}
+	pstats, err := c.PropPatch(ctx, "/a/x", []Proppatch{{
+		Props: []Property{{XMLName: custom, InnerXML: []byte("blue")}},
+	}})
+	if err != nil || len(pstats) != 1 || pstats[0].Status != http.StatusOK {
+		t.Fatalf("PropPatch: got %+v, %v", pstats, err)
+	}
+
+	resps, err := c.PropFind(ctx, "/a", 1, []xml.Name{length, custom})
+	if err != nil {
+		t.Fatalf("PropFind: %v", err)
+	}
+	sort.Slice(resps, func(i, j int) bool { return resps[i].Name < resps[j].Name })
+	var names []string
+	for _, r := range resps {
+		names = append(names, r.Name)
+	}
+	if want := []string{"/a/", "/a/x"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("PropFind: got names %q, want %q", names, want)
+	}
+	got := map[xml.Name]string{}
+	for _, ps := range resps[1].Propstats {
+		if ps.Status == http.StatusOK {
+			for _, p := range ps.Props {
+				got[p.XMLName] = xmlText(p.InnerXML)
+			}
+		}
+	}
+	if want := map[xml.Name]string{length: "5", custom: "blue"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PropFind /a/x: got %v, want %v", got, want)
+	}
+	resps, err = c.PropFind(ctx, "/a/x", 0, nil)
+	if err != nil || len(resps) != 1 || len(resps[0].Propstats) == 0 {
+		t.Fatalf("PropFind allprop: got %+v, %v", resps, err)
+	}
+
+	if err := c.Copy(ctx, "/a/x", "/a/y", 0, false); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	wantStatus(t, "Copy without overwrite", c.Copy(ctx, "/a/x", "/a/y", 0, false), http.StatusPreconditionFailed)
+	if err := c.Move(ctx, "/a/y", "/a/z", false); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	// A lock is submitted by the client that created it only.
+	token, granted, err := c.Lock(ctx, LockDetails{
+		Root:     "/a",
+		Duration: time.Hour,
+		OwnerXML: "<D:href>alice</D:href>",
+	})
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if granted.Root != "/a" || granted.Duration != time.Hour || granted.ZeroDepth {
+		t.Errorf("Lock: got %+v", granted)
+	}
+	wantStatus(t, "Put to a resource locked by another client", other.Put(ctx, "/a/x", strings.NewReader("x")), StatusLocked)
+	if err := c.Put(ctx, "/a/x", strings.NewReader("bye")); err != nil {
+		t.Errorf("Put to a locked resource: %v", err)
+	}
+	if err := c.Move(ctx, "/a/z", "/a/w", false); err != nil {
+		t.Errorf("Move within a locked collection: %v", err)
+	}
+	if ld, err := c.RefreshLock(ctx, token, 2*time.Hour); err != nil || ld.Duration != 2*time.Hour {
+		t.Errorf("RefreshLock: got %+v, %v", ld, err)
+	}
+	if err := c.Unlock(ctx, token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := c.Unlock(ctx, token); err != ErrNoSuchLock {
+		t.Errorf("second Unlock: got %v, want ErrNoSuchLock", err)
+	}
+	if err := other.Put(ctx, "/a/x", strings.NewReader("x")); err != nil {
+		t.Errorf("Put after Unlock: %v", err)
+	}
+
+	if err := c.Delete(ctx, "/a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	wantStatus(t, "Delete of a missing resource", c.Delete(ctx, "/a"), http.StatusNotFound)
+}
+
+func TestClientLockTokens(t *testing.T) {
+	ctx := context.Background()
+	h := &Handler{
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+	}
+	var deleteIf string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleteIf = r.Header.Get("If")
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/m", "/s", "/r"} {
+		if err := c.MkCol(ctx, name); err != nil {
+			t.Fatalf("MkCol(%q): %v", name, err)
+		}
+	}
+	if err := c.Put(ctx, "/m/x", strings.NewReader("x")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Deleting a collection submits the tokens of the locks on its members.
+	token, _, err := c.Lock(ctx, LockDetails{Root: "/m/x", Duration: time.Hour})
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if err := c.Delete(ctx, "/m"); err != nil {
+		t.Errorf("Delete of a collection with a locked member: %v", err)
+	}
+	if want := "<" + srv.URL + "/m/x> (<" + token + ">)"; deleteIf != want {
+		t.Errorf("Delete of a collection with a locked member: got If header %q, want %q", deleteIf, want)
+	}
+
+	// Tokens of locks that the server no longer holds are forgotten.
+	for _, tc := range []struct {
+		name    string
+		refresh bool
+		want    int
+	}{
+		{"/s", false, http.StatusConflict},
+		{"/r", true, http.StatusPreconditionFailed},
+	} {
+		token, _, err := c.Lock(ctx, LockDetails{Root: tc.name, Duration: time.Hour})
+		if err != nil {
+			t.Fatalf("Lock: %v", err)
+		}
+		// The lock expires on the server.
+		if err := h.LockSystem.Unlock(time.Now(), token); err != nil {
+			t.Fatal(err)
+		}
+		wantStatus(t, "Put with an expired lock", c.Put(ctx, tc.name+"/y", strings.NewReader("y")), http.StatusPreconditionFailed)
+		if tc.refresh {
+			_, err = c.RefreshLock(ctx, token, time.Hour)
+			wantStatus(t, "RefreshLock of an expired lock", err, tc.want)
+		} else {
+			wantStatus(t, "Unlock of an expired lock", c.Unlock(ctx, token), tc.want)
+		}
+		if err := c.Unlock(ctx, token); err != ErrNoSuchLock {
+			t.Errorf("Unlock of a forgotten lock: got %v, want ErrNoSuchLock", err)
+		}
+		if err := c.Put(ctx, tc.name+"/y", strings.NewReader("y")); err != nil {
+			t.Errorf("Put after forgetting an expired lock: %v", err)
+		}
+	}
+}
+
+func TestClientFileSystem(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestClient(t, &Handler{
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+	}, "/")
+	fs := backend.FileSystem()
+
+	if err := fs.Mkdir(ctx, "/d", 0777); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := fs.Mkdir(ctx, "/d", 0777); !os.IsExist(err) {
+		t.Errorf("Mkdir of an existing directory: got %v, want an os.ErrExist error", err)
+	}
+	if _, err := fs.OpenFile(ctx, "/missing/f", os.O_RDWR|os.O_CREATE, 0666); !os.IsNotExist(err) {
+		t.Errorf("OpenFile in a missing directory: got %v, want an os.ErrNotExist error", err)
+	}
+
+	f, err := fs.OpenFile(ctx, "/d/f", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	for _, s := range []string{"0123", "4567", "89"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fi, err := fs.Stat(ctx, "/d/f")
+	if err != nil || fi.IsDir() || fi.Size() != 10 || fi.Name() != "f" {
+		t.Fatalf("Stat: got %v, %v", fi, err)
+	}
+	if _, err := fs.Stat(ctx, "/d/missing"); !os.IsNotExist(err) {
+		t.Errorf("Stat of a missing file: got %v, want an os.ErrNotExist error", err)
+	}
+
+	f, err = fs.OpenFile(ctx, "/d/f", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Errorf("Write to a read-only file: got nil error")
+	}
+	if _, err := f.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil || string(b) != "6789" {
+		t.Errorf("Read after Seek: got %q, %v, want %q", b, err, "6789")
+	}
+	if n, err := f.Seek(0, io.SeekEnd); err != nil || n != 10 {
+		t.Errorf("Seek to the end: got %d, %v, want 10", n, err)
+	}
+	if n, err := f.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Read at the end: got %d, %v, want io.EOF", n, err)
+	}
+	f.Close()
+
+	if err := fs.Rename(ctx, "/d/f", "/d/g"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	d, err := fs.OpenFile(ctx, "/d", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	children, err := d.Readdir(-1)
+	if err != nil || len(children) != 1 || children[0].Name() != "g" {
+		t.Errorf("Readdir: got %v, %v, want g", children, err)
+	}
+	d.Close()
+	if err := fs.RemoveAll(ctx, "/d"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := fs.Stat(ctx, "/d"); !os.IsNotExist(err) {
+		t.Errorf("Stat after RemoveAll: got %v, want an os.ErrNotExist error", err)
+	}
+
+	// A Handler serves the remote FileSystem.
+	c := newTestClient(t, &Handler{
+		FileSystem: fs,
+		LockSystem: NewMemLS(),
+	}, "/")
+	if err := c.MkCol(ctx, "/e"); err != nil {
+		t.Fatalf("MkCol through the Handler: %v", err)
+	}
+	if err := c.Put(ctx, "/e/h", strings.NewReader("remote")); err != nil {
+		t.Fatalf("Put through the Handler: %v", err)
+	}
+	custom := xml.Name{Space: "urn:x", Local: "size"}
+	if _, err := c.PropPatch(ctx, "/e/h", []Proppatch{{
+		Props: []Property{{XMLName: custom, InnerXML: []byte("large")}},
+	}}); err != nil {
+		t.Fatalf("PropPatch through the Handler: %v", err)
+	}
+	r, err := backend.Get(ctx, "/e/h")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	b, err = io.ReadAll(r)
+	r.Close()
+	if err != nil || string(b) != "remote" {
+		t.Errorf("Get: got %q, %v, want %q", b, err, "remote")
+	}
+	resps, err := c.PropFind(ctx, "/e/h", 0, []xml.Name{custom, {Space: "DAV:", Local: "getcontentlength"}})
+	if err != nil || len(resps) != 1 || len(resps[0].Propstats) != 1 || len(resps[0].Propstats[0].Props) != 2 {
+		t.Fatalf("PropFind through the Handler: got %+v, %v", resps, err)
+	}
+	for _, p := range resps[0].Propstats[0].Props {
+		if got, want := xmlText(p.InnerXML), map[string]string{"size": "large", "getcontentlength": "6"}[p.XMLName.Local]; got != want {
+			t.Errorf("PropFind %v: got %q, want %q", p.XMLName, got, want)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileSystem returns a FileSystem backed by the resources of c, so that a
+// remote WebDAV share can be served by a Handler.
+//
+// Files of the FileSystem implement DeadPropsHolder. Files opened for
+// writing are uploaded with a single PUT request when they are closed, and
+// can only be written sequentially after they are created or truncated.
+func (c *Client) FileSystem() FileSystem {
+	return remoteFS{c}
+}
+
+type remoteFS struct {
+	c *Client
+}
+
+// statProps are the properties that describe a remoteFileInfo.
+var statProps = []xml.Name{
+	{Space: "DAV:", Local: "resourcetype"},
+	{Space: "DAV:", Local: "getcontentlength"},
+	{Space: "DAV:", Local: "getlastmodified"},
+	{Space: "DAV:", Local: "getetag"},
+	{Space: "DAV:", Local: "getcontenttype"},
+}
+
+// fsError returns err, the error of a request made for the FileSystem method
+// op on the resource name, as the error of that method.
+func fsError(op, name string, err error) error {
+	if e, ok := err.(*StatusError); ok {
+		switch e.StatusCode {
+		case http.StatusNotFound, http.StatusConflict:
+			err = os.ErrNotExist
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed:
+			err = os.ErrPermission
+		case http.StatusPreconditionFailed:
+			err = os.ErrExist
+		case StatusLocked:
+			err = ErrLocked
+		case StatusInsufficientStorage:
+			err = ErrQuotaExceeded
+		}
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+func (fs remoteFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	err := fs.c.MkCol(ctx, name)
+	if e, ok := err.(*StatusError); ok && e.StatusCode == http.StatusMethodNotAllowed {
+		// MKCOL is not allowed on existing resources.
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	if err != nil {
+		return fsError("mkdir", name, err)
+	}
+	return nil
+}
+
+func (fs remoteFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (File, error) {
+	f := &remoteFile{
+		c:     fs.c,
+		ctx:   ctx,
+		name:  slashClean(name),
+		write: flag&(os.O_WRONLY|os.O_RDWR) != 0,
+	}
+	fi, err := fs.stat(ctx, "open", f.name)
+	switch {
+	case err == nil:
+		if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+		}
+		if fi.isDir && f.write {
+			return nil, &os.PathError{Op: "open", Path: name, Err: errIsDirectory}
+		}
+		f.fi = fi
+		f.put = f.write && flag&os.O_TRUNC != 0
+	case os.IsNotExist(err) && flag&os.O_CREATE != 0:
+		// Check that the parent exists, as os.OpenFile would.
+		if dir, err := fs.stat(ctx, "open", path.Dir(f.name)); err != nil {
+			return nil, err
+		} else if !dir.isDir {
+			return nil, &os.PathError{Op: "open", Path: name, Err: errNotADirectory}
+		}
+		f.fi = &remoteFileInfo{name: f.name, modTime: time.Now()}
+		f.put = true
+	default:
+		return nil, err
+	}
+	if flag&os.O_APPEND != 0 && f.write {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errUnsupportedWrite}
+	}
+	return f, nil
+}
+
+func (fs remoteFS) RemoveAll(ctx context.Context, name string) error {
+	if err := fs.c.Delete(ctx, name); err != nil {
+		return fsError("removeall", name, err)
+	}
+	return nil
+}
+
+func (fs remoteFS) Rename(ctx context.Context, oldName, newName string) error {
+	if err := fs.c.Move(ctx, oldName, newName, true); err != nil {
+		return fsError("rename", oldName, err)
+	}
+	return nil
+}
+
+func (fs remoteFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	fi, err := fs.stat(ctx, "stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fi, nil
+}
+
+func (fs remoteFS) stat(ctx context.Context, op, name string) (*remoteFileInfo, error) {
+	resps, err := fs.c.PropFind(ctx, name, 0, statProps)
+	if err != nil {
+		return nil, fsError(op, name, err)
+	}
+	if len(resps) == 0 {
+		return nil, &os.PathError{Op: op, Path: name, Err: errInvalidResponse}
+	}
+	return newRemoteFileInfo(resps[0]), nil
+}
+
+// A remoteFileInfo describes a resource of a remoteFS. It implements
+// ContentTyper and ETager.
+type remoteFileInfo struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	isDir       bool
+	etag        string
+	contentType string
+}
+
+// newRemoteFileInfo returns the description of the resource of r, using
+// the properties in statProps.
+func newRemoteFileInfo(r Response) *remoteFileInfo {
+	fi := &remoteFileInfo{name: slashClean(r.Name)}
+	for _, ps := range r.Propstats {
+		if ps.Status != http.StatusOK {
+			continue
+		}
+		for _, p := range ps.Props {
+			if p.XMLName.Space != "DAV:" {
+				continue
+			}
+			switch p.XMLName.Local {
+			case "resourcetype":
+				fi.isDir = hasElement(p.InnerXML, xml.Name{Space: "DAV:", Local: "collection"})
+			case "getcontentlength":
+				fi.size, _ = strconv.ParseInt(strings.TrimSpace(xmlText(p.InnerXML)), 10, 64)
+			case "getlastmodified":
+				fi.modTime, _ = http.ParseTime(strings.TrimSpace(xmlText(p.InnerXML)))
+			case "getetag":
+				fi.etag = strings.TrimSpace(xmlText(p.InnerXML))
+			case "getcontenttype":
+				fi.contentType = strings.TrimSpace(xmlText(p.InnerXML))
+			}
+		}
+	}
+	return fi
+}
+
+func (fi *remoteFileInfo) Name() string       { return path.Base(fi.name) }
+func (fi *remoteFileInfo) Size() int64        { return fi.size }
+func (fi *remoteFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *remoteFileInfo) IsDir() bool        { return fi.isDir }
+func (fi *remoteFileInfo) Sys() interface{}   { return nil }
+
+func (fi *remoteFileInfo) Mode() os.FileMode {
+	if fi.isDir {
+		return os.ModeDir | 0777
+	}
+	return 0666
+}
+
+func (fi *remoteFileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.etag == "" {
+		return "", ErrNotImplemented
+	}
+	return fi.etag, nil
+}
+
+func (fi *remoteFileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.contentType == "" {
+		return "", ErrNotImplemented
+	}
+	return fi.contentType, nil
+}
+
+// A remoteFile is a File of a remoteFS.
+type remoteFile struct {
+	c    *Client
+	ctx  context.Context
+	name string
+	fi   *remoteFileInfo
+
+	// off is the offset of the next Read, from which body reads.
+	off  int64
+	body io.ReadCloser
+
+	// write is whether the file was opened for writing. If put is true,
+	// the file was created or truncated, and Close stores what was written,
+	// which is sent to the PUT request started by the first Write through pw.
+	write   bool
+	put     bool
+	written int64
+	pw      *io.PipeWriter
+	putErr  chan error
+
+	children []os.FileInfo
+}
+
+func (f *remoteFile) Close() error {
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	if !f.put {
+		return nil
+	}
+	f.put = false
+	var err error
+	if f.pw == nil {
+		err = f.c.Put(f.ctx, f.name, http.NoBody)
+	} else {
+		f.pw.Close()
+		err = <-f.putErr
+	}
+	if err != nil {
+		return fsError("close", f.name, err)
+	}
+	return nil
+}
+
+func (f *remoteFile) Read(p []byte) (int, error) {
+	if f.fi.isDir {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: errIsDirectory}
+	}
+	if f.put {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: errUnsupportedWrite}
+	}
+	if f.body == nil {
+		body, err := f.c.get(f.ctx, f.name, f.off)
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, fsError("read", f.name, err)
+		}
+		f.body = body
+	}
+	n, err := f.body.Read(p)
+	f.off += int64(n)
+	return n, err
+}
+
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	off := offset
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		off += f.off
+	case io.SeekEnd:
+		off += f.fi.size
+	default:
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: os.ErrInvalid}
+	}
+	if off < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: os.ErrInvalid}
+	}
+	if off != f.off {
+		if f.pw != nil {
+			return 0, &os.PathError{Op: "seek", Path: f.name, Err: errUnsupportedWrite}
+		}
+		if f.body != nil {
+			f.body.Close()
+			f.body = nil
+		}
+		f.off = off
+	}
+	return off, nil
+}
+
+func (f *remoteFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.fi.isDir {
+		return nil, os.ErrInvalid
+	}
+	if f.children == nil {
+		resps, err := f.c.PropFind(f.ctx, f.name, 1, statProps)
+		if err != nil {
+			return nil, fsError("readdir", f.name, err)
+		}
+		f.children = []os.FileInfo{}
+		for _, r := range resps {
+			if slashClean(r.Name) != f.name {
+				f.children = append(f.children, newRemoteFileInfo(r))
+			}
+		}
+	}
+	// The os.File Readdir docs say that at the end of a directory, the error
+	// is io.EOF if count > 0 and nil if count <= 0.
+	if count <= 0 {
+		children := f.children
+		f.children = f.children[len(f.children):]
+		return children, nil
+	}
+	if len(f.children) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(f.children))
+	children := f.children[:count]
+	f.children = f.children[count:]
+	return children, nil
+}
+
+func (f *remoteFile) Stat() (os.FileInfo, error) {
+	if f.put {
+		return &remoteFileInfo{name: f.name, size: f.written, modTime: time.Now()}, nil
+	}
+	return f.fi, nil
+}
+
+func (f *remoteFile) Write(p []byte) (int, error) {
+	if !f.write {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+	}
+	if !f.put || f.off != 0 {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: errUnsupportedWrite}
+	}
+	if f.pw == nil {
+		pr, pw := io.Pipe()
+		f.pw, f.putErr = pw, make(chan error, 1)
+		go func() {
+			err := f.c.Put(f.ctx, f.name, pr)
+			// Unblock Write if the request failed before reading everything.
+			pr.CloseWithError(cmp.Or(err, io.ErrClosedPipe))
+			f.putErr <- err
+		}()
+	}
+	n, err := f.pw.Write(p)
+	f.written += int64(n)
+	if err != nil {
+		return n, fsError("write", f.name, err)
+	}
+	return n, nil
+}
+
+func (f *remoteFile) DeadProps() (map[xml.Name]Property, error) {
+	resps, err := f.c.PropFind(f.ctx, f.name, 0, nil)
+	if err != nil {
+		return nil, fsError("deadprops", f.name, err)
+	}
+	props := make(map[xml.Name]Property)
+	for _, r := range resps {
+		for _, ps := range r.Propstats {
+			if ps.Status != http.StatusOK {
+				continue
+			}
+			for _, p := range ps.Props {
+				if _, ok := liveProps[p.XMLName]; !ok {
+					props[p.XMLName] = p
+				}
+			}
+		}
+	}
+	return props, nil
+}
+
+func (f *remoteFile) Patch(patches []Proppatch) ([]Propstat, error) {
+	pstats, err := f.c.PropPatch(f.ctx, f.name, patches)
+	if err != nil {
+		return nil, fsError("patch", f.name, err)
+	}
+	return pstats, nil
+}
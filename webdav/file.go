@@ -7,6 +7,7 @@ package webdav
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"io"
 	"net/http"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -55,6 +57,35 @@ type File interface {
 	io.Writer
 }
 
+// ErrQuotaExceeded is returned when writing to a File would exceed the
+// storage available to its FileSystem.
+var ErrQuotaExceeded = errors.New("webdav: quota exceeded")
+
+// A QuotaFileSystem is a FileSystem that can report the storage used by and
+// available to its resources, as described in RFC 4331.
+//
+// A Handler reports the DAV:quota-available-bytes and DAV:quota-used-bytes
+// properties of a QuotaFileSystem, and rejects PUT requests whose content
+// would not fit in the available storage.
+type QuotaFileSystem interface {
+	FileSystem
+
+	// QuotaAvailable returns the number of bytes still available for
+	// storing resources under name. A negative value means the available
+	// storage is unknown or unlimited. It is called before each PUT
+	// request, so it should be cheap.
+	//
+	// See https://www.rfc-editor.org/rfc/rfc4331#section-3
+	QuotaAvailable(ctx context.Context, name string) (int64, error)
+
+	// QuotaUsed returns the number of bytes used by name and, if it is a
+	// directory, everything it contains. It is only called for the
+	// DAV:quota-used-bytes property.
+	//
+	// See https://www.rfc-editor.org/rfc/rfc4331#section-4
+	QuotaUsed(ctx context.Context, name string) (int64, error)
+}
+
 // A Dir implements FileSystem using the native file system restricted to a
 // specific directory tree.
 //
@@ -63,6 +94,10 @@ type File interface {
 // separated by filepath.Separator, which isn't necessarily '/'.
 //
 // An empty Dir is treated as ".".
+//
+// A Dir implements QuotaFileSystem, reporting the free space of the
+// underlying volume where the platform supports it. The storage used by a
+// directory is computed by walking it.
 type Dir string
 
 func (d Dir) resolve(name string) string {
@@ -128,20 +163,58 @@ func (d Dir) Stat(ctx context.Context, name string) (os.FileInfo, error) {
 	return os.Stat(name)
 }
 
+func (d Dir) QuotaAvailable(ctx context.Context, name string) (int64, error) {
+	if name = d.resolve(name); name == "" {
+		return 0, os.ErrNotExist
+	}
+	return diskFree(name)
+}
+
+func (d Dir) QuotaUsed(ctx context.Context, name string) (used int64, err error) {
+	if name = d.resolve(name); name == "" {
+		return 0, os.ErrNotExist
+	}
+	err = filepath.Walk(name, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			used += info.Size()
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return used, nil
+}
+
 // NewMemFS returns a new in-memory FileSystem implementation.
 func NewMemFS() FileSystem {
+	return NewMemFSWithQuota(0)
+}
+
+// NewMemFSWithQuota returns a new in-memory FileSystem implementation that
+// stores at most limit bytes of file data. Writes that would exceed the limit
+// fail with ErrQuotaExceeded. A limit of zero or less means no limit.
+//
+// The returned FileSystem implements QuotaFileSystem and ChangeLogFileSystem.
+func NewMemFSWithQuota(limit int64) FileSystem {
 	return &memFS{
 		root: memFSNode{
 			children: make(map[string]*memFSNode),
 			mode:     0660 | os.ModeDir,
 			modTime:  time.Now(),
 		},
+		limit:      limit,
+		epoch:      newSyncEpoch(),
+		tombstones: make(map[string]uint64),
 	}
 }
 
 // A memFS implements FileSystem, storing all metadata and actual file data
-// in-memory. No limits on filesystem size are used, so it is not recommended
-// this be used where the clients are untrusted.
+// in-memory. Unless a limit is set, no limits on filesystem size are used, so
+// it is not recommended this be used where the clients are untrusted.
 //
 // Concurrent access is permitted. The tree structure is protected by a mutex,
 // and each node's contents and metadata are protected by a per-node mutex.
@@ -150,6 +223,37 @@ func NewMemFS() FileSystem {
 type memFS struct {
 	mu   sync.Mutex
 	root memFSNode
+
+	// limit is the maximum number of bytes of file data, if positive.
+	limit int64
+	// used is the number of bytes of file data stored.
+	used atomic.Int64
+
+	// epoch identifies the change log in sync tokens.
+	epoch string
+	// seq is the sequence number of the latest change.
+	seq atomic.Uint64
+	// tombstones holds the sequence number of the removal of each removed
+	// or renamed resource, keyed by its cleaned name. It is protected by mu.
+	tombstones map[string]uint64
+	// minSyncSeq is the sequence number of the oldest sync token still
+	// accepted. The tombstones of earlier removals were pruned. It is
+	// protected by mu.
+	minSyncSeq uint64
+}
+
+// reserve accounts for n more bytes of file data, reporting whether they fit
+// within the limit.
+func (fs *memFS) reserve(n int64) bool {
+	for {
+		used := fs.used.Load()
+		if fs.limit > 0 && used+n > fs.limit {
+			return false
+		}
+		if fs.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
 }
 
 // TODO: clean up and rationalize the walk/find code.
@@ -259,6 +363,7 @@ func (fs *memFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error
 		children: make(map[string]*memFSNode),
 		mode:     perm.Perm() | os.ModeDir,
 		modTime:  time.Now(),
+		seq:      fs.seq.Add(1),
 	}
 	return nil
 }
@@ -298,6 +403,7 @@ func (fs *memFS) OpenFile(ctx context.Context, name string, flag int, perm os.Fi
 			if n == nil {
 				n = &memFSNode{
 					mode: perm.Perm(),
+					seq:  fs.seq.Add(1),
 				}
 				dir.children[frag] = n
 			}
@@ -307,7 +413,9 @@ func (fs *memFS) OpenFile(ctx context.Context, name string, flag int, perm os.Fi
 		}
 		if flag&(os.O_WRONLY|os.O_RDWR) != 0 && flag&os.O_TRUNC != 0 {
 			n.mu.Lock()
+			fs.used.Add(-int64(len(n.data)))
 			n.data = nil
+			n.seq = fs.seq.Add(1)
 			n.mu.Unlock()
 		}
 	}
@@ -317,6 +425,7 @@ func (fs *memFS) OpenFile(ctx context.Context, name string, flag int, perm os.Fi
 		children = append(children, c.stat(cName))
 	}
 	return &memFile{
+		fs:               fs,
 		n:                n,
 		nameSnapshot:     frag,
 		childrenSnapshot: children,
@@ -335,6 +444,10 @@ func (fs *memFS) RemoveAll(ctx context.Context, name string) error {
 		// We can't remove the root.
 		return os.ErrInvalid
 	}
+	if n, ok := dir.children[frag]; ok {
+		fs.used.Add(-n.usage())
+		fs.bury(slashClean(name), n, fs.seq.Add(1))
+	}
 	delete(dir.children, frag)
 	return nil
 }
@@ -385,6 +498,13 @@ func (fs *memFS) Rename(ctx context.Context, oldName, newName string) error {
 			}
 		}
 	}
+	seq := fs.seq.Add(1)
+	if nNode, ok := nDir.children[nFrag]; ok {
+		fs.used.Add(-nNode.usage())
+		fs.bury(newName, nNode, seq)
+	}
+	fs.bury(oldName, oNode, seq)
+	oNode.touch(seq)
 	delete(oDir.children, oFrag)
 	nDir.children[nFrag] = oNode
 	return nil
@@ -408,6 +528,46 @@ func (fs *memFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
 	return nil, os.ErrNotExist
 }
 
+func (fs *memFS) QuotaAvailable(ctx context.Context, name string) (int64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, err := fs.quotaNode(name); err != nil {
+		return 0, err
+	}
+	if fs.limit <= 0 {
+		return -1, nil
+	}
+	return max(fs.limit-fs.used.Load(), 0), nil
+}
+
+func (fs *memFS) QuotaUsed(ctx context.Context, name string) (int64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	n, err := fs.quotaNode(name)
+	if err != nil {
+		return 0, err
+	}
+	return n.usage(), nil
+}
+
+// quotaNode returns the node of name. The caller must hold fs.mu.
+func (fs *memFS) quotaNode(name string) (*memFSNode, error) {
+	dir, frag, err := fs.find("quota", name)
+	if err != nil {
+		return nil, err
+	}
+	if dir == nil {
+		return &fs.root, nil
+	}
+	n := dir.children[frag]
+	if n == nil {
+		return nil, os.ErrNotExist
+	}
+	return n, nil
+}
+
 // A memFSNode represents a single entry in the in-memory filesystem and also
 // implements os.FileInfo.
 type memFSNode struct {
@@ -419,6 +579,9 @@ type memFSNode struct {
 	mode      os.FileMode
 	modTime   time.Time
 	deadProps map[xml.Name]Property
+	// seq is the sequence number of the latest change to the node, as
+	// recorded in the memFS change log.
+	seq uint64
 }
 
 func (n *memFSNode) stat(name string) *memFileInfo {
@@ -432,6 +595,18 @@ func (n *memFSNode) stat(name string) *memFileInfo {
 	}
 }
 
+// usage returns the number of bytes of file data stored in n and its
+// descendants. The caller must hold memFS.mu.
+func (n *memFSNode) usage() int64 {
+	n.mu.Lock()
+	size := int64(len(n.data))
+	n.mu.Unlock()
+	for _, c := range n.children {
+		size += c.usage()
+	}
+	return size
+}
+
 func (n *memFSNode) DeadProps() (map[xml.Name]Property, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -483,6 +658,7 @@ func (f *memFileInfo) Sys() interface{}   { return nil }
 // per-node) read/write position, and a snapshot of the memFS' tree structure
 // (a node's name and children) for that node.
 type memFile struct {
+	fs               *memFS
 	n                *memFSNode
 	nameSnapshot     string
 	childrenSnapshot []os.FileInfo
@@ -493,8 +669,17 @@ type memFile struct {
 // A *memFile implements the optional DeadPropsHolder interface.
 var _ DeadPropsHolder = (*memFile)(nil)
 
-func (f *memFile) DeadProps() (map[xml.Name]Property, error)     { return f.n.DeadProps() }
-func (f *memFile) Patch(patches []Proppatch) ([]Propstat, error) { return f.n.Patch(patches) }
+func (f *memFile) DeadProps() (map[xml.Name]Property, error) { return f.n.DeadProps() }
+
+func (f *memFile) Patch(patches []Proppatch) ([]Propstat, error) {
+	pstats, err := f.n.Patch(patches)
+	if err == nil {
+		f.n.mu.Lock()
+		f.n.seq = f.fs.seq.Add(1)
+		f.n.mu.Unlock()
+	}
+	return pstats, err
+}
 
 func (f *memFile) Close() error {
 	return nil
@@ -575,6 +760,9 @@ func (f *memFile) Write(p []byte) (int, error) {
 	if f.n.mode.IsDir() {
 		return 0, os.ErrInvalid
 	}
+	if grow := f.pos + len(p) - len(f.n.data); grow > 0 && !f.fs.reserve(int64(grow)) {
+		return 0, ErrQuotaExceeded
+	}
 	if f.pos < len(f.n.data) {
 		n := copy(f.n.data[f.pos:], p)
 		f.pos += n
@@ -602,6 +790,7 @@ func (f *memFile) Write(p []byte) (int, error) {
 		f.pos = len(f.n.data)
 	}
 	f.n.modTime = time.Now()
+	f.n.seq = f.fs.seq.Add(1)
 	return lenp, nil
 }
 
@@ -734,6 +923,9 @@ func copyFiles(ctx context.Context, fs FileSystem, src, dst string, overwrite bo
 		_, copyErr := io.Copy(dstFile, srcFile)
 		propsErr := copyProps(dstFile, srcFile)
 		closeErr := dstFile.Close()
+		if errors.Is(copyErr, ErrQuotaExceeded) {
+			return StatusInsufficientStorage, copyErr
+		}
 		if copyErr != nil {
 			return http.StatusInternalServerError, copyErr
 		}
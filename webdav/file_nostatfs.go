@@ -0,0 +1,13 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !windows
+
+package webdav
+
+// diskFree reports that the free space of the volume holding name is
+// unknown.
+func diskFree(name string) (int64, error) {
+	return -1, nil
+}
@@ -524,6 +524,121 @@ func TestMemFS(t *testing.T) {
 	testFS(t, NewMemFS())
 }
 
+func TestMemFSQuota(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemFSWithQuota(10).(QuotaFileSystem)
+	if err := fs.Mkdir(ctx, "/d", 0777); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	write := func(name, data string) error {
+		f, err := fs.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write([]byte(data))
+		return err
+	}
+	checkQuota := func(desc, name string, wantAvailable, wantUsed int64) {
+		t.Helper()
+		available, err := fs.QuotaAvailable(ctx, name)
+		if err != nil {
+			t.Fatalf("%s: QuotaAvailable(%q): %v", desc, name, err)
+		}
+		used, err := fs.QuotaUsed(ctx, name)
+		if err != nil {
+			t.Fatalf("%s: QuotaUsed(%q): %v", desc, name, err)
+		}
+		if available != wantAvailable || used != wantUsed {
+			t.Errorf("%s: QuotaAvailable(%q), QuotaUsed(%[2]q) = %d, %d; want %d, %d", desc, name, available, used, wantAvailable, wantUsed)
+		}
+	}
+
+	if err := write("/d/a", "abcdef"); err != nil {
+		t.Fatalf("write /d/a: %v", err)
+	}
+	checkQuota("after write", "/", 4, 6)
+	checkQuota("after write", "/d", 4, 6)
+	checkQuota("after write", "/d/a", 4, 6)
+
+	if err := write("/b", "12345"); err != ErrQuotaExceeded {
+		t.Fatalf("write /b: got %v, want %v", err, ErrQuotaExceeded)
+	}
+	if err := write("/b", "1234"); err != nil {
+		t.Fatalf("write /b: %v", err)
+	}
+	checkQuota("after second write", "/", 0, 10)
+	checkQuota("after second write", "/d", 0, 6)
+
+	// Truncating /d/a releases its storage.
+	if err := write("/d/a", "xy"); err != nil {
+		t.Fatalf("rewrite /d/a: %v", err)
+	}
+	checkQuota("after rewrite", "/", 4, 6)
+
+	// Overwriting /d/a by renaming releases its storage.
+	if err := fs.Rename(ctx, "/b", "/d/a"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	checkQuota("after rename", "/", 6, 4)
+
+	if err := fs.RemoveAll(ctx, "/d"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	checkQuota("after remove", "/", 10, 0)
+
+	if _, err := fs.QuotaAvailable(ctx, "/d"); !os.IsNotExist(err) {
+		t.Errorf("QuotaAvailable of removed directory: got %v, want not exist", err)
+	}
+	if _, err := fs.QuotaUsed(ctx, "/d"); !os.IsNotExist(err) {
+		t.Errorf("QuotaUsed of removed directory: got %v, want not exist", err)
+	}
+}
+
+func TestDirQuota(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "d"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "d", "a"), []byte("abcdef"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b"), []byte("1234"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fs := Dir(dir)
+	for _, tc := range []struct {
+		name     string
+		wantUsed int64
+	}{
+		{"/", 10},
+		{"/d", 6},
+		{"/b", 4},
+	} {
+		used, err := fs.QuotaUsed(ctx, tc.name)
+		if err != nil {
+			t.Fatalf("QuotaUsed(%q): %v", tc.name, err)
+		}
+		if used != tc.wantUsed {
+			t.Errorf("QuotaUsed(%q) = %d, want %d", tc.name, used, tc.wantUsed)
+		}
+		available, err := fs.QuotaAvailable(ctx, tc.name)
+		if err != nil {
+			t.Fatalf("QuotaAvailable(%q): %v", tc.name, err)
+		}
+		switch runtime.GOOS {
+		case "darwin", "dragonfly", "freebsd", "linux", "windows":
+			if available < 0 {
+				t.Errorf("QuotaAvailable(%q) = %d, want >= 0", tc.name, available)
+			}
+		}
+	}
+	if _, err := fs.QuotaUsed(ctx, "/missing"); !os.IsNotExist(err) {
+		t.Errorf("QuotaUsed(/missing): got %v, want not exist", err)
+	}
+}
+
 func TestMemFSRoot(t *testing.T) {
 	ctx := context.Background()
 	fs := NewMemFS()
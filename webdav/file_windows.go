@@ -0,0 +1,30 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// diskFree returns the number of bytes available to the caller on the
+// volume holding name.
+func diskFree(name string) (int64, error) {
+	// GetDiskFreeSpaceEx takes a directory.
+	if fi, err := os.Stat(name); err == nil && !fi.IsDir() {
+		name = filepath.Dir(name)
+	}
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	var avail uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, nil, nil); err != nil {
+		return 0, err
+	}
+	return int64(avail), nil
+}
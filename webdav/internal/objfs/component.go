@@ -0,0 +1,167 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package objfs
+
+import (
+	"errors"
+	"strings"
+)
+
+// A Component is a component of an iCalendar (RFC 5545) or vCard (RFC 6350)
+// object, delimited by BEGIN and END content lines.
+type Component struct {
+	Name     string
+	Props    []Prop
+	Children []*Component
+}
+
+// A Prop is a property content line of a Component.
+type Prop struct {
+	Name   string
+	Params map[string][]string
+	Value  string
+}
+
+// PropsNamed returns the properties of c with the given name.
+func (c *Component) PropsNamed(name string) []Prop {
+	var props []Prop
+	for _, p := range c.Props {
+		if p.Name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Prop returns the first property of c with the given name, or nil.
+func (c *Component) Prop(name string) *Prop {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns the child components of c with the given name.
+func (c *Component) ChildrenNamed(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Children {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+var errInvalidObject = errors.New("objfs: invalid object")
+
+// Parse parses an iCalendar or vCard object, which must consist of a single
+// top-level component. Property and parameter names are upper-cased.
+// Property values are not unescaped.
+func Parse(data []byte) (*Component, error) {
+	// Unfold lines (RFC 5545 section 3.1).
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var root *Component
+	var stack []*Component
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseContentLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.Name {
+		case "BEGIN":
+			if root != nil && len(stack) == 0 {
+				return nil, errInvalidObject
+			}
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, errInvalidObject
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, errInvalidObject
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+	if root == nil || len(stack) != 0 {
+		return nil, errInvalidObject
+	}
+	return root, nil
+}
+
+// parseContentLine parses a line of the form
+//
+//	name *(";" param-name "=" param-value *("," param-value)) ":" value
+func parseContentLine(line string) (Prop, error) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return Prop{}, errInvalidObject
+	}
+	p := Prop{Name: strings.ToUpper(line[:i])}
+	line = line[i:]
+	for line[0] == ';' {
+		line = line[1:]
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return Prop{}, errInvalidObject
+		}
+		name := strings.ToUpper(line[:eq])
+		line = line[eq:]
+		// Read comma-separated values, which may be quoted.
+		for line != "" && (line[0] == '=' || line[0] == ',') {
+			line = line[1:]
+			var v string
+			if strings.HasPrefix(line, `"`) {
+				end := strings.IndexByte(line[1:], '"')
+				if end < 0 {
+					return Prop{}, errInvalidObject
+				}
+				v, line = line[1:end+1], line[end+2:]
+			} else {
+				end := strings.IndexAny(line, ",;:")
+				if end < 0 {
+					return Prop{}, errInvalidObject
+				}
+				v, line = line[:end], line[end:]
+			}
+			if p.Params == nil {
+				p.Params = make(map[string][]string)
+			}
+			p.Params[name] = append(p.Params[name], v)
+		}
+		if line == "" {
+			return Prop{}, errInvalidObject
+		}
+	}
+	if line[0] != ':' {
+		return Prop{}, errInvalidObject
+	}
+	p.Value = line[1:]
+	return p, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package objfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:A long\r\n" +
+		"  summary\r\n" +
+		"attendee;CN=\"Doe, Jane\";ROLE=REQ-PARTICIPANT,CHAIR:mailto:jane@example.com\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	got, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := &Component{
+		Name:  "VCALENDAR",
+		Props: []Prop{{Name: "VERSION", Value: "2.0"}},
+		Children: []*Component{{
+			Name: "VEVENT",
+			Props: []Prop{
+				{Name: "SUMMARY", Value: "A long summary"},
+				{
+					Name: "ATTENDEE",
+					Params: map[string][]string{
+						"CN":   {"Doe, Jane"},
+						"ROLE": {"REQ-PARTICIPANT", "CHAIR"},
+					},
+					Value: "mailto:jane@example.com",
+				},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"VERSION:2.0\r\n",
+		"BEGIN:VCARD\r\n",
+		"BEGIN:VCARD\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCARD\r\nEND:VCARD\r\nBEGIN:VCARD\r\nEND:VCARD\r\n",
+		"BEGIN:VCARD\r\nFN\r\nEND:VCARD\r\n",
+		"BEGIN:VCARD\r\nFN;X=\"a:b\r\nEND:VCARD\r\n",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q): got nil error", bad)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package objfs implements a webdav.FileSystem over collections of small
+// objects, such as CalDAV calendars or CardDAV address books, and the
+// helpers shared by the servers built on it.
+package objfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// A Collection is a collection of objects.
+type Collection struct {
+	// Path is the cleaned name of the collection, without a trailing slash.
+	Path    string
+	ModTime time.Time
+	// ResourceType is the XML representation of the DAV:resourcetype
+	// property of the collection.
+	ResourceType string
+	// Props holds the protected properties of the collection.
+	Props []webdav.Property
+}
+
+// An Object is a resource held in a collection.
+type Object struct {
+	// Path is the cleaned name of the object. Its parent is a collection.
+	Path string
+	// ETag is the entity tag of the object, or empty to derive one from
+	// ModTime and the size of Data.
+	ETag    string
+	ModTime time.Time
+	Data    []byte
+}
+
+// A Backend stores collections and their objects. Methods return an error
+// satisfying errors.Is(err, fs.ErrNotExist) for a missing resource.
+type Backend interface {
+	Collections(ctx context.Context) ([]Collection, error)
+	Collection(ctx context.Context, path string) (*Collection, error)
+	DeleteCollection(ctx context.Context, path string) error
+	Objects(ctx context.Context, collectionPath string) ([]Object, error)
+	Object(ctx context.Context, path string) (*Object, error)
+	PutObject(ctx context.Context, path string, data []byte) error
+	DeleteObject(ctx context.Context, path string) error
+}
+
+// An FS is a webdav.FileSystem that serves the collections and objects of
+// a Backend. Collections may be nested in directories, which exist as long
+// as they contain a collection. Objects can only be created in collections,
+// and directories and collections cannot be created through the FS.
+type FS struct {
+	Backend Backend
+	// ContentType is the media type of objects.
+	ContentType string
+}
+
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
+
+// Mkdir always fails; collections are created through the Backend.
+func (fsys *FS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (fsys *FS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	name = clean(name)
+	if flag&os.O_TRUNC != 0 {
+		if _, err := fsys.Backend.Collection(ctx, path.Dir(name)); err != nil {
+			return nil, err
+		}
+		if _, err := fsys.Backend.Collection(ctx, name); err == nil {
+			return nil, os.ErrInvalid
+		}
+		return &writeFile{ctx: ctx, fsys: fsys, name: name}, nil
+	}
+	fi, err := fsys.stat(ctx, name)
+	if err != nil {
+		if os.IsNotExist(err) && flag&os.O_CREATE != 0 {
+			return fsys.OpenFile(ctx, name, flag|os.O_TRUNC, perm)
+		}
+		return nil, err
+	}
+	switch {
+	case fi.obj != nil:
+		return &objectFile{Reader: bytes.NewReader(fi.obj.Data), fi: fi}, nil
+	case fi.coll != nil:
+		objs, err := fsys.Backend.Objects(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		children := make([]os.FileInfo, len(objs))
+		for i := range objs {
+			children[i] = fsys.objectInfo(&objs[i])
+		}
+		return &collectionFile{dirFile: dirFile{fi: fi, children: children}}, nil
+	}
+	children, err := fsys.dirChildren(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{fi: fi, children: children}, nil
+}
+
+// RemoveAll removes a collection or an object.
+func (fsys *FS) RemoveAll(ctx context.Context, name string) error {
+	fi, err := fsys.stat(ctx, clean(name))
+	if err != nil {
+		return err
+	}
+	switch {
+	case fi.obj != nil:
+		return fsys.Backend.DeleteObject(ctx, fi.obj.Path)
+	case fi.coll != nil:
+		return fsys.Backend.DeleteCollection(ctx, fi.coll.Path)
+	}
+	return os.ErrPermission
+}
+
+// Rename moves an object to another name in any collection.
+func (fsys *FS) Rename(ctx context.Context, oldName, newName string) error {
+	oldName, newName = clean(oldName), clean(newName)
+	obj, err := fsys.Backend.Object(ctx, oldName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return os.ErrPermission
+		}
+		return err
+	}
+	if _, err := fsys.Backend.Collection(ctx, path.Dir(newName)); err != nil {
+		return err
+	}
+	if err := fsys.Backend.PutObject(ctx, newName, obj.Data); err != nil {
+		return err
+	}
+	return fsys.Backend.DeleteObject(ctx, oldName)
+}
+
+func (fsys *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	fi, err := fsys.stat(ctx, clean(name))
+	if err != nil {
+		return nil, err
+	}
+	return fi, nil
+}
+
+func (fsys *FS) stat(ctx context.Context, name string) (*fileInfo, error) {
+	if name == "/" {
+		return &fileInfo{name: "/", dir: true}, nil
+	}
+	coll, err := fsys.Backend.Collection(ctx, name)
+	if err == nil {
+		return collectionInfo(coll), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	obj, err := fsys.Backend.Object(ctx, name)
+	if err == nil {
+		return fsys.objectInfo(obj), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	colls, err := fsys.Backend.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range colls {
+		if strings.HasPrefix(c.Path, name+"/") {
+			return &fileInfo{name: path.Base(name), dir: true}, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// dirChildren returns the directories and collections directly within the
+// directory name.
+func (fsys *FS) dirChildren(ctx context.Context, name string) ([]os.FileInfo, error) {
+	colls, err := fsys.Backend.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(name, "/") + "/"
+	seen := make(map[string]bool)
+	var children []os.FileInfo
+	for i, c := range colls {
+		rest, ok := strings.CutPrefix(c.Path, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		child, _, nested := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		if nested {
+			children = append(children, &fileInfo{name: child, dir: true})
+		} else {
+			children = append(children, collectionInfo(&colls[i]))
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+	return children, nil
+}
+
+// Objects returns the objects that a report on the resource name with the
+// given depth applies to: the object name itself or, unless depth is 0, the
+// objects of the collection name.
+func (fsys *FS) Objects(ctx context.Context, name string, depth int) ([]Object, error) {
+	name = clean(name)
+	if _, err := fsys.Backend.Collection(ctx, name); err == nil {
+		if depth == 0 {
+			return nil, nil
+		}
+		return fsys.Backend.Objects(ctx, name)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	obj, err := fsys.Backend.Object(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return []Object{*obj}, nil
+}
+
+func collectionInfo(c *Collection) *fileInfo {
+	return &fileInfo{
+		name:         path.Base(c.Path),
+		modTime:      c.ModTime,
+		dir:          true,
+		resourceType: c.ResourceType,
+		coll:         c,
+	}
+}
+
+func (fsys *FS) objectInfo(o *Object) *fileInfo {
+	return &fileInfo{
+		name:        path.Base(o.Path),
+		size:        int64(len(o.Data)),
+		modTime:     o.ModTime,
+		etag:        o.ETag,
+		contentType: fsys.ContentType,
+		obj:         o,
+	}
+}
+
+// A fileInfo describes a directory, collection or object. It implements
+// the optional webdav.ETager, webdav.ContentTyper and webdav.ResourceTyper
+// interfaces.
+type fileInfo struct {
+	name         string
+	size         int64
+	modTime      time.Time
+	dir          bool
+	etag         string
+	contentType  string
+	resourceType string
+
+	coll *Collection // non-nil for a collection
+	obj  *Object     // non-nil for an object
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi *fileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.etag, nil
+}
+
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.contentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.contentType, nil
+}
+
+func (fi *fileInfo) ResourceType(ctx context.Context) (string, error) {
+	if fi.resourceType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.resourceType, nil
+}
+
+// A dirFile is an open directory.
+type dirFile struct {
+	fi       *fileInfo
+	children []os.FileInfo
+	pos      int
+}
+
+func (f *dirFile) Close() error                   { return nil }
+func (f *dirFile) Read(p []byte) (int, error)     { return 0, os.ErrInvalid }
+func (f *dirFile) Seek(int64, int) (int64, error) { return 0, os.ErrInvalid }
+func (f *dirFile) Stat() (os.FileInfo, error)     { return f.fi, nil }
+func (f *dirFile) Write(p []byte) (int, error)    { return 0, os.ErrPermission }
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	rest := f.children[f.pos:]
+	if count <= 0 {
+		f.pos = len(f.children)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	rest = rest[:min(count, len(rest))]
+	f.pos += len(rest)
+	return rest, nil
+}
+
+// A collectionFile is an open collection. Its protected properties are
+// reported as dead properties, which cannot be patched.
+type collectionFile struct {
+	dirFile
+}
+
+func (f *collectionFile) DeadProps() (map[xml.Name]webdav.Property, error) {
+	props := make(map[xml.Name]webdav.Property, len(f.fi.coll.Props))
+	for _, p := range f.fi.coll.Props {
+		props[p.XMLName] = p
+	}
+	return props, nil
+}
+
+func (f *collectionFile) Patch(patches []webdav.Proppatch) ([]webdav.Propstat, error) {
+	protected := make(map[xml.Name]bool)
+	for _, p := range f.fi.coll.Props {
+		protected[p.XMLName] = true
+	}
+	pstatProtected := webdav.Propstat{
+		Status:   http.StatusForbidden,
+		XMLError: `<D:cannot-modify-protected-property xmlns:D="DAV:"/>`,
+	}
+	pstatForbidden := webdav.Propstat{Status: http.StatusForbidden}
+	for _, patch := range patches {
+		for _, p := range patch.Props {
+			if protected[p.XMLName] {
+				pstatProtected.Props = append(pstatProtected.Props, webdav.Property{XMLName: p.XMLName})
+			} else {
+				pstatForbidden.Props = append(pstatForbidden.Props, webdav.Property{XMLName: p.XMLName})
+			}
+		}
+	}
+	var pstats []webdav.Propstat
+	for _, ps := range []webdav.Propstat{pstatProtected, pstatForbidden} {
+		if len(ps.Props) > 0 {
+			pstats = append(pstats, ps)
+		}
+	}
+	return pstats, nil
+}
+
+// An objectFile is an object open for reading.
+type objectFile struct {
+	*bytes.Reader
+	fi *fileInfo
+}
+
+func (f *objectFile) Close() error                       { return nil }
+func (f *objectFile) Readdir(int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *objectFile) Stat() (os.FileInfo, error)         { return f.fi, nil }
+func (f *objectFile) Write(p []byte) (int, error)        { return 0, os.ErrPermission }
+
+// A writeFile buffers the new content of an object, which is stored when
+// the file is closed or its info is requested.
+type writeFile struct {
+	ctx  context.Context
+	fsys *FS
+	name string
+	buf  bytes.Buffer
+	fi   *fileInfo // non-nil once stored
+}
+
+func (f *writeFile) Read(p []byte) (int, error)         { return 0, os.ErrInvalid }
+func (f *writeFile) Readdir(int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *writeFile) Seek(int64, int) (int64, error)     { return 0, os.ErrInvalid }
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	if f.fi != nil {
+		return 0, os.ErrClosed
+	}
+	return f.buf.Write(p)
+}
+
+// Stat stores the object, so that its info reflects the stored content.
+// The webdav.Handler reports the ETag of that info after a PUT.
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	if err := f.store(); err != nil {
+		return nil, err
+	}
+	return f.fi, nil
+}
+
+func (f *writeFile) Close() error {
+	return f.store()
+}
+
+func (f *writeFile) store() error {
+	if f.fi != nil {
+		return nil
+	}
+	if err := f.fsys.Backend.PutObject(f.ctx, f.name, f.buf.Bytes()); err != nil {
+		return err
+	}
+	obj, err := f.fsys.Backend.Object(f.ctx, f.name)
+	if err != nil {
+		return err
+	}
+	f.fi = f.fsys.objectInfo(obj)
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package objfs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/webdav"
+)
+
+// PropNames is the list of property names in a DAV:prop element of a
+// report request. The content of each property element is ignored.
+type PropNames []xml.Name
+
+// UnmarshalXML appends the names of the elements enclosed within start
+// to pn.
+func (pn *PropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			*pn = append(*pn, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Split removes the property named data from pn, reporting whether it was
+// present.
+func (pn PropNames) Split(data xml.Name) (rest []xml.Name, found bool) {
+	for _, n := range pn {
+		if n == data {
+			found = true
+		} else {
+			rest = append(rest, n)
+		}
+	}
+	return rest, found
+}
+
+// AddProp adds p to the Propstat with status 200 OK in pstats.
+func AddProp(pstats []webdav.Propstat, p webdav.Property) []webdav.Propstat {
+	for i := range pstats {
+		if pstats[i].Status == http.StatusOK {
+			pstats[i].Props = append(pstats[i].Props, p)
+			return pstats
+		}
+	}
+	return append(pstats, webdav.Propstat{
+		Status: http.StatusOK,
+		Props:  []webdav.Property{p},
+	})
+}
+
+// TextProp returns a property holding text, escaped as XML.
+func TextProp(name xml.Name, text string) webdav.Property {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return webdav.Property{XMLName: name, InnerXML: buf.Bytes()}
+}
+
+// HrefName returns the name of the resource that href refers to within a
+// webdav.Handler with the given prefix.
+func HrefName(prefix, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(u.Path, prefix)
+	if !ok {
+		return "", false
+	}
+	return clean(name), true
+}
+
+// WriteError writes an HTTP error response whose DAV:error body holds the
+// given precondition or postcondition element.
+// See http://www.webdav.org/specs/rfc4918.html#precondition.postcondition.xml.elements
+func WriteError(w http.ResponseWriter, status int, condition string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<D:error xmlns:D="DAV:">%s</D:error>`, condition)
+}
+
+// An OptionsWriter adds a compliance class to the DAV header, and methods
+// to the Allow header, of a webdav.Handler's response to an OPTIONS request.
+type OptionsWriter struct {
+	http.ResponseWriter
+	Class   string
+	Methods string
+
+	done bool
+}
+
+// AddHeaders updates the headers, unless they have already been written.
+// It must be called after the webdav.Handler has served the request.
+func (w *OptionsWriter) AddHeaders() {
+	if w.done {
+		return
+	}
+	w.done = true
+	h := w.Header()
+	if dav := h.Get("DAV"); dav != "" {
+		h.Set("DAV", dav+", "+w.Class)
+	}
+	if allow := h.Get("Allow"); allow != "" && w.Methods != "" {
+		h.Set("Allow", allow+", "+w.Methods)
+	}
+}
+
+func (w *OptionsWriter) WriteHeader(code int) {
+	w.AddHeaders()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *OptionsWriter) Write(p []byte) (int, error) {
+	w.AddHeaders()
+	return w.ResponseWriter.Write(p)
+}
+
+// A TextMatch tests property text against a string, as described by RFC 4791
+// section 9.7.5 and RFC 6352 section 10.5.4.
+type TextMatch struct {
+	Text string
+	// Collation is "i;octet", "i;ascii-casemap" or "i;unicode-casemap".
+	Collation string
+	// MatchType is "equals", "contains", "starts-with" or "ends-with".
+	MatchType string
+	Negate    bool
+}
+
+// ValidCollation reports whether c is a supported collation.
+func ValidCollation(c string) bool {
+	switch c {
+	case "i;octet", "i;ascii-casemap", "i;unicode-casemap":
+		return true
+	}
+	return false
+}
+
+// ValidMatchType reports whether t is a supported match type.
+func ValidMatchType(t string) bool {
+	switch t {
+	case "equals", "contains", "starts-with", "ends-with":
+		return true
+	}
+	return false
+}
+
+// Match reports whether s matches m.
+func (m *TextMatch) Match(s string) bool {
+	text := m.Text
+	switch m.Collation {
+	case "i;ascii-casemap":
+		text, s = asciiLower(text), asciiLower(s)
+	case "i;unicode-casemap":
+		text, s = strings.ToLower(text), strings.ToLower(s)
+	}
+	var ok bool
+	switch m.MatchType {
+	case "equals":
+		ok = s == text
+	case "starts-with":
+		ok = strings.HasPrefix(s, text)
+	case "ends-with":
+		ok = strings.HasSuffix(s, text)
+	default:
+		ok = strings.Contains(s, text)
+	}
+	return ok != m.Negate
+}
+
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
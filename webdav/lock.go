@@ -109,6 +109,13 @@ type LockDetails struct {
 	// ZeroDepth is whether the lock has zero depth. If it does not have zero
 	// depth, it has infinite depth.
 	ZeroDepth bool
+
+	// temporary is whether the lock is held by a Handler for the duration
+	// of a request. Temporary locks are not journaled by the LockSystem
+	// returned by NewFileLS, and expire after temporaryLockTimeout in the
+	// store of the LockSystem returned by NewStoreLS, so that they do not
+	// outlive a process that stops before unlocking them.
+	temporary bool
 }
 
 // NewMemLS returns a new in-memory LockSystem.
@@ -126,8 +133,13 @@ type memLS struct {
 	byToken map[string]*memLSNode
 	gen     uint64
 	// byExpiry only contains those nodes whose LockDetails have a finite
-	// Duration and are yet to expire.
+	// Duration, or that are temporary locks loaded from a LockStore, and are
+	// yet to expire.
 	byExpiry byExpiry
+	// journal, if non-nil, is called to record each lock that is created,
+	// refreshed or unlocked, before the change is made. If it returns an
+	// error, the change is not made.
+	journal func(rec lockRecord) error
 }
 
 func (m *memLS) nextToken() string {
@@ -149,15 +161,34 @@ func (m *memLS) Confirm(now time.Time, name0, name1 string, conditions ...Condit
 	defer m.mu.Unlock()
 	m.collectExpiredNodes(now)
 
-	var n0, n1 *memLSNode
+	n0, n1, err := m.confirm(name0, name1, conditions...)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if n1 != nil {
+			m.unhold(n1)
+		}
+		if n0 != nil {
+			m.unhold(n0)
+		}
+	}, nil
+}
+
+// confirm holds the nodes that lock the named resources, as Confirm does, and
+// returns them. n1 is nil if both resources are locked by n0. The caller must
+// hold m.mu.
+func (m *memLS) confirm(name0, name1 string, conditions ...Condition) (n0, n1 *memLSNode, err error) {
 	if name0 != "" {
 		if n0 = m.lookup(slashClean(name0), conditions...); n0 == nil {
-			return nil, ErrConfirmationFailed
+			return nil, nil, ErrConfirmationFailed
 		}
 	}
 	if name1 != "" {
 		if n1 = m.lookup(slashClean(name1), conditions...); n1 == nil {
-			return nil, ErrConfirmationFailed
+			return nil, nil, ErrConfirmationFailed
 		}
 	}
 
@@ -172,16 +203,7 @@ func (m *memLS) Confirm(now time.Time, name0, name1 string, conditions ...Condit
 	if n1 != nil {
 		m.hold(n1)
 	}
-	return func() {
-		m.mu.Lock()
-		defer m.mu.Unlock()
-		if n1 != nil {
-			m.unhold(n1)
-		}
-		if n0 != nil {
-			m.unhold(n0)
-		}
-	}, nil
+	return n0, n1, nil
 }
 
 // lookup returns the node n that locks the named resource, provided that n
@@ -238,13 +260,22 @@ func (m *memLS) Create(now time.Time, details LockDetails) (string, error) {
 	if !m.canCreate(details.Root, details.ZeroDepth) {
 		return "", ErrLocked
 	}
+	token := m.nextToken()
+	if m.journal != nil && !details.temporary {
+		if err := m.journal(m.record(now, token, details)); err != nil {
+			return "", err
+		}
+	}
 	n := m.create(details.Root)
-	n.token = m.nextToken()
+	n.token = token
 	m.byToken[n.token] = n
 	n.details = details
 	if n.details.Duration >= 0 {
 		n.expiry = now.Add(n.details.Duration)
 		heap.Push(&m.byExpiry, n)
+	} else if n.details.temporary {
+		// The lock does not expire here, but it does in a LockStore.
+		n.expiry = now.Add(temporaryLockTimeout)
 	}
 	return n.token, nil
 }
@@ -261,6 +292,13 @@ func (m *memLS) Refresh(now time.Time, token string, duration time.Duration) (Lo
 	if n.held {
 		return LockDetails{}, ErrLocked
 	}
+	if m.journal != nil {
+		details := n.details
+		details.Duration = duration
+		if err := m.journal(m.record(now, token, details)); err != nil {
+			return LockDetails{}, err
+		}
+	}
 	if n.byExpiryIndex >= 0 {
 		heap.Remove(&m.byExpiry, n.byExpiryIndex)
 	}
@@ -284,6 +322,11 @@ func (m *memLS) Unlock(now time.Time, token string) error {
 	if n.held {
 		return ErrLocked
 	}
+	if m.journal != nil && !n.details.temporary {
+		if err := m.journal(lockRecord{Now: now, Token: token, Unlocked: true}); err != nil {
+			return err
+		}
+	}
 	m.remove(n)
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/rand"
 	"path"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
@@ -204,9 +205,12 @@ func TestMemLSLookup(t *testing.T) {
 	}
 }
 
-func TestMemLSConfirm(t *testing.T) {
+func TestLockSystemConfirm(t *testing.T) {
+	forEachLockSystem(t, testLockSystemConfirm)
+}
+
+func testLockSystemConfirm(t *testing.T, m testLS) {
 	now := time.Unix(0, 0)
-	m := NewMemLS().(*memLS)
 	alice, err := m.Create(now, LockDetails{
 		Root:      "/alice",
 		Duration:  infiniteTimeout,
@@ -300,9 +304,12 @@ func TestMemLSConfirm(t *testing.T) {
 	}
 }
 
-func TestMemLSNonCanonicalRoot(t *testing.T) {
+func TestLockSystemNonCanonicalRoot(t *testing.T) {
+	forEachLockSystem(t, testLockSystemNonCanonicalRoot)
+}
+
+func testLockSystemNonCanonicalRoot(t *testing.T, m testLS) {
 	now := time.Unix(0, 0)
-	m := NewMemLS().(*memLS)
 	token, err := m.Create(now, LockDetails{
 		Root:     "/foo/./bar//",
 		Duration: 1 * time.Second,
@@ -321,8 +328,11 @@ func TestMemLSNonCanonicalRoot(t *testing.T) {
 	}
 }
 
-func TestMemLSExpiry(t *testing.T) {
-	m := NewMemLS().(*memLS)
+func TestLockSystemExpiry(t *testing.T) {
+	forEachLockSystem(t, testLockSystemExpiry)
+}
+
+func testLockSystemExpiry(t *testing.T, ls testLS) {
 	testCases := []string{
 		"setNow 0",
 		"create /a.5",
@@ -388,7 +398,7 @@ func TestMemLSExpiry(t *testing.T) {
 
 			switch op {
 			case "create":
-				token, err := m.Create(now, LockDetails{
+				token, err := ls.Create(now, LockDetails{
 					Root:      root,
 					Duration:  dur,
 					ZeroDepth: true,
@@ -403,7 +413,7 @@ func TestMemLSExpiry(t *testing.T) {
 				if token == "" {
 					t.Fatalf("test case #%d %q: no token for %q", i, tc, root)
 				}
-				got, err := m.Refresh(now, token, dur)
+				got, err := ls.Refresh(now, token, dur)
 				if err != nil {
 					t.Fatalf("test case #%d %q: Refresh: %v", i, tc, err)
 				}
@@ -425,6 +435,7 @@ func TestMemLSExpiry(t *testing.T) {
 			now = time.Unix(0, 0).Add(time.Duration(d) * time.Second)
 
 		case "want":
+			m := ls.mem()
 			m.mu.Lock()
 			m.collectExpiredNodes(now)
 			got := make([]string, 0, len(m.byToken))
@@ -443,15 +454,18 @@ func TestMemLSExpiry(t *testing.T) {
 			}
 		}
 
-		if err := m.consistent(); err != nil {
+		if err := ls.consistent(); err != nil {
 			t.Fatalf("test case #%d %q: inconsistent state: %v", i, tc, err)
 		}
 	}
 }
 
-func TestMemLS(t *testing.T) {
+func TestLockSystem(t *testing.T) {
+	forEachLockSystem(t, testLockSystem)
+}
+
+func testLockSystem(t *testing.T, m testLS) {
 	now := time.Unix(0, 0)
-	m := NewMemLS().(*memLS)
 	rng := rand.New(rand.NewSource(0))
 	tokens := map[string]string{}
 	nConfirm, nCreate, nRefresh, nUnlock := 0, 0, 0, 0
@@ -535,6 +549,44 @@ func TestMemLS(t *testing.T) {
 	}
 }
 
+// A testLS is a LockSystem under test.
+type testLS struct {
+	LockSystem
+	// mem returns the memLS that holds the locks.
+	mem func() *memLS
+}
+
+func (ls testLS) consistent() error {
+	return ls.mem().consistent()
+}
+
+// forEachLockSystem runs f as a subtest for each LockSystem implementation.
+func forEachLockSystem(t *testing.T, f func(t *testing.T, ls testLS)) {
+	t.Run("mem", func(t *testing.T) {
+		m := NewMemLS().(*memLS)
+		f(t, testLS{m, func() *memLS { return m }})
+	})
+	t.Run("file", func(t *testing.T) {
+		ls, err := NewFileLS(filepath.Join(t.TempDir(), "locks"))
+		if err != nil {
+			t.Fatalf("NewFileLS: %v", err)
+		}
+		m := ls.(*fileLS).memLS
+		f(t, testLS{ls, func() *memLS { return m }})
+	})
+	t.Run("store", func(t *testing.T) {
+		s := NewStoreLS(&memLockStore{}).(*storeLS)
+		f(t, testLS{s, func() *memLS {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.load(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			return s.m
+		}})
+	})
+}
+
 func (m *memLS) consistent() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
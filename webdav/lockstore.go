@@ -0,0 +1,417 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLockStoreConflict is returned by a LockStore's CompareAndSwap method if
+// the stored locks were changed since they were loaded.
+var ErrLockStoreConflict = errors.New("webdav: lock store conflict")
+
+// A LockStore stores the locks of a LockSystem returned by NewStoreLS, so
+// that they can be shared by several processes, such as replicas of a
+// server behind a load balancer. It is typically backed by a key-value store
+// that supports compare-and-swap, with all the locks stored under one key.
+type LockStore interface {
+	// Load returns the stored locks, in an opaque encoding, and their
+	// version. If nothing is stored yet, it returns nil data and a zero
+	// version.
+	Load() (data []byte, version uint64, err error)
+
+	// CompareAndSwap stores data if the version of the stored locks is
+	// still version, and returns the new version, which must be different
+	// from all the previous ones. If the version differs, it stores nothing
+	// and returns ErrLockStoreConflict.
+	CompareAndSwap(version uint64, data []byte) (newVersion uint64, err error)
+}
+
+// A lockRecord describes a lock. It is the unit of the journal of a
+// LockSystem returned by NewFileLS, and of the data stored in a LockStore.
+type lockRecord struct {
+	// Now is the time at which the lock was created, refreshed or
+	// unlocked. Locks that expired by then are removed on replay.
+	Now time.Time `json:"now,omitzero"`
+	// Gen is the generation of lock tokens. Tokens are never reused.
+	Gen uint64 `json:"gen,omitempty"`
+
+	Token     string        `json:"token,omitempty"`
+	Unlocked  bool          `json:"unlocked,omitempty"`
+	Root      string        `json:"root,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	OwnerXML  string        `json:"owner,omitempty"`
+	ZeroDepth bool          `json:"zeroDepth,omitempty"`
+	Expiry    time.Time     `json:"expiry,omitzero"`
+	// Temporary marks the locks held by a Handler for the duration of a
+	// request. They expire at Expiry even though their Duration is
+	// infinite.
+	Temporary bool `json:"temporary,omitempty"`
+}
+
+// temporaryLockTimeout is how long the temporary locks of a Handler are kept
+// in a LockStore, in case the process holding them stops before unlocking
+// them. Requests that take longer may then run concurrently with
+// conflicting ones.
+const temporaryLockTimeout = time.Hour
+
+// lockTable is the data stored in a LockStore.
+type lockTable struct {
+	Gen   uint64       `json:"gen"`
+	Locks []lockRecord `json:"locks,omitempty"`
+}
+
+// record returns the record of the lock token with the given details,
+// created or refreshed at now. The caller must hold m.mu.
+func (m *memLS) record(now time.Time, token string, details LockDetails) lockRecord {
+	rec := lockRecord{
+		Now:       now,
+		Gen:       m.gen,
+		Token:     token,
+		Root:      details.Root,
+		Duration:  details.Duration,
+		OwnerXML:  details.OwnerXML,
+		ZeroDepth: details.ZeroDepth,
+	}
+	if details.Duration >= 0 {
+		rec.Expiry = now.Add(details.Duration)
+	}
+	return rec
+}
+
+// records returns the records of the locks of m, sorted by token. Temporary
+// locks are only included if temporary is true. The caller must hold m.mu.
+func (m *memLS) records(temporary bool) []lockRecord {
+	recs := make([]lockRecord, 0, len(m.byToken))
+	for token, n := range m.byToken {
+		if n.details.temporary && !temporary {
+			continue
+		}
+		rec := lockRecord{
+			Token:     token,
+			Root:      n.details.Root,
+			Duration:  n.details.Duration,
+			OwnerXML:  n.details.OwnerXML,
+			ZeroDepth: n.details.ZeroDepth,
+		}
+		if n.details.Duration >= 0 || n.details.temporary {
+			rec.Expiry = n.expiry
+			rec.Temporary = n.details.temporary
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].Token < recs[j].Token })
+	return recs
+}
+
+// replay applies rec to m, replacing any lock with the same token. The
+// caller must hold m.mu.
+func (m *memLS) replay(rec lockRecord) {
+	m.collectExpiredNodes(rec.Now)
+	m.gen = max(m.gen, rec.Gen)
+	if rec.Token == "" {
+		return
+	}
+	if n := m.byToken[rec.Token]; n != nil {
+		m.remove(n)
+	}
+	if rec.Unlocked {
+		return
+	}
+	n := m.create(rec.Root)
+	n.token = rec.Token
+	m.byToken[n.token] = n
+	n.details = LockDetails{
+		Root:      rec.Root,
+		Duration:  rec.Duration,
+		OwnerXML:  rec.OwnerXML,
+		ZeroDepth: rec.ZeroDepth,
+		temporary: rec.Temporary,
+	}
+	if n.details.Duration >= 0 || n.details.temporary {
+		n.expiry = rec.Expiry
+		heap.Push(&m.byExpiry, n)
+	}
+}
+
+// minJournalCompaction is the number of records above which the journal of a
+// fileLS is compacted, once it also holds more than twice as many records as
+// there are locks.
+const minJournalCompaction = 1024
+
+// NewFileLS returns a LockSystem that keeps its locks in memory, like the one
+// returned by NewMemLS, and journals every change to the named file, so that
+// the locks survive restarts. The temporary locks that a Handler creates for
+// a request are not journaled, as a restart ends the request. The file is
+// created if it does not exist. It must not be used by more than one
+// LockSystem at a time.
+func NewFileLS(name string) (LockSystem, error) {
+	m := NewMemLS().(*memLS)
+	f, err := os.Open(name)
+	if err == nil {
+		err = replayJournal(m, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("webdav: reading lock journal %s: %v", name, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	ls := &fileLS{memLS: m, name: name}
+	// Rewriting the journal drops the records of expired locks, and any
+	// record that was partially written when the process stopped.
+	if err := ls.compact(); err != nil {
+		return nil, err
+	}
+	m.journal = ls.write
+	return ls, nil
+}
+
+// replayJournal applies the records read from r to m.
+func replayJournal(m *memLS, r io.Reader) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var rec lockRecord
+		switch err := dec.Decode(&rec); err {
+		case nil:
+			m.replay(rec)
+		case io.EOF, io.ErrUnexpectedEOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+type fileLS struct {
+	*memLS
+	name string
+	// n is the number of records in the journal. It is guarded by memLS.mu.
+	n int
+}
+
+// write appends rec to the journal. The caller must hold memLS.mu.
+func (ls *fileLS) write(rec lockRecord) error {
+	if ls.n >= minJournalCompaction && ls.n >= 2*len(ls.byToken) {
+		return ls.compact(rec)
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(ls.name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	ls.n++
+	return nil
+}
+
+// compact replaces the journal with the records of the current locks,
+// followed by recs. The caller must hold memLS.mu, except from NewFileLS.
+func (ls *fileLS) compact(recs ...lockRecord) error {
+	f, err := os.CreateTemp(filepath.Dir(ls.name), filepath.Base(ls.name)+".*")
+	if err != nil {
+		return err
+	}
+	recs = append(append([]lockRecord{{Gen: ls.gen}}, ls.records(false)...), recs...)
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range recs {
+		if err = enc.Encode(rec); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), ls.name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	ls.n = len(recs)
+	return nil
+}
+
+// NewStoreLS returns a LockSystem that keeps its locks in store. Each change
+// loads the locks, applies the change and stores them with CompareAndSwap,
+// starting over if another LockSystem changed them in the meantime.
+//
+// The temporary locks that a Handler creates for a request are stored too,
+// so that they exclude conflicting requests served by other LockSystems,
+// but they expire after an hour in case the process stops before unlocking
+// them.
+//
+// The locks held between a call to Confirm and its release function are
+// only known to the LockSystem that confirmed them: another LockSystem
+// sharing the store may refresh or unlock them, or let them expire.
+func NewStoreLS(store LockStore) LockSystem {
+	return &storeLS{
+		store: store,
+		held:  make(map[string]bool),
+	}
+}
+
+type storeLS struct {
+	store LockStore
+
+	mu sync.Mutex
+	// m holds the locks loaded from the store, at version. It is nil if they
+	// are to be loaded again.
+	m       *memLS
+	version uint64
+	// held is the set of tokens of the locks held by Confirm.
+	held map[string]bool
+}
+
+// load brings s.m up to date with the store. The caller must hold s.mu.
+func (s *storeLS) load() error {
+	data, version, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	if s.m != nil && version == s.version {
+		return nil
+	}
+	m := NewMemLS().(*memLS)
+	if data != nil {
+		var t lockTable
+		if err := json.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("webdav: decoding stored locks: %v", err)
+		}
+		m.gen = max(m.gen, t.Gen)
+		for _, rec := range t.Locks {
+			m.replay(rec)
+		}
+	}
+	for token := range s.held {
+		if n := m.byToken[token]; n != nil {
+			m.hold(n)
+		}
+	}
+	s.m, s.version = m, version
+	return nil
+}
+
+// update calls f with the current locks, and stores them unless f returns an
+// error. It starts over if the stored locks changed since they were loaded.
+func (s *storeLS) update(f func(m *memLS) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if err := s.load(); err != nil {
+			return err
+		}
+		if err := f(s.m); err != nil {
+			return err
+		}
+		s.m.mu.Lock()
+		data, err := json.Marshal(lockTable{Gen: s.m.gen, Locks: s.m.records(true)})
+		s.m.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		version, err := s.store.CompareAndSwap(s.version, data)
+		if err == nil {
+			s.version = version
+			return nil
+		}
+		// The locks in s.m were not stored.
+		s.m = nil
+		if err != ErrLockStoreConflict {
+			return err
+		}
+	}
+}
+
+func (s *storeLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	m := s.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectExpiredNodes(now)
+
+	n0, n1, err := m.confirm(name0, name1, conditions...)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []string
+	for _, n := range []*memLSNode{n0, n1} {
+		if n != nil {
+			s.held[n.token] = true
+			tokens = append(tokens, n.token)
+		}
+	}
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, token := range tokens {
+			delete(s.held, token)
+			if m := s.m; m != nil {
+				m.mu.Lock()
+				if n := m.byToken[token]; n != nil && n.held {
+					m.unhold(n)
+				}
+				m.mu.Unlock()
+			}
+		}
+	}, nil
+}
+
+func (s *storeLS) Create(now time.Time, details LockDetails) (token string, err error) {
+	err = s.update(func(m *memLS) error {
+		token, err = m.Create(now, details)
+		return err
+	})
+	return token, err
+}
+
+func (s *storeLS) Refresh(now time.Time, token string, duration time.Duration) (details LockDetails, err error) {
+	err = s.update(func(m *memLS) error {
+		details, err = m.Refresh(now, token, duration)
+		return err
+	})
+	return details, err
+}
+
+func (s *storeLS) Unlock(now time.Time, token string) error {
+	return s.update(func(m *memLS) error {
+		return m.Unlock(now, token)
+	})
+}
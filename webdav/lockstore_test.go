@@ -0,0 +1,219 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memLockStore is an in-memory LockStore.
+type memLockStore struct {
+	mu      sync.Mutex
+	data    []byte
+	version uint64
+	// beforeSwap, if non-nil, is called at the start of CompareAndSwap.
+	beforeSwap func()
+}
+
+func (s *memLockStore) Load() ([]byte, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return bytes.Clone(s.data), s.version, nil
+}
+
+func (s *memLockStore) CompareAndSwap(version uint64, data []byte) (uint64, error) {
+	if f := s.beforeSwap; f != nil {
+		s.beforeSwap = nil
+		f()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version != s.version {
+		return 0, ErrLockStoreConflict
+	}
+	s.data = bytes.Clone(data)
+	s.version++
+	return s.version, nil
+}
+
+func TestFileLSRestart(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "locks")
+	now := time.Unix(0, 0)
+	ls, err := NewFileLS(name)
+	if err != nil {
+		t.Fatalf("NewFileLS: %v", err)
+	}
+	create := func(ls LockSystem, root string, d time.Duration) string {
+		t.Helper()
+		token, err := ls.Create(now, LockDetails{Root: root, Duration: d, OwnerXML: "<D:href>" + root + "</D:href>"})
+		if err != nil {
+			t.Fatalf("Create(%q): %v", root, err)
+		}
+		return token
+	}
+	a := create(ls, "/a", infiniteTimeout)
+	b := create(ls, "/b", 10*time.Second)
+	c := create(ls, "/c", time.Second)
+	d := create(ls, "/d", infiniteTimeout)
+	if _, err := ls.Refresh(now, b, 20*time.Second); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if err := ls.Unlock(now, d); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	// Simulate a crash while appending a record.
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"token":"`)
+	f.Close()
+
+	reopen := func(old LockSystem) LockSystem {
+		t.Helper()
+		ls, err := NewFileLS(name)
+		if err != nil {
+			t.Fatalf("NewFileLS: %v", err)
+		}
+		m, oldm := ls.(*fileLS).memLS, old.(*fileLS).memLS
+		if err := m.consistent(); err != nil {
+			t.Fatalf("reopened: inconsistent state: %v", err)
+		}
+		// Compare the encoded records, as decoding changes the location
+		// of times.
+		got, _ := json.Marshal(m.records(false))
+		want, _ := json.Marshal(oldm.records(false))
+		if !bytes.Equal(got, want) {
+			t.Fatalf("reopened:\ngot  %s\nwant %s", got, want)
+		}
+		if m.gen != oldm.gen {
+			t.Fatalf("reopened: gen %d, want %d", m.gen, oldm.gen)
+		}
+		return ls
+	}
+	ls = reopen(ls)
+
+	if _, err := ls.Create(now, LockDetails{Root: "/a/x"}); err != ErrLocked {
+		t.Errorf("Create under /a: got %v, want ErrLocked", err)
+	}
+	now = now.Add(15 * time.Second)
+	if _, err := ls.Confirm(now, "/c", "", Condition{Token: c}); err != ErrConfirmationFailed {
+		t.Errorf("Confirm of expired lock: got %v, want ErrConfirmationFailed", err)
+	}
+	if got, err := ls.Refresh(now, b, 5*time.Second); err != nil || got.OwnerXML != "<D:href>/b</D:href>" {
+		t.Errorf("Refresh: got %+v, %v", got, err)
+	}
+	if err := ls.Unlock(now, d); err != ErrNoSuchLock {
+		t.Errorf("Unlock of removed lock: got %v, want ErrNoSuchLock", err)
+	}
+	if e := create(ls, "/e", infiniteTimeout); e == a || e == b || e == c || e == d {
+		t.Errorf("Create: reused token %q", e)
+	}
+
+	// Refreshing repeatedly compacts the journal.
+	for range 3 * minJournalCompaction {
+		if _, err := ls.Refresh(now, a, infiniteTimeout); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+	}
+	if n := ls.(*fileLS).n; n > minJournalCompaction {
+		t.Errorf("journal has %d records, want at most %d", n, minJournalCompaction)
+	}
+	reopen(ls)
+}
+
+func TestStoreLSShared(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := &memLockStore{}
+	ls0, ls1 := NewStoreLS(store), NewStoreLS(store)
+
+	token, err := ls0.Create(now, LockDetails{Root: "/x", Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := ls1.Create(now, LockDetails{Root: "/x/y", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Errorf("Create of a locked resource: got %v, want ErrLocked", err)
+	}
+	release, err := ls1.Confirm(now, "/x/y", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if _, err := ls1.Refresh(now, token, time.Second); err != ErrLocked {
+		t.Errorf("Refresh of a held lock: got %v, want ErrLocked", err)
+	}
+	release()
+
+	// A change made by another LockSystem between loading and storing the
+	// locks is not lost.
+	var other string
+	store.beforeSwap = func() {
+		if other, err = ls1.Create(now, LockDetails{Root: "/z", Duration: infiniteTimeout}); err != nil {
+			t.Errorf("Create: %v", err)
+		}
+	}
+	if _, err := ls0.Create(now, LockDetails{Root: "/z", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Errorf("Create of a concurrently locked resource: got %v, want ErrLocked", err)
+	}
+	if other == "" || other == token {
+		t.Errorf("Create: got token %q, want a new token", other)
+	}
+
+	if err := ls1.Unlock(now, token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if _, err := ls0.Confirm(now, "/x", "", Condition{Token: token}); err != ErrConfirmationFailed {
+		t.Errorf("Confirm of an unlocked lock: got %v, want ErrConfirmationFailed", err)
+	}
+	if err := ls0.Unlock(now, other); err != nil {
+		t.Errorf("Unlock: %v", err)
+	}
+}
+
+func TestTemporaryLocksCrash(t *testing.T) {
+	// confirm creates the temporary locks of a PUT request without an If
+	// header, and never releases them, as if the process stopped.
+	confirm := func(ls LockSystem) {
+		t.Helper()
+		h := &Handler{LockSystem: ls}
+		if _, _, err := h.confirmLocks(httptest.NewRequest("PUT", "/a", nil), "/a", ""); err != nil {
+			t.Fatalf("confirmLocks: %v", err)
+		}
+		if _, err := ls.Create(time.Now(), LockDetails{Root: "/a", Duration: infiniteTimeout}); err != ErrLocked {
+			t.Fatalf("Create of a resource with a temporary lock: got %v, want ErrLocked", err)
+		}
+	}
+
+	name := filepath.Join(t.TempDir(), "locks")
+	ls, err := NewFileLS(name)
+	if err != nil {
+		t.Fatalf("NewFileLS: %v", err)
+	}
+	confirm(ls)
+	ls, err = NewFileLS(name)
+	if err != nil {
+		t.Fatalf("NewFileLS: %v", err)
+	}
+	if _, err := ls.Create(time.Now(), LockDetails{Root: "/a", Duration: infiniteTimeout}); err != nil {
+		t.Errorf("Create after a restart: %v", err)
+	}
+
+	store := &memLockStore{}
+	confirm(NewStoreLS(store))
+	other := NewStoreLS(store)
+	if _, err := other.Create(time.Now(), LockDetails{Root: "/a", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Errorf("Create in another LockSystem: got %v, want ErrLocked", err)
+	}
+	if _, err := other.Create(time.Now().Add(temporaryLockTimeout), LockDetails{Root: "/a", Duration: infiniteTimeout}); err != nil {
+		t.Errorf("Create in another LockSystem after the temporary locks expired: %v", err)
+	}
+}
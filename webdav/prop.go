@@ -51,16 +51,15 @@ type Propstat struct {
 	ResponseDescription string
 }
 
-// makePropstats returns a slice containing those of x and y whose Props slice
-// is non-empty. If both are empty, it returns a slice containing an otherwise
+// makePropstats returns a slice containing those of ps whose Props slice is
+// non-empty. If all are empty, it returns a slice containing an otherwise
 // zero Propstat whose HTTP status code is 200 OK.
-func makePropstats(x, y Propstat) []Propstat {
-	pstats := make([]Propstat, 0, 2)
-	if len(x.Props) != 0 {
-		pstats = append(pstats, x)
-	}
-	if len(y.Props) != 0 {
-		pstats = append(pstats, y)
+func makePropstats(ps ...Propstat) []Propstat {
+	pstats := make([]Propstat, 0, len(ps))
+	for _, p := range ps {
+		if len(p.Props) != 0 {
+			pstats = append(pstats, p)
+		}
 	}
 	if len(pstats) == 0 {
 		pstats = append(pstats, Propstat{
@@ -106,6 +105,10 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, FileSystem, LockSystem, string, os.FileInfo) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// named is true if the property may be expensive to compute, so that
+	// it is only returned when requested by name. See RFC 4331 section 3 and
+	// RFC 6578 section 4.
+	named bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -158,6 +161,33 @@ var liveProps = map[xml.Name]struct {
 		findFn: findSupportedLock,
 		dir:    true,
 	},
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn: findQuotaAvailableBytes,
+		dir:    true,
+		named:  true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn: findQuotaUsedBytes,
+		dir:    true,
+		named:  true,
+	},
+	{Space: "DAV:", Local: "sync-token"}: {
+		findFn: findSyncToken,
+		dir:    true,
+		named:  true,
+	},
+
+	// The ACL properties are only defined if the Handler has an Authorizer.
+	{Space: "DAV:", Local: "current-user-privilege-set"}: {
+		findFn: findCurrentUserPrivilegeSet,
+		dir:    true,
+		named:  true,
+	},
+	{Space: "DAV:", Local: "acl"}: {
+		findFn: findACL,
+		dir:    true,
+		named:  true,
+	},
 }
 
 // TODO(nigeltao) merge props and allprop?
@@ -188,6 +218,7 @@ func props(ctx context.Context, fs FileSystem, ls LockSystem, name string, pname
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
+	pstatForbidden := Propstat{Status: http.StatusForbidden}
 	for _, pn := range pnames {
 		// If this file has dead properties, check if they contain pn.
 		if dp, ok := deadProps[pn]; ok {
@@ -197,6 +228,18 @@ func props(ctx context.Context, fs FileSystem, ls LockSystem, name string, pname
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
 			innerXML, err := prop.findFn(ctx, fs, ls, name, fi)
+			if err == errUndefinedProperty {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
+			if err == errNeedPrivileges {
+				pstatForbidden.Props = append(pstatForbidden.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -210,7 +253,7 @@ func props(ctx context.Context, fs FileSystem, ls LockSystem, name string, pname
 			})
 		}
 	}
-	return makePropstats(pstatOK, pstatNotFound), nil
+	return makePropstats(pstatOK, pstatNotFound, pstatForbidden), nil
 }
 
 // propnames returns the property names defined for resource name.
@@ -236,11 +279,15 @@ func propnames(ctx context.Context, fs FileSystem, ls LockSystem, name string) (
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
-		if prop.findFn != nil && (prop.dir || !isDir) {
+		if prop.findFn != nil && (prop.dir || !isDir) && !prop.named {
 			pnames = append(pnames, pn)
 		}
 	}
 	for pn := range deadProps {
+		if prop, ok := liveProps[pn]; ok && prop.findFn != nil && (prop.dir || !isDir) && !prop.named {
+			// Already listed; the dead property overrides the live one.
+			continue
+		}
 		pnames = append(pnames, pn)
 	}
 	return pnames, nil
@@ -356,7 +403,32 @@ func escapeXML(s string) string {
 	return s
 }
 
+// ResourceTyper is an optional interface for the os.FileInfo objects
+// returned by the FileSystem.
+//
+// If this interface is defined then it will be used to read the
+// DAV:resourcetype property of the object, which extensions of WebDAV use
+// to mark special collections.
+//
+// If this interface is not defined the resource type will be a
+// DAV:collection for directories and empty otherwise.
+type ResourceTyper interface {
+	// ResourceType returns the XML representation of the resource type,
+	// with fully expanded XML namespaces, as for Property.InnerXML.
+	//
+	// If this returns error ErrNotImplemented then the error will
+	// be ignored and the base implementation will be used
+	// instead.
+	ResourceType(ctx context.Context) (string, error)
+}
+
 func findResourceType(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	if do, ok := fi.(ResourceTyper); ok {
+		rtype, err := do.ResourceType(ctx)
+		if err != ErrNotImplemented {
+			return rtype, err
+		}
+	}
 	if fi.IsDir() {
 		return `<D:collection xmlns:D="DAV:"/>`, nil
 	}
@@ -460,6 +532,35 @@ func findETag(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi
 	return fmt.Sprintf(`"%x%x"`, fi.ModTime().UnixNano(), fi.Size()), nil
 }
 
+func findQuotaAvailableBytes(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	qfs, ok := fs.(QuotaFileSystem)
+	if !ok {
+		return "", errUndefinedProperty
+	}
+	available, err := qfs.QuotaAvailable(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if available < 0 {
+		// RFC 4331 section 3 permits omitting the property when there
+		// is no known limit.
+		return "", errUndefinedProperty
+	}
+	return strconv.FormatInt(available, 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	qfs, ok := fs.(QuotaFileSystem)
+	if !ok {
+		return "", errUndefinedProperty
+	}
+	used, err := qfs.QuotaUsed(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(used, 10), nil
+}
+
 func findSupportedLock(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
 	return `` +
 		`<D:lockentry xmlns:D="DAV:">` +
@@ -467,3 +568,15 @@ func findSupportedLock(ctx context.Context, fs FileSystem, ls LockSystem, name s
 		`<D:locktype><D:write/></D:locktype>` +
 		`</D:lockentry>`, nil
 }
+
+func findSyncToken(ctx context.Context, fs FileSystem, ls LockSystem, name string, fi os.FileInfo) (string, error) {
+	cfs, ok := fs.(ChangeLogFileSystem)
+	if !ok || !fi.IsDir() {
+		return "", errUndefinedProperty
+	}
+	token, err := cfs.SyncToken(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return escapeXML(token), nil
+}
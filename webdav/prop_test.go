@@ -487,6 +487,60 @@ func TestMemPS(t *testing.T) {
 				}},
 			}},
 		}},
+	}, {
+		desc:    "propfind quota properties",
+		buildfs: []string{"mkdir /dir", "write /dir/a foo", "write /dir/b barbaz", "write /c x"},
+		propOp: []propOp{{
+			op:   "propfind",
+			name: "/dir",
+			pnames: []xml.Name{
+				{Space: "DAV:", Local: "quota-available-bytes"},
+				{Space: "DAV:", Local: "quota-used-bytes"},
+			},
+			wantPropstats: []Propstat{{
+				Status: http.StatusOK,
+				Props: []Property{{
+					XMLName:  xml.Name{Space: "DAV:", Local: "quota-used-bytes"},
+					InnerXML: []byte("9"),
+				}},
+			}, {
+				// The file system has no limit.
+				Status: http.StatusNotFound,
+				Props: []Property{{
+					XMLName: xml.Name{Space: "DAV:", Local: "quota-available-bytes"},
+				}},
+			}},
+		}, {
+			op:   "propfind",
+			name: "/",
+			pnames: []xml.Name{
+				{Space: "DAV:", Local: "quota-used-bytes"},
+			},
+			wantPropstats: []Propstat{{
+				Status: http.StatusOK,
+				Props: []Property{{
+					XMLName:  xml.Name{Space: "DAV:", Local: "quota-used-bytes"},
+					InnerXML: []byte("10"),
+				}},
+			}},
+		}},
+	}, {
+		desc:        "propfind quota properties without QuotaFileSystem",
+		noDeadProps: true,
+		buildfs:     []string{"mkdir /dir"},
+		propOp: []propOp{{
+			op:   "propfind",
+			name: "/dir",
+			pnames: []xml.Name{
+				{Space: "DAV:", Local: "quota-used-bytes"},
+			},
+			wantPropstats: []Propstat{{
+				Status: http.StatusNotFound,
+				Props: []Property{{
+					XMLName: xml.Name{Space: "DAV:", Local: "quota-used-bytes"},
+				}},
+			}},
+		}},
 	}, {
 		desc:    "bad: propfind unknown property",
 		buildfs: []string{"mkdir /dir"},
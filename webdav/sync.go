@@ -0,0 +1,417 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInvalidSyncToken is returned by a ChangeLogFileSystem for a sync token
+// that it did not issue or no longer remembers. Clients then have to
+// synchronize the collection from scratch.
+var ErrInvalidSyncToken = errors.New("webdav: invalid sync token")
+
+// A Change describes a resource that changed since a sync token was issued.
+type Change struct {
+	// Name is the name of the resource.
+	Name string
+	// Deleted is true if the resource was removed or renamed.
+	Deleted bool
+}
+
+// A ChangeLogFileSystem is a FileSystem that keeps track of changes to its
+// resources, so that clients can synchronize collections incrementally with
+// the DAV:sync-collection report.
+//
+// See https://www.rfc-editor.org/rfc/rfc6578
+type ChangeLogFileSystem interface {
+	FileSystem
+
+	// SyncToken returns a sync token, which is a URI, representing the
+	// current state of the collection name.
+	SyncToken(ctx context.Context, name string) (string, error)
+
+	// Changes returns the members of the collection name that were
+	// created, modified or removed since token was returned by SyncToken
+	// or Changes, along with a sync token for the current state. Only the
+	// immediate members are reported unless infinite is true, in which case
+	// all descendants are. It returns ErrInvalidSyncToken if token is not
+	// a valid sync token for name.
+	Changes(ctx context.Context, name, token string, infinite bool) (changes []Change, newToken string, err error)
+}
+
+const syncTokenPrefix = "urn:x-go-webdav:sync:"
+
+var syncEpochs atomic.Uint64
+
+// newSyncEpoch returns a string that identifies a change log in sync tokens,
+// distinguishing it from other change logs, including those of previous
+// processes.
+func newSyncEpoch() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(syncEpochs.Add(1), 36)
+}
+
+func makeSyncToken(epoch string, seq uint64) string {
+	return syncTokenPrefix + epoch + ":" + strconv.FormatUint(seq, 10)
+}
+
+// parseSyncToken returns the sequence number of a sync token issued by the
+// change log identified by epoch.
+func parseSyncToken(token, epoch string) (uint64, error) {
+	rest, ok := strings.CutPrefix(token, syncTokenPrefix+epoch+":")
+	if !ok {
+		return 0, ErrInvalidSyncToken
+	}
+	seq, err := strconv.ParseUint(rest, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSyncToken
+	}
+	return seq, nil
+}
+
+// isMember reports whether name is a member of the collection dir, or a
+// descendant if infinite is true.
+func isMember(dir, name string, infinite bool) bool {
+	if infinite {
+		return name != dir && (dir == "/" || strings.HasPrefix(name, dir+"/"))
+	}
+	return name != dir && path.Dir(name) == dir
+}
+
+// sortChanges sorts changes by name.
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+}
+
+// touch records a change to n and its descendants. The caller must hold
+// memFS.mu.
+func (n *memFSNode) touch(seq uint64) {
+	n.mu.Lock()
+	n.seq = seq
+	n.mu.Unlock()
+	for _, c := range n.children {
+		c.touch(seq)
+	}
+}
+
+// maxMemFSTombstones is the number of removals that a memFS remembers. When
+// there are more, the older half is forgotten, and the sync tokens issued
+// before them become invalid.
+const maxMemFSTombstones = 1 << 14
+
+// bury records the removal of the node n named name, and its descendants.
+// The caller must hold memFS.mu.
+func (fs *memFS) bury(name string, n *memFSNode, seq uint64) {
+	var walk func(name string, n *memFSNode)
+	walk = func(name string, n *memFSNode) {
+		fs.tombstones[name] = seq
+		for cName, c := range n.children {
+			walk(path.Join(name, cName), c)
+		}
+	}
+	walk(name, n)
+	if len(fs.tombstones) > maxMemFSTombstones {
+		fs.pruneTombstones()
+	}
+}
+
+// pruneTombstones forgets the older half of the removals. The caller must
+// hold memFS.mu.
+func (fs *memFS) pruneTombstones() {
+	seqs := slices.Sorted(maps.Values(fs.tombstones))
+	cutoff := seqs[len(seqs)-maxMemFSTombstones/2-1]
+	for name, seq := range fs.tombstones {
+		if seq <= cutoff {
+			delete(fs.tombstones, name)
+		}
+	}
+	// The changes since an older token may include forgotten removals.
+	fs.minSyncSeq = max(fs.minSyncSeq, cutoff)
+}
+
+// findDir returns the directory node name. The caller must hold memFS.mu.
+func (fs *memFS) findDir(op, name string) (*memFSNode, error) {
+	dir, frag, err := fs.find(op, name)
+	if err != nil {
+		return nil, err
+	}
+	n := &fs.root
+	if dir != nil {
+		if n = dir.children[frag]; n == nil {
+			return nil, os.ErrNotExist
+		}
+	}
+	if n.children == nil {
+		return nil, errNotADirectory
+	}
+	return n, nil
+}
+
+func (fs *memFS) SyncToken(ctx context.Context, name string) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, err := fs.findDir("synctoken", name); err != nil {
+		return "", err
+	}
+	return makeSyncToken(fs.epoch, fs.seq.Load()), nil
+}
+
+func (fs *memFS) Changes(ctx context.Context, name, token string, infinite bool) ([]Change, string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	n, err := fs.findDir("changes", name)
+	if err != nil {
+		return nil, "", err
+	}
+	// Writes to files do not hold the tree lock. Load the sequence number
+	// before the walk, so that writes racing with it are reported again
+	// by the next call.
+	seq := fs.seq.Load()
+	since, err := parseSyncToken(token, fs.epoch)
+	if err != nil || since > seq || since < fs.minSyncSeq {
+		return nil, "", ErrInvalidSyncToken
+	}
+
+	name = slashClean(name)
+	var changes []Change
+	exists := make(map[string]bool)
+	var walk func(name string, n *memFSNode)
+	walk = func(name string, n *memFSNode) {
+		for cName, c := range n.children {
+			cName = path.Join(name, cName)
+			exists[cName] = true
+			c.mu.Lock()
+			changed := c.seq > since
+			c.mu.Unlock()
+			if changed {
+				changes = append(changes, Change{Name: cName})
+			}
+			if infinite {
+				walk(cName, c)
+			}
+		}
+	}
+	walk(name, n)
+	for tName, tSeq := range fs.tombstones {
+		if tSeq > since && !exists[tName] && isMember(name, tName, infinite) {
+			changes = append(changes, Change{Name: tName, Deleted: true})
+		}
+	}
+	sortChanges(changes)
+	return changes, makeSyncToken(fs.epoch, seq), nil
+}
+
+// maxDirSnapshots is the number of snapshots of each collection that a Dir
+// remembers. Older sync tokens are invalid.
+const maxDirSnapshots = 8
+
+// maxDirSnapshotSize is the total size of the snapshots of all the Dirs,
+// counting one per snapshot and one per resource within it. When it is
+// exceeded, the oldest snapshots are forgotten, whatever their collection.
+var maxDirSnapshotSize = 1 << 20
+
+// dirSync holds the snapshots of the collections of every Dir, which are
+// compared to report changes. Snapshots are keyed by the absolute native
+// path of their collection, and are lost when the process exits.
+var dirSync struct {
+	mu        sync.Mutex
+	epoch     string
+	seq       uint64
+	snapshots map[string][]dirSnapshot
+	// size is the total size of the snapshots, as bounded by
+	// maxDirSnapshotSize, and n is their number.
+	size, n int
+	// order lists the snapshots from the oldest to the newest. It may
+	// still list snapshots that were forgotten.
+	order []dirSnapshotRef
+}
+
+type dirSnapshotRef struct {
+	key string
+	seq uint64
+}
+
+// A dirSnapshot records the state of the resources within a collection of a
+// Dir, keyed by their name.
+type dirSnapshot struct {
+	seq     uint64
+	entries map[string]dirEntry
+}
+
+type dirEntry struct {
+	modTime int64 // in nanoseconds since the Unix epoch
+	size    int64
+	mode    os.FileMode
+}
+
+// snapshot returns the state of the resources within the collection name.
+func (d Dir) snapshot(name string) (key string, entries map[string]dirEntry, err error) {
+	root := d.resolve(name)
+	if root == "" {
+		return "", nil, os.ErrNotExist
+	}
+	fi, err := os.Stat(root)
+	if err != nil {
+		return "", nil, err
+	}
+	if !fi.IsDir() {
+		return "", nil, errNotADirectory
+	}
+	if key, err = filepath.Abs(root); err != nil {
+		return "", nil, err
+	}
+	name = slashClean(name)
+	entries = make(map[string]dirEntry)
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed during the walk.
+				return nil
+			}
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		entries[path.Join(name, filepath.ToSlash(rel))] = dirEntry{
+			modTime: info.ModTime().UnixNano(),
+			size:    info.Size(),
+			mode:    info.Mode(),
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return key, entries, nil
+}
+
+// rememberDirSnapshot returns the sync token for the snapshot entries of the
+// collection key, reusing the latest one if nothing changed. The caller must
+// hold dirSync.mu.
+func rememberDirSnapshot(key string, entries map[string]dirEntry) string {
+	if dirSync.snapshots == nil {
+		dirSync.epoch = newSyncEpoch()
+		dirSync.snapshots = make(map[string][]dirSnapshot)
+	}
+	snaps := dirSync.snapshots[key]
+	if len(snaps) > 0 && maps.Equal(snaps[len(snaps)-1].entries, entries) {
+		return makeSyncToken(dirSync.epoch, snaps[len(snaps)-1].seq)
+	}
+	dirSync.seq++
+	dirSync.snapshots[key] = append(snaps, dirSnapshot{seq: dirSync.seq, entries: entries})
+	dirSync.size += 1 + len(entries)
+	dirSync.n++
+	dirSync.order = append(dirSync.order, dirSnapshotRef{key, dirSync.seq})
+	if len(snaps)+1 > maxDirSnapshots {
+		forgetDirSnapshot(key)
+	}
+	// The new snapshot is kept even if it is larger than the limit.
+	for dirSync.size > maxDirSnapshotSize && dirSync.order[0].seq != dirSync.seq {
+		ref := dirSync.order[0]
+		dirSync.order = dirSync.order[1:]
+		if s := dirSync.snapshots[ref.key]; len(s) > 0 && s[0].seq == ref.seq {
+			forgetDirSnapshot(ref.key)
+		}
+	}
+	if len(dirSync.order) > 2*dirSync.n {
+		// Drop the references to snapshots forgotten because their
+		// collection had too many.
+		dirSync.order = slices.DeleteFunc(dirSync.order, func(ref dirSnapshotRef) bool {
+			return !slices.ContainsFunc(dirSync.snapshots[ref.key], func(s dirSnapshot) bool { return s.seq == ref.seq })
+		})
+	}
+	return makeSyncToken(dirSync.epoch, dirSync.seq)
+}
+
+// forgetDirSnapshot forgets the oldest snapshot of the collection key. The
+// caller must hold dirSync.mu.
+func forgetDirSnapshot(key string) {
+	snaps := dirSync.snapshots[key]
+	dirSync.size -= 1 + len(snaps[0].entries)
+	dirSync.n--
+	if len(snaps) == 1 {
+		delete(dirSync.snapshots, key)
+	} else {
+		dirSync.snapshots[key] = slices.Delete(snaps, 0, 1)
+	}
+}
+
+// SyncToken implements ChangeLogFileSystem. A Dir detects changes by
+// comparing the modification time, size and mode of files with a snapshot
+// of the collection taken when the sync token was issued, so changes made
+// outside the Dir are reported too.
+//
+// Each call to SyncToken or Changes walks the whole collection, and its
+// snapshot takes memory in proportion to the number of resources in it.
+// The last few snapshots of each collection are remembered, within a
+// limit on the total size of the snapshots of all the Dirs of the process;
+// the sync tokens of forgotten snapshots are invalid.
+func (d Dir) SyncToken(ctx context.Context, name string) (string, error) {
+	key, entries, err := d.snapshot(name)
+	if err != nil {
+		return "", err
+	}
+	dirSync.mu.Lock()
+	defer dirSync.mu.Unlock()
+	return rememberDirSnapshot(key, entries), nil
+}
+
+// Changes implements ChangeLogFileSystem.
+func (d Dir) Changes(ctx context.Context, name, token string, infinite bool) ([]Change, string, error) {
+	key, entries, err := d.snapshot(name)
+	if err != nil {
+		return nil, "", err
+	}
+	dirSync.mu.Lock()
+	defer dirSync.mu.Unlock()
+	seq, err := parseSyncToken(token, dirSync.epoch)
+	if err != nil || dirSync.snapshots == nil {
+		return nil, "", ErrInvalidSyncToken
+	}
+	var old map[string]dirEntry
+	for _, s := range dirSync.snapshots[key] {
+		if s.seq == seq {
+			old = s.entries
+			break
+		}
+	}
+	if old == nil {
+		return nil, "", ErrInvalidSyncToken
+	}
+
+	name = slashClean(name)
+	var changes []Change
+	for n, e := range entries {
+		if prev, ok := old[n]; (!ok || prev != e) && isMember(name, n, infinite) {
+			changes = append(changes, Change{Name: n})
+		}
+	}
+	for n := range old {
+		if _, ok := entries[n]; !ok && isMember(name, n, infinite) {
+			changes = append(changes, Change{Name: n, Deleted: true})
+		}
+	}
+	sortChanges(changes)
+	return changes, rememberDirSnapshot(key, entries), nil
+}
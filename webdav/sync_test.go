@@ -0,0 +1,332 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMemFSChanges(t *testing.T) {
+	testChangeLog(t, NewMemFS().(ChangeLogFileSystem))
+}
+
+func TestDirChanges(t *testing.T) {
+	testChangeLog(t, Dir(t.TempDir()))
+}
+
+func testChangeLog(t *testing.T, fs ChangeLogFileSystem) {
+	ctx := context.Background()
+	write := func(name, data string) {
+		t.Helper()
+		f, err := fs.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			t.Fatalf("OpenFile(%q): %v", name, err)
+		}
+		if _, err := f.Write([]byte(data)); err != nil {
+			t.Fatalf("Write(%q): %v", name, err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close(%q): %v", name, err)
+		}
+	}
+	// changes returns the changes since token, omitting directories that
+	// still exist: a Dir reports directories whose members changed, as
+	// their modification time changes.
+	changes := func(token string, infinite bool, want []Change) string {
+		t.Helper()
+		got, newToken, err := fs.Changes(ctx, "/a", token, infinite)
+		if err != nil {
+			t.Fatalf("Changes(%q, %t): %v", token, infinite, err)
+		}
+		var files []Change
+		for _, c := range got {
+			if fi, err := fs.Stat(ctx, c.Name); c.Deleted || err != nil || !fi.IsDir() {
+				files = append(files, c)
+			}
+		}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("Changes(%q, %t):\ngot  %v\nwant %v", token, infinite, files, want)
+		}
+		return newToken
+	}
+
+	for _, name := range []string{"/a", "/a/b"} {
+		if err := fs.Mkdir(ctx, name, 0777); err != nil {
+			t.Fatalf("Mkdir(%q): %v", name, err)
+		}
+	}
+	write("/a/x", "1")
+	write("/a/b/y", "1")
+
+	token, err := fs.SyncToken(ctx, "/a")
+	if err != nil {
+		t.Fatalf("SyncToken: %v", err)
+	}
+	if _, err := fs.SyncToken(ctx, "/a/x"); err == nil {
+		t.Errorf("SyncToken of a file: got nil error")
+	}
+	token = changes(token, true, nil)
+
+	write("/a/x", "22")
+	if err := fs.RemoveAll(ctx, "/a/b/y"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	write("/a/b/z", "1")
+	changes(token, false, []Change{{Name: "/a/x"}})
+	token = changes(token, true, []Change{
+		{Name: "/a/b/y", Deleted: true},
+		{Name: "/a/b/z"},
+		{Name: "/a/x"},
+	})
+
+	if err := fs.Rename(ctx, "/a/x", "/a/w"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	token = changes(token, true, []Change{
+		{Name: "/a/w"},
+		{Name: "/a/x", Deleted: true},
+	})
+
+	if err := fs.RemoveAll(ctx, "/a/b"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	changes(token, false, []Change{{Name: "/a/b", Deleted: true}})
+	changes(token, true, []Change{
+		{Name: "/a/b", Deleted: true},
+		{Name: "/a/b/z", Deleted: true},
+	})
+
+	for _, bad := range []string{"", "urn:x-go-webdav:sync:bogus:1", token + "0"} {
+		if _, _, err := fs.Changes(ctx, "/a", bad, true); err != ErrInvalidSyncToken {
+			t.Errorf("Changes(%q): got %v, want %v", bad, err, ErrInvalidSyncToken)
+		}
+	}
+}
+
+func TestMemFSTombstonePruning(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemFS().(*memFS)
+	if err := fs.Mkdir(ctx, "/d", 0777); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	for i := range maxMemFSTombstones {
+		f, err := fs.OpenFile(ctx, "/d/"+strconv.Itoa(i), os.O_RDWR|os.O_CREATE, 0666)
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		f.Close()
+	}
+	old, err := fs.SyncToken(ctx, "/")
+	if err != nil {
+		t.Fatalf("SyncToken: %v", err)
+	}
+	if err := fs.RemoveAll(ctx, "/d"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if n := len(fs.tombstones); n > maxMemFSTombstones {
+		t.Errorf("got %d tombstones, want at most %d", n, maxMemFSTombstones)
+	}
+	if _, _, err := fs.Changes(ctx, "/", old, true); err != ErrInvalidSyncToken {
+		t.Errorf("Changes since removals that were forgotten: got %v, want %v", err, ErrInvalidSyncToken)
+	}
+
+	token, err := fs.SyncToken(ctx, "/")
+	if err != nil {
+		t.Fatalf("SyncToken: %v", err)
+	}
+	if err := fs.Mkdir(ctx, "/e", 0777); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := fs.RemoveAll(ctx, "/e"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	changes, _, err := fs.Changes(ctx, "/", token, true)
+	if want := []Change{{Name: "/e", Deleted: true}}; err != nil || !reflect.DeepEqual(changes, want) {
+		t.Errorf("Changes: got %v, %v; want %v", changes, err, want)
+	}
+}
+
+func TestDirSnapshotLimit(t *testing.T) {
+	defer func(size int) { maxDirSnapshotSize = size }(maxDirSnapshotSize)
+	maxDirSnapshotSize = 8
+
+	ctx := context.Background()
+	dir := Dir(t.TempDir())
+	var tokens []string
+	for _, name := range []string{"/a", "/b", "/c"} {
+		if err := dir.Mkdir(ctx, name, 0777); err != nil {
+			t.Fatalf("Mkdir: %v", err)
+		}
+		for _, member := range []string{"/x", "/y"} {
+			if err := dir.Mkdir(ctx, name+member, 0777); err != nil {
+				t.Fatalf("Mkdir: %v", err)
+			}
+		}
+		token, err := dir.SyncToken(ctx, name)
+		if err != nil {
+			t.Fatalf("SyncToken: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+	// Each snapshot has a size of 3, so the oldest one was forgotten.
+	dirSync.mu.Lock()
+	size := dirSync.size
+	dirSync.mu.Unlock()
+	if size > maxDirSnapshotSize {
+		t.Errorf("snapshots have a total size of %d, want at most %d", size, maxDirSnapshotSize)
+	}
+	if _, _, err := dir.Changes(ctx, "/a", tokens[0], true); err != ErrInvalidSyncToken {
+		t.Errorf("Changes of the oldest snapshot: got %v, want %v", err, ErrInvalidSyncToken)
+	}
+	if _, _, err := dir.Changes(ctx, "/c", tokens[2], true); err != nil {
+		t.Errorf("Changes of the newest snapshot: %v", err)
+	}
+}
+
+func TestSyncCollection(t *testing.T) {
+	srv := httptest.NewServer(&Handler{
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+	})
+	defer srv.Close()
+
+	do := func(method, p, body string, hdr map[string]string, wantStatus int) string {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+p, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, p, err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != wantStatus {
+			t.Fatalf("%s %s: got status %d, want %d\n%s", method, p, res.StatusCode, wantStatus, b)
+		}
+		return string(b)
+	}
+	type result struct {
+		href, status, length string
+	}
+	sync := func(token, level, limit string, wantStatus int) (results []result, newToken, body string) {
+		t.Helper()
+		body = do("REPORT", "/dir/", `<?xml version="1.0" encoding="utf-8" ?>
+			<D:sync-collection xmlns:D="DAV:">
+				<D:sync-token>`+token+`</D:sync-token>
+				<D:sync-level>`+level+`</D:sync-level>`+limit+`
+				<D:prop><D:getcontentlength/></D:prop>
+			</D:sync-collection>`, nil, wantStatus)
+		if wantStatus != StatusMulti {
+			return nil, "", body
+		}
+		var ms struct {
+			Responses []struct {
+				Href     string `xml:"href"`
+				Status   string `xml:"status"`
+				Propstat []struct {
+					Status string `xml:"status"`
+					Length string `xml:"prop>getcontentlength"`
+				} `xml:"propstat"`
+			} `xml:"response"`
+			SyncToken string `xml:"sync-token"`
+		}
+		if err := xml.Unmarshal([]byte(body), &ms); err != nil {
+			t.Fatalf("sync-collection: %v\n%s", err, body)
+		}
+		for _, r := range ms.Responses {
+			res := result{href: r.Href, status: r.Status}
+			for _, ps := range r.Propstat {
+				res.status = ps.Status
+				res.length = ps.Length
+			}
+			results = append(results, res)
+		}
+		return results, ms.SyncToken, body
+	}
+
+	do("MKCOL", "/dir", "", nil, http.StatusCreated)
+	do("MKCOL", "/dir/sub", "", nil, http.StatusCreated)
+	do("PUT", "/dir/a", "aaa", nil, http.StatusCreated)
+	do("PUT", "/dir/sub/b", "b", nil, http.StatusCreated)
+
+	got, token, _ := sync("", "1", "", StatusMulti)
+	want := []result{
+		{"/dir/a", "HTTP/1.1 200 OK", "3"},
+		{"/dir/sub/", "HTTP/1.1 404 Not Found", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("initial sync-collection:\ngot  %v\nwant %v", got, want)
+	}
+	if token == "" {
+		t.Fatalf("initial sync-collection: no sync token")
+	}
+	if got, _, _ := sync(token, "infinite", "", StatusMulti); len(got) != 0 {
+		t.Errorf("sync-collection without changes: got %v, want none", got)
+	}
+
+	do("PUT", "/dir/a", "aaaaa", nil, http.StatusCreated)
+	do("DELETE", "/dir/sub/b", "", nil, http.StatusNoContent)
+	do("PUT", "/dir/c", "", nil, http.StatusCreated)
+	got, newToken, _ := sync(token, "infinite", "", StatusMulti)
+	want = []result{
+		{"/dir/a", "HTTP/1.1 200 OK", "5"},
+		{"/dir/c", "HTTP/1.1 200 OK", "0"},
+		{"/dir/sub/b", "HTTP/1.1 404 Not Found", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sync-collection:\ngot  %v\nwant %v", got, want)
+	}
+	if newToken == token {
+		t.Errorf("sync-collection: sync token unchanged after changes")
+	}
+
+	// A PROPPATCH is a change too.
+	do("PROPPATCH", "/dir/c", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propertyupdate xmlns:D="DAV:"><D:set><D:prop><Z:x xmlns:Z="z">y</Z:x></D:prop></D:set></D:propertyupdate>`,
+		nil, StatusMulti)
+	if got, _, _ := sync(newToken, "1", "", StatusMulti); len(got) != 1 || got[0].href != "/dir/c" {
+		t.Errorf("sync-collection after PROPPATCH: got %v, want /dir/c", got)
+	}
+
+	if _, _, body := sync("urn:x-go-webdav:sync:bogus:0", "1", "", http.StatusForbidden); !strings.Contains(body, "valid-sync-token") {
+		t.Errorf("sync-collection with invalid token: got body %q, want valid-sync-token error", body)
+	}
+	sync(token, "infinite", `<D:limit><D:nresults>2</D:nresults></D:limit>`, http.StatusInsufficientStorage)
+	sync(token, "2", "", http.StatusBadRequest)
+	do("REPORT", "/dir/", `<D:sync-collection xmlns:D="DAV:"><D:sync-token/><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`,
+		map[string]string{"Depth": "1"}, http.StatusBadRequest)
+	do("REPORT", "/dir/a", `<D:sync-collection xmlns:D="DAV:"><D:sync-token/><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`,
+		nil, http.StatusForbidden)
+
+	body := do("PROPFIND", "/dir/", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propfind xmlns:D="DAV:"><D:prop><D:sync-token/></D:prop></D:propfind>`,
+		map[string]string{"Depth": "0"}, StatusMulti)
+	if !strings.Contains(body, "urn:x-go-webdav:sync:") {
+		t.Errorf("PROPFIND sync-token: got %q, want a sync token", body)
+	}
+	body = do("PROPFIND", "/dir/", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propfind xmlns:D="DAV:"><D:allprop/></D:propfind>`,
+		map[string]string{"Depth": "0"}, StatusMulti)
+	if strings.Contains(body, "sync-token") {
+		t.Errorf("PROPFIND allprop: got %q, want no sync-token", body)
+	}
+}
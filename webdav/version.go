@@ -0,0 +1,296 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotVersionControlled is returned by a VersionedFileSystem for a
+	// resource that is not under version control.
+	ErrNotVersionControlled = errors.New("webdav: not version-controlled")
+	// ErrCheckedIn is returned by a VersionedFileSystem's Checkin method
+	// for a resource that is not checked out.
+	ErrCheckedIn = errors.New("webdav: checked in")
+	// ErrCheckedOut is returned by a VersionedFileSystem's Checkout method
+	// for a resource that is already checked out.
+	ErrCheckedOut = errors.New("webdav: checked out")
+)
+
+// A Version describes one version of a version-controlled resource.
+type Version struct {
+	// Name is the DAV:version-name of the version. It is unique among the
+	// versions of a resource.
+	Name string
+	// Predecessor is the Name of the version that this version was created
+	// from, or empty for the first version.
+	Predecessor string
+	// Size is the length in bytes of the version's content.
+	Size int64
+	// ModTime is the time the version was created.
+	ModTime time.Time
+}
+
+// A VersionedFileSystem is a FileSystem that supports linear versioning of
+// its files, as described by the core versioning feature of RFC 3253.
+//
+// A Handler serving a VersionedFileSystem supports the VERSION-CONTROL,
+// CHECKOUT, CHECKIN and REPORT methods, with the DAV:version-tree report.
+// The content of a version is served by GET requests for the resource's URL
+// with a "version" query parameter holding the version's Name. A checked-in
+// resource cannot be modified with PUT or PROPPATCH, deleted, moved, or
+// overwritten by COPY or MOVE until it is checked out.
+//
+// See http://www.webdav.org/specs/rfc3253.html
+type VersionedFileSystem interface {
+	FileSystem
+
+	// VersionControl puts the file name under version control, recording
+	// its current content as the first version. The file is left checked
+	// in. It is not an error if name is already under version control.
+	VersionControl(ctx context.Context, name string) error
+
+	// Checkout allows the file name to be modified. It returns
+	// ErrCheckedOut if name is already checked out.
+	Checkout(ctx context.Context, name string) error
+
+	// Checkin records the current content of the file name as a new
+	// version, succeeding the previous one, and checks name in. It returns
+	// ErrCheckedIn if name is not checked out.
+	Checkin(ctx context.Context, name string) (Version, error)
+
+	// Versions returns the versions of the file name, oldest first, and
+	// whether name is checked out.
+	Versions(ctx context.Context, name string) (versions []Version, checkedOut bool, err error)
+
+	// OpenVersion opens the content of the named version of the file name
+	// for reading.
+	OpenVersion(ctx context.Context, name, version string) (File, error)
+}
+
+// NewMemVersionedFS returns a new in-memory VersionedFileSystem
+// implementation.
+func NewMemVersionedFS() VersionedFileSystem {
+	return &memVersionFS{
+		FileSystem: NewMemFS(),
+		histories:  make(map[string]*memVersionHistory),
+	}
+}
+
+// A memVersionFS adds versioning to a FileSystem, keeping the content of
+// every version in memory.
+type memVersionFS struct {
+	FileSystem
+
+	mu sync.Mutex
+	// histories holds the version history of each version-controlled file,
+	// keyed by its cleaned name.
+	histories map[string]*memVersionHistory
+}
+
+type memVersionHistory struct {
+	versions   []memVersion
+	checkedOut bool
+}
+
+type memVersion struct {
+	Version
+	data []byte
+}
+
+// snapshot reads the current content of the file name.
+func (fs *memVersionFS) snapshot(ctx context.Context, name string) ([]byte, error) {
+	f, err := fs.FileSystem.OpenFile(ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, os.ErrInvalid
+	}
+	return io.ReadAll(f)
+}
+
+func (fs *memVersionFS) VersionControl(ctx context.Context, name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	name = slashClean(name)
+	if fs.histories[name] != nil {
+		return nil
+	}
+	data, err := fs.snapshot(ctx, name)
+	if err != nil {
+		return err
+	}
+	fs.histories[name] = &memVersionHistory{
+		versions: []memVersion{{
+			Version: Version{
+				Name:    "1",
+				Size:    int64(len(data)),
+				ModTime: time.Now(),
+			},
+			data: data,
+		}},
+	}
+	return nil
+}
+
+func (fs *memVersionFS) Checkout(ctx context.Context, name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	h := fs.histories[slashClean(name)]
+	if h == nil {
+		return ErrNotVersionControlled
+	}
+	if h.checkedOut {
+		return ErrCheckedOut
+	}
+	h.checkedOut = true
+	return nil
+}
+
+func (fs *memVersionFS) Checkin(ctx context.Context, name string) (Version, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	name = slashClean(name)
+	h := fs.histories[name]
+	if h == nil {
+		return Version{}, ErrNotVersionControlled
+	}
+	if !h.checkedOut {
+		return Version{}, ErrCheckedIn
+	}
+	data, err := fs.snapshot(ctx, name)
+	if err != nil {
+		return Version{}, err
+	}
+	v := memVersion{
+		Version: Version{
+			Name:        strconv.Itoa(len(h.versions) + 1),
+			Predecessor: h.versions[len(h.versions)-1].Name,
+			Size:        int64(len(data)),
+			ModTime:     time.Now(),
+		},
+		data: data,
+	}
+	h.versions = append(h.versions, v)
+	h.checkedOut = false
+	return v.Version, nil
+}
+
+func (fs *memVersionFS) Versions(ctx context.Context, name string) ([]Version, bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	h := fs.histories[slashClean(name)]
+	if h == nil {
+		return nil, false, ErrNotVersionControlled
+	}
+	versions := make([]Version, len(h.versions))
+	for i, v := range h.versions {
+		versions[i] = v.Version
+	}
+	return versions, h.checkedOut, nil
+}
+
+func (fs *memVersionFS) OpenVersion(ctx context.Context, name, version string) (File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	name = slashClean(name)
+	h := fs.histories[name]
+	if h == nil {
+		return nil, ErrNotVersionControlled
+	}
+	for _, v := range h.versions {
+		if v.Name == version {
+			return &memVersionFile{
+				Reader: bytes.NewReader(v.data),
+				fi: &memFileInfo{
+					name:    path.Base(name),
+					size:    v.Size,
+					mode:    0444,
+					modTime: v.ModTime,
+				},
+			}, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (fs *memVersionFS) RemoveAll(ctx context.Context, name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.FileSystem.RemoveAll(ctx, name); err != nil {
+		return err
+	}
+	name = slashClean(name)
+	for n := range fs.histories {
+		if n == name || strings.HasPrefix(n, name+"/") {
+			delete(fs.histories, n)
+		}
+	}
+	return nil
+}
+
+func (fs *memVersionFS) Rename(ctx context.Context, oldName, newName string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.FileSystem.Rename(ctx, oldName, newName); err != nil {
+		return err
+	}
+	oldName, newName = slashClean(oldName), slashClean(newName)
+	if oldName == newName {
+		return nil
+	}
+	moved := make(map[string]*memVersionHistory)
+	for n, h := range fs.histories {
+		switch {
+		case n == oldName:
+			moved[newName] = h
+		case strings.HasPrefix(n, oldName+"/"):
+			moved[newName+n[len(oldName):]] = h
+		case n == newName || strings.HasPrefix(n, newName+"/"):
+			// Overwritten by the rename.
+		default:
+			continue
+		}
+		delete(fs.histories, n)
+	}
+	for n, h := range moved {
+		fs.histories[n] = h
+	}
+	return nil
+}
+
+// A memVersionFile is a read-only File holding the content of a version.
+type memVersionFile struct {
+	*bytes.Reader
+	fi *memFileInfo
+}
+
+func (f *memVersionFile) Close() error                       { return nil }
+func (f *memVersionFile) Readdir(int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *memVersionFile) Stat() (os.FileInfo, error)         { return f.fi, nil }
+func (f *memVersionFile) Write(p []byte) (int, error)        { return 0, os.ErrPermission }
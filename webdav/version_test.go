@@ -0,0 +1,372 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMemVersionedFS(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemVersionedFS()
+	write := func(name, data string) {
+		t.Helper()
+		f, err := fs.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			t.Fatalf("OpenFile(%q): %v", name, err)
+		}
+		if _, err := f.Write([]byte(data)); err != nil {
+			t.Fatalf("Write(%q): %v", name, err)
+		}
+		f.Close()
+	}
+	readVersion := func(name, version string) string {
+		t.Helper()
+		f, err := fs.OpenVersion(ctx, name, version)
+		if err != nil {
+			t.Fatalf("OpenVersion(%q, %q): %v", name, version, err)
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("reading version %q of %q: %v", version, name, err)
+		}
+		return string(b)
+	}
+	versionNames := func(name string) (names []string, preds []string, checkedOut bool) {
+		t.Helper()
+		vs, checkedOut, err := fs.Versions(ctx, name)
+		if err != nil {
+			t.Fatalf("Versions(%q): %v", name, err)
+		}
+		for _, v := range vs {
+			names = append(names, v.Name)
+			preds = append(preds, v.Predecessor)
+		}
+		return names, preds, checkedOut
+	}
+
+	if err := fs.Mkdir(ctx, "/d", 0777); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	write("/d/f", "one")
+	if _, _, err := fs.Versions(ctx, "/d/f"); err != ErrNotVersionControlled {
+		t.Fatalf("Versions before VERSION-CONTROL: got %v, want %v", err, ErrNotVersionControlled)
+	}
+	if err := fs.Checkout(ctx, "/d/f"); err != ErrNotVersionControlled {
+		t.Fatalf("Checkout before VERSION-CONTROL: got %v, want %v", err, ErrNotVersionControlled)
+	}
+	if err := fs.VersionControl(ctx, "/d"); err == nil {
+		t.Fatalf("VersionControl of a directory: got nil error")
+	}
+	if err := fs.VersionControl(ctx, "/d/f"); err != nil {
+		t.Fatalf("VersionControl: %v", err)
+	}
+	if err := fs.VersionControl(ctx, "/d/f"); err != nil {
+		t.Fatalf("second VersionControl: %v", err)
+	}
+	if _, err := fs.Checkin(ctx, "/d/f"); err != ErrCheckedIn {
+		t.Fatalf("Checkin of checked-in file: got %v, want %v", err, ErrCheckedIn)
+	}
+	if err := fs.Checkout(ctx, "/d/f"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if err := fs.Checkout(ctx, "/d/f"); err != ErrCheckedOut {
+		t.Fatalf("Checkout of checked-out file: got %v, want %v", err, ErrCheckedOut)
+	}
+	write("/d/f", "two!")
+	v, err := fs.Checkin(ctx, "/d/f")
+	if err != nil {
+		t.Fatalf("Checkin: %v", err)
+	}
+	if v.Name != "2" || v.Predecessor != "1" || v.Size != 4 {
+		t.Errorf("Checkin: got %+v, want version 2 of size 4 succeeding 1", v)
+	}
+
+	names, preds, checkedOut := versionNames("/d/f")
+	if want := []string{"1", "2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("version names: got %q, want %q", names, want)
+	}
+	if want := []string{"", "1"}; !reflect.DeepEqual(preds, want) {
+		t.Errorf("predecessors: got %q, want %q", preds, want)
+	}
+	if checkedOut {
+		t.Errorf("checked out after Checkin")
+	}
+	if got := readVersion("/d/f", "1"); got != "one" {
+		t.Errorf("version 1: got %q, want %q", got, "one")
+	}
+	if got := readVersion("/d/f", "2"); got != "two!" {
+		t.Errorf("version 2: got %q, want %q", got, "two!")
+	}
+	if _, err := fs.OpenVersion(ctx, "/d/f", "3"); !os.IsNotExist(err) {
+		t.Errorf("OpenVersion of missing version: got %v, want not exist", err)
+	}
+
+	// The version history follows the file when its directory is renamed,
+	// and is removed along with it.
+	if err := fs.Rename(ctx, "/d", "/e"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if _, _, err := fs.Versions(ctx, "/d/f"); err != ErrNotVersionControlled {
+		t.Errorf("Versions of old name: got %v, want %v", err, ErrNotVersionControlled)
+	}
+	if names, _, _ := versionNames("/e/f"); len(names) != 2 {
+		t.Errorf("Versions of new name: got %q, want 2 versions", names)
+	}
+	if err := fs.RemoveAll(ctx, "/e"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, _, err := fs.Versions(ctx, "/e/f"); err != ErrNotVersionControlled {
+		t.Errorf("Versions after RemoveAll: got %v, want %v", err, ErrNotVersionControlled)
+	}
+}
+
+func TestVersioningMethods(t *testing.T) {
+	h := &Handler{
+		Prefix:     "/dav",
+		FileSystem: NewMemVersionedFS(),
+		LockSystem: NewMemLS(),
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	do := func(method, urlStr, body string, wantStatus int) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, urlStr, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, urlStr, err)
+		}
+		if res.StatusCode != wantStatus {
+			t.Fatalf("%s %s: got status %d, want %d", method, urlStr, res.StatusCode, wantStatus)
+		}
+		return res
+	}
+	readBody := func(res *http.Response) string {
+		t.Helper()
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	res := do("OPTIONS", srv.URL+"/dav/", "", http.StatusOK)
+	if got := res.Header.Get("DAV"); got != "1, 2, version-control" {
+		t.Errorf("DAV header: got %q, want %q", got, "1, 2, version-control")
+	}
+	do("MKCOL", srv.URL+"/dav/dir", "", http.StatusCreated)
+	do("VERSION-CONTROL", srv.URL+"/dav/dir", "", http.StatusMethodNotAllowed)
+	do("VERSION-CONTROL", srv.URL+"/dav/missing", "", http.StatusNotFound)
+
+	do("PUT", srv.URL+"/dav/doc", "first", http.StatusCreated)
+	do("CHECKOUT", srv.URL+"/dav/doc", "", http.StatusConflict)
+	do("VERSION-CONTROL", srv.URL+"/dav/doc", "", http.StatusOK)
+	do("PUT", srv.URL+"/dav/doc", "rejected", http.StatusConflict)
+	do("CHECKIN", srv.URL+"/dav/doc", "", http.StatusConflict)
+	do("CHECKOUT", srv.URL+"/dav/doc", "", http.StatusOK)
+	do("PUT", srv.URL+"/dav/doc", "second", http.StatusCreated)
+	res = do("CHECKIN", srv.URL+"/dav/doc", "", http.StatusCreated)
+	if got, want := res.Header.Get("Location"), "/dav/doc?version=2"; got != want {
+		t.Errorf("CHECKIN Location: got %q, want %q", got, want)
+	}
+
+	if got := readBody(do("GET", srv.URL+"/dav/doc?version=1", "", http.StatusOK)); got != "first" {
+		t.Errorf("GET version 1: got %q, want %q", got, "first")
+	}
+	if got := readBody(do("GET", srv.URL+"/dav/doc", "", http.StatusOK)); got != "second" {
+		t.Errorf("GET current: got %q, want %q", got, "second")
+	}
+	do("GET", srv.URL+"/dav/doc?version=9", "", http.StatusNotFound)
+
+	res = do("REPORT", srv.URL+"/dav/doc", `<?xml version="1.0" encoding="utf-8" ?>
+		<D:version-tree xmlns:D="DAV:">
+			<D:prop>
+				<D:version-name/>
+				<D:successor-set/>
+				<D:getcontentlength/>
+				<D:creator-displayname/>
+			</D:prop>
+		</D:version-tree>`, StatusMulti)
+	var ms struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Prop struct {
+					VersionName   string `xml:"version-name"`
+					SuccessorSet  string `xml:"successor-set>href"`
+					ContentLength string `xml:"getcontentlength"`
+				} `xml:"prop"`
+				Status string `xml:"status"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal([]byte(readBody(res)), &ms); err != nil {
+		t.Fatalf("REPORT: unmarshaling response: %v", err)
+	}
+	type version struct {
+		href, name, successor, length string
+		notFound                      bool
+	}
+	var got []version
+	for _, r := range ms.Responses {
+		v := version{href: r.Href}
+		for _, ps := range r.Propstat {
+			if strings.Contains(ps.Status, " 404 ") {
+				v.notFound = true
+				continue
+			}
+			v.name = ps.Prop.VersionName
+			v.successor = ps.Prop.SuccessorSet
+			v.length = ps.Prop.ContentLength
+		}
+		got = append(got, v)
+	}
+	want := []version{
+		{"/dav/doc?version=1", "1", "/dav/doc?version=2", "5", true},
+		{"/dav/doc?version=2", "2", "", "6", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("REPORT version-tree:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	do("REPORT", srv.URL+"/dav/doc", `<D:expand-property xmlns:D="DAV:"/>`, http.StatusForbidden)
+	do("REPORT", srv.URL+"/dav/doc", ``, http.StatusBadRequest)
+	do("REPORT", srv.URL+"/dav/dir", `<D:version-tree xmlns:D="DAV:"><D:prop><D:version-name/></D:prop></D:version-tree>`, http.StatusConflict)
+
+	large := `<D:version-tree xmlns:D="DAV:"><D:prop><D:version-name/></D:prop>` +
+		strings.Repeat(" ", maxReportBody) + `</D:version-tree>`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("REPORT", "/dav/doc", strings.NewReader(large)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("REPORT with a %d byte body: got status %d, want %d", len(large), w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestVersioningUnsupported(t *testing.T) {
+	h := &Handler{
+		FileSystem: NewMemFS(),
+		LockSystem: NewMemLS(),
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("PUT", srv.URL+"/doc", strings.NewReader("x"))
+	if res, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else {
+		res.Body.Close()
+	}
+	for _, tc := range []struct {
+		method, body string
+		want         int
+	}{
+		{"VERSION-CONTROL", "", http.StatusMethodNotAllowed},
+		{"CHECKOUT", "", http.StatusMethodNotAllowed},
+		{"CHECKIN", "", http.StatusMethodNotAllowed},
+		{"REPORT", `<D:version-tree xmlns:D="DAV:"><D:prop/></D:version-tree>`, http.StatusForbidden},
+	} {
+		req, err := http.NewRequest(tc.method, srv.URL+"/doc", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.method, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.method, res.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestCheckedInProtection(t *testing.T) {
+	h := &Handler{
+		FileSystem: NewMemVersionedFS(),
+		LockSystem: NewMemLS(),
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	do := func(method, name, body string, hdr map[string]string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+name, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, name, err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	for _, req := range []struct{ method, name, body string }{
+		{"MKCOL", "/dir", ""},
+		{"PUT", "/dir/doc", "checked in"},
+		{"PUT", "/other", "other"},
+		{"VERSION-CONTROL", "/dir/doc", ""},
+	} {
+		if got := do(req.method, req.name, req.body, nil); got >= 300 {
+			t.Fatalf("%s %s: got status %d", req.method, req.name, got)
+		}
+	}
+
+	const proppatch = `<?xml version="1.0" encoding="utf-8" ?>
+		<D:propertyupdate xmlns:D="DAV:">
+			<D:set><D:prop><Z:color xmlns:Z="urn:x">red</Z:color></D:prop></D:set>
+		</D:propertyupdate>`
+	// Each request would modify the checked-in /dir/doc.
+	for _, tc := range []struct {
+		desc, method, name, body string
+		hdr                      map[string]string
+	}{
+		{"PUT", "PUT", "/dir/doc", "changed", nil},
+		{"PROPPATCH", "PROPPATCH", "/dir/doc", proppatch, nil},
+		{"DELETE", "DELETE", "/dir/doc", "", nil},
+		{"DELETE of its collection", "DELETE", "/dir", "", nil},
+		{"COPY onto it", "COPY", "/other", "", map[string]string{"Destination": "/dir/doc", "Overwrite": "T"}},
+		{"COPY onto its collection", "COPY", "/other", "", map[string]string{"Destination": "/dir", "Overwrite": "T"}},
+		{"MOVE onto it", "MOVE", "/other", "", map[string]string{"Destination": "/dir/doc", "Overwrite": "T"}},
+		{"MOVE of it", "MOVE", "/dir/doc", "", map[string]string{"Destination": "/moved"}},
+		{"MOVE of its collection", "MOVE", "/dir", "", map[string]string{"Destination": "/moved"}},
+	} {
+		if got := do(tc.method, tc.name, tc.body, tc.hdr); got != http.StatusConflict {
+			t.Errorf("%s: got status %d, want %d", tc.desc, got, http.StatusConflict)
+		}
+	}
+	if fi, err := h.FileSystem.Stat(context.Background(), "/dir/doc"); err != nil || fi.Size() != int64(len("checked in")) {
+		t.Fatalf("checked-in resource was modified: Stat = %v, %v", fi, err)
+	}
+
+	// Once checked out, it can be modified.
+	for _, req := range []struct{ method, name, body string }{
+		{"CHECKOUT", "/dir/doc", ""},
+		{"PROPPATCH", "/dir/doc", proppatch},
+		{"PUT", "/dir/doc", "changed"},
+		{"DELETE", "/dir", ""},
+	} {
+		if got := do(req.method, req.name, req.body, nil); got >= 300 {
+			t.Errorf("%s %s of a checked-out resource: got status %d", req.method, req.name, got)
+		}
+	}
+}
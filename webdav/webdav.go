@@ -2,10 +2,13 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package webdav provides a WebDAV server implementation.
+// Package webdav provides a WebDAV server and client implementation.
 package webdav // import "golang.org/x/net/webdav"
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -28,6 +32,58 @@ type Handler struct {
 	// Logger is an optional error logger. If non-nil, it will be called
 	// for all HTTP requests.
 	Logger func(*http.Request, error)
+	// Reports optionally runs REPORT requests, keyed by the name of the
+	// request body's root element. It takes precedence over the reports
+	// that the Handler supports itself.
+	Reports map[xml.Name]ReportFunc
+	// Methods optionally runs requests whose methods the Handler does not
+	// support itself, keyed by method. Each method creates a collection,
+	// like MKCOL: the request must satisfy the locks on its resource, and
+	// needs the DAV:bind privilege on the parent collection.
+	Methods map[string]MethodFunc
+	// Authorizer optionally restricts what the principal making a request
+	// may do, and serves the DAV:current-user-privilege-set and DAV:acl
+	// properties.
+	Authorizer Authorizer
+}
+
+// A ReportFunc runs a REPORT request for the resource name. The depth is 0,
+// 1 or -1 for infinity, and body is the request body, which holds at most
+// maxReportBody bytes.
+//
+// On success, it returns the responses to write in a multistatus response.
+// Otherwise, it returns the HTTP status to write and a non-nil error.
+//
+// See http://www.webdav.org/specs/rfc3253.html#METHOD_REPORT
+type ReportFunc func(ctx context.Context, name string, depth int, body io.Reader) (responses []Response, status int, err error)
+
+// A MethodFunc runs a request, registered in Handler.Methods, for the
+// resource name. The Handler has confirmed the locks of the request.
+//
+// It returns the HTTP status to write, or 0 if it has written the response
+// itself, and any error to log.
+type MethodFunc func(w http.ResponseWriter, r *http.Request, name string) (status int, err error)
+
+// A Response describes a resource in a multistatus response.
+// See http://www.webdav.org/specs/rfc4918.html#ELEMENT_response
+type Response struct {
+	// Name is the name of the resource within the Handler's FileSystem.
+	// A trailing slash marks a collection.
+	Name string
+	// Propstats holds the properties of the resource.
+	Propstats []Propstat
+	// Status is the HTTP status of the resource. It is only used if
+	// Propstats is empty.
+	Status int
+}
+
+// Props returns the status of the properties named pnames for the resource
+// name in h.FileSystem, as reported by PROPFIND.
+//
+// Each Propstat has a unique status and each property name will only be part
+// of one Propstat element.
+func (h *Handler) Props(ctx context.Context, name string, pnames []xml.Name) ([]Propstat, error) {
+	return props(ctx, h.FileSystem, h.LockSystem, name, pnames)
 }
 
 func (h *Handler) stripPrefix(p string) (string, int, error) {
@@ -46,7 +102,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		status, err = http.StatusInternalServerError, errNoFileSystem
 	} else if h.LockSystem == nil {
 		status, err = http.StatusInternalServerError, errNoLockSystem
-	} else {
+	} else if r, status, err = h.authorize(w, r); err == nil {
+		status, err = http.StatusBadRequest, errUnsupportedMethod
 		switch r.Method {
 		case "OPTIONS":
 			status, err = h.handleOptions(w, r)
@@ -68,6 +125,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			status, err = h.handlePropfind(w, r)
 		case "PROPPATCH":
 			status, err = h.handleProppatch(w, r)
+		case "REPORT":
+			status, err = h.handleReport(w, r)
+		case "VERSION-CONTROL":
+			status, err = h.handleVersionControl(w, r)
+		case "CHECKOUT":
+			status, err = h.handleCheckout(w, r)
+		case "CHECKIN":
+			status, err = h.handleCheckin(w, r)
+		default:
+			if fn := h.Methods[r.Method]; fn != nil {
+				status, err = h.handleMethod(w, r, fn)
+			}
 		}
 	}
 
@@ -87,6 +156,7 @@ func (h *Handler) lock(now time.Time, root string) (token string, status int, er
 		Root:      root,
 		Duration:  infiniteTimeout,
 		ZeroDepth: true,
+		temporary: true,
 	})
 	if err != nil {
 		if err == ErrLocked {
@@ -182,11 +252,19 @@ func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status
 			allow = "OPTIONS, LOCK, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND"
 		} else {
 			allow = "OPTIONS, LOCK, GET, HEAD, POST, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND, PUT"
+			if _, ok := h.FileSystem.(VersionedFileSystem); ok {
+				allow += ", VERSION-CONTROL, CHECKOUT, CHECKIN, REPORT"
+			}
 		}
 	}
 	w.Header().Set("Allow", allow)
 	// http://www.webdav.org/specs/rfc4918.html#dav.compliance.classes
-	w.Header().Set("DAV", "1, 2")
+	dav := "1, 2"
+	if _, ok := h.FileSystem.(VersionedFileSystem); ok {
+		// http://www.webdav.org/specs/rfc3253.html#rfc.section.3.6
+		dav += ", version-control"
+	}
+	w.Header().Set("DAV", dav)
 	// http://msdn.microsoft.com/en-au/library/cc250217.aspx
 	w.Header().Set("MS-Author-Via", "DAV")
 	return 0, nil
@@ -199,7 +277,13 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	}
 	// TODO: check locks for read-only access??
 	ctx := r.Context()
-	f, err := h.FileSystem.OpenFile(ctx, reqPath, os.O_RDONLY, 0)
+	var f File
+	vfs, ok := h.FileSystem.(VersionedFileSystem)
+	if version := r.URL.Query().Get("version"); ok && version != "" {
+		f, err = vfs.OpenVersion(ctx, reqPath, version)
+	} else {
+		f, err = h.FileSystem.OpenFile(ctx, reqPath, os.O_RDONLY, 0)
+	}
 	if err != nil {
 		return http.StatusNotFound, err
 	}
@@ -234,6 +318,10 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 
 	ctx := r.Context()
 
+	if h.checkedIn(ctx, reqPath, infiniteDepth) {
+		return http.StatusConflict, ErrCheckedIn
+	}
+
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -265,6 +353,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	// comments in http.checkEtag.
 	ctx := r.Context()
 
+	if h.checkedIn(ctx, reqPath, 0) {
+		return http.StatusConflict, ErrCheckedIn
+	}
+	if !h.fitsQuota(ctx, reqPath, r.ContentLength) {
+		return StatusInsufficientStorage, ErrQuotaExceeded
+	}
 	f, err := h.FileSystem.OpenFile(ctx, reqPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -275,6 +369,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	_, copyErr := io.Copy(f, r.Body)
 	fi, statErr := f.Stat()
 	closeErr := f.Close()
+	if errors.Is(copyErr, ErrQuotaExceeded) {
+		return StatusInsufficientStorage, copyErr
+	}
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if copyErr != nil {
 		return http.StatusMethodNotAllowed, copyErr
@@ -293,6 +390,50 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	return http.StatusCreated, nil
 }
 
+// checkedIn reports whether name, or any member of the collection name
+// within depth, is a checked-in version-controlled resource. Its content
+// and properties cannot be modified until it is checked out.
+//
+// See http://www.webdav.org/specs/rfc3253.html#rfc.section.3.1.1
+func (h *Handler) checkedIn(ctx context.Context, name string, depth int) bool {
+	vfs, ok := h.FileSystem.(VersionedFileSystem)
+	if !ok {
+		return false
+	}
+	fi, err := vfs.Stat(ctx, name)
+	if err != nil {
+		return false
+	}
+	err = walkFS(ctx, vfs, depth, name, fi, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if _, checkedOut, err := vfs.Versions(ctx, name); err == nil && !checkedOut {
+			return ErrCheckedIn
+		}
+		return nil
+	})
+	return err == ErrCheckedIn
+}
+
+// fitsQuota reports whether size bytes can be written to the file reqPath,
+// replacing any existing content. It is always true if h.FileSystem is not a
+// QuotaFileSystem or the size or available storage is unknown.
+func (h *Handler) fitsQuota(ctx context.Context, reqPath string, size int64) bool {
+	qfs, ok := h.FileSystem.(QuotaFileSystem)
+	if !ok || size <= 0 {
+		return true
+	}
+	available, err := qfs.QuotaAvailable(ctx, path.Dir(reqPath))
+	if err != nil || available < 0 {
+		return true
+	}
+	if fi, err := qfs.Stat(ctx, reqPath); err == nil && !fi.IsDir() {
+		available += fi.Size()
+	}
+	return size <= available
+}
+
 func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status int, err error) {
 	reqPath, status, err := h.stripPrefix(r.URL.Path)
 	if err != nil {
@@ -318,6 +459,19 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	return http.StatusCreated, nil
 }
 
+func (h *Handler) handleMethod(w http.ResponseWriter, r *http.Request, fn MethodFunc) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
+	return fn(w, r, reqPath)
+}
+
 func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status int, err error) {
 	hdr := r.Header.Get("Destination")
 	if hdr == "" {
@@ -373,6 +527,9 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 				return http.StatusBadRequest, errInvalidDepth
 			}
 		}
+		if h.checkedIn(ctx, dst, infiniteDepth) {
+			return http.StatusConflict, ErrCheckedIn
+		}
 		return copyFiles(ctx, h.FileSystem, src, dst, r.Header.Get("Overwrite") != "F", depth, 0)
 	}
 
@@ -390,6 +547,9 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 			return http.StatusBadRequest, errInvalidDepth
 		}
 	}
+	if h.checkedIn(ctx, src, infiniteDepth) || h.checkedIn(ctx, dst, infiniteDepth) {
+		return http.StatusConflict, ErrCheckedIn
+	}
 	return moveFiles(ctx, h.FileSystem, src, dst, r.Header.Get("Overwrite") == "T")
 }
 
@@ -541,6 +701,23 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		if err != nil {
 			return handlePropfindError(err, info)
 		}
+		href := path.Join(h.Prefix, reqPath)
+		if href != "/" && info.IsDir() {
+			href += "/"
+		}
+
+		// Members that the principal may not read are listed without
+		// their properties, and their own members are skipped.
+		if ok, err := canRead(ctx, reqPath); err != nil {
+			return err
+		} else if !ok {
+			resp := makePropstatResponse(href, nil)
+			resp.Status = fmt.Sprintf("HTTP/1.1 %d %s", http.StatusForbidden, StatusText(http.StatusForbidden))
+			if err := mw.write(resp); err != nil || !info.IsDir() {
+				return err
+			}
+			return filepath.SkipDir
+		}
 
 		var pstats []Propstat
 		if pf.Propname != nil {
@@ -561,10 +738,6 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		if err != nil {
 			return handlePropfindError(err, info)
 		}
-		href := path.Join(h.Prefix, reqPath)
-		if href != "/" && info.IsDir() {
-			href += "/"
-		}
 		return mw.write(makePropstatResponse(href, pstats))
 	}
 
@@ -598,6 +771,9 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 		}
 		return http.StatusMethodNotAllowed, err
 	}
+	if h.checkedIn(ctx, reqPath, 0) {
+		return http.StatusConflict, ErrCheckedIn
+	}
 	patches, status, err := readProppatch(r.Body)
 	if err != nil {
 		return status, err
@@ -618,6 +794,398 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	return 0, nil
 }
 
+// maxReportBody is the maximum size of a REPORT request body. The body is
+// read into memory, since it is decoded once to find the report's name and
+// again by the report itself.
+const maxReportBody = 1 << 20
+
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	ctx := r.Context()
+	if _, err := h.FileSystem.Stat(ctx, reqPath); err != nil {
+		if os.IsNotExist(err) {
+			return http.StatusNotFound, err
+		}
+		return http.StatusMethodNotAllowed, err
+	}
+	depth := 0
+	if hdr := r.Header.Get("Depth"); hdr != "" {
+		depth = parseDepth(hdr)
+		if depth == invalidDepth {
+			return http.StatusBadRequest, errInvalidDepth
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReportBody+1))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if len(body) > maxReportBody {
+		return http.StatusRequestEntityTooLarge, errReportTooLarge
+	}
+	name, decode, status, err := readReport(bytes.NewReader(body))
+	if err != nil {
+		return status, err
+	}
+	if fn := h.Reports[name]; fn != nil {
+		responses, status, err := fn(ctx, reqPath, depth, bytes.NewReader(body))
+		if err != nil {
+			return status, err
+		}
+		return h.writeResponses(ctx, w, responses)
+	}
+	switch name {
+	case xml.Name{Space: "DAV:", Local: "version-tree"}:
+		if vfs, ok := h.FileSystem.(VersionedFileSystem); ok {
+			return h.reportVersionTree(w, r, vfs, reqPath, depth, decode)
+		}
+	case xml.Name{Space: "DAV:", Local: "sync-collection"}:
+		if cfs, ok := h.FileSystem.(ChangeLogFileSystem); ok {
+			return h.reportSyncCollection(w, r, cfs, reqPath, depth, decode)
+		}
+	}
+	// http://www.webdav.org/specs/rfc3253.html#rfc.section.3.6 requires a
+	// DAV:supported-report precondition failure.
+	return http.StatusForbidden, errUnsupportedReport
+}
+
+// reportVersionTree writes the requested properties of every version of the
+// version-controlled resource reqPath.
+//
+// See http://www.webdav.org/specs/rfc3253.html#REPORT_version-tree
+func (h *Handler) reportVersionTree(w http.ResponseWriter, r *http.Request, vfs VersionedFileSystem, reqPath string, depth int, decode func(interface{}) error) (status int, err error) {
+	// Only the version history of reqPath itself is reported.
+	if depth != 0 {
+		return http.StatusBadRequest, errInvalidDepth
+	}
+	var vt versionTree
+	if err := decode(&vt); err != nil {
+		return http.StatusBadRequest, err
+	}
+	versions, _, err := vfs.Versions(r.Context(), reqPath)
+	if err != nil {
+		return versionErrorStatus(err), err
+	}
+	successors := make(map[string][]string)
+	for _, v := range versions {
+		if v.Predecessor != "" {
+			successors[v.Predecessor] = append(successors[v.Predecessor], v.Name)
+		}
+	}
+	hrefSet := func(names []string) string {
+		var b strings.Builder
+		for _, n := range names {
+			b.WriteString(`<D:href xmlns:D="DAV:">`)
+			b.WriteString(escapeXML(h.versionHref(reqPath, n)))
+			b.WriteString(`</D:href>`)
+		}
+		return b.String()
+	}
+
+	mw := multistatusWriter{w: w}
+	if err := mw.writeHeader(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, v := range versions {
+		pstatOK := Propstat{Status: http.StatusOK}
+		pstatNotFound := Propstat{Status: http.StatusNotFound}
+		for _, pn := range vt.Prop {
+			var innerXML string
+			switch pn {
+			case xml.Name{Space: "DAV:", Local: "version-name"}:
+				innerXML = escapeXML(v.Name)
+			case xml.Name{Space: "DAV:", Local: "predecessor-set"}:
+				var preds []string
+				if v.Predecessor != "" {
+					preds = []string{v.Predecessor}
+				}
+				innerXML = hrefSet(preds)
+			case xml.Name{Space: "DAV:", Local: "successor-set"}:
+				innerXML = hrefSet(successors[v.Name])
+			case xml.Name{Space: "DAV:", Local: "resourcetype"}:
+				innerXML = ""
+			case xml.Name{Space: "DAV:", Local: "getcontentlength"}:
+				innerXML = strconv.FormatInt(v.Size, 10)
+			case xml.Name{Space: "DAV:", Local: "getlastmodified"}:
+				innerXML = v.ModTime.UTC().Format(http.TimeFormat)
+			default:
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{XMLName: pn})
+				continue
+			}
+			pstatOK.Props = append(pstatOK.Props, Property{
+				XMLName:  pn,
+				InnerXML: []byte(innerXML),
+			})
+		}
+		resp := makePropstatResponse(path.Join(h.Prefix, reqPath), makePropstats(pstatOK, pstatNotFound))
+		resp.Href[0] = h.versionHref(reqPath, v.Name)
+		if err := mw.write(resp); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if err := mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// reportSyncCollection writes the members of the collection reqPath that
+// changed since the sync token of the request, followed by a new sync token.
+// An empty sync token reports all members.
+//
+// See https://www.rfc-editor.org/rfc/rfc6578#section-3.2
+func (h *Handler) reportSyncCollection(w http.ResponseWriter, r *http.Request, cfs ChangeLogFileSystem, reqPath string, depth int, decode func(interface{}) error) (status int, err error) {
+	if depth != 0 {
+		return http.StatusBadRequest, errInvalidDepth
+	}
+	var sc syncCollection
+	if err := decode(&sc); err != nil {
+		return http.StatusBadRequest, err
+	}
+	infinite := false
+	switch sc.SyncLevel {
+	case "1":
+	case "infinite":
+		infinite = true
+	default:
+		return http.StatusBadRequest, errInvalidReport
+	}
+	ctx := r.Context()
+	fi, err := cfs.Stat(ctx, reqPath)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !fi.IsDir() {
+		return http.StatusForbidden, errUnsupportedReport
+	}
+
+	var changes []Change
+	var token string
+	if sc.SyncToken == "" {
+		// Take the token first, so that changes made during the walk are
+		// reported again by the next synchronization.
+		if token, err = cfs.SyncToken(ctx, reqPath); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		walkDepth := 1
+		if infinite {
+			walkDepth = infiniteDepth
+		}
+		err = walkFS(ctx, cfs, walkDepth, reqPath, fi, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if name != reqPath {
+				changes = append(changes, Change{Name: name})
+			}
+			return nil
+		})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		sortChanges(changes)
+	} else {
+		changes, token, err = cfs.Changes(ctx, reqPath, sc.SyncToken, infinite)
+		if err == ErrInvalidSyncToken {
+			writeError(w, http.StatusForbidden, `<D:valid-sync-token/>`)
+			return 0, err
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if sc.Limit != nil && len(changes) > sc.Limit.NResults {
+		// Changes cannot be split across several responses, as there is
+		// no sync token for a partial result.
+		writeError(w, http.StatusInsufficientStorage, `<D:number-of-matches-within-limits/>`)
+		return 0, errTooManyMatches
+	}
+
+	mw := multistatusWriter{w: w, syncToken: token}
+	if err := mw.writeHeader(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, c := range changes {
+		href := path.Join(h.Prefix, c.Name)
+		var resp *response
+		if !c.Deleted {
+			info, err := cfs.Stat(ctx, c.Name)
+			if err == nil {
+				if info.IsDir() {
+					href += "/"
+				}
+				readable, err := canRead(ctx, c.Name)
+				if err != nil {
+					return http.StatusInternalServerError, err
+				}
+				if readable {
+					pstats, err := props(ctx, cfs, h.LockSystem, c.Name, sc.Prop)
+					if err != nil {
+						return http.StatusInternalServerError, err
+					}
+					resp = makePropstatResponse(href, pstats)
+				} else {
+					// Members that the principal may not read are
+					// reported without their properties.
+					resp = makePropstatResponse(href, nil)
+					resp.Status = fmt.Sprintf("HTTP/1.1 %d %s", http.StatusForbidden, StatusText(http.StatusForbidden))
+				}
+			} else if !os.IsNotExist(err) {
+				return http.StatusInternalServerError, err
+			}
+		}
+		if resp == nil {
+			// Removed members are reported with a 404 status.
+			resp = makePropstatResponse(href, nil)
+			resp.Status = fmt.Sprintf("HTTP/1.1 %d %s", http.StatusNotFound, StatusText(http.StatusNotFound))
+		}
+		if err := mw.write(resp); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if err := mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// writeResponses writes responses as a multistatus response. Resources that
+// the principal may not read are reported with a 403 status instead of their
+// properties.
+func (h *Handler) writeResponses(ctx context.Context, w http.ResponseWriter, responses []Response) (status int, err error) {
+	mw := multistatusWriter{w: w}
+	if err := mw.writeHeader(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, r := range responses {
+		href := path.Join(h.Prefix, r.Name)
+		if href != "/" && strings.HasSuffix(r.Name, "/") {
+			href += "/"
+		}
+		pstats, respStatus := r.Propstats, r.Status
+		if ok, err := canRead(ctx, slashClean(r.Name)); err != nil {
+			return http.StatusInternalServerError, err
+		} else if !ok {
+			pstats, respStatus = nil, http.StatusForbidden
+		}
+		resp := makePropstatResponse(href, pstats)
+		if len(pstats) == 0 {
+			resp.Status = fmt.Sprintf("HTTP/1.1 %d %s", respStatus, StatusText(respStatus))
+		}
+		if err := mw.write(resp); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if err := mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// versionHref returns the URL of the named version of reqPath.
+func (h *Handler) versionHref(reqPath, version string) string {
+	return (&url.URL{Path: path.Join(h.Prefix, reqPath)}).EscapedPath() + "?version=" + url.QueryEscape(version)
+}
+
+// versionErrorStatus returns the HTTP status for an error returned by a
+// VersionedFileSystem.
+func versionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotVersionControlled), errors.Is(err, ErrCheckedIn), errors.Is(err, ErrCheckedOut):
+		return http.StatusConflict
+	case os.IsNotExist(err):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// versionedFileSystem returns h.FileSystem as a VersionedFileSystem, if it
+// is one, after confirming the locks of the request for the file reqPath.
+func (h *Handler) versionedFileSystem(r *http.Request, reqPath string) (vfs VersionedFileSystem, release func(), status int, err error) {
+	vfs, ok := h.FileSystem.(VersionedFileSystem)
+	if !ok {
+		return nil, nil, http.StatusMethodNotAllowed, errUnsupportedMethod
+	}
+	fi, err := vfs.Stat(r.Context(), reqPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, http.StatusNotFound, err
+		}
+		return nil, nil, http.StatusMethodNotAllowed, err
+	}
+	if fi.IsDir() {
+		// Version-controlled collections are not supported.
+		return nil, nil, http.StatusMethodNotAllowed, errUnsupportedMethod
+	}
+	release, status, err = h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return nil, nil, status, err
+	}
+	return vfs, release, 0, nil
+}
+
+func (h *Handler) handleVersionControl(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	vfs, release, status, err := h.versionedFileSystem(r, reqPath)
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
+	if err := vfs.VersionControl(r.Context(), reqPath); err != nil {
+		return versionErrorStatus(err), err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Handler) handleCheckout(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	vfs, release, status, err := h.versionedFileSystem(r, reqPath)
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
+	if err := vfs.Checkout(r.Context(), reqPath); err != nil {
+		return versionErrorStatus(err), err
+	}
+	// http://www.webdav.org/specs/rfc3253.html#METHOD_CHECKOUT
+	w.Header().Set("Cache-Control", "no-cache")
+	return http.StatusOK, nil
+}
+
+func (h *Handler) handleCheckin(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	vfs, release, status, err := h.versionedFileSystem(r, reqPath)
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
+	v, err := vfs.Checkin(r.Context(), reqPath)
+	if err != nil {
+		return versionErrorStatus(err), err
+	}
+	// http://www.webdav.org/specs/rfc3253.html#METHOD_CHECKIN
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Location", h.versionHref(reqPath, v.Name))
+	return http.StatusCreated, nil
+}
+
 func makePropstatResponse(href string, pstats []Propstat) *response {
 	resp := response{
 		Href:     []string{(&url.URL{Path: href}).EscapedPath()},
@@ -722,18 +1290,27 @@ var (
 	errDirectoryNotEmpty       = errors.New("webdav: directory not empty")
 	errInvalidDepth            = errors.New("webdav: invalid depth")
 	errInvalidDestination      = errors.New("webdav: invalid destination")
+	errInvalidEndpoint         = errors.New("webdav: invalid endpoint")
 	errInvalidIfHeader         = errors.New("webdav: invalid If header")
 	errInvalidLockInfo         = errors.New("webdav: invalid lock info")
 	errInvalidLockToken        = errors.New("webdav: invalid lock token")
 	errInvalidPropfind         = errors.New("webdav: invalid propfind")
 	errInvalidProppatch        = errors.New("webdav: invalid proppatch")
+	errInvalidReport           = errors.New("webdav: invalid report")
 	errInvalidResponse         = errors.New("webdav: invalid response")
 	errInvalidTimeout          = errors.New("webdav: invalid timeout")
+	errIsDirectory             = errors.New("webdav: is a directory")
 	errNoFileSystem            = errors.New("webdav: no file system")
+	errNeedPrivileges          = errors.New("webdav: need privileges")
 	errNoLockSystem            = errors.New("webdav: no lock system")
 	errNotADirectory           = errors.New("webdav: not a directory")
 	errPrefixMismatch          = errors.New("webdav: prefix mismatch")
 	errRecursionTooDeep        = errors.New("webdav: recursion too deep")
+	errReportTooLarge          = errors.New("webdav: report too large")
+	errTooManyMatches          = errors.New("webdav: too many matches")
+	errUndefinedProperty       = errors.New("webdav: undefined property")
 	errUnsupportedLockInfo     = errors.New("webdav: unsupported lock info")
 	errUnsupportedMethod       = errors.New("webdav: unsupported method")
+	errUnsupportedReport       = errors.New("webdav: unsupported report")
+	errUnsupportedWrite        = errors.New("webdav: unsupported write")
 )
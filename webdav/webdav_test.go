@@ -18,6 +18,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TODO: add tests to check XML responses with the expected prefix path
@@ -406,3 +407,115 @@ func TestPutRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestPutQuota(t *testing.T) {
+	h := &Handler{
+		FileSystem: NewMemFSWithQuota(8),
+		LockSystem: NewMemLS(),
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	put := func(urlStr string, body io.Reader) int {
+		t.Helper()
+		req, err := http.NewRequest("PUT", urlStr, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	testCases := []struct {
+		name string
+		path string
+		body io.Reader
+		want int
+	}{{
+		name: "fits",
+		path: "/a",
+		body: strings.NewReader("12345"),
+		want: http.StatusCreated,
+	}, {
+		name: "content_length_over_quota",
+		path: "/b",
+		body: strings.NewReader("12345"),
+		want: StatusInsufficientStorage,
+	}, {
+		name: "overwrite_releases_quota",
+		path: "/a",
+		body: strings.NewReader("12345678"),
+		want: http.StatusCreated,
+	}, {
+		name: "unknown_length_over_quota",
+		path: "/c",
+		// A MultiReader hides the length, so the request is chunked.
+		body: io.MultiReader(strings.NewReader("x")),
+		want: StatusInsufficientStorage,
+	}}
+	for _, tc := range testCases {
+		if got := put(srv.URL+tc.path, tc.body); got != tc.want {
+			t.Errorf("name=%q: got status code %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemFS()
+	ls := NewMemLS()
+	h := &Handler{
+		FileSystem: fs,
+		LockSystem: ls,
+		Methods: map[string]MethodFunc{
+			"MKTHING": func(w http.ResponseWriter, r *http.Request, name string) (int, error) {
+				if err := fs.Mkdir(r.Context(), name, 0777); err != nil {
+					return http.StatusConflict, err
+				}
+				return http.StatusCreated, nil
+			},
+		},
+		Authorizer: &testAuthorizer{rights: map[string]map[string][]Privilege{
+			"alice": {"/": {PrivilegeAll}},
+			"bob":   {"/": {PrivilegeRead}},
+		}},
+	}
+	if err := fs.Mkdir(ctx, "/locked", 0777); err != nil {
+		t.Fatal(err)
+	}
+	token, err := ls.Create(time.Now(), LockDetails{Root: "/locked", Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		user, name, ifHdr string
+		want              int
+	}{
+		{"alice", "/a", "", http.StatusCreated},
+		{"bob", "/b", "", http.StatusForbidden},
+		{"alice", "/locked/a", "", StatusLocked},
+		{"alice", "/locked/a", "(" + token + ")", http.StatusCreated},
+	} {
+		r := httptest.NewRequest("MKTHING", tc.name, nil)
+		r.SetBasicAuth(tc.user, "")
+		if tc.ifHdr != "" {
+			r.Header.Set("If", tc.ifHdr)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("MKTHING %s by %s: got status %d, want %d", tc.name, tc.user, w.Code, tc.want)
+		}
+	}
+	r := httptest.NewRequest("MKOTHER", "/c", nil)
+	r.SetBasicAuth("alice", "")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unregistered method: got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	// As of https://go-review.googlesource.com/#/c/12772/ which was submitted
@@ -86,14 +88,17 @@ func writeLockInfo(w io.Writer, token string, ld LockDetails) (int, error) {
 	if ld.ZeroDepth {
 		depth = "0"
 	}
-	timeout := ld.Duration / time.Second
+	timeout := "Infinite"
+	if ld.Duration >= 0 {
+		timeout = fmt.Sprintf("Second-%d", ld.Duration/time.Second)
+	}
 	return fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"+
 		"<D:prop xmlns:D=\"DAV:\"><D:lockdiscovery><D:activelock>\n"+
 		"	<D:locktype><D:write/></D:locktype>\n"+
 		"	<D:lockscope><D:exclusive/></D:lockscope>\n"+
 		"	<D:depth>%s</D:depth>\n"+
 		"	<D:owner>%s</D:owner>\n"+
-		"	<D:timeout>Second-%d</D:timeout>\n"+
+		"	<D:timeout>%s</D:timeout>\n"+
 		"	<D:locktoken><D:href>%s</D:href></D:locktoken>\n"+
 		"	<D:lockroot><D:href>%s</D:href></D:lockroot>\n"+
 		"</D:activelock></D:lockdiscovery></D:prop>",
@@ -204,6 +209,46 @@ func readPropfind(r io.Reader) (pf propfind, status int, err error) {
 	return pf, 0, nil
 }
 
+// readReport reads the start of the body of a REPORT request. It returns the
+// name of the root element, which names the report, and a function that
+// decodes the whole report into v.
+// http://www.webdav.org/specs/rfc3253.html#METHOD_REPORT
+func readReport(r io.Reader) (name xml.Name, decode func(v interface{}) error, status int, err error) {
+	d := ixml.NewDecoder(r)
+	for {
+		t, err := next(d)
+		if err != nil {
+			if err == io.EOF {
+				err = errInvalidReport
+			}
+			return xml.Name{}, nil, http.StatusBadRequest, err
+		}
+		if start, ok := t.(ixml.StartElement); ok {
+			decode = func(v interface{}) error {
+				return d.DecodeElement(v, &start)
+			}
+			return xml.Name(start.Name), decode, 0, nil
+		}
+	}
+}
+
+// http://www.webdav.org/specs/rfc3253.html#REPORT_version-tree
+type versionTree struct {
+	XMLName ixml.Name     `xml:"DAV: version-tree"`
+	Prop    propfindProps `xml:"DAV: prop"`
+}
+
+// https://www.rfc-editor.org/rfc/rfc6578#section-6.1
+type syncCollection struct {
+	XMLName   ixml.Name `xml:"DAV: sync-collection"`
+	SyncToken string    `xml:"DAV: sync-token"`
+	SyncLevel string    `xml:"DAV: sync-level"`
+	Limit     *struct {
+		NResults int `xml:"DAV: nresults"`
+	} `xml:"DAV: limit"`
+	Prop propfindProps `xml:"DAV: prop"`
+}
+
 // Property represents a single DAV resource property as defined in RFC 4918.
 // See http://www.webdav.org/specs/rfc4918.html#data.model.for.resource.properties
 type Property struct {
@@ -239,6 +284,18 @@ type xmlError struct {
 	InnerXML []byte    `xml:",innerxml"`
 }
 
+// writeError writes an HTTP response with the given status whose body is a
+// DAV:error element holding the precondition or postcondition element
+// innerXML, such as `<D:lock-token-submitted/>`.
+// See http://www.webdav.org/specs/rfc4918.html#precondition.postcondition.xml.elements
+func writeError(w http.ResponseWriter, status int, innerXML string) error {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<D:error xmlns:D="DAV:">%s</D:error>`, innerXML)
+	return err
+}
+
 // http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat
 // See multistatusWriter for the "D:" namespace prefix.
 type propstat struct {
@@ -312,6 +369,9 @@ type multistatusWriter struct {
 	// close will be emitted. Empty response descriptions are not
 	// written.
 	responseDescription string
+	// syncToken is the optional sync-token of the multistatus element,
+	// written by close if not empty. See RFC 6578 section 6.4.
+	syncToken string
 
 	w   http.ResponseWriter
 	enc *ixml.Encoder
@@ -388,6 +448,14 @@ func (w *multistatusWriter) close() error {
 			ixml.EndElement{Name: name},
 		)
 	}
+	if w.syncToken != "" {
+		name := ixml.Name{Space: "DAV:", Local: "sync-token"}
+		end = append(end,
+			ixml.StartElement{Name: name},
+			ixml.CharData(w.syncToken),
+			ixml.EndElement{Name: name},
+		)
+	}
 	end = append(end, ixml.EndElement{
 		Name: ixml.Name{Space: "DAV:", Local: "multistatus"},
 	})
@@ -452,6 +520,21 @@ type proppatchProps []Property
 // UnmarshalXML returns an error if start does not contain any properties or if
 // property values contain syntactically incorrect XML.
 func (ps *proppatchProps) UnmarshalXML(d *ixml.Decoder, start ixml.StartElement) error {
+	if err := (*multistatusProps)(ps).UnmarshalXML(d, start); err != nil {
+		return err
+	}
+	if len(*ps) == 0 {
+		return fmt.Errorf("%s must not be empty", start.Name.Local)
+	}
+	return nil
+}
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_prop (for multistatus)
+type multistatusProps []Property
+
+// UnmarshalXML is like proppatchProps.UnmarshalXML, except that start may
+// be empty.
+func (ps *multistatusProps) UnmarshalXML(d *ixml.Decoder, start ixml.StartElement) error {
 	lang := xmlLang(start, "")
 	for {
 		t, err := next(d)
@@ -460,9 +543,6 @@ func (ps *proppatchProps) UnmarshalXML(d *ixml.Decoder, start ixml.StartElement)
 		}
 		switch elem := t.(type) {
 		case ixml.EndElement:
-			if len(*ps) == 0 {
-				return fmt.Errorf("%s must not be empty", start.Name.Local)
-			}
 			return nil
 		case ixml.StartElement:
 			p := Property{
@@ -517,3 +597,112 @@ func readProppatch(r io.Reader) (patches []Proppatch, status int, err error) {
 	}
 	return patches, 0, nil
 }
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_multistatus (for clients)
+type multistatus struct {
+	XMLName   ixml.Name             `xml:"DAV: multistatus"`
+	Responses []multistatusResponse `xml:"DAV: response"`
+}
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_response (for clients)
+type multistatusResponse struct {
+	Href     []string              `xml:"DAV: href"`
+	Propstat []multistatusPropstat `xml:"DAV: propstat"`
+	Status   string                `xml:"DAV: status"`
+	Error    *xmlValue             `xml:"DAV: error"`
+}
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat (for clients)
+type multistatusPropstat struct {
+	Prop                multistatusProps `xml:"DAV: prop"`
+	Status              string           `xml:"DAV: status"`
+	Error               *xmlValue        `xml:"DAV: error"`
+	ResponseDescription string           `xml:"DAV: responsedescription"`
+}
+
+func readMultistatus(r io.Reader) (ms multistatus, err error) {
+	err = ixml.NewDecoder(r).Decode(&ms)
+	return ms, err
+}
+
+// parseStatus returns the code of a DAV:status element, such as
+// "HTTP/1.1 404 Not Found", or zero if s is not a valid status.
+func parseStatus(s string) int {
+	f := strings.Fields(s)
+	if len(f) < 2 || !strings.HasPrefix(f[0], "HTTP/") {
+		return 0
+	}
+	code, err := strconv.Atoi(f[1])
+	if err != nil || code < 100 || code > 999 {
+		return 0
+	}
+	return code
+}
+
+// readXMLError returns the contents of the DAV:error element that is the
+// body of an HTTP response, if any.
+func readXMLError(r io.Reader) string {
+	d := ixml.NewDecoder(r)
+	t, err := next(d)
+	if err != nil {
+		return ""
+	}
+	start, ok := t.(ixml.StartElement)
+	if !ok || start.Name != (ixml.Name{Space: "DAV:", Local: "error"}) {
+		return ""
+	}
+	var v xmlValue
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return ""
+	}
+	return string(v)
+}
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_activelock (for clients)
+type activeLock struct {
+	Depth     string `xml:"DAV: depth"`
+	Timeout   string `xml:"DAV: timeout"`
+	LockToken string `xml:"DAV: locktoken>href"`
+	LockRoot  string `xml:"DAV: lockroot>href"`
+}
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_lockdiscovery (for clients)
+type lockDiscovery struct {
+	XMLName     ixml.Name    `xml:"DAV: prop"`
+	ActiveLocks []activeLock `xml:"DAV: lockdiscovery>activelock"`
+}
+
+func readLockDiscovery(r io.Reader) (ld lockDiscovery, err error) {
+	err = ixml.NewDecoder(r).Decode(&ld)
+	return ld, err
+}
+
+// xmlText returns the character data of the XML value v, which is a
+// property value or the contents of a DAV:href element.
+func xmlText(v []byte) string {
+	var b strings.Builder
+	d := ixml.NewDecoder(bytes.NewReader(v))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return b.String()
+		}
+		if c, ok := t.(ixml.CharData); ok {
+			b.Write(c)
+		}
+	}
+}
+
+// hasElement reports whether the XML value v contains an element named name.
+func hasElement(v []byte, name xml.Name) bool {
+	d := ixml.NewDecoder(bytes.NewReader(v))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return false
+		}
+		if s, ok := t.(ixml.StartElement); ok && s.Name == ixml.Name(name) {
+			return true
+		}
+	}
+}
@@ -44,12 +44,12 @@ func NewConfig(server, origin string) (config *Config, err error) {
 func NewClient(config *Config, rwc io.ReadWriteCloser) (ws *Conn, err error) {
 	br := bufio.NewReader(rwc)
 	bw := bufio.NewWriter(rwc)
-	err = hybiClientHandshake(config, br, bw)
+	deflate, err := hybiClientHandshake(config, br, bw)
 	if err != nil {
 		return
 	}
 	buf := bufio.NewReadWriter(br, bw)
-	ws = newHybiClientConn(config, buf, rwc)
+	ws = newHybiClientConn(config, deflate, buf, rwc)
 	return
 }
 
@@ -92,6 +92,9 @@ func (config *Config) DialContext(ctx context.Context) (*Conn, error) {
 	if config.Origin == nil {
 		return nil, &DialError{config, ErrBadWebSocketOrigin}
 	}
+	if config.HTTP2Conn != nil {
+		return config.dialExtendedConnect(ctx)
+	}
 
 	dialer := config.Dialer
 	if dialer == nil {
@@ -0,0 +1,455 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+// This file implements the permessage-deflate extension.
+// https://www.rfc-editor.org/rfc/rfc7692
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressionOptions configures the permessage-deflate extension
+// (RFC 7692), which compresses the payload of text and binary messages.
+//
+// The package's compressor always uses a 32KB (15 bit) window. If the peer
+// asks for a smaller window, messages are compressed without references to
+// earlier data (Huffman coding only).
+type CompressionOptions struct {
+	// Level is the compression level, as defined by compress/flate.
+	// If zero, flate.DefaultCompression is used.
+	Level int
+
+	// ServerNoContextTakeover requests that the server reset its
+	// compression context after every message.
+	ServerNoContextTakeover bool
+
+	// ClientNoContextTakeover requests that the client reset its
+	// compression context after every message.
+	ClientNoContextTakeover bool
+
+	// ServerMaxWindowBits limits the LZ77 window used by the server's
+	// compressor, from 8 to 15 bits. Zero means no limit.
+	ServerMaxWindowBits int
+
+	// ClientMaxWindowBits limits the LZ77 window used by the client's
+	// compressor, from 8 to 15 bits. Zero means no limit.
+	// A server can only impose this limit if the client offers it.
+	ClientMaxWindowBits int
+}
+
+const (
+	deflateExtension = "permessage-deflate"
+	maxWindowBits    = 15
+	minWindowBits    = 8
+	maxWindowSize    = 1 << maxWindowBits
+)
+
+// deflateTail is appended to a compressed message before inflating it:
+// the empty stored block stripped by the sender (RFC 7692, Section 7.2.2),
+// followed by a final empty stored block so the reader ends cleanly.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+// deflateParams are the negotiated parameters of the permessage-deflate
+// extension. A window size of zero means the parameter was absent.
+type deflateParams struct {
+	serverNoContextTakeover bool
+	clientNoContextTakeover bool
+	serverMaxWindowBits     int
+	clientMaxWindowBits     int
+	// clientMaxWindowBitsOffered is set when an offer includes the
+	// client_max_window_bits parameter, with or without a value.
+	clientMaxWindowBitsOffered bool
+}
+
+func (p *deflateParams) String() string {
+	var b strings.Builder
+	b.WriteString(deflateExtension)
+	if p.serverNoContextTakeover {
+		b.WriteString("; server_no_context_takeover")
+	}
+	if p.clientNoContextTakeover {
+		b.WriteString("; client_no_context_takeover")
+	}
+	if p.serverMaxWindowBits != 0 {
+		b.WriteString("; server_max_window_bits=" + strconv.Itoa(p.serverMaxWindowBits))
+	}
+	if p.clientMaxWindowBits != 0 {
+		b.WriteString("; client_max_window_bits=" + strconv.Itoa(p.clientMaxWindowBits))
+	} else if p.clientMaxWindowBitsOffered {
+		b.WriteString("; client_max_window_bits")
+	}
+	return b.String()
+}
+
+// An extension is one element of a Sec-WebSocket-Extensions header.
+type extension struct {
+	name   string
+	params []extensionParam
+}
+
+type extensionParam struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// parseExtensions parses the Sec-WebSocket-Extensions header fields in h.
+// See RFC 6455, Section 9.1.
+func parseExtensions(h http.Header) ([]extension, error) {
+	var exts []extension
+	for _, v := range h.Values("Sec-Websocket-Extensions") {
+		for _, elem := range splitQuoted(v, ',') {
+			if strings.TrimSpace(elem) == "" {
+				continue
+			}
+			parts := splitQuoted(elem, ';')
+			ext := extension{name: strings.TrimSpace(parts[0])}
+			if !isToken(ext.name) {
+				return nil, ErrUnsupportedExtensions
+			}
+			for _, part := range parts[1:] {
+				name, value, hasValue := strings.Cut(part, "=")
+				p := extensionParam{name: strings.TrimSpace(name), hasValue: hasValue}
+				if !isToken(p.name) {
+					return nil, ErrUnsupportedExtensions
+				}
+				if hasValue {
+					value = strings.TrimSpace(value)
+					if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+						value = value[1 : len(value)-1]
+					}
+					if !isToken(value) {
+						return nil, ErrUnsupportedExtensions
+					}
+					p.value = value
+				}
+				ext.params = append(ext.params, p)
+			}
+			exts = append(exts, ext)
+		}
+	}
+	return exts, nil
+}
+
+// splitQuoted splits s at each sep which is not inside a quoted-string.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped := false, false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`()<>@,;:\"/[]?={}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDeflateParams validates the parameters of a permessage-deflate
+// extension offer or response.
+func parseDeflateParams(ext extension) (*deflateParams, error) {
+	p := &deflateParams{}
+	seen := make(map[string]bool)
+	for _, param := range ext.params {
+		if seen[param.name] {
+			return nil, ErrUnsupportedExtensions
+		}
+		seen[param.name] = true
+		switch param.name {
+		case "server_no_context_takeover":
+			if param.hasValue {
+				return nil, ErrUnsupportedExtensions
+			}
+			p.serverNoContextTakeover = true
+		case "client_no_context_takeover":
+			if param.hasValue {
+				return nil, ErrUnsupportedExtensions
+			}
+			p.clientNoContextTakeover = true
+		case "server_max_window_bits":
+			bits, ok := parseWindowBits(param.value)
+			if !param.hasValue || !ok {
+				return nil, ErrUnsupportedExtensions
+			}
+			p.serverMaxWindowBits = bits
+		case "client_max_window_bits":
+			p.clientMaxWindowBitsOffered = true
+			if param.hasValue {
+				bits, ok := parseWindowBits(param.value)
+				if !ok {
+					return nil, ErrUnsupportedExtensions
+				}
+				p.clientMaxWindowBits = bits
+			}
+		default:
+			return nil, ErrUnsupportedExtensions
+		}
+	}
+	return p, nil
+}
+
+func parseWindowBits(s string) (int, bool) {
+	if len(s) == 0 || len(s) > 2 || s[0] == '0' {
+		return 0, false
+	}
+	bits, err := strconv.Atoi(s)
+	if err != nil || bits < minWindowBits || bits > maxWindowBits {
+		return 0, false
+	}
+	return bits, true
+}
+
+// deflateOffer returns the extension offer a client sends for opts.
+func deflateOffer(opts *CompressionOptions) *deflateParams {
+	return &deflateParams{
+		serverNoContextTakeover:    opts.ServerNoContextTakeover,
+		clientNoContextTakeover:    opts.ClientNoContextTakeover,
+		serverMaxWindowBits:        opts.ServerMaxWindowBits,
+		clientMaxWindowBits:        opts.ClientMaxWindowBits,
+		clientMaxWindowBitsOffered: true,
+	}
+}
+
+// acceptDeflateResponse validates a server's response to a client's offer.
+func acceptDeflateResponse(opts *CompressionOptions, exts []extension) (*deflateParams, error) {
+	if len(exts) == 0 {
+		return nil, nil
+	}
+	if len(exts) > 1 || exts[0].name != deflateExtension {
+		return nil, ErrUnsupportedExtensions
+	}
+	p, err := parseDeflateParams(exts[0])
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServerMaxWindowBits != 0 && (p.serverMaxWindowBits == 0 || p.serverMaxWindowBits > opts.ServerMaxWindowBits) {
+		// The server must honor a limit on its window.
+		return nil, ErrUnsupportedExtensions
+	}
+	if opts.ServerNoContextTakeover && !p.serverNoContextTakeover {
+		return nil, ErrUnsupportedExtensions
+	}
+	if p.clientMaxWindowBitsOffered && p.clientMaxWindowBits == 0 {
+		// A response must include a value.
+		return nil, ErrUnsupportedExtensions
+	}
+	if opts.ClientNoContextTakeover {
+		p.clientNoContextTakeover = true
+	}
+	if opts.ClientMaxWindowBits != 0 && (p.clientMaxWindowBits == 0 || p.clientMaxWindowBits > opts.ClientMaxWindowBits) {
+		p.clientMaxWindowBits = opts.ClientMaxWindowBits
+	}
+	return p, nil
+}
+
+// acceptDeflateOffer chooses the first acceptable permessage-deflate offer
+// in exts, and returns the parameters to respond with.
+// It returns nil if there is no acceptable offer.
+func acceptDeflateOffer(opts *CompressionOptions, exts []extension) *deflateParams {
+	for _, ext := range exts {
+		if ext.name != deflateExtension {
+			continue
+		}
+		offer, err := parseDeflateParams(ext)
+		if err != nil {
+			continue
+		}
+		p := &deflateParams{
+			serverNoContextTakeover: offer.serverNoContextTakeover || opts.ServerNoContextTakeover,
+			clientNoContextTakeover: offer.clientNoContextTakeover || opts.ClientNoContextTakeover,
+			serverMaxWindowBits:     minBits(offer.serverMaxWindowBits, opts.ServerMaxWindowBits),
+		}
+		if offer.clientMaxWindowBitsOffered {
+			p.clientMaxWindowBits = minBits(offer.clientMaxWindowBits, opts.ClientMaxWindowBits)
+		}
+		return p
+	}
+	return nil
+}
+
+// minBits returns the smaller of two window sizes, where zero means no limit.
+func minBits(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// A deflater compresses the messages written to a connection.
+type deflater struct {
+	level             int
+	noContextTakeover bool
+	fw                *flate.Writer
+	buf               bytes.Buffer
+}
+
+func newDeflater(opts *CompressionOptions, noContextTakeover bool, windowBits int) *deflater {
+	level := flate.DefaultCompression
+	if opts != nil && opts.Level != 0 {
+		level = opts.Level
+	}
+	if windowBits != 0 && windowBits < maxWindowBits {
+		// compress/flate can't limit its window, so avoid back references.
+		level = flate.HuffmanOnly
+	}
+	return &deflater{level: level, noContextTakeover: noContextTakeover}
+}
+
+// compress returns the compressed form of msg.
+// The result is valid until the next call to compress.
+func (d *deflater) compress(msg []byte) ([]byte, error) {
+	d.buf.Reset()
+	if d.fw == nil {
+		fw, err := flate.NewWriter(&d.buf, d.level)
+		if err != nil {
+			return nil, err
+		}
+		d.fw = fw
+	} else if d.noContextTakeover {
+		d.fw.Reset(&d.buf)
+	}
+	if _, err := d.fw.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := d.fw.Flush(); err != nil {
+		return nil, err
+	}
+	// Remove the empty stored block emitted by Flush (RFC 7692, Section 7.2.1).
+	return bytes.TrimSuffix(d.buf.Bytes(), deflateTail[:4]), nil
+}
+
+// deflateFrameWriterFactory creates frame writers which compress the
+// payload of data frames and set the RSV1 bit.
+type deflateFrameWriterFactory struct {
+	hybiFrameWriterFactory
+	deflater *deflater
+}
+
+func (buf deflateFrameWriterFactory) NewFrameWriter(payloadType byte) (frameWriter, error) {
+	w, err := buf.hybiFrameWriterFactory.NewFrameWriter(payloadType)
+	if err != nil || (payloadType != TextFrame && payloadType != BinaryFrame) {
+		return w, err
+	}
+	hw := w.(*hybiFrameWriter)
+	hw.header.Rsv[0] = true
+	return &deflateFrameWriter{hybiFrameWriter: hw, deflater: buf.deflater}, nil
+}
+
+type deflateFrameWriter struct {
+	*hybiFrameWriter
+	deflater *deflater
+}
+
+func (frame *deflateFrameWriter) Write(msg []byte) (n int, err error) {
+	p, err := frame.deflater.compress(msg)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := frame.hybiFrameWriter.Write(p); err != nil {
+		return 0, err
+	}
+	return len(msg), nil
+}
+
+// An inflater decompresses the messages read from a connection.
+type inflater struct {
+	noContextTakeover bool
+	fr                io.ReadCloser
+	dict              []byte // recent output, when the peer keeps its context
+}
+
+// newMessage returns a frameReader which inflates the compressed message
+// beginning with frame.
+func (f *inflater) newMessage(ws *Conn, frame *hybiFrameReader) *inflateFrameReader {
+	msg := &hybiMessageReader{conn: ws, frame: frame}
+	src := io.MultiReader(msg, bytes.NewReader(deflateTail))
+	if f.fr == nil {
+		f.fr = flate.NewReader(src)
+	} else {
+		var dict []byte
+		if !f.noContextTakeover {
+			dict = f.dict
+		}
+		f.fr.(flate.Resetter).Reset(src, dict)
+	}
+	return &inflateFrameReader{
+		inflater:    f,
+		msg:         msg,
+		payloadType: frame.PayloadType(),
+		length:      frame.Len(),
+	}
+}
+
+// record saves the most recent output of the inflater,
+// which the peer may refer to in its next message.
+func (f *inflater) record(p []byte) {
+	if f.noContextTakeover || len(p) == 0 {
+		return
+	}
+	if len(p) >= maxWindowSize {
+		f.dict = append(f.dict[:0], p[len(p)-maxWindowSize:]...)
+		return
+	}
+	if n := len(f.dict) + len(p) - maxWindowSize; n > 0 {
+		f.dict = f.dict[:copy(f.dict, f.dict[n:])]
+	}
+	f.dict = append(f.dict, p...)
+}
+
+// An inflateFrameReader presents a compressed message as a single frame
+// with the decompressed payload.
+type inflateFrameReader struct {
+	inflater    *inflater
+	msg         *hybiMessageReader
+	payloadType byte
+	length      int
+}
+
+func (frame *inflateFrameReader) Read(p []byte) (n int, err error) {
+	n, err = frame.inflater.fr.Read(p)
+	frame.inflater.record(p[:n])
+	if err == io.EOF {
+		// Discard anything following a final deflate block.
+		if _, err := io.Copy(io.Discard, frame.msg); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (frame *inflateFrameReader) PayloadType() byte { return frame.payloadType }
+
+func (frame *inflateFrameReader) HeaderReader() io.Reader { return nil }
+
+func (frame *inflateFrameReader) TrailerReader() io.Reader { return nil }
+
+// Len returns the length of the first frame of the message.
+func (frame *inflateFrameReader) Len() int { return frame.length }
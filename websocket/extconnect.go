@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+// This file implements WebSockets over HTTP/2 streams,
+// using the extended CONNECT method.
+// https://www.rfc-editor.org/rfc/rfc8441
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// isExtendedConnect reports whether req opens a WebSocket
+// with an HTTP/2 extended CONNECT request.
+func isExtendedConnect(req *http.Request) bool {
+	return req.ProtoMajor == 2 && req.Method == "CONNECT" &&
+		strings.EqualFold(req.Header.Get(":protocol"), "websocket")
+}
+
+// serveExtendedConnect performs the server handshake for a WebSocket
+// opened with an HTTP/2 extended CONNECT request, and runs the handler.
+func (s Server) serveExtendedConnect(w http.ResponseWriter, req *http.Request) {
+	hs := &hybiServerHandshaker{Config: &s.Config}
+	code, err := hs.readHandshakeFields(req)
+	if err == ErrBadWebSocketVersion {
+		w.Header().Set("Sec-WebSocket-Version", SupportedProtocolVersion)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if s.Handshake != nil {
+		if err := s.Handshake(hs.Config, req); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+	if len(hs.Protocol) > 1 {
+		// You need choose a Protocol in Handshake func in Server.
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h := w.Header()
+	for k, vv := range hs.Header {
+		if !handshakeHeader[k] {
+			h[k] = vv
+		}
+	}
+	if len(hs.Protocol) > 0 {
+		h.Set("Sec-WebSocket-Protocol", hs.Protocol[0])
+	}
+	if hs.deflate != nil {
+		h.Set("Sec-WebSocket-Extensions", hs.deflate.String())
+	}
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	rwc := &streamConn{r: req.Body, w: &flushWriter{w: w, rc: rc}}
+	conn := newHybiServerConn(hs.Config, hs.deflate, nil, rwc, req)
+	s.Handler(conn)
+}
+
+// flushWriter flushes each write to an http.ResponseWriter.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.rc.Flush()
+}
+
+// streamConn is the io.ReadWriteCloser underlying a WebSocket
+// on an HTTP/2 stream.
+type streamConn struct {
+	r io.ReadCloser
+	w io.Writer
+
+	closeOnce sync.Once
+	closeW    io.Closer          // if non-nil, closes the writing side
+	cancel    context.CancelFunc // if non-nil, cancels the request
+}
+
+func (c *streamConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *streamConn) Write(p []byte) (int, error) { return c.w.Write(p) }
+
+func (c *streamConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.closeW != nil {
+			err = c.closeW.Close()
+		}
+		if err1 := c.r.Close(); err == nil {
+			err = err1
+		}
+		if c.cancel != nil {
+			c.cancel()
+		}
+	})
+	return err
+}
+
+// dialExtendedConnect opens a WebSocket as a stream on config.HTTP2Conn,
+// with an extended CONNECT request.
+func (config *Config) dialExtendedConnect(ctx context.Context) (*Conn, error) {
+	if config.Version != ProtocolVersionHybi13 {
+		return nil, &DialError{config, ErrBadProtocolVersion}
+	}
+	u := *config.Location
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, &DialError{config, ErrBadScheme}
+	}
+	h := make(http.Header)
+	for k, vv := range config.Header {
+		if !handshakeHeader[http.CanonicalHeaderKey(k)] {
+			h[k] = vv
+		}
+	}
+	h.Set(":protocol", "websocket")
+	h.Set("Sec-WebSocket-Version", SupportedProtocolVersion)
+	h.Set("Origin", strings.ToLower(config.Origin.String()))
+	if len(config.Protocol) > 0 {
+		h.Set("Sec-WebSocket-Protocol", strings.Join(config.Protocol, ", "))
+	}
+	if config.Compression != nil {
+		h.Set("Sec-WebSocket-Extensions", deflateOffer(config.Compression).String())
+	}
+
+	// The stream outlives ctx, which only bounds the handshake.
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	pr, pw := io.Pipe()
+	req := (&http.Request{
+		Method:     "CONNECT",
+		URL:        &u,
+		Host:       removeZone(u.Host),
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     h,
+		Body:       pr,
+	}).WithContext(reqCtx)
+	resp, err := config.HTTP2Conn.RoundTrip(req)
+	if !stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		err = ctx.Err()
+	}
+	if err == nil && resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		err = ErrBadStatus
+	}
+	var deflate *deflateParams
+	if err == nil {
+		deflate, err = readHandshakeResponseFields(config, resp.Header)
+		if err != nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		pw.Close()
+		cancel()
+		return nil, &DialError{config, err}
+	}
+	rwc := &streamConn{r: resp.Body, w: pw, closeW: pw, cancel: cancel}
+	return newHybiClientConn(config, deflate, nil, rwc), nil
+}
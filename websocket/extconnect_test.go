@@ -0,0 +1,216 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// streamRoundTripper passes requests to a handler, as an HTTP/2 server
+// would, with the request and response bodies connected by pipes.
+type streamRoundTripper struct {
+	handler http.Handler
+}
+
+func (rt streamRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "CONNECT" || req.Header.Get(":protocol") == "" {
+		return nil, errors.New("not an extended CONNECT request")
+	}
+	sreq := req.Clone(req.Context())
+	sreq.Proto, sreq.ProtoMajor, sreq.ProtoMinor = "HTTP/2.0", 2, 0
+	sreq.RequestURI = req.URL.RequestURI()
+	pr, pw := io.Pipe()
+	w := &streamResponseWriter{
+		header:  make(http.Header),
+		body:    pw,
+		headerc: make(chan int, 1),
+	}
+	go func() {
+		rt.handler.ServeHTTP(w, sreq)
+		w.WriteHeader(http.StatusOK)
+		pw.Close()
+		req.Body.Close()
+	}()
+	select {
+	case code := <-w.headerc:
+		return &http.Response{
+			StatusCode: code,
+			Proto:      "HTTP/2.0",
+			ProtoMajor: 2,
+			Header:     w.header,
+			Body:       pr,
+		}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+type streamResponseWriter struct {
+	header      http.Header
+	body        io.Writer
+	headerc     chan int
+	wroteHeader bool
+}
+
+func (w *streamResponseWriter) Header() http.Header { return w.header }
+
+func (w *streamResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.header = w.header.Clone()
+	w.headerc <- code
+}
+
+func (w *streamResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+func (w *streamResponseWriter) Flush() {}
+
+func newExtendedConnectConfig(t *testing.T, handler http.Handler) *Config {
+	config, err := NewConfig("wss://example.com/chat?x=1", "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.HTTP2Conn = streamRoundTripper{handler}
+	return config
+}
+
+func TestExtendedConnect(t *testing.T) {
+	reqc := make(chan *http.Request, 1)
+	s := Server{
+		Config: Config{Compression: &CompressionOptions{}},
+		Handshake: func(config *Config, req *http.Request) error {
+			reqc <- req
+			config.Protocol = []string{"chat"}
+			return nil
+		},
+		Handler: func(ws *Conn) {
+			if ws.Config().Location.String() != "ws://example.com/chat?x=1" {
+				t.Errorf("server location %v", ws.Config().Location)
+			}
+			messageEchoServer(ws)
+		},
+	}
+	config := newExtendedConnectConfig(t, s)
+	config.Protocol = []string{"chat", "superchat"}
+	config.Compression = &CompressionOptions{}
+	ws, err := config.DialContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	req := <-reqc
+	if req.Host != "example.com" {
+		t.Errorf("request Host = %q, want example.com", req.Host)
+	}
+	if got := req.Header.Get("Sec-WebSocket-Key"); got != "" {
+		t.Errorf("request sent Sec-WebSocket-Key %q", got)
+	}
+	if got, want := req.Header.Get("Origin"), "https://example.com"; got != want {
+		t.Errorf("request Origin = %q, want %q", got, want)
+	}
+	if got, want := config.Protocol, []string{"chat"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("negotiated protocol %q, want %q", got, want)
+	}
+	if ws.deflate == nil {
+		t.Errorf("permessage-deflate not negotiated")
+	}
+
+	for _, msg := range []string{"hello", "world"} {
+		if err := Message.Send(ws, msg); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := Message.Receive(ws, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != msg {
+			t.Errorf("got %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestExtendedConnectHandler(t *testing.T) {
+	// Handler checks the origin, like it does for HTTP/1.1.
+	config := newExtendedConnectConfig(t, Handler(echoServer))
+	ws, err := config.DialContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if _, err := ws.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 16)
+	n, err := ws.Read(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(msg[:n]); got != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestExtendedConnectRejected(t *testing.T) {
+	s := Server{
+		Handshake: func(config *Config, req *http.Request) error {
+			return errors.New("rejected")
+		},
+		Handler: func(ws *Conn) {
+			t.Errorf("handler called for rejected handshake")
+		},
+	}
+	config := newExtendedConnectConfig(t, s)
+	_, err := config.DialContext(context.Background())
+	var dialErr *DialError
+	if !errors.As(err, &dialErr) || dialErr.Err != ErrBadStatus {
+		t.Errorf("DialContext: got error %v, want ErrBadStatus", err)
+	}
+}
+
+func TestIsExtendedConnect(t *testing.T) {
+	for _, test := range []struct {
+		req  *http.Request
+		want bool
+	}{{
+		req: &http.Request{
+			Method:     "CONNECT",
+			ProtoMajor: 2,
+			Header:     http.Header{":protocol": {"websocket"}},
+			URL:        &url.URL{Path: "/"},
+		},
+		want: true,
+	}, {
+		req: &http.Request{
+			Method:     "CONNECT",
+			ProtoMajor: 2,
+			Header:     http.Header{},
+			URL:        &url.URL{Host: "example.com:443"},
+		},
+		want: false,
+	}, {
+		req: &http.Request{
+			Method:     "GET",
+			ProtoMajor: 1,
+			Header:     http.Header{"Upgrade": {"websocket"}},
+			URL:        &url.URL{Path: "/"},
+		},
+		want: false,
+	}} {
+		if got := isExtendedConnect(test.req); got != test.want {
+			t.Errorf("isExtendedConnect(%v %v) = %v, want %v", test.req.Method, test.req.Header, got, test.want)
+		}
+	}
+}
@@ -47,14 +47,15 @@ var (
 	ErrNotImplemented        = &ProtocolError{"not implemented"}
 
 	handshakeHeader = map[string]bool{
-		"Host":                   true,
-		"Upgrade":                true,
-		"Connection":             true,
-		"Sec-Websocket-Key":      true,
-		"Sec-Websocket-Origin":   true,
-		"Sec-Websocket-Version":  true,
-		"Sec-Websocket-Protocol": true,
-		"Sec-Websocket-Accept":   true,
+		"Host":                     true,
+		"Upgrade":                  true,
+		"Connection":               true,
+		"Sec-Websocket-Key":        true,
+		"Sec-Websocket-Origin":     true,
+		"Sec-Websocket-Version":    true,
+		"Sec-Websocket-Protocol":   true,
+		"Sec-Websocket-Accept":     true,
+		"Sec-Websocket-Extensions": true,
 	}
 )
 
@@ -176,6 +177,68 @@ func (buf hybiFrameReaderFactory) NewFrameReader() (frame frameReader, err error
 	return
 }
 
+// A hybiMessageReader reads the payload of a message,
+// which may span several frames.
+type hybiMessageReader struct {
+	conn  *Conn
+	frame *hybiFrameReader
+}
+
+func (m *hybiMessageReader) Read(p []byte) (int, error) {
+	for {
+		n, err := m.frame.Read(p)
+		if err == io.EOF {
+			if lr, ok := m.frame.reader.(*io.LimitedReader); ok && lr.N > 0 {
+				err = io.ErrUnexpectedEOF
+			} else if n > 0 {
+				err = nil
+			}
+		}
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		if m.frame.header.Fin {
+			return 0, io.EOF
+		}
+		next, err := m.conn.nextContinuation()
+		if err != nil {
+			return 0, err
+		}
+		m.frame = next
+	}
+}
+
+func (m *hybiMessageReader) PayloadType() byte { return m.frame.PayloadType() }
+
+func (m *hybiMessageReader) HeaderReader() io.Reader { return nil }
+
+func (m *hybiMessageReader) TrailerReader() io.Reader { return nil }
+
+func (m *hybiMessageReader) Len() int { return m.frame.Len() }
+
+// nextContinuation reads the next frame of a fragmented message,
+// handling any interleaved control frames.
+func (ws *Conn) nextContinuation() (*hybiFrameReader, error) {
+	for {
+		frame, err := ws.frameReaderFactory.NewFrameReader()
+		if err != nil {
+			return nil, err
+		}
+		if op := frame.PayloadType(); op == TextFrame || op == BinaryFrame {
+			// A new message began before the last one ended.
+			ws.frameHandler.WriteClose(closeStatusProtocolError)
+			return nil, io.EOF
+		}
+		frame, err = ws.frameHandler.HandleFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			return frame.(*hybiFrameReader), nil
+		}
+	}
+}
+
 // A HybiFrameWriter is a writer for hybi frame.
 type hybiFrameWriter struct {
 	writer *bufio.Writer
@@ -261,9 +324,22 @@ func (buf hybiFrameWriterFactory) NewFrameWriter(payloadType byte) (frame frameW
 type hybiFrameHandler struct {
 	conn        *Conn
 	payloadType byte
+	inflater    *inflater // non-nil if permessage-deflate is in use
 }
 
 func (handler *hybiFrameHandler) HandleFrame(frame frameReader) (frameReader, error) {
+	fh := &frame.(*hybiFrameReader).header
+	if fh.Rsv[1] || fh.Rsv[2] {
+		handler.WriteClose(closeStatusProtocolError)
+		return nil, io.EOF
+	}
+	if fh.Rsv[0] {
+		// RSV1 marks the first frame of a compressed message.
+		if handler.inflater == nil || (fh.OpCode != TextFrame && fh.OpCode != BinaryFrame) {
+			handler.WriteClose(closeStatusProtocolError)
+			return nil, io.EOF
+		}
+	}
 	if handler.conn.IsServerConn() {
 		// The client MUST mask all frames sent to the server.
 		if frame.(*hybiFrameReader).header.MaskingKey == nil {
@@ -285,19 +361,30 @@ func (handler *hybiFrameHandler) HandleFrame(frame frameReader) (frameReader, er
 		frame.(*hybiFrameReader).header.OpCode = handler.payloadType
 	case TextFrame, BinaryFrame:
 		handler.payloadType = frame.PayloadType()
-	case CloseFrame:
-		return nil, io.EOF
-	case PingFrame, PongFrame:
+		if fh.Rsv[0] {
+			return handler.inflater.newMessage(handler.conn, frame.(*hybiFrameReader)), nil
+		}
+	case CloseFrame, PingFrame, PongFrame:
 		b := make([]byte, maxControlFramePayloadLength)
 		n, err := io.ReadFull(frame, b)
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			return nil, err
 		}
 		io.Copy(io.Discard, frame)
-		if frame.PayloadType() == PingFrame {
+		switch frame.PayloadType() {
+		case CloseFrame:
+			handler.conn.setCloseStatus(b[:n])
+			return nil, io.EOF
+		case PingFrame:
 			if _, err := handler.WritePong(b[:n]); err != nil {
 				return nil, err
 			}
+		case PongFrame:
+			if h := handler.conn.pongHandler(); h != nil {
+				if err := h(string(b[:n])); err != nil {
+					return nil, err
+				}
+			}
 		}
 		return nil, nil
 	}
@@ -331,19 +418,37 @@ func (handler *hybiFrameHandler) WritePong(msg []byte) (n int, err error) {
 }
 
 // newHybiConn creates a new WebSocket connection speaking hybi draft protocol.
-func newHybiConn(config *Config, buf *bufio.ReadWriter, rwc io.ReadWriteCloser, request *http.Request) *Conn {
+// The permessage-deflate extension is used if deflate is non-nil.
+func newHybiConn(config *Config, deflate *deflateParams, buf *bufio.ReadWriter, rwc io.ReadWriteCloser, request *http.Request) *Conn {
 	if buf == nil {
 		br := bufio.NewReader(rwc)
 		bw := bufio.NewWriter(rwc)
 		buf = bufio.NewReadWriter(br, bw)
 	}
-	ws := &Conn{config: config, request: request, buf: buf, rwc: rwc,
+	ws := &Conn{config: config, request: request, deflate: deflate, buf: buf, rwc: rwc,
 		frameReaderFactory: hybiFrameReaderFactory{buf.Reader},
 		frameWriterFactory: hybiFrameWriterFactory{
 			buf.Writer, request == nil},
 		PayloadType:        TextFrame,
 		defaultCloseStatus: closeStatusNormal}
-	ws.frameHandler = &hybiFrameHandler{conn: ws}
+	handler := &hybiFrameHandler{conn: ws}
+	if p := deflate; p != nil {
+		// Each side compresses with its own parameters,
+		// and inflates with the peer's.
+		isClient := request == nil
+		writeNoContext, readNoContext := p.serverNoContextTakeover, p.clientNoContextTakeover
+		windowBits := p.serverMaxWindowBits
+		if isClient {
+			writeNoContext, readNoContext = readNoContext, writeNoContext
+			windowBits = p.clientMaxWindowBits
+		}
+		ws.frameWriterFactory = deflateFrameWriterFactory{
+			hybiFrameWriterFactory: ws.frameWriterFactory.(hybiFrameWriterFactory),
+			deflater:               newDeflater(config.Compression, writeNoContext, windowBits),
+		}
+		handler.inflater = &inflater{noContextTakeover: readNoContext}
+	}
+	ws.frameHandler = handler
 	return ws
 }
 
@@ -400,8 +505,9 @@ func getNonceAccept(nonce []byte) (expected []byte, err error) {
 	return
 }
 
-// Client handshake described in draft-ietf-hybi-thewebsocket-protocol-17
-func hybiClientHandshake(config *Config, br *bufio.Reader, bw *bufio.Writer) (err error) {
+// Client handshake described in draft-ietf-hybi-thewebsocket-protocol-17.
+// It returns the negotiated permessage-deflate parameters, if any.
+func hybiClientHandshake(config *Config, br *bufio.Reader, bw *bufio.Writer) (deflate *deflateParams, err error) {
 	bw.WriteString("GET " + config.Location.RequestURI() + " HTTP/1.1\r\n")
 
 	// According to RFC 6874, an HTTP client, proxy, or other
@@ -418,46 +524,67 @@ func hybiClientHandshake(config *Config, br *bufio.Reader, bw *bufio.Writer) (er
 	bw.WriteString("Origin: " + strings.ToLower(config.Origin.String()) + "\r\n")
 
 	if config.Version != ProtocolVersionHybi13 {
-		return ErrBadProtocolVersion
+		return nil, ErrBadProtocolVersion
 	}
 
 	bw.WriteString("Sec-WebSocket-Version: " + fmt.Sprintf("%d", config.Version) + "\r\n")
 	if len(config.Protocol) > 0 {
 		bw.WriteString("Sec-WebSocket-Protocol: " + strings.Join(config.Protocol, ", ") + "\r\n")
 	}
-	// TODO(ukai): send Sec-WebSocket-Extensions.
+	if config.Compression != nil {
+		bw.WriteString("Sec-WebSocket-Extensions: " + deflateOffer(config.Compression).String() + "\r\n")
+	}
 	err = config.Header.WriteSubset(bw, handshakeHeader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	bw.WriteString("\r\n")
 	if err = bw.Flush(); err != nil {
-		return err
+		return nil, err
 	}
 
 	resp, err := http.ReadResponse(br, &http.Request{Method: "GET"})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if resp.StatusCode != 101 {
-		return ErrBadStatus
+		return nil, ErrBadStatus
 	}
 	if strings.ToLower(resp.Header.Get("Upgrade")) != "websocket" ||
 		strings.ToLower(resp.Header.Get("Connection")) != "upgrade" {
-		return ErrBadUpgrade
+		return nil, ErrBadUpgrade
 	}
 	expectedAccept, err := getNonceAccept(nonce)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if resp.Header.Get("Sec-WebSocket-Accept") != string(expectedAccept) {
-		return ErrChallengeResponse
+		return nil, ErrChallengeResponse
+	}
+	return readHandshakeResponseFields(config, resp.Header)
+}
+
+// readHandshakeResponseFields checks the extensions and subprotocol
+// chosen by the server, which are common to HTTP/1.1 and HTTP/2, and
+// returns the negotiated permessage-deflate parameters, if any. The
+// parameters are kept on the connection rather than in config, which
+// may be shared by several connections.
+func readHandshakeResponseFields(config *Config, h http.Header) (deflate *deflateParams, err error) {
+	exts, err := parseExtensions(h)
+	if err != nil {
+		return nil, err
 	}
-	if resp.Header.Get("Sec-WebSocket-Extensions") != "" {
-		return ErrUnsupportedExtensions
+	if len(exts) > 0 {
+		if config.Compression == nil {
+			return nil, ErrUnsupportedExtensions
+		}
+		deflate, err = acceptDeflateResponse(config.Compression, exts)
+		if err != nil {
+			return nil, err
+		}
 	}
-	offeredProtocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	offeredProtocol := h.Get("Sec-WebSocket-Protocol")
 	if offeredProtocol != "" {
 		protocolMatched := false
 		for i := 0; i < len(config.Protocol); i++ {
@@ -467,23 +594,24 @@ func hybiClientHandshake(config *Config, br *bufio.Reader, bw *bufio.Writer) (er
 			}
 		}
 		if !protocolMatched {
-			return ErrBadWebSocketProtocol
+			return nil, ErrBadWebSocketProtocol
 		}
 		config.Protocol = []string{offeredProtocol}
 	}
 
-	return nil
+	return deflate, nil
 }
 
 // newHybiClientConn creates a client WebSocket connection after handshake.
-func newHybiClientConn(config *Config, buf *bufio.ReadWriter, rwc io.ReadWriteCloser) *Conn {
-	return newHybiConn(config, buf, rwc, nil)
+func newHybiClientConn(config *Config, deflate *deflateParams, buf *bufio.ReadWriter, rwc io.ReadWriteCloser) *Conn {
+	return newHybiConn(config, deflate, buf, rwc, nil)
 }
 
 // A HybiServerHandshaker performs a server handshake using hybi draft protocol.
 type hybiServerHandshaker struct {
 	*Config
-	accept []byte
+	accept  []byte
+	deflate *deflateParams // negotiated permessage-deflate parameters
 }
 
 func (c *hybiServerHandshaker) ReadHandshake(buf *bufio.Reader, req *http.Request) (code int, err error) {
@@ -502,6 +630,19 @@ func (c *hybiServerHandshaker) ReadHandshake(buf *bufio.Reader, req *http.Reques
 	if key == "" {
 		return http.StatusBadRequest, ErrChallengeResponse
 	}
+	if code, err := c.readHandshakeFields(req); err != nil {
+		return code, err
+	}
+	c.accept, err = getNonceAccept([]byte(key))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusSwitchingProtocols, nil
+}
+
+// readHandshakeFields reads the parts of the handshake request which
+// are common to HTTP/1.1 and HTTP/2.
+func (c *hybiServerHandshaker) readHandshakeFields(req *http.Request) (code int, err error) {
 	version := req.Header.Get("Sec-Websocket-Version")
 	switch version {
 	case "13":
@@ -526,11 +667,16 @@ func (c *hybiServerHandshaker) ReadHandshake(buf *bufio.Reader, req *http.Reques
 			c.Protocol = append(c.Protocol, strings.TrimSpace(protocols[i]))
 		}
 	}
-	c.accept, err = getNonceAccept([]byte(key))
-	if err != nil {
-		return http.StatusInternalServerError, err
+	c.deflate = nil
+	if c.Compression != nil {
+		// Unparsable extension offers are ignored,
+		// rather than failing the handshake.
+		exts, err := parseExtensions(req.Header)
+		if err == nil {
+			c.deflate = acceptDeflateOffer(c.Compression, exts)
+		}
 	}
-	return http.StatusSwitchingProtocols, nil
+	return http.StatusOK, nil
 }
 
 // Origin parses the Origin header in req.
@@ -561,7 +707,9 @@ func (c *hybiServerHandshaker) AcceptHandshake(buf *bufio.Writer) (err error) {
 	if len(c.Protocol) > 0 {
 		buf.WriteString("Sec-WebSocket-Protocol: " + c.Protocol[0] + "\r\n")
 	}
-	// TODO(ukai): send Sec-WebSocket-Extensions.
+	if c.deflate != nil {
+		buf.WriteString("Sec-WebSocket-Extensions: " + c.deflate.String() + "\r\n")
+	}
 	if c.Header != nil {
 		err := c.Header.WriteSubset(buf, handshakeHeader)
 		if err != nil {
@@ -573,10 +721,10 @@ func (c *hybiServerHandshaker) AcceptHandshake(buf *bufio.Writer) (err error) {
 }
 
 func (c *hybiServerHandshaker) NewServerConn(buf *bufio.ReadWriter, rwc io.ReadWriteCloser, request *http.Request) *Conn {
-	return newHybiServerConn(c.Config, buf, rwc, request)
+	return newHybiServerConn(c.Config, c.deflate, buf, rwc, request)
 }
 
 // newHybiServerConn returns a new WebSocket connection speaking hybi draft protocol.
-func newHybiServerConn(config *Config, buf *bufio.ReadWriter, rwc io.ReadWriteCloser, request *http.Request) *Conn {
-	return newHybiConn(config, buf, rwc, request)
+func newHybiServerConn(config *Config, deflate *deflateParams, buf *bufio.ReadWriter, rwc io.ReadWriteCloser, request *http.Request) *Conn {
+	return newHybiConn(config, deflate, buf, rwc, request)
 }
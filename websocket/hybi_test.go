@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -68,7 +69,7 @@ Sec-WebSocket-Protocol: chat
 		config.handshakeData = map[string]string{
 			"key": "dGhlIHNhbXBsZSBub25jZQ==",
 		}
-		if err := hybiClientHandshake(&config, br, bw); err != nil {
+		if _, err := hybiClientHandshake(&config, br, bw); err != nil {
 			t.Fatal("handshake", err)
 		}
 		req, err := http.ReadRequest(bufio.NewReader(&b))
@@ -132,7 +133,7 @@ Sec-WebSocket-Protocol: chat
 	config.handshakeData = map[string]string{
 		"key": "dGhlIHNhbXBsZSBub25jZQ==",
 	}
-	err = hybiClientHandshake(config, br, bw)
+	_, err = hybiClientHandshake(config, br, bw)
 	if err != nil {
 		t.Errorf("handshake failed: %v", err)
 	}
@@ -411,7 +412,7 @@ func TestHybiClientRead(t *testing.T) {
 		0x81, 0x05, 'w', 'o', 'r', 'l', 'd'}
 	br := bufio.NewReader(bytes.NewBuffer(wireData))
 	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
-	conn := newHybiConn(newConfig(t, "/"), bufio.NewReadWriter(br, bw), nil, nil)
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, nil)
 
 	msg := make([]byte, 512)
 	n, err := conn.Read(msg)
@@ -449,7 +450,7 @@ func TestHybiShortRead(t *testing.T) {
 		0x81, 0x05, 'w', 'o', 'r', 'l', 'd'}
 	br := bufio.NewReader(bytes.NewBuffer(wireData))
 	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
-	conn := newHybiConn(newConfig(t, "/"), bufio.NewReadWriter(br, bw), nil, nil)
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, nil)
 
 	step := 0
 	pos := 0
@@ -493,7 +494,7 @@ func TestHybiServerRead(t *testing.T) {
 	}
 	br := bufio.NewReader(bytes.NewBuffer(wireData))
 	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
-	conn := newHybiConn(newConfig(t, "/"), bufio.NewReadWriter(br, bw), nil, new(http.Request))
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, new(http.Request))
 
 	expected := [][]byte{[]byte("hello"), []byte("world")}
 
@@ -533,7 +534,7 @@ func TestHybiServerReadWithoutMasking(t *testing.T) {
 	wireData := []byte{0x81, 0x05, 'h', 'e', 'l', 'l', 'o'}
 	br := bufio.NewReader(bytes.NewBuffer(wireData))
 	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
-	conn := newHybiConn(newConfig(t, "/"), bufio.NewReadWriter(br, bw), nil, new(http.Request))
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, new(http.Request))
 	// server MUST close the connection upon receiving a non-masked frame.
 	msg := make([]byte, 512)
 	_, err := conn.Read(msg)
@@ -548,7 +549,7 @@ func TestHybiClientReadWithMasking(t *testing.T) {
 	}
 	br := bufio.NewReader(bytes.NewBuffer(wireData))
 	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
-	conn := newHybiConn(newConfig(t, "/"), bufio.NewReadWriter(br, bw), nil, nil)
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, nil)
 
 	// client MUST close the connection upon receiving a masked frame.
 	msg := make([]byte, 512)
@@ -606,3 +607,270 @@ Sec-WebSocket-Version: 13
 		t.Errorf("handshake expected %q but got %q", expectedResponse, b.String())
 	}
 }
+
+func newDeflateConfig(t *testing.T) *Config {
+	config := newConfig(t, "/")
+	config.Compression = &CompressionOptions{}
+	return config
+}
+
+func TestHybiCompressedRead(t *testing.T) {
+	// Examples from RFC 7692, Section 7.2.3.
+	wireData := []byte{
+		0xc1, 0x07, 0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00, // "Hello"
+		0x41, 0x03, 0xf2, 0x48, 0xcd, // "Hello", fragmented
+		0x89, 0x00, // ping
+		0x80, 0x04, 0xc9, 0xc9, 0x07, 0x00,
+		0xc1, 0x05, 0xf2, 0x00, 0x11, 0x00, 0x00, // "Hello", using the shared context
+		0xc1, 0x01, 0x00, // ""
+		0x81, 0x05, 'w', 'o', 'r', 'l', 'd', // uncompressed
+	}
+	br := bufio.NewReader(bytes.NewBuffer(wireData))
+	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
+	conn := newHybiConn(newDeflateConfig(t), &deflateParams{}, bufio.NewReadWriter(br, bw), nil, nil)
+
+	for _, want := range []string{"Hello", "Hello", "Hello", "", "world"} {
+		var got string
+		if err := Message.Receive(conn, &got); err != nil {
+			t.Fatalf("receive %q: %v", want, err)
+		}
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	var got string
+	if err := Message.Receive(conn, &got); err == nil {
+		t.Errorf("read not EOF")
+	}
+}
+
+func TestHybiCompressedWrite(t *testing.T) {
+	for _, p := range []*deflateParams{
+		{},
+		{serverNoContextTakeover: true},
+		{serverMaxWindowBits: 8},
+	} {
+		var b bytes.Buffer
+		bw := bufio.NewWriter(&b)
+		br := bufio.NewReader(bytes.NewBuffer([]byte{}))
+		server := newHybiConn(newDeflateConfig(t), p, bufio.NewReadWriter(br, bw), nil, new(http.Request))
+		msgs := []string{"Hello", "Hello", "", strings.Repeat("Hello, world. ", 1000)}
+		for _, msg := range msgs {
+			if _, err := server.Write([]byte(msg)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if b.Bytes()[0] != 0xc1 {
+			t.Errorf("%v: first frame header %#x, want RSV1 set", p, b.Bytes()[0])
+		}
+
+		br = bufio.NewReader(&b)
+		bw = bufio.NewWriter(bytes.NewBuffer([]byte{}))
+		client := newHybiConn(newDeflateConfig(t), p, bufio.NewReadWriter(br, bw), nil, nil)
+		for _, want := range msgs {
+			var got string
+			if err := Message.Receive(client, &got); err != nil {
+				t.Fatalf("%v: receive: %v", p, err)
+			}
+			if got != want {
+				t.Errorf("%v: got %q, want %q", p, got, want)
+			}
+		}
+	}
+}
+
+func TestHybiReadRSV1WithoutCompression(t *testing.T) {
+	wireData := []byte{0xc1, 0x07, 0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00}
+	br := bufio.NewReader(bytes.NewBuffer(wireData))
+	var b bytes.Buffer
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bufio.NewWriter(&b)), nil, nil)
+	msg := make([]byte, 512)
+	if _, err := conn.Read(msg); err != io.EOF {
+		t.Errorf("read compressed frame, expect %q, but got %q", io.EOF, err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte{0x88}) {
+		t.Errorf("expected close frame, got %x", b.Bytes())
+	}
+}
+
+func TestAcceptDeflateOffer(t *testing.T) {
+	for _, test := range []struct {
+		opts   CompressionOptions
+		offer  string
+		accept string // empty if the offer is declined
+	}{{
+		offer:  "permessage-deflate",
+		accept: "permessage-deflate",
+	}, {
+		offer:  "permessage-deflate; client_max_window_bits",
+		accept: "permessage-deflate",
+	}, {
+		opts:   CompressionOptions{ClientMaxWindowBits: 10},
+		offer:  "permessage-deflate; client_max_window_bits",
+		accept: "permessage-deflate; client_max_window_bits=10",
+	}, {
+		opts:   CompressionOptions{ClientMaxWindowBits: 10},
+		offer:  "permessage-deflate",
+		accept: "permessage-deflate",
+	}, {
+		offer:  `permessage-deflate; server_max_window_bits="10"; client_no_context_takeover`,
+		accept: "permessage-deflate; client_no_context_takeover; server_max_window_bits=10",
+	}, {
+		opts:   CompressionOptions{ServerNoContextTakeover: true, ServerMaxWindowBits: 12},
+		offer:  "permessage-deflate; server_max_window_bits=14",
+		accept: "permessage-deflate; server_no_context_takeover; server_max_window_bits=12",
+	}, {
+		// The first acceptable offer is chosen.
+		offer:  "x-webkit-deflate-frame, permessage-deflate; server_max_window_bits=16, permessage-deflate; server_no_context_takeover",
+		accept: "permessage-deflate; server_no_context_takeover",
+	}, {
+		offer: "permessage-deflate; server_no_context_takeover; server_no_context_takeover",
+	}, {
+		offer: "permessage-deflate; server_max_window_bits",
+	}, {
+		offer: "permessage-deflate; server_max_window_bits=010",
+	}, {
+		offer: "permessage-deflate; unknown",
+	}, {
+		offer: "x-webkit-deflate-frame",
+	}} {
+		h := http.Header{"Sec-Websocket-Extensions": {test.offer}}
+		exts, err := parseExtensions(h)
+		if err != nil {
+			t.Errorf("parseExtensions(%q): %v", test.offer, err)
+			continue
+		}
+		var accept string
+		if p := acceptDeflateOffer(&test.opts, exts); p != nil {
+			accept = p.String()
+		}
+		if accept != test.accept {
+			t.Errorf("offer %q with options %+v: accepted %q, want %q", test.offer, test.opts, accept, test.accept)
+		}
+	}
+}
+
+func TestHybiClientHandshakeCompression(t *testing.T) {
+	for _, test := range []struct {
+		response string
+		wantErr  error
+		want     *deflateParams
+	}{{
+		response: "",
+		want:     nil,
+	}, {
+		response: "permessage-deflate; server_no_context_takeover",
+		want:     &deflateParams{serverNoContextTakeover: true},
+	}, {
+		// The server must honor server_no_context_takeover.
+		response: "permessage-deflate",
+		wantErr:  ErrUnsupportedExtensions,
+	}, {
+		response: "permessage-deflate; server_no_context_takeover; client_max_window_bits=9",
+		want: &deflateParams{
+			serverNoContextTakeover:    true,
+			clientMaxWindowBits:        9,
+			clientMaxWindowBitsOffered: true,
+		},
+	}, {
+		response: "permessage-deflate; client_max_window_bits",
+		wantErr:  ErrUnsupportedExtensions,
+	}, {
+		response: "x-webkit-deflate-frame",
+		wantErr:  ErrUnsupportedExtensions,
+	}, {
+		response: "permessage-deflate, permessage-deflate",
+		wantErr:  ErrUnsupportedExtensions,
+	}} {
+		resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=\r\n"
+		if test.response != "" {
+			resp += "Sec-WebSocket-Extensions: " + test.response + "\r\n"
+		}
+		resp += "\r\n"
+		var b bytes.Buffer
+		bw := bufio.NewWriter(&b)
+		br := bufio.NewReader(strings.NewReader(resp))
+		config := newConfig(t, "/chat")
+		config.Compression = &CompressionOptions{ServerNoContextTakeover: true}
+		config.handshakeData = map[string]string{
+			"key": "dGhlIHNhbXBsZSBub25jZQ==",
+		}
+		deflate, err := hybiClientHandshake(config, br, bw)
+		if err != test.wantErr {
+			t.Errorf("response %q: got error %v, want %v", test.response, err, test.wantErr)
+			continue
+		}
+		req, err := http.ReadRequest(bufio.NewReader(&b))
+		if err != nil {
+			t.Fatal("read request", err)
+		}
+		const wantOffer = "permessage-deflate; server_no_context_takeover; client_max_window_bits"
+		if got := req.Header.Get("Sec-WebSocket-Extensions"); got != wantOffer {
+			t.Errorf("offered %q, want %q", got, wantOffer)
+		}
+		if test.wantErr == nil && !reflect.DeepEqual(deflate, test.want) {
+			t.Errorf("response %q: negotiated %v, want %v", test.response, deflate, test.want)
+		}
+	}
+}
+
+func TestHybiNextReaderFragmented(t *testing.T) {
+	wireData := []byte{
+		0x01, 0x03, 'h', 'e', 'l', // fragmented text message
+		0x89, 0x00, // ping
+		0x00, 0x01, 'l',
+		0x80, 0x01, 'o',
+		0x82, 0x02, 0x01, 0x02, // binary message
+	}
+	br := bufio.NewReader(bytes.NewBuffer(wireData))
+	bw := bufio.NewWriter(bytes.NewBuffer([]byte{}))
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bw), nil, nil)
+
+	for _, want := range []struct {
+		typ  byte
+		data string
+	}{
+		{TextFrame, "hello"},
+		{BinaryFrame, "\x01\x02"},
+	} {
+		typ, r, err := conn.NextReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != want.typ || string(data) != want.data {
+			t.Errorf("got message %v %q, want %v %q", typ, data, want.typ, want.data)
+		}
+	}
+	if _, _, err := conn.NextReader(); err == nil {
+		t.Errorf("read not EOF")
+	}
+}
+
+func TestHybiNextReaderInterleavedMessage(t *testing.T) {
+	wireData := []byte{
+		0x01, 0x03, 'h', 'e', 'l', // fragmented text message
+		0x81, 0x01, 'x', // new message before the previous one ended
+	}
+	br := bufio.NewReader(bytes.NewBuffer(wireData))
+	var b bytes.Buffer
+	conn := newHybiConn(newConfig(t, "/"), nil, bufio.NewReadWriter(br, bufio.NewWriter(&b)), nil, nil)
+	_, r, err := conn.NextReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "hel" {
+		t.Errorf("got message %q, want %q", data, "hel")
+	}
+	// The client sends a masked close frame with a 2 byte status.
+	if !bytes.HasPrefix(b.Bytes(), []byte{0x88, 0x82}) {
+		t.Errorf("wrote %x, want close frame", b.Bytes())
+	}
+}
@@ -52,6 +52,10 @@ func newServerConn(rwc io.ReadWriteCloser, buf *bufio.ReadWriter, req *http.Requ
 }
 
 // Server represents a server of a WebSocket.
+//
+// Besides the HTTP/1.1 Upgrade handshake, a Server accepts WebSockets
+// opened on HTTP/2 streams with the extended CONNECT method (RFC 8441),
+// when the HTTP/2 server has extended CONNECT enabled.
 type Server struct {
 	// Config is a WebSocket configuration for new WebSocket connection.
 	Config
@@ -71,6 +75,10 @@ func (s Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func (s Server) serveWebSocket(w http.ResponseWriter, req *http.Request) {
+	if isExtendedConnect(req) {
+		s.serveExtendedConnect(w, req)
+		return
+	}
 	rwc, buf, err := w.(http.Hijacker).Hijack()
 	if err != nil {
 		panic("Hijack failed: " + err.Error())
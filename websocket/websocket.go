@@ -15,6 +15,7 @@ package websocket // import "golang.org/x/net/websocket"
 import (
 	"bufio"
 	"crypto/tls"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"io"
@@ -69,6 +70,24 @@ var (
 // exceeds limit set by Conn.MaxPayloadBytes
 var ErrFrameTooLarge = errors.New("websocket: frame payload size exceeds limit")
 
+// ErrReadLimit is returned when reading a message larger than the limit
+// set by Conn.SetReadLimit.
+var ErrReadLimit = errors.New("websocket: message exceeds read limit")
+
+// Close status codes, as defined in RFC 6455, Section 7.4.1.
+const (
+	CloseNormalClosure      = closeStatusNormal
+	CloseGoingAway          = closeStatusGoingAway
+	CloseProtocolError      = closeStatusProtocolError
+	CloseUnsupportedData    = closeStatusUnsupportedData
+	CloseNoStatusReceived   = closeStatusNoStatusRcvd
+	CloseAbnormalClosure    = closeStatusAbnormalClosure
+	CloseInvalidPayloadData = closeStatusBadMessageData
+	ClosePolicyViolation    = closeStatusPolicyViolation
+	CloseMessageTooBig      = closeStatusTooBigData
+	CloseMandatoryExtension = closeStatusExtensionMismatch
+)
+
 // Addr is an implementation of net.Addr for WebSocket.
 type Addr struct {
 	*url.URL
@@ -100,6 +119,18 @@ type Config struct {
 	// Dialer used when opening websocket connections.
 	Dialer *net.Dialer
 
+	// Compression, if non-nil, enables the permessage-deflate extension.
+	// A client offers the extension when it connects, and a server
+	// accepts it when the client offers it.
+	Compression *CompressionOptions
+
+	// HTTP2Conn, if non-nil, is an HTTP/2 client connection, such as a
+	// *golang.org/x/net/http2.ClientConn, on which DialContext opens the
+	// WebSocket as a stream using the extended CONNECT method (RFC 8441),
+	// instead of dialing a new connection. Dialer and TlsConfig are
+	// not used.
+	HTTP2Conn http.RoundTripper
+
 	handshakeData map[string]string
 }
 
@@ -163,6 +194,7 @@ type frameHandler interface {
 type Conn struct {
 	config  *Config
 	request *http.Request
+	deflate *deflateParams // negotiated permessage-deflate parameters, or nil
 
 	buf *bufio.ReadWriter
 	rwc io.ReadWriteCloser
@@ -181,6 +213,12 @@ type Conn struct {
 	// MaxPayloadBytes limits the size of frame payload received over Conn
 	// by Codec's Receive method. If zero, DefaultMaxPayloadBytes is used.
 	MaxPayloadBytes int
+
+	mu          sync.Mutex
+	readLimit   int64
+	onPong      func(appData string) error
+	closeCode   int // status received in the peer's close frame
+	closeReason string
 }
 
 // Read implements the io.Reader interface:
@@ -230,6 +268,214 @@ func (ws *Conn) Write(msg []byte) (n int, err error) {
 	return n, err
 }
 
+// NextReader returns the type and payload of the next text or binary
+// message received from the peer, skipping any unread part of the
+// previous message. Control frames are handled while waiting for the
+// message, and while reading it.
+//
+// The returned reader is valid until the next call to NextReader, Read,
+// or a Codec's Receive method. When NextReader returns io.EOF after the
+// peer closes the connection, CloseStatus reports the peer's status.
+func (ws *Conn) NextReader() (messageType byte, r io.Reader, err error) {
+	ws.rio.Lock()
+	defer ws.rio.Unlock()
+	if ws.frameReader != nil {
+		_, err = io.Copy(io.Discard, ws.frameReader)
+		ws.frameReader = nil
+		if err != nil {
+			return UnknownFrame, nil, err
+		}
+	}
+	for {
+		frame, err := ws.frameReaderFactory.NewFrameReader()
+		if err != nil {
+			return UnknownFrame, nil, err
+		}
+		frame, err = ws.frameHandler.HandleFrame(frame)
+		if err != nil {
+			return UnknownFrame, nil, err
+		}
+		if frame == nil {
+			continue
+		}
+		if hf, ok := frame.(*hybiFrameReader); ok {
+			frame = &hybiMessageReader{conn: ws, frame: hf}
+		}
+		ws.frameReader = frame
+		return frame.PayloadType(), &messageReader{ws: ws, frame: frame}, nil
+	}
+}
+
+// A messageReader reads a message returned by NextReader.
+type messageReader struct {
+	ws    *Conn
+	frame frameReader
+	n     int64 // bytes read so far
+	err   error
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	ws := r.ws
+	ws.rio.Lock()
+	defer ws.rio.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if ws.frameReader != r.frame {
+		// The connection has moved on to another message.
+		return 0, io.EOF
+	}
+	ws.mu.Lock()
+	limit := ws.readLimit
+	ws.mu.Unlock()
+	if limit > 0 && int64(len(p)) > limit-r.n+1 {
+		// Read one byte past the limit, to detect an oversized message.
+		p = p[:limit-r.n+1]
+	}
+	n, err := r.frame.Read(p)
+	r.n += int64(n)
+	if limit > 0 && r.n > limit {
+		ws.frameHandler.WriteClose(closeStatusTooBigData)
+		r.err = ErrReadLimit
+		return 0, r.err
+	}
+	if err != nil {
+		ws.frameReader = nil
+		r.err = err
+	}
+	return n, err
+}
+
+// NextWriter returns a writer for the next message to send to the peer,
+// where messageType is TextFrame or BinaryFrame. The message is sent
+// when the writer is closed.
+func (ws *Conn) NextWriter(messageType byte) (io.WriteCloser, error) {
+	if messageType != TextFrame && messageType != BinaryFrame {
+		return nil, ErrNotSupported
+	}
+	return &messageWriter{ws: ws, payloadType: messageType}, nil
+}
+
+var errMessageClosed = errors.New("websocket: write to closed message writer")
+
+// A messageWriter buffers a message returned by NextWriter.
+type messageWriter struct {
+	ws          *Conn
+	payloadType byte
+	buf         []byte
+	closed      bool
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errMessageClosed
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *messageWriter) Close() error {
+	if w.closed {
+		return errMessageClosed
+	}
+	w.closed = true
+	ws := w.ws
+	ws.wio.Lock()
+	defer ws.wio.Unlock()
+	fw, err := ws.frameWriterFactory.NewFrameWriter(w.payloadType)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(w.buf)
+	fw.Close()
+	return err
+}
+
+// WriteControl sends a control frame, where messageType is PingFrame,
+// PongFrame or CloseFrame. The payload of a control frame is limited to
+// 125 bytes. Use FormatCloseMessage to create the payload of a close frame.
+//
+// WriteControl may be called while a message is being read or written.
+func (ws *Conn) WriteControl(messageType byte, data []byte) error {
+	switch messageType {
+	case PingFrame, PongFrame, CloseFrame:
+	default:
+		return ErrNotSupported
+	}
+	if len(data) > maxControlFramePayloadLength {
+		return ErrBadFrame
+	}
+	ws.wio.Lock()
+	defer ws.wio.Unlock()
+	w, err := ws.frameWriterFactory.NewFrameWriter(messageType)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	w.Close()
+	return err
+}
+
+// FormatCloseMessage returns the payload of a close frame
+// with the given status code and reason.
+// The status CloseNoStatusReceived produces an empty payload.
+func FormatCloseMessage(code int, reason string) []byte {
+	if code == CloseNoStatusReceived {
+		return []byte{}
+	}
+	buf := make([]byte, 2+len(reason))
+	buf[0] = byte(code >> 8)
+	buf[1] = byte(code)
+	copy(buf[2:], reason)
+	return buf
+}
+
+// SetPongHandler sets the function called for each pong frame received
+// from the peer, with the frame's application data. Pong frames are
+// processed while reading messages. An error returned by h is returned
+// by the read which processed the frame.
+func (ws *Conn) SetPongHandler(h func(appData string) error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.onPong = h
+}
+
+func (ws *Conn) pongHandler() func(appData string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.onPong
+}
+
+// SetReadLimit sets the maximum size in bytes of a message read with
+// NextReader. If a message exceeds the limit, the connection is closed
+// with status CloseMessageTooBig and reading returns ErrReadLimit.
+// A limit of zero means no limit.
+func (ws *Conn) SetReadLimit(limit int64) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.readLimit = limit
+}
+
+// CloseStatus returns the status code and reason from the close frame
+// sent by the peer. The code is CloseNoStatusReceived if the frame had no
+// status, and zero if no close frame has been received.
+func (ws *Conn) CloseStatus() (code int, reason string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.closeCode, ws.closeReason
+}
+
+func (ws *Conn) setCloseStatus(payload []byte) {
+	code, reason := CloseNoStatusReceived, ""
+	if len(payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(payload))
+		reason = string(payload[2:])
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.closeCode, ws.closeReason = code, reason
+}
+
 // Close implements the io.Closer interface.
 func (ws *Conn) Close() error {
 	err := ws.frameHandler.WriteClose(ws.defaultCloseStatus)
@@ -352,6 +598,19 @@ again:
 	if maxPayloadBytes == 0 {
 		maxPayloadBytes = DefaultMaxPayloadBytes
 	}
+	if _, ok := frame.(*inflateFrameReader); ok {
+		// The size of a compressed message is only known once
+		// it has been inflated.
+		data, err := io.ReadAll(io.LimitReader(frame, int64(maxPayloadBytes)+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxPayloadBytes {
+			ws.frameReader = frame
+			return ErrFrameTooLarge
+		}
+		return cd.Unmarshal(data, frame.PayloadType(), v)
+	}
 	if hf, ok := frame.(*hybiFrameReader); ok && hf.header.Length > int64(maxPayloadBytes) {
 		// payload size exceeds limit, no need to call Unmarshal
 		//
@@ -126,6 +126,17 @@ func startServer() {
 		Handler:   Handler(subProtoServer),
 	}
 	http.Handle("/subproto", subproto)
+	http.Handle("/deflate", Server{
+		Config:  Config{Compression: &CompressionOptions{}},
+		Handler: Handler(countServer),
+	})
+	http.Handle("/deflate-nocontext", Server{
+		Config: Config{Compression: &CompressionOptions{
+			ServerNoContextTakeover: true,
+			ServerMaxWindowBits:     10,
+		}},
+		Handler: Handler(echoServer),
+	})
 	server := httptest.NewServer(nil)
 	serverAddr = server.Listener.Addr().String()
 	log.Print("Test WebSocket server listening on ", serverAddr)
@@ -663,3 +674,352 @@ func TestCodec_ReceiveLimited(t *testing.T) {
 	}
 	<-handlerDone
 }
+
+func TestCompression(t *testing.T) {
+	once.Do(startServer)
+
+	config := newConfig(t, "/deflate")
+	config.Compression = &CompressionOptions{}
+	conn, err := DialConfig(config)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	defer conn.Close()
+	if conn.deflate == nil {
+		t.Fatal("permessage-deflate not negotiated")
+	}
+
+	// Later messages refer to earlier ones when context takeover is in use.
+	for i := 1; i < 4; i++ {
+		s := strings.Repeat(`{"hello": "world"}`, 1000*i)
+		if err := JSON.Send(conn, Count{S: s, N: 0}); err != nil {
+			t.Fatal("send", err)
+		}
+		var count Count
+		if err := JSON.Receive(conn, &count); err != nil {
+			t.Fatal("receive", err)
+		}
+		if count.N != 1 || count.S != s {
+			t.Fatalf("message %v: got count {%q, %v}, want {%q, 1}", i, count.S[:20], count.N, s[:20])
+		}
+	}
+}
+
+func TestCompressionNoContextTakeover(t *testing.T) {
+	once.Do(startServer)
+
+	config := newConfig(t, "/deflate-nocontext")
+	config.Compression = &CompressionOptions{
+		ClientNoContextTakeover: true,
+		ClientMaxWindowBits:     9,
+	}
+	conn, err := DialConfig(config)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	defer conn.Close()
+	want := &deflateParams{
+		serverNoContextTakeover: true,
+		clientNoContextTakeover: true,
+		serverMaxWindowBits:     10,
+		clientMaxWindowBits:     9,
+		// The response included client_max_window_bits.
+		clientMaxWindowBitsOffered: true,
+	}
+	if !reflect.DeepEqual(conn.deflate, want) {
+		t.Fatalf("negotiated %v, want %v", conn.deflate, want)
+	}
+
+	for _, msg := range []string{"hello", strings.Repeat("hello, world ", 100), "hello"} {
+		if err := Message.Send(conn, msg); err != nil {
+			t.Fatal("send", err)
+		}
+		var got string
+		if err := Message.Receive(conn, &got); err != nil {
+			t.Fatal("receive", err)
+		}
+		if got != msg {
+			t.Errorf("got %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestCompressionSharedConfig(t *testing.T) {
+	once.Do(startServer)
+
+	// The parameters negotiated for one connection do not leak into
+	// another one dialed with the same Config.
+	config := newConfig(t, "/deflate")
+	config.Compression = &CompressionOptions{}
+	compressed, err := DialConfig(config)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	defer compressed.Close()
+	config.Location = newConfig(t, "/count").Location
+	plain, err := DialConfig(config)
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	defer plain.Close()
+	if compressed.deflate == nil || plain.deflate != nil {
+		t.Fatalf("negotiated %v and %v, want permessage-deflate for the first connection only", compressed.deflate, plain.deflate)
+	}
+	for _, conn := range []*Conn{compressed, plain} {
+		if err := JSON.Send(conn, Count{S: "hello", N: 0}); err != nil {
+			t.Fatal("send", err)
+		}
+		var count Count
+		if err := JSON.Receive(conn, &count); err != nil {
+			t.Fatal("receive", err)
+		}
+		if count.N != 1 || count.S != "hello" {
+			t.Errorf("got count {%q, %v}, want {hello, 1}", count.S, count.N)
+		}
+	}
+}
+
+func TestCompressionNotOffered(t *testing.T) {
+	once.Do(startServer)
+
+	conn, err := DialConfig(newConfig(t, "/deflate"))
+	if err != nil {
+		t.Fatal("dialing", err)
+	}
+	defer conn.Close()
+	if conn.deflate != nil {
+		t.Fatalf("negotiated %v, want no extension", conn.deflate)
+	}
+	if err := JSON.Send(conn, Count{S: "hello", N: 0}); err != nil {
+		t.Fatal("send", err)
+	}
+	var count Count
+	if err := JSON.Receive(conn, &count); err != nil {
+		t.Fatal("receive", err)
+	}
+	if count.N != 1 || count.S != "hello" {
+		t.Errorf("got count %v, want {hello 1}", count)
+	}
+}
+
+func TestCodec_ReceiveLimitedCompressed(t *testing.T) {
+	const limit = 128
+	var payloads [][]byte
+	for _, size := range []int{
+		1024,
+		limit + 1,
+		limit,
+	} {
+		payloads = append(payloads, bytes.Repeat([]byte{'x'}, size))
+	}
+	handlerDone := make(chan struct{})
+	limitedHandler := func(ws *Conn) {
+		defer close(handlerDone)
+		ws.MaxPayloadBytes = limit
+		defer ws.Close()
+		for i, p := range payloads {
+			t.Logf("payload #%d (size %d, exceeds limit: %v)", i, len(p), len(p) > limit)
+			var recv []byte
+			err := Message.Receive(ws, &recv)
+			switch err {
+			case nil:
+			case ErrFrameTooLarge:
+				if len(p) <= limit {
+					t.Fatalf("unexpected frame size limit: expected %d bytes of payload having limit at %d", len(p), limit)
+				}
+				continue
+			default:
+				t.Fatalf("unexpected error: %v (want either nil or ErrFrameTooLarge)", err)
+			}
+			if len(recv) > limit {
+				t.Fatalf("received %d bytes of payload having limit at %d", len(recv), limit)
+			}
+			if !bytes.Equal(p, recv) {
+				t.Fatalf("received payload differs:\ngot:\t%v\nwant:\t%v", recv, p)
+			}
+		}
+	}
+	server := httptest.NewServer(Server{
+		Config:  Config{Compression: &CompressionOptions{}},
+		Handler: limitedHandler,
+	})
+	defer server.CloseClientConnections()
+	defer server.Close()
+
+	addr := server.Listener.Addr().String()
+	config, err := NewConfig("ws://"+addr+"/", "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Compression = &CompressionOptions{}
+	ws, err := DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	for i, p := range payloads {
+		if err := Message.Send(ws, p); err != nil {
+			t.Fatalf("payload #%d (size %d): %v", i, len(p), err)
+		}
+	}
+	<-handlerDone
+}
+
+func newMessageServer(t *testing.T, handler func(ws *Conn)) *Conn {
+	server := httptest.NewServer(Server{Handler: handler})
+	t.Cleanup(server.Close)
+	config, err := NewConfig("ws://"+server.Listener.Addr().String()+"/", "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// messageEchoServer echoes messages using NextReader and NextWriter.
+func messageEchoServer(ws *Conn) {
+	defer ws.Close()
+	for {
+		typ, r, err := ws.NextReader()
+		if err != nil {
+			return
+		}
+		w, err := ws.NextWriter(typ)
+		if err != nil {
+			return
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return
+		}
+		if err := w.Close(); err != nil {
+			return
+		}
+	}
+}
+
+func TestNextReaderWriter(t *testing.T) {
+	ws := newMessageServer(t, messageEchoServer)
+
+	pongs := make(chan string, 1)
+	ws.SetPongHandler(func(appData string) error {
+		pongs <- appData
+		return nil
+	})
+	if err := ws.WriteControl(PingFrame, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []byte{BinaryFrame, TextFrame} {
+		w, err := ws.NextWriter(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"hello", ", ", "world"} {
+			io.WriteString(w, s)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		gotType, r, err := ws.NextReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotType != typ || string(got) != "hello, world" {
+			t.Errorf("got message %v %q, want %v %q", gotType, got, typ, "hello, world")
+		}
+	}
+	select {
+	case got := <-pongs:
+		if got != "ping" {
+			t.Errorf("pong handler called with %q, want %q", got, "ping")
+		}
+	default:
+		t.Errorf("pong handler not called")
+	}
+}
+
+func TestCloseStatus(t *testing.T) {
+	type status struct {
+		code   int
+		reason string
+	}
+	statusc := make(chan status, 1)
+	ws := newMessageServer(t, func(ws *Conn) {
+		defer ws.Close()
+		_, _, err := ws.NextReader()
+		if err != io.EOF {
+			t.Errorf("NextReader: got error %v, want io.EOF", err)
+		}
+		code, reason := ws.CloseStatus()
+		statusc <- status{code, reason}
+	})
+	if code, _ := ws.CloseStatus(); code != 0 {
+		t.Errorf("CloseStatus before close = %v, want 0", code)
+	}
+	if err := ws.WriteControl(CloseFrame, FormatCloseMessage(4000, "bye")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-statusc, (status{4000, "bye"}); got != want {
+		t.Errorf("server got close status %v, want %v", got, want)
+	}
+	// The server's Close sends CloseNormalClosure.
+	if _, _, err := ws.NextReader(); err != io.EOF {
+		t.Errorf("NextReader: got error %v, want io.EOF", err)
+	}
+	if code, reason := ws.CloseStatus(); code != CloseNormalClosure || reason != "" {
+		t.Errorf("client got close status %v %q, want %v", code, reason, CloseNormalClosure)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	errc := make(chan error, 1)
+	ws := newMessageServer(t, func(ws *Conn) {
+		defer ws.Close()
+		ws.SetReadLimit(8)
+		for {
+			_, r, err := ws.NextReader()
+			if err != nil {
+				errc <- err
+				return
+			}
+			if _, err := io.ReadAll(r); err != nil {
+				errc <- err
+				return
+			}
+		}
+	})
+	for _, msg := range []string{"12345678", "123456789"} {
+		if err := Message.Send(ws, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-errc; err != ErrReadLimit {
+		t.Errorf("server read: got error %v, want ErrReadLimit", err)
+	}
+	if _, _, err := ws.NextReader(); err != io.EOF {
+		t.Errorf("NextReader: got error %v, want io.EOF", err)
+	}
+	if code, _ := ws.CloseStatus(); code != CloseMessageTooBig {
+		t.Errorf("client got close status %v, want %v", code, CloseMessageTooBig)
+	}
+}
+
+func TestWriteControlErrors(t *testing.T) {
+	ws := newMessageServer(t, messageEchoServer)
+	if err := ws.WriteControl(TextFrame, nil); err != ErrNotSupported {
+		t.Errorf("WriteControl(TextFrame): got %v, want ErrNotSupported", err)
+	}
+	if err := ws.WriteControl(PingFrame, make([]byte, 126)); err != ErrBadFrame {
+		t.Errorf("WriteControl with 126 byte payload: got %v, want ErrBadFrame", err)
+	}
+	if _, err := ws.NextWriter(PingFrame); err != ErrNotSupported {
+		t.Errorf("NextWriter(PingFrame): got %v, want ErrNotSupported", err)
+	}
+}